@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"bookstorage/internal/catalog"
 	"bookstorage/internal/config"
 	"bookstorage/internal/database"
 	"bookstorage/internal/server"
@@ -46,9 +47,15 @@ ENVIRONMENT VARIABLES
     BOOKSTORAGE_SECRET_KEY           Secret key for sessions
     BOOKSTORAGE_SUPERADMIN_USERNAME  Super admin username (default: superadmin)
     BOOKSTORAGE_SUPERADMIN_PASSWORD  Super admin password
+    BOOKSTORAGE_REGISTRATION_MODE    open, approval, invite or closed (default: approval)
+    BOOKSTORAGE_REQUIRE_ADMIN_2FA    true to make admins enroll two-factor authentication (default: false)
     BOOKSTORAGE_PUBLIC_ORIGIN         Public site URL without trailing slash (required for Google OAuth), e.g. https://books.example.com
     BOOKSTORAGE_GOOGLE_CLIENT_ID      Google OAuth 2.0 Web client ID (optional; with secret and public origin enables Sign in with Google)
     BOOKSTORAGE_GOOGLE_CLIENT_SECRET  Google OAuth client secret
+    BOOKSTORAGE_OIDC_PROVIDERS        Comma-separated OpenID Connect provider IDs (e.g. authelia,keycloak); each reads
+                                      BOOKSTORAGE_OIDC_<ID>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _NAME, _SCOPES, _USERNAME_CLAIM
+    BOOKSTORAGE_PROXY_AUTH_HEADER     Header carrying the user authenticated by a reverse proxy (e.g. Remote-User); honoured
+                                      only from BOOKSTORAGE_TRUSTED_PROXIES (CIDRs). See .env.example for groups/auto-create
     BOOKSTORAGE_HTTP_READ_TIMEOUT_SEC  Seconds to read the full request (default 15)
     BOOKSTORAGE_HTTP_WRITE_TIMEOUT_SEC Seconds until response must be fully written (default 120; includes handler time — raise for slow admin batches)
 
@@ -142,6 +149,10 @@ func main() {
 	settings.EnvFilePath = envFile
 
 	siteConfig := config.LoadSiteConfig(root)
+	catalog.SetOpenLibraryBaseURL(settings.OpenLibraryURL)
+	if unknown := catalog.SetEnabledProviders(settings.CatalogSources); len(unknown) > 0 {
+		log.Printf("BOOKSTORAGE_CATALOG_SOURCES: unknown sources ignored: %s", strings.Join(unknown, ", "))
+	}
 
 	db, err := database.Open(settings)
 	if err != nil {
@@ -188,36 +199,62 @@ func main() {
 	mux.HandleFunc("/lang/{lang}", app.HandleSetLanguage)
 	mux.HandleFunc("/register", app.HandleRegister)
 	mux.HandleFunc("/login", app.HandleLogin)
+	mux.HandleFunc("/login/2fa", app.HandleLoginTwoFactor)
 	mux.HandleFunc("/forgot-password", app.HandleForgotPassword)
 	mux.HandleFunc("/reset-password", app.HandleResetPassword)
+	mux.HandleFunc("/digest/unsubscribe", app.HandleDigestUnsubscribe)
+	mux.HandleFunc("GET /verify-email", app.HandleVerifyEmail)
 	mux.HandleFunc("/auth/google", app.HandleGoogleOAuthStart)
 	mux.HandleFunc("/auth/google/callback", app.HandleGoogleOAuthCallback)
 	mux.HandleFunc("/auth/google/link", app.RequireLogin(app.HandleGoogleOAuthLink))
+	mux.HandleFunc("GET /auth/oidc/{provider}", app.HandleOIDCStart)
+	mux.HandleFunc("GET /auth/oidc/{provider}/callback", app.HandleOIDCCallback)
+	mux.HandleFunc("GET /oauth/authorize", app.HandleOAuthAuthorize)
+	mux.HandleFunc("POST /oauth/authorize", app.RequireLogin(app.HandleOAuthAuthorizeDecision))
+	mux.HandleFunc("POST /oauth/token", app.HandleOAuthToken)
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", app.HandleOAuthMetadata)
+	mux.HandleFunc("GET /auth/oidc/{provider}/link", app.RequireLogin(app.HandleOIDCLink))
 	mux.HandleFunc("/logout", app.HandleLogout)
 	mux.HandleFunc("GET /api/session/ping", app.HandleAPISessionPing)
 	mux.HandleFunc("/dashboard", app.RequireLogin(app.HandleDashboard))
 	mux.HandleFunc("/stats", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleStats)))
 	mux.HandleFunc("/profile", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfile)))
 	mux.HandleFunc("/profile/passkeys", app.RequireLogin(app.HandleProfilePasskeys))
+	mux.HandleFunc("GET /profile/2fa", app.RequireLogin(app.HandleProfileTwoFactor))
+	mux.HandleFunc("POST /profile/2fa/enable", app.RequireLogin(app.HandleProfileTwoFactorEnable))
+	mux.HandleFunc("POST /profile/2fa/recovery-codes", app.RequireLogin(app.HandleProfileTwoFactorRecoveryCodes))
+	mux.HandleFunc("POST /profile/2fa/disable", app.RequireLogin(app.HandleProfileTwoFactorDisable))
 	mux.HandleFunc("POST /profile/logout_all", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleLogoutAll)))
 	mux.HandleFunc("POST /profile/reset_reading_activity", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileResetReadingActivity)))
+	mux.HandleFunc("POST /profile/digest", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileDigest)))
+	mux.HandleFunc("POST /profile/email/resend", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileEmailResend)))
+	mux.HandleFunc("POST /profile/email/cancel", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileEmailCancel)))
 	mux.HandleFunc("POST /profile/blocklist/add", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileBlocklistAdd)))
 	mux.HandleFunc("POST /profile/blocklist/remove", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleProfileBlocklistRemove)))
 	mux.HandleFunc("POST /profile/google/unlink", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleGoogleUnlink)))
+	mux.HandleFunc("POST /profile/identities/{id}/unlink", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleOIDCUnlink)))
 	mux.HandleFunc("POST /profile/delete", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleDeleteProfile)))
 	mux.HandleFunc("POST /profile/api-tokens", app.RequireLogin(app.HandleCreateAPIToken))
 	mux.HandleFunc("POST /profile/api-tokens/revoke/{id}", app.RequireLogin(app.HandleRevokeAPIToken))
+	mux.HandleFunc("POST /profile/apps/{id}/revoke", app.RequireLogin(app.HandleOAuthAppRevoke))
 	mux.HandleFunc("POST /profile/webhooks", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleCreateWebhook)))
 	mux.HandleFunc("POST /profile/webhooks/{id}", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleUpdateWebhook)))
 	mux.HandleFunc("POST /profile/webhooks/{id}/delete", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleDeleteWebhook)))
 	mux.HandleFunc("POST /profile/webhooks/{id}/test", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleTestWebhook)))
+	mux.HandleFunc("GET /api/webhooks", app.RequireLogin(app.HandleAPIWebhooks))
+	mux.HandleFunc("POST /api/webhooks", app.RequireLogin(app.HandleAPIWebhooks))
+	mux.HandleFunc("DELETE /api/webhooks/{id}", app.RequireLogin(app.HandleAPIWebhookDelete))
 	mux.HandleFunc("/reading-sites", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleReadingSites)))
 	mux.HandleFunc("POST /reading-sites/edit", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleReadingSiteEdit)))
 	mux.HandleFunc("POST /reading-sites/delete", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleReadingSiteDelete)))
 	mux.HandleFunc("POST /reading-sites/probe", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleReadingSiteProbe)))
 	mux.HandleFunc("POST /reading-sites/probe-all", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleReadingSiteProbeAll)))
 	mux.HandleFunc("GET /api/reading-sites/match", app.RequireLogin(app.HandleAPIReadingSiteMatch))
-	mux.HandleFunc("GET /api/reading-sites", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksRead)(app.HandleAPIReadingSitesList)))
+	mux.HandleFunc("GET /api/reading-sites", app.RequireLogin(app.HandleAPIReadingSitesList))
+	mux.HandleFunc("POST /api/reading-sites", app.RequireLogin(app.HandleAPIReadingSiteCreate))
+	mux.HandleFunc("PATCH /api/reading-sites/{id}", app.RequireLogin(app.HandleAPIReadingSiteUpdate))
+	mux.HandleFunc("DELETE /api/reading-sites/{id}", app.RequireLogin(app.HandleAPIReadingSiteDelete))
+	mux.HandleFunc("POST /api/reading-sites/{id}/probe", app.RequireLogin(app.HandleAPIReadingSiteProbe))
 	mux.HandleFunc("/tools", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleTools)))
 	mux.HandleFunc("/tools/csv-import", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleToolsCSVImport)))
 	mux.HandleFunc("/tools/duplicates", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleDuplicates)))
@@ -232,18 +269,43 @@ func main() {
 	mux.HandleFunc("GET /api/recommendations", app.RequireLogin(app.HandleRecommendations))
 	mux.HandleFunc("POST /api/recommendations/dismiss", app.RequireLogin(app.HandleDismissRecommendation))
 	mux.HandleFunc("GET /api/recommendations/media", app.RequireLogin(app.HandleRecommendationMedia))
-	mux.HandleFunc("GET /api/works", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksRead)(app.HandleAPIWorksList)))
-	mux.HandleFunc("GET /api/works/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksRead)(app.HandleAPIWorksDetail)))
-	mux.HandleFunc("POST /api/works", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleAPIWorksCreate)))
-	mux.HandleFunc("POST /api/works/bulk", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleAPIWorksBulk)))
-	mux.HandleFunc("PATCH /api/works/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleAPIWorksUpdate)))
-	mux.HandleFunc("DELETE /api/works/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleAPIWorksDelete)))
-	mux.HandleFunc("GET /api/stats", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksRead)(app.HandleAPIStats)))
+	// Bearer tokens reach a route only through its entry (and scope) in server's apiTokenRoutes.
+	mux.HandleFunc("GET /api/works", app.RequireLogin(app.HandleAPIWorksList))
+	mux.HandleFunc("GET /api/works/{id}", app.RequireLogin(app.HandleAPIWorksDetail))
+	mux.HandleFunc("POST /api/works", app.RequireLogin(app.HandleAPIWorksCreate))
+	mux.HandleFunc("POST /api/works/bulk", app.RequireLogin(app.HandleAPIWorksBulk))
+	mux.HandleFunc("PATCH /api/works/{id}", app.RequireLogin(app.HandleAPIWorksUpdate))
+	mux.HandleFunc("DELETE /api/works/{id}", app.RequireLogin(app.HandleAPIWorksDelete))
+	mux.HandleFunc("GET /api/works/{id}/history", app.RequireLogin(app.HandleAPIWorkHistory))
+	mux.HandleFunc("POST /api/works/{id}/undo", app.RequireLogin(app.HandleAPIWorkUndo))
+	mux.HandleFunc("GET /api/works/{id}/reads", app.RequireLogin(app.HandleAPIWorkReads))
+	mux.HandleFunc("POST /api/works/{id}/reread", app.RequireLogin(app.HandleAPIWorkReread))
+	mux.HandleFunc("PATCH /api/works/{id}/reads/{readID}", app.RequireLogin(app.HandleAPIWorkReadUpdate))
+	mux.HandleFunc("DELETE /api/works/{id}/reads/{readID}", app.RequireLogin(app.HandleAPIWorkReadUpdate))
+	mux.HandleFunc("GET /api/works/{id}/collection", app.RequireLogin(app.HandleAPIWorkCollection))
+	mux.HandleFunc("POST /api/works/{id}/collection", app.RequireLogin(app.HandleAPIWorkCollection))
+	mux.HandleFunc("PATCH /api/works/{id}/collection/{copyID}", app.RequireLogin(app.HandleAPIWorkCopy))
+	mux.HandleFunc("DELETE /api/works/{id}/collection/{copyID}", app.RequireLogin(app.HandleAPIWorkCopy))
+	mux.HandleFunc("GET /api/works/{id}/releases", app.RequireLogin(app.HandleAPIWorkReleases))
+	mux.HandleFunc("GET /api/collection/missing", app.RequireLogin(app.HandleAPICollectionMissing))
+	mux.HandleFunc("GET /api/notifications", app.RequireLogin(app.HandleAPINotifications))
+	mux.HandleFunc("POST /api/notifications", app.RequireLogin(app.HandleAPINotifications))
+	mux.HandleFunc("GET /api/tags", app.RequireLogin(app.HandleAPITagsList))
+	mux.HandleFunc("GET /api/series/{id}", app.RequireLogin(app.HandleAPISeries))
+	mux.HandleFunc("POST /api/series/{id}", app.RequireLogin(app.HandleAPISeries))
+	mux.HandleFunc("GET /api/stats", app.RequireLogin(app.HandleAPIStats))
+	mux.HandleFunc("GET /api/goals", app.RequireLogin(app.HandleAPIGoals))
+	mux.HandleFunc("POST /api/goals", app.RequireLogin(app.HandleAPIGoals))
+	mux.HandleFunc("DELETE /api/goals/{id}", app.RequireLogin(app.HandleAPIGoalDelete))
 	mux.HandleFunc("/edit/{id}", app.RequireLogin(app.HandleEditWork))
-	mux.HandleFunc("POST /api/increment/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleIncrement)))
-	mux.HandleFunc("POST /api/decrement/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleDecrement)))
-	mux.HandleFunc("POST /api/set-chapter/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleSetChapter)))
-	mux.HandleFunc("POST /api/delete/{id}", app.RequireLogin(app.RequireAPIScope(server.ScopeWorksWrite)(app.HandleDeleteWorkAPI)))
+	mux.HandleFunc("GET /series/{id}", app.RequireLogin(app.HandleSeriesPage))
+	mux.HandleFunc("GET /collection", app.RequireLogin(app.HandleCollectionPage))
+	mux.HandleFunc("GET /notifications", app.RequireLogin(app.HandleNotificationsPage))
+	mux.HandleFunc("POST /notifications/read", app.RequireLogin(app.HandleNotificationsRead))
+	mux.HandleFunc("POST /api/increment/{id}", app.RequireLogin(app.HandleIncrement))
+	mux.HandleFunc("POST /api/decrement/{id}", app.RequireLogin(app.HandleDecrement))
+	mux.HandleFunc("POST /api/set-chapter/{id}", app.RequireLogin(app.HandleSetChapter))
+	mux.HandleFunc("POST /api/delete/{id}", app.RequireLogin(app.HandleDeleteWorkAPI))
 	mux.HandleFunc("/export", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleExport)))
 	mux.HandleFunc("POST /import", app.RequireLogin(app.MobileRedirectToDashboard(app.HandleImport)))
 	mux.HandleFunc("/admin/accounts", app.RequireAdmin(app.MobileRedirectToDashboard(app.HandleAdminAccounts)))
@@ -253,10 +315,21 @@ func main() {
 	mux.HandleFunc("POST /api/admin/migrate-postgres/run", app.RequireAdmin(app.RequireSuperadmin(app.RequireWebOnly(app.HandleAPIAdminMigratePostgresRun))))
 	mux.HandleFunc("POST /api/admin/database/delete", app.RequireAdmin(app.RequireWebOnly(app.HandleAPIAdminDatabaseDelete)))
 	mux.HandleFunc("POST /admin/approve/{id}", app.RequireAdmin(app.MobileRedirectToDashboard(app.HandleApproveAccount)))
+	mux.HandleFunc("POST /admin/reject/{id}", app.RequireAdmin(app.MobileRedirectToDashboard(app.HandleRejectAccount)))
+	mux.HandleFunc("GET /admin/accounts/{id}/review", app.RequireAdmin(app.MobileRedirectToDashboard(app.HandleAdminRegistrationReview)))
 	mux.HandleFunc("POST /admin/delete_account/{id}", app.RequireAdmin(app.MobileRedirectToDashboard(app.HandleDeleteAccount)))
 	mux.HandleFunc("POST /admin/promote/{id}", app.RequireAdmin(app.RequireSuperadmin(app.MobileRedirectToDashboard(app.HandlePromoteAccount))))
+	mux.HandleFunc("/admin/invites", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminInvites)))
+	mux.HandleFunc("POST /admin/invites/create", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminInviteCreate)))
+	mux.HandleFunc("POST /admin/invites/{id}/revoke", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminInviteRevoke)))
+	mux.HandleFunc("/admin/oauth-clients", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminOAuthClients)))
+	mux.HandleFunc("POST /admin/oauth-clients/create", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminOAuthClientCreate)))
+	mux.HandleFunc("POST /admin/oauth-clients/{id}/revoke", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminOAuthClientRevoke)))
 	mux.HandleFunc("/admin/backups", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminBackups)))
 	mux.HandleFunc("/admin/audit", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminAuditLog)))
+	mux.HandleFunc("/admin/mail", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminMail)))
+	mux.HandleFunc("POST /admin/mail/{id}/retry", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminMailRetry)))
+	mux.HandleFunc("POST /admin/mail/{id}/delete", app.RequireAdmin(app.RequireWebOnly(app.HandleAdminMailDelete)))
 	mux.HandleFunc("GET /api/admin/instance-stats", app.RequireAdmin(app.HandleAPIAdminInstanceStats))
 	mux.HandleFunc("POST /auth/webauthn/register/begin", app.RequireLogin(app.HandleWebAuthnRegisterBegin))
 	mux.HandleFunc("POST /auth/webauthn/register/finish", app.RequireLogin(app.HandleWebAuthnRegisterFinish))
@@ -268,11 +341,19 @@ func main() {
 	proberCtx, proberCancel := context.WithCancel(context.Background())
 	defer proberCancel()
 	app.StartBackgroundProber(proberCtx, 5*time.Minute)
+	// New chapters of followed works (MangaDex feeds, RSS/Atom release feeds); each source has its own recheck delay.
+	app.StartChapterFeedPoller(proberCtx, 30*time.Minute)
 	app.StartWebhookWorker(proberCtx)
+	// Outbound mail (password resets, digests) is queued in mail_outbox and delivered with retries.
+	app.StartMailOutboxWorker(proberCtx)
+	// Opt-in reading digests (daily/weekly), checked hourly; idle until mail is configured.
+	app.StartDigestScheduler(proberCtx, time.Hour)
+	// Accounts left pending longer than BOOKSTORAGE_PENDING_ACCOUNT_EXPIRY_DAYS are deleted (off by default).
+	app.StartPendingAccountExpiry(proberCtx, time.Hour)
 
 	addr := settings.Host + ":" + strconv.Itoa(settings.Port)
 	log.Printf("%s v%s listening on %s (%s)", appName, Version, addr, settings.Environment)
-	handler := app.WithAccessLog(app.WithRequestID(app.SecurityHeaders(app.WithErrorPages(app.WithDatabaseUnavailable(app.WithRequestPolicies(app.WithAPITokenContext(app.WithAPITokenRoutePolicy(mux))))))))
+	handler := app.Handler(mux)
 	readTO := httpTimeoutSeconds("BOOKSTORAGE_HTTP_READ_TIMEOUT_SEC", 15)
 	writeTO := httpTimeoutSeconds("BOOKSTORAGE_HTTP_WRITE_TIMEOUT_SEC", 120)
 	srv := &http.Server{
@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type anilistProvider struct{}
+
+func init() { RegisterProvider(anilistProvider{}) }
+
+func (anilistProvider) Name() string  { return "anilist" }
+func (anilistProvider) Label() string { return "AniList" }
+
+func (anilistProvider) Capabilities() Capabilities {
+	return Capabilities{Search: true, Browse: true, GetByID: true}
+}
+
+// AnilistResultsToHits maps AniList results to source-independent hits.
+func AnilistResultsToHits(items []AnilistResult) []CatalogMediaHit {
+	out := make([]CatalogMediaHit, 0, len(items))
+	for _, r := range items {
+		out = append(out, CatalogMediaHit{
+			Source:      "anilist",
+			ExternalID:  strconv.Itoa(r.ID),
+			Title:       r.Title,
+			ReadingType: r.ReadingType,
+			ImageURL:    r.ImageURL,
+			Genres:      r.Genres,
+			Tags:        r.Tags,
+			IsAdult:     r.IsAdult,
+		})
+	}
+	return out
+}
+
+func (anilistProvider) Search(query string, limit int) ([]CatalogMediaHit, error) {
+	items, err := SearchAnilist(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return AnilistResultsToHits(items), nil
+}
+
+func (anilistProvider) Browse(p BrowseParams, skip, take int) ([]CatalogMediaHit, bool, error) {
+	notIn := make(map[int]struct{}, len(p.NotInIDs))
+	for ext := range p.NotInIDs {
+		if id, err := strconv.Atoi(strings.TrimSpace(ext)); err == nil && id > 0 {
+			notIn[id] = struct{}{}
+		}
+	}
+	items, hasNext, err := BrowseMediaCollect(BrowseMediaParams{
+		GenreIn:        p.GenreIn,
+		TagIn:          p.TagIn,
+		TagNotIn:       p.TagNotIn,
+		PerPage:        p.PerPage,
+		Sort:           p.Sort,
+		NotInIDs:       notIn,
+		IsAdult:        p.IsAdult,
+		ReadingTypesIn: p.ReadingTypesIn,
+		MediaMatch:     p.MediaMatch,
+	}, skip, take)
+	if err != nil {
+		return nil, false, err
+	}
+	return AnilistResultsToHits(items), hasNext, nil
+}
+
+func (anilistProvider) GetByID(externalID string) (*ProviderMedia, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(externalID))
+	if err != nil || id <= 0 {
+		return nil, ErrMediaNotFound
+	}
+	d, err := GetMediaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || d.Title == "" {
+		return nil, ErrMediaNotFound
+	}
+	tags := make([]string, 0, len(d.Tags))
+	for _, t := range d.Tags {
+		tags = append(tags, t.Name)
+	}
+	var alt []string
+	for _, t := range []string{d.RawMedia.Title.Romaji, d.RawMedia.Title.English} {
+		if t = strings.TrimSpace(t); t != "" && t != d.Title {
+			alt = append(alt, t)
+		}
+	}
+	return &ProviderMedia{
+		Hit: CatalogMediaHit{
+			Source:      "anilist",
+			ExternalID:  strconv.Itoa(d.ID),
+			Title:       d.Title,
+			ReadingType: ReadingTypeFromAnilistDetail(d),
+			ImageURL:    d.ImageURL,
+			Genres:      d.Genres,
+			Tags:        tags,
+			IsAdult:     d.RawMedia.IsAdult,
+		},
+		Description: d.Description,
+		AltTitles:   alt,
+	}, nil
+}
+
+func (anilistProvider) PageURL(externalID string) string {
+	return "https://anilist.co/manga/" + url.PathEscape(externalID)
+}
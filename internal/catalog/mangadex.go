@@ -11,7 +11,7 @@ import (
 	"time"
 )
 
-const mangadexAPIBase = "https://api.mangadex.org"
+const mangadexDefaultBase = "https://api.mangadex.org"
 const mangadexTimeout = 10 * time.Second
 const mangadexMinInterval = 250 * time.Millisecond
 const mangadexMaxResponseBytes = 4 << 20
@@ -19,9 +19,28 @@ const mangadexMaxResponseBytes = 4 << 20
 var (
 	mdMu         sync.Mutex
 	mdLastCall   time.Time
+	mdBaseURL    = mangadexDefaultBase
 	mdHTTPClient = &http.Client{Timeout: mangadexTimeout}
 )
 
+// SetMangaDexBaseURL overrides the MangaDex API base (e.g. a test server).
+// An empty value restores https://api.mangadex.org.
+func SetMangaDexBaseURL(base string) {
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		base = mangadexDefaultBase
+	}
+	mdMu.Lock()
+	mdBaseURL = base
+	mdMu.Unlock()
+}
+
+func mangadexBase() string {
+	mdMu.Lock()
+	defer mdMu.Unlock()
+	return mdBaseURL
+}
+
 func mangadexThrottle() {
 	mdMu.Lock()
 	defer mdMu.Unlock()
@@ -88,7 +107,7 @@ type BrowseMangaDexParams struct {
 
 func mangadexGET(path string, query url.Values) ([]byte, error) {
 	mangadexThrottle()
-	u := mangadexAPIBase + path
+	u := mangadexBase() + path
 	if len(query) > 0 {
 		u += "?" + query.Encode()
 	}
@@ -110,6 +129,9 @@ func mangadexGET(path string, query url.Values) ([]byte, error) {
 		time.Sleep(800 * time.Millisecond)
 		return mangadexGET(path, query)
 	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrMediaNotFound
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("mangadex_http_%d", resp.StatusCode)
 	}
@@ -344,51 +366,12 @@ func BrowseMangaDexCollect(p BrowseMangaDexParams, skip, take int) ([]CatalogMed
 		perPage = 100
 	}
 	p.PerPage = perPage
-
-	type batch struct {
-		items []CatalogMediaHit
-		full  bool
-	}
-	var pages []batch
-	const maxAPIPages = 40
-
-	for apiPage := 1; apiPage <= maxAPIPages; apiPage++ {
+	return collectPages(perPage, 40, skip, take, func(page int) ([]CatalogMediaHit, int, error) {
 		pageP := p
-		pageP.Page = apiPage
+		pageP.Page = page
 		pageP.MaxResults = perPage
-		items, sourceCount, err := BrowseMangaDex(pageP)
-		if err != nil {
-			return nil, false, err
-		}
-		pages = append(pages, batch{items: items, full: sourceCount >= perPage})
-		if !pages[len(pages)-1].full {
-			break
-		}
-		total := 0
-		for _, pg := range pages {
-			total += len(pg.items)
-		}
-		if total >= skip+take {
-			break
-		}
-	}
-
-	var out []CatalogMediaHit
-	skipped := 0
-	for pi, page := range pages {
-		for i, item := range page.items {
-			if skipped < skip {
-				skipped++
-				continue
-			}
-			out = append(out, item)
-			if len(out) >= take {
-				hasNext := i < len(page.items)-1 || page.full || pi < len(pages)-1
-				return out, hasNext, nil
-			}
-		}
-	}
-	return out, false, nil
+		return BrowseMangaDex(pageP)
+	})
 }
 
 // SearchMangaDex searches MangaDex by title.
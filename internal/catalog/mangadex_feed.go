@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// mangadexFeedPageSize covers a few unnumbered entries (oneshots, extras) sorted before the
+// latest numbered chapter.
+const mangadexFeedPageSize = 10
+
+type mdFeedResponse struct {
+	Data []struct {
+		Attributes struct {
+			Chapter            *string `json:"chapter"`
+			TranslatedLanguage string  `json:"translatedLanguage"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// LatestMangaDexChapter returns the highest chapter number published for a MangaDex manga,
+// restricted to languages when non-empty. It returns 0 when no numbered chapter exists.
+func LatestMangaDexChapter(mangaID string, languages []string) (float64, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return 0, ErrMediaNotFound
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(mangadexFeedPageSize))
+	q.Set("order[chapter]", "desc")
+	for _, r := range []string{"safe", "suggestive", "erotica", "pornographic"} {
+		q.Add("contentRating[]", r)
+	}
+	for _, lang := range languages {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			q.Add("translatedLanguage[]", lang)
+		}
+	}
+	body, err := mangadexGET("/manga/"+url.PathEscape(mangaID)+"/feed", q)
+	if err != nil {
+		return 0, err
+	}
+	var resp mdFeedResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, fmt.Errorf("mangadex feed: %w", err)
+	}
+	latest := 0.0
+	for _, ch := range resp.Data {
+		if ch.Attributes.Chapter == nil {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(*ch.Attributes.Chapter), 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		if n > latest {
+			latest = n
+		}
+	}
+	return latest, nil
+}
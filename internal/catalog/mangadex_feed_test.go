@@ -0,0 +1,49 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withMangaDexServer(t *testing.T, h http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	SetMangaDexBaseURL(srv.URL)
+	t.Cleanup(func() {
+		SetMangaDexBaseURL("")
+		srv.Close()
+	})
+}
+
+func TestLatestMangaDexChapter(t *testing.T) {
+	withMangaDexServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manga/md-1/feed":
+			q := r.URL.Query()
+			if q.Get("order[chapter]") != "desc" || len(q["translatedLanguage[]"]) != 1 || q["translatedLanguage[]"][0] != "en" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"data": [
+				{"attributes": {"chapter": null, "translatedLanguage": "en"}},
+				{"attributes": {"chapter": "112.5", "translatedLanguage": "en"}},
+				{"attributes": {"chapter": "112", "translatedLanguage": "en"}}
+			]}`))
+		case "/manga/empty/feed":
+			_, _ = w.Write([]byte(`{"data": []}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	if n, err := LatestMangaDexChapter("md-1", []string{"en", " "}); err != nil || n != 112.5 {
+		t.Fatalf("latest=%v err=%v", n, err)
+	}
+	if n, err := LatestMangaDexChapter("empty", nil); err != nil || n != 0 {
+		t.Fatalf("empty feed latest=%v err=%v", n, err)
+	}
+	if _, err := LatestMangaDexChapter("gone", nil); !errors.Is(err, ErrMediaNotFound) {
+		t.Fatalf("missing manga err=%v", err)
+	}
+}
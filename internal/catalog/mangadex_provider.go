@@ -0,0 +1,39 @@
+package catalog
+
+import "net/url"
+
+type mangaDexProvider struct{}
+
+func init() { RegisterProvider(mangaDexProvider{}) }
+
+func (mangaDexProvider) Name() string  { return "mangadex" }
+func (mangaDexProvider) Label() string { return "MangaDex" }
+
+func (mangaDexProvider) Capabilities() Capabilities {
+	return Capabilities{Search: true, Browse: true}
+}
+
+func (mangaDexProvider) Search(query string, limit int) ([]CatalogMediaHit, error) {
+	return SearchMangaDex(query, limit)
+}
+
+func (mangaDexProvider) Browse(p BrowseParams, skip, take int) ([]CatalogMediaHit, bool, error) {
+	return BrowseMangaDexCollect(BrowseMangaDexParams{
+		GenreIn:        p.GenreIn,
+		PerPage:        p.PerPage,
+		Sort:           p.Sort,
+		NotInIDs:       p.NotInIDs,
+		IsAdult:        p.IsAdult,
+		ReadingTypesIn: p.ReadingTypesIn,
+		TagNotIn:       p.TagNotIn,
+		MediaMatch:     p.MediaMatch,
+	}, skip, take)
+}
+
+func (mangaDexProvider) GetByID(string) (*ProviderMedia, error) {
+	return nil, ErrNotSupported
+}
+
+func (mangaDexProvider) PageURL(externalID string) string {
+	return "https://mangadex.org/title/" + url.PathEscape(externalID)
+}
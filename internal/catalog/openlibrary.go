@@ -0,0 +1,444 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const openLibraryDefaultBase = "https://openlibrary.org"
+const openLibraryCoversBase = "https://covers.openlibrary.org"
+const openLibraryTimeout = 10 * time.Second
+const openLibraryMinInterval = 350 * time.Millisecond
+const openLibraryMaxResponseBytes = 4 << 20
+const openLibraryMaxSubjects = 12
+
+const openLibrarySearchFields = "key,title,cover_i,subject"
+
+var (
+	olMu         sync.Mutex
+	olLastCall   time.Time
+	olBaseURL    = openLibraryDefaultBase
+	olHTTPClient = &http.Client{Timeout: openLibraryTimeout}
+)
+
+// SetOpenLibraryBaseURL overrides the Open Library API base (e.g. a local mirror or a test server).
+// An empty value restores https://openlibrary.org.
+func SetOpenLibraryBaseURL(base string) {
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		base = openLibraryDefaultBase
+	}
+	olMu.Lock()
+	olBaseURL = base
+	olMu.Unlock()
+}
+
+func openLibraryBase() string {
+	olMu.Lock()
+	defer olMu.Unlock()
+	return olBaseURL
+}
+
+func openLibraryThrottle() {
+	olMu.Lock()
+	defer olMu.Unlock()
+	if elapsed := time.Since(olLastCall); elapsed < openLibraryMinInterval {
+		time.Sleep(openLibraryMinInterval - elapsed)
+	}
+	olLastCall = time.Now()
+}
+
+func openLibraryGET(path string, query url.Values) ([]byte, error) {
+	openLibraryThrottle()
+	u := openLibraryBase() + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// Open Library asks API clients to identify themselves.
+	req.Header.Set("User-Agent", "BookStorage")
+	resp, err := olHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, openLibraryMaxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrMediaNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("openlibrary_http_%d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+type olSearchDoc struct {
+	Key      string   `json:"key"`
+	Title    string   `json:"title"`
+	CoverID  int      `json:"cover_i"`
+	Subjects []string `json:"subject"`
+}
+
+type olSearchResponse struct {
+	NumFound int           `json:"numFound"`
+	Docs     []olSearchDoc `json:"docs"`
+}
+
+// openLibraryWorkID strips the "/works/" prefix: "/works/OL45804W" -> "OL45804W".
+func openLibraryWorkID(key string) string {
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(key, "/works/")
+	if key == "" || strings.Contains(key, "/") {
+		return ""
+	}
+	return key
+}
+
+func openLibraryCoverURL(coverID int) string {
+	if coverID <= 0 {
+		return ""
+	}
+	return openLibraryCoversBase + "/b/id/" + strconv.Itoa(coverID) + "-L.jpg"
+}
+
+// Open Library subjects mix genres with catalog bookkeeping; these never describe the book itself.
+var openLibraryNoiseSubjects = map[string]struct{}{
+	"accessible book":  {},
+	"protected daisy":  {},
+	"in library":       {},
+	"lending library":  {},
+	"large type books": {},
+	"overdrive":        {},
+	"open library":     {},
+	"staff picks":      {},
+}
+
+// splitOpenLibrarySubjects keeps readable subjects: those naming an AniList genre become genres
+// (so the browse genre filter applies), the rest become tags.
+func splitOpenLibrarySubjects(subjects []string) (genres, tags []string) {
+	seen := make(map[string]struct{})
+	for _, raw := range subjects {
+		s := strings.TrimSpace(raw)
+		lower := strings.ToLower(s)
+		if s == "" || strings.ContainsAny(s, ":=") || len(s) > 60 {
+			continue
+		}
+		if _, noise := openLibraryNoiseSubjects[lower]; noise {
+			continue
+		}
+		if _, dup := seen[lower]; dup {
+			continue
+		}
+		seen[lower] = struct{}{}
+		if g := anilistGenreFold(s); g != "" {
+			genres = append(genres, g)
+			continue
+		}
+		if len(tags) < openLibraryMaxSubjects {
+			tags = append(tags, s)
+		}
+	}
+	return genres, tags
+}
+
+func anilistGenreFold(s string) string {
+	for _, g := range anilistGenres {
+		if strings.EqualFold(g, s) {
+			return g
+		}
+	}
+	return ""
+}
+
+// mapOpenLibraryReadingType picks the closest reading type. BookStorage has no plain novel type,
+// so prose falls back to Light Novel (progress counted in volumes).
+func mapOpenLibraryReadingType(subjects []string) string {
+	for _, s := range subjects {
+		if isWebtoonKeyword(s) {
+			return "Webtoon"
+		}
+	}
+	for _, s := range subjects {
+		lower := strings.ToLower(s)
+		if strings.Contains(lower, "manga") || strings.Contains(lower, "comic books") || strings.Contains(lower, "graphic novels") {
+			return "Manga"
+		}
+	}
+	return "Light Novel"
+}
+
+func openLibraryIsAdult(subjects []string) bool {
+	for _, s := range subjects {
+		lower := strings.ToLower(s)
+		if strings.Contains(lower, "erotica") || strings.Contains(lower, "erotic fiction") {
+			return true
+		}
+	}
+	return false
+}
+
+func mapOpenLibraryHit(workID, title string, coverID int, subjects []string) CatalogMediaHit {
+	genres, tags := splitOpenLibrarySubjects(subjects)
+	return CatalogMediaHit{
+		Source:      "openlibrary",
+		ExternalID:  workID,
+		Title:       strings.TrimSpace(title),
+		ReadingType: mapOpenLibraryReadingType(subjects),
+		ImageURL:    openLibraryCoverURL(coverID),
+		Genres:      genres,
+		Tags:        tags,
+		IsAdult:     openLibraryIsAdult(subjects),
+	}
+}
+
+// NormalizeISBNQuery returns the bare ISBN-10/13 when q looks like one ("978-2-...", "2-7560-..."), else "".
+// Only the shape is checked; Open Library simply returns nothing for a bad checksum.
+func NormalizeISBNQuery(q string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(q) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == 'x' || r == 'X':
+			b.WriteRune('X')
+		case r == '-' || r == ' ':
+		default:
+			return ""
+		}
+	}
+	s := b.String()
+	if len(s) == 13 && !strings.Contains(s, "X") {
+		return s
+	}
+	if len(s) == 10 && !strings.Contains(s[:9], "X") {
+		return s
+	}
+	return ""
+}
+
+// BrowseOpenLibraryParams filters Open Library subject listings (post-filtered like MangaDex).
+type BrowseOpenLibraryParams struct {
+	GenreIn        []string
+	PerPage        int
+	Page           int
+	Sort           string // POPULARITY_DESC, SCORE_DESC
+	NotInIDs       map[string]struct{}
+	IsAdult        *bool
+	ReadingTypesIn []string
+	TagNotIn       []string
+	MediaMatch     func(genres, tags []string) bool
+	MaxResults     int
+}
+
+func parseOpenLibrarySearch(body []byte, p BrowseOpenLibraryParams) ([]CatalogMediaHit, int, error) {
+	var raw olSearchResponse
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, 0, err
+	}
+	typeFilter := make(map[string]struct{}, len(p.ReadingTypesIn))
+	for _, rt := range p.ReadingTypesIn {
+		typeFilter[rt] = struct{}{}
+	}
+	max := p.MaxResults
+	if max <= 0 {
+		max = p.PerPage
+	}
+	var out []CatalogMediaHit
+	for _, doc := range raw.Docs {
+		id := openLibraryWorkID(doc.Key)
+		if id == "" {
+			continue
+		}
+		if p.NotInIDs != nil {
+			if _, skip := p.NotInIDs[id]; skip {
+				continue
+			}
+		}
+		hit := mapOpenLibraryHit(id, doc.Title, doc.CoverID, doc.Subjects)
+		if hit.Title == "" {
+			continue
+		}
+		if len(p.TagNotIn) > 0 && labelsMatchAny(append(append([]string(nil), hit.Genres...), hit.Tags...), p.TagNotIn) {
+			continue
+		}
+		if p.MediaMatch != nil && !p.MediaMatch(hit.Genres, hit.Tags) {
+			continue
+		}
+		if p.IsAdult != nil && hit.IsAdult != *p.IsAdult {
+			continue
+		}
+		if len(typeFilter) > 0 {
+			if _, ok := typeFilter[hit.ReadingType]; !ok {
+				continue
+			}
+		}
+		out = append(out, hit)
+		if max > 0 && len(out) >= max {
+			break
+		}
+	}
+	return out, len(raw.Docs), nil
+}
+
+func openLibraryBrowseQuery(p BrowseOpenLibraryParams) url.Values {
+	q := url.Values{}
+	subjects := make([]string, 0, len(p.GenreIn))
+	for _, g := range p.GenreIn {
+		if g = strings.TrimSpace(g); g != "" {
+			subjects = append(subjects, `subject:"`+strings.ReplaceAll(g, `"`, "")+`"`)
+		}
+	}
+	if len(subjects) == 0 {
+		subjects = append(subjects, "subject:fiction")
+	}
+	q.Set("q", strings.Join(subjects, " OR "))
+	q.Set("fields", openLibrarySearchFields)
+	limit := p.PerPage
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	q.Set("limit", strconv.Itoa(limit))
+	if p.Page > 1 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	switch p.Sort {
+	case "SCORE_DESC":
+		q.Set("sort", "rating")
+	default:
+		q.Set("sort", "readinglog")
+	}
+	return q
+}
+
+// BrowseOpenLibrary runs one Open Library search page by subject with local filters.
+func BrowseOpenLibrary(p BrowseOpenLibraryParams) ([]CatalogMediaHit, int, error) {
+	if p.PerPage <= 0 {
+		p.PerPage = 20
+	}
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	body, err := openLibraryGET("/search.json", openLibraryBrowseQuery(p))
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseOpenLibrarySearch(body, p)
+}
+
+// BrowseOpenLibraryCollect pages until skip/take satisfied (like BrowseMangaDexCollect).
+func BrowseOpenLibraryCollect(p BrowseOpenLibraryParams, skip, take int) ([]CatalogMediaHit, bool, error) {
+	if take <= 0 {
+		take = 20
+	}
+	perPage := p.PerPage
+	if perPage <= 0 {
+		perPage = 25
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	p.PerPage = perPage
+	return collectPages(perPage, 20, skip, take, func(page int) ([]CatalogMediaHit, int, error) {
+		pageP := p
+		pageP.Page = page
+		pageP.MaxResults = perPage
+		return BrowseOpenLibrary(pageP)
+	})
+}
+
+// SearchOpenLibrary searches Open Library by title, or by ISBN when the query is one.
+func SearchOpenLibrary(query string, limit int) ([]CatalogMediaHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	q := url.Values{}
+	if isbn := NormalizeISBNQuery(query); isbn != "" {
+		q.Set("isbn", isbn)
+	} else {
+		q.Set("title", query)
+	}
+	q.Set("fields", openLibrarySearchFields)
+	q.Set("limit", strconv.Itoa(limit))
+	body, err := openLibraryGET("/search.json", q)
+	if err != nil {
+		return nil, err
+	}
+	hits, _, err := parseOpenLibrarySearch(body, BrowseOpenLibraryParams{PerPage: limit, MaxResults: limit})
+	return hits, err
+}
+
+// GetOpenLibraryWork fetches one work (cover, subjects, description) by its id ("OL45804W").
+func GetOpenLibraryWork(workID string) (*ProviderMedia, error) {
+	workID = openLibraryWorkID(workID)
+	if workID == "" || !strings.HasPrefix(workID, "OL") {
+		return nil, fmt.Errorf("openlibrary: invalid work id")
+	}
+	body, err := openLibraryGET("/works/"+url.PathEscape(workID)+".json", nil)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Key         string          `json:"key"`
+		Title       string          `json:"title"`
+		Covers      []int           `json:"covers"`
+		Subjects    []string        `json:"subjects"`
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	cover := 0
+	for _, c := range raw.Covers {
+		if c > 0 {
+			cover = c
+			break
+		}
+	}
+	id := openLibraryWorkID(raw.Key)
+	if id == "" {
+		id = workID
+	}
+	return &ProviderMedia{
+		Hit:         mapOpenLibraryHit(id, raw.Title, cover, raw.Subjects),
+		Description: openLibraryText(raw.Description),
+	}, nil
+}
+
+// openLibraryText decodes fields that are either a string or {"type": "/type/text", "value": "..."}.
+func openLibraryText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var typed struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &typed) == nil {
+		return strings.TrimSpace(typed.Value)
+	}
+	return ""
+}
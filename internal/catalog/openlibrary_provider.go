@@ -0,0 +1,39 @@
+package catalog
+
+import "net/url"
+
+type openLibraryProvider struct{}
+
+func init() { RegisterProvider(openLibraryProvider{}) }
+
+func (openLibraryProvider) Name() string  { return "openlibrary" }
+func (openLibraryProvider) Label() string { return "Open Library" }
+
+func (openLibraryProvider) Capabilities() Capabilities {
+	return Capabilities{Search: true, Browse: true, GetByID: true, ISBN: true}
+}
+
+func (openLibraryProvider) Search(query string, limit int) ([]CatalogMediaHit, error) {
+	return SearchOpenLibrary(query, limit)
+}
+
+func (openLibraryProvider) Browse(p BrowseParams, skip, take int) ([]CatalogMediaHit, bool, error) {
+	return BrowseOpenLibraryCollect(BrowseOpenLibraryParams{
+		GenreIn:        p.GenreIn,
+		PerPage:        p.PerPage,
+		Sort:           p.Sort,
+		NotInIDs:       p.NotInIDs,
+		IsAdult:        p.IsAdult,
+		ReadingTypesIn: p.ReadingTypesIn,
+		TagNotIn:       p.TagNotIn,
+		MediaMatch:     p.MediaMatch,
+	}, skip, take)
+}
+
+func (openLibraryProvider) GetByID(externalID string) (*ProviderMedia, error) {
+	return GetOpenLibraryWork(externalID)
+}
+
+func (openLibraryProvider) PageURL(externalID string) string {
+	return "https://openlibrary.org/works/" + url.PathEscape(externalID)
+}
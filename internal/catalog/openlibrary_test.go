@@ -0,0 +1,112 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withOpenLibraryServer(t *testing.T, h http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	SetOpenLibraryBaseURL(srv.URL)
+	t.Cleanup(func() {
+		SetOpenLibraryBaseURL("")
+		srv.Close()
+	})
+}
+
+func TestNormalizeISBNQuery(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"978-2-7560-9876-5": "9782756098765",
+		"2 7560 9876 x":     "275609876X",
+		"Dune":              "",
+		"12345":             "",
+		"97827560987X5":     "",
+	}
+	for in, want := range cases {
+		if got := NormalizeISBNQuery(in); got != want {
+			t.Fatalf("%q: got %q want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchOpenLibrary_isbnAndMapping(t *testing.T) {
+	withOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search.json" || r.URL.Query().Get("isbn") != "9780441172719" || r.URL.Query().Get("title") != "" {
+			t.Errorf("unexpected request %s", r.URL.String())
+		}
+		_, _ = w.Write([]byte(`{"numFound": 2, "docs": [
+			{"key": "/works/OL893415W", "title": "Dune", "cover_i": 11481354,
+			 "subject": ["Science fiction", "Fantasy", "Accessible book", "nyt:mass-market-paperback=2021-10-17", "fantasy", "Dune (Imaginary place)"]},
+			{"key": "/works/OL1W", "title": "  "}
+		]}`))
+	})
+	hits, err := SearchOpenLibrary("978-0-441-17271-9", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("hits=%+v", hits)
+	}
+	h := hits[0]
+	if h.Source != "openlibrary" || h.ExternalID != "OL893415W" || h.ReadingType != "Light Novel" ||
+		h.ImageURL != "https://covers.openlibrary.org/b/id/11481354-L.jpg" || h.IsAdult {
+		t.Fatalf("hit=%+v", h)
+	}
+	if len(h.Genres) != 1 || h.Genres[0] != "Fantasy" || len(h.Tags) != 2 || h.Tags[0] != "Science fiction" {
+		t.Fatalf("genres=%v tags=%v", h.Genres, h.Tags)
+	}
+}
+
+func TestBrowseOpenLibraryCollect_filters(t *testing.T) {
+	withOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != `subject:"Romance" OR subject:"Drama"` || r.URL.Query().Get("sort") != "rating" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"numFound": 3, "docs": [
+			{"key": "/works/OL10W", "title": "Known", "subject": ["Romance"]},
+			{"key": "/works/OL11W", "title": "Spicy", "subject": ["Romance", "Erotica"]},
+			{"key": "/works/OL12W", "title": "Comic", "subject": ["Drama", "Comic books, strips, etc"]}
+		]}`))
+	})
+	notAdult := false
+	hits, hasNext, err := BrowseOpenLibraryCollect(BrowseOpenLibraryParams{
+		GenreIn:  []string{"Romance", "Drama"},
+		Sort:     "SCORE_DESC",
+		NotInIDs: map[string]struct{}{"OL10W": {}},
+		IsAdult:  &notAdult,
+	}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNext || len(hits) != 1 || hits[0].Title != "Comic" || hits[0].ReadingType != "Manga" {
+		t.Fatalf("hasNext=%v hits=%+v", hasNext, hits)
+	}
+}
+
+func TestGetOpenLibraryWork(t *testing.T) {
+	withOpenLibraryServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/OL27448W.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"key": "/works/OL27448W", "title": "The Lord of the Rings", "covers": [-1, 14625765],
+			"subjects": ["Fantasy", "Adventure"], "description": {"type": "/type/text", "value": " An epic. "}}`))
+	})
+	work, err := GetOpenLibraryWork("/works/OL27448W")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Hit.Title != "The Lord of the Rings" || work.Description != "An epic." ||
+		work.Hit.ImageURL != "https://covers.openlibrary.org/b/id/14625765-L.jpg" || len(work.Hit.Genres) != 2 {
+		t.Fatalf("work=%+v", work)
+	}
+	if _, err := GetOpenLibraryWork("OL404W"); err == nil {
+		t.Fatal("expected error for missing work")
+	}
+	if _, err := GetOpenLibraryWork("../admin"); err == nil {
+		t.Fatal("expected error for invalid id")
+	}
+}
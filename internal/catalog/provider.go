@@ -0,0 +1,211 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNotSupported is returned when a provider lacks the requested capability.
+var ErrNotSupported = errors.New("catalog: not supported by provider")
+
+// ErrMediaNotFound is returned by GetByID when the upstream has no such entry.
+var ErrMediaNotFound = errors.New("catalog: media not found")
+
+// Capabilities lists what a Provider supports; callers skip providers lacking one.
+type Capabilities struct {
+	Search  bool
+	Browse  bool
+	GetByID bool
+	// ISBN reports that Search accepts an ISBN-10/13 as the query.
+	ISBN bool
+}
+
+// BrowseParams are the source-independent browse filters. Providers apply what their API
+// supports upstream and post-filter the rest (TagIn is only honored where the API has it).
+type BrowseParams struct {
+	GenreIn        []string
+	TagIn          []string
+	TagNotIn       []string
+	PerPage        int
+	Sort           string // POPULARITY_DESC, SCORE_DESC
+	NotInIDs       map[string]struct{}
+	IsAdult        *bool
+	ReadingTypesIn []string
+	MediaMatch     func(genres, tags []string) bool
+}
+
+// ProviderMedia is one entry fetched by id, with the fields cached in the catalog table.
+type ProviderMedia struct {
+	Hit         CatalogMediaHit
+	Description string
+	AltTitles   []string
+}
+
+// Provider is a catalog source (AniList, MangaDex, Open Library…). Name is the value stored in
+// catalog.source and accepted as ?source=.
+type Provider interface {
+	Name() string
+	Label() string
+	Capabilities() Capabilities
+	Search(query string, limit int) ([]CatalogMediaHit, error)
+	// Browse returns take hits after skipping skip, and whether more are available.
+	Browse(p BrowseParams, skip, take int) ([]CatalogMediaHit, bool, error)
+	GetByID(externalID string) (*ProviderMedia, error)
+	// PageURL is the public web page of an entry, or "".
+	PageURL(externalID string) string
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   []Provider
+	// enabledProviders is nil when every registered provider is enabled.
+	enabledProviders map[string]bool
+)
+
+func providerKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// RegisterProvider adds a provider; registration order is the display and fallback order.
+// It panics on a duplicate name, like database/sql.Register.
+func RegisterProvider(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	key := providerKey(p.Name())
+	for _, existing := range providers {
+		if providerKey(existing.Name()) == key {
+			panic(fmt.Sprintf("catalog: provider %q registered twice", key))
+		}
+	}
+	providers = append(providers, p)
+}
+
+// SetEnabledProviders restricts the instance to the named providers (empty enables all)
+// and returns the names that match no registered provider.
+func SetEnabledProviders(names []string) (unknown []string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if len(names) == 0 {
+		enabledProviders = nil
+		return nil
+	}
+	enabled := make(map[string]bool, len(names))
+	for _, n := range names {
+		key := providerKey(n)
+		if key == "" {
+			continue
+		}
+		found := false
+		for _, p := range providers {
+			if providerKey(p.Name()) == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, key)
+			continue
+		}
+		enabled[key] = true
+	}
+	enabledProviders = enabled
+	return unknown
+}
+
+// LookupProvider returns a registered provider, enabled or not (existing catalog rows keep
+// their page links when a source is turned off).
+func LookupProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	key := providerKey(name)
+	for _, p := range providers {
+		if providerKey(p.Name()) == key {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// EnabledProvider returns the named provider when it is registered and enabled.
+func EnabledProvider(name string) (Provider, bool) {
+	p, ok := LookupProvider(name)
+	if !ok {
+		return nil, false
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	if enabledProviders != nil && !enabledProviders[providerKey(p.Name())] {
+		return nil, false
+	}
+	return p, true
+}
+
+// EnabledProviders lists enabled providers in registration order.
+func EnabledProviders() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	out := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		if enabledProviders == nil || enabledProviders[providerKey(p.Name())] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// FirstEnabledProvider returns the first enabled provider satisfying want, or nil.
+func FirstEnabledProvider(want func(Capabilities) bool) Provider {
+	for _, p := range EnabledProviders() {
+		if want == nil || want(p.Capabilities()) {
+			return p
+		}
+	}
+	return nil
+}
+
+// collectPages pages through fetch until skip+take hits are gathered (shared by Browse
+// implementations whose API only filters part of what the app asks for). fetch returns the
+// filtered hits of one API page and the raw item count of that page.
+func collectPages(perPage, maxPages, skip, take int, fetch func(page int) ([]CatalogMediaHit, int, error)) ([]CatalogMediaHit, bool, error) {
+	type batch struct {
+		items []CatalogMediaHit
+		full  bool
+	}
+	var pages []batch
+	for apiPage := 1; apiPage <= maxPages; apiPage++ {
+		items, sourceCount, err := fetch(apiPage)
+		if err != nil {
+			return nil, false, err
+		}
+		pages = append(pages, batch{items: items, full: sourceCount >= perPage})
+		if !pages[len(pages)-1].full {
+			break
+		}
+		total := 0
+		for _, pg := range pages {
+			total += len(pg.items)
+		}
+		if total >= skip+take {
+			break
+		}
+	}
+
+	var out []CatalogMediaHit
+	skipped := 0
+	for pi, page := range pages {
+		for i, item := range page.items {
+			if skipped < skip {
+				skipped++
+				continue
+			}
+			out = append(out, item)
+			if len(out) >= take {
+				hasNext := i < len(page.items)-1 || page.full || pi < len(pages)-1
+				return out, hasNext, nil
+			}
+		}
+	}
+	return out, false, nil
+}
@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func providerNames(ps []Provider) string {
+	names := make([]string, 0, len(ps))
+	for _, p := range ps {
+		names = append(names, p.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestProviderRegistry(t *testing.T) {
+	t.Cleanup(func() { SetEnabledProviders(nil) })
+	if got := providerNames(EnabledProviders()); got != "anilist,mangadex,openlibrary" {
+		t.Fatalf("providers=%s", got)
+	}
+	if p, ok := LookupProvider(" AniList "); !ok || p.Name() != "anilist" {
+		t.Fatalf("lookup anilist: %v %v", p, ok)
+	}
+
+	unknown := SetEnabledProviders([]string{"OpenLibrary", "kitsu", "mangadex"})
+	if len(unknown) != 1 || unknown[0] != "kitsu" {
+		t.Fatalf("unknown=%v", unknown)
+	}
+	if got := providerNames(EnabledProviders()); got != "mangadex,openlibrary" {
+		t.Fatalf("enabled=%s", got)
+	}
+	if _, ok := EnabledProvider("anilist"); ok {
+		t.Fatal("anilist should be disabled")
+	}
+	if _, ok := LookupProvider("anilist"); !ok {
+		t.Fatal("disabled providers stay registered")
+	}
+	if p := FirstEnabledProvider(func(c Capabilities) bool { return c.ISBN }); p == nil || p.Name() != "openlibrary" {
+		t.Fatalf("isbn provider=%v", p)
+	}
+	if p := FirstEnabledProvider(func(c Capabilities) bool { return c.GetByID }); p == nil || p.Name() != "openlibrary" {
+		t.Fatalf("get-by-id provider=%v", p)
+	}
+	if _, err := (mangaDexProvider{}).GetByID("x"); err != ErrNotSupported {
+		t.Fatalf("mangadex GetByID err=%v", err)
+	}
+}
+
+func TestCollectPages(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	fetch := func(page int) ([]CatalogMediaHit, int, error) {
+		calls++
+		// Each full API page keeps 2 of 3 items after local filters; the third page is short.
+		if page == 3 {
+			return []CatalogMediaHit{{ExternalID: "p3a"}}, 1, nil
+		}
+		id := string(rune('0' + page))
+		return []CatalogMediaHit{{ExternalID: id + "a"}, {ExternalID: id + "b"}}, 3, nil
+	}
+	hits, hasNext, err := collectPages(3, 10, 1, 2, fetch)
+	if err != nil || !hasNext || len(hits) != 2 || hits[0].ExternalID != "1b" || hits[1].ExternalID != "2a" || calls != 2 {
+		t.Fatalf("hits=%+v hasNext=%v calls=%d err=%v", hits, hasNext, calls, err)
+	}
+	calls = 0
+	hits, hasNext, _ = collectPages(3, 10, 4, 5, fetch)
+	if hasNext || len(hits) != 1 || hits[0].ExternalID != "p3a" || calls != 3 {
+		t.Fatalf("tail hits=%+v hasNext=%v calls=%d", hits, hasNext, calls)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/netip"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -35,22 +36,60 @@ type Settings struct {
 	EnableHSTS         bool
 	// RequireAccountValidation controls whether non-admin accounts must be approved (validated=1) before login.
 	RequireAccountValidation bool
+	// RegistrationMode is "open", "approval", "invite" or "closed"; empty follows RequireAccountValidation.
+	RegistrationMode string
+	// RequireAdmin2FA makes admins enroll a TOTP authenticator before they can use admin pages.
+	RequireAdmin2FA bool
+	// PendingAccountExpiryDays deletes accounts still awaiting approval after that many days; 0 keeps them indefinitely.
+	PendingAccountExpiryDays int
 	// TranslateURL is a LibreTranslate-compatible API base URL (no trailing slash), e.g. https://libretranslate.com — empty disables auto-translation.
 	TranslateURL    string
 	TranslateAPIKey string
+	// CatalogSources lists the enabled catalog providers (e.g. anilist, mangadex, openlibrary); empty enables all.
+	CatalogSources []string
+	// OpenLibraryURL is the Open Library API base URL (no trailing slash); empty means https://openlibrary.org.
+	OpenLibraryURL string
+	// ChapterFeedLanguages restricts new-chapter detection to these MangaDex translation languages (e.g. en, fr); empty accepts any.
+	ChapterFeedLanguages []string
 	// MetricsToken, if non-empty, protects GET /metrics (Authorization: Bearer only). If empty, /metrics is only reachable from loopback clients.
 	MetricsToken string
 	// TrustProxy uses X-Forwarded-For as client IP for rate limiting when true (set behind a trusted reverse proxy).
 	TrustProxy bool
+	// TrustedProxies are the peer addresses allowed to assert the user through ProxyAuthHeader.
+	TrustedProxies []netip.Prefix
+	// ProxyAuthHeader (e.g. Remote-User) names the header carrying the username authenticated by the
+	// reverse proxy (Authelia, oauth2-proxy...); empty disables forward authentication.
+	ProxyAuthHeader string
+	// ProxyAuthEmailHeader / ProxyAuthGroupsHeader optionally carry the user's email and comma-separated groups.
+	ProxyAuthEmailHeader  string
+	ProxyAuthGroupsHeader string
+	// ProxyAuthAdminGroups grants admin to members of any of these groups and revokes it from everyone else.
+	ProxyAuthAdminGroups []string
+	// ProxyAuthAutoCreate creates an account for an unknown proxy user instead of refusing the header.
+	ProxyAuthAutoCreate bool
+	// ProxyAuthLogoutURL is where /logout sends the browser so the proxy session ends too.
+	ProxyAuthLogoutURL string
 	// PublicOrigin is the public base URL without trailing slash (e.g. https://books.example.com). Required for Google OAuth redirect_uri.
 	PublicOrigin string
 	// GoogleClientID / GoogleClientSecret enable Sign in with Google when set with PublicOrigin.
 	GoogleClientID     string
 	GoogleClientSecret string
-	// MailjetAPIKeyPublic / MailjetAPIKeyPrivate / MailFrom enable transactional email when set with PublicOrigin.
+	// OIDCProviders are the generic OpenID Connect identity providers (Authelia, Keycloak, Authentik...) offered on the login page.
+	OIDCProviders []OIDCProvider
+	// MailFrom is the sender address of transactional email; it enables mail with PublicOrigin and one backend (Mailjet or SMTP).
+	MailFrom string
+	// MailjetAPIKeyPublic / MailjetAPIKeyPrivate select the Mailjet HTTP API backend.
 	MailjetAPIKeyPublic  string
 	MailjetAPIKeyPrivate string
-	MailFrom             string
+	// SMTPHost selects the SMTP backend (own relay). SMTPPort defaults to 587, or 465 with SMTPSecurity "tls", or 25 with "none".
+	SMTPHost string
+	SMTPPort int
+	// SMTPSecurity is "starttls" (default, STARTTLS required), "tls" (implicit TLS) or "none" (plain connection).
+	SMTPSecurity string
+	// SMTPUsername / SMTPPassword enable SMTP authentication; SMTPAuth is "plain" (default) or "login".
+	SMTPUsername string
+	SMTPPassword string
+	SMTPAuth     string
 	// Timezone is the IANA timezone name used to display times in the web UI (e.g. "Europe/Paris"). Defaults to UTC.
 	Timezone string
 }
@@ -95,15 +134,130 @@ func (s *Settings) GoogleOAuthConfigured() bool {
 		strings.TrimSpace(s.GoogleClientSecret) != ""
 }
 
+// OIDCProvider describes one OpenID Connect identity provider, read from BOOKSTORAGE_OIDC_<ID>_* variables.
+type OIDCProvider struct {
+	// ID is the short name used in URLs (/auth/oidc/{id}) and in user_identities.provider.
+	ID string
+	// Name is the label shown on the login button; defaults to ID.
+	Name string
+	// Issuer is the issuer URL; {Issuer}/.well-known/openid-configuration must serve the discovery document.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes requested at authorization; "openid" is always included.
+	Scopes []string
+	// UsernameClaim is the ID-token claim used to name new accounts (default preferred_username, then the email local part).
+	UsernameClaim string
+}
+
+// ProxyAuthConfigured reports whether forward authentication headers are honoured.
+func (s *Settings) ProxyAuthConfigured() bool {
+	return s != nil && s.ProxyAuthHeader != "" && len(s.TrustedProxies) > 0
+}
+
+// TrustedProxy reports whether addr (an IP, optionally with port) is one of TrustedProxies.
+func (s *Settings) TrustedProxy(addr string) bool {
+	if s == nil || len(s.TrustedProxies) == 0 {
+		return false
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range s.TrustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// OIDCConfigured reports whether generic OIDC login routes should be active.
+func (s *Settings) OIDCConfigured() bool {
+	return s != nil && strings.TrimSpace(s.PublicOrigin) != "" && len(s.OIDCProviders) > 0
+}
+
+// OIDCProvider returns the configured provider with the given ID.
+func (s *Settings) OIDCProvider(id string) (OIDCProvider, bool) {
+	if !s.OIDCConfigured() {
+		return OIDCProvider{}, false
+	}
+	for _, p := range s.OIDCProviders {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return OIDCProvider{}, false
+}
+
 // MailConfigured reports whether transactional email (password reset) should be active.
 func (s *Settings) MailConfigured() bool {
 	if s == nil {
 		return false
 	}
 	return strings.TrimSpace(s.PublicOrigin) != "" &&
+		strings.TrimSpace(s.MailFrom) != "" &&
+		(s.MailjetConfigured() || s.SMTPConfigured())
+}
+
+// MailjetConfigured reports whether both Mailjet API keys are set.
+func (s *Settings) MailjetConfigured() bool {
+	return s != nil &&
 		strings.TrimSpace(s.MailjetAPIKeyPublic) != "" &&
-		strings.TrimSpace(s.MailjetAPIKeyPrivate) != "" &&
-		strings.TrimSpace(s.MailFrom) != ""
+		strings.TrimSpace(s.MailjetAPIKeyPrivate) != ""
+}
+
+// SMTPConfigured reports whether an SMTP relay is set (BOOKSTORAGE_SMTP_HOST).
+func (s *Settings) SMTPConfigured() bool {
+	return s != nil && strings.TrimSpace(s.SMTPHost) != ""
+}
+
+// Values accepted by BOOKSTORAGE_SMTP_SECURITY and BOOKSTORAGE_SMTP_AUTH.
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+	SMTPAuthPlain        = "plain"
+	SMTPAuthLogin        = "login"
+)
+
+// Values accepted by BOOKSTORAGE_REGISTRATION_MODE.
+const (
+	RegistrationOpen     = "open"
+	RegistrationApproval = "approval"
+	RegistrationInvite   = "invite"
+	RegistrationClosed   = "closed"
+)
+
+// EffectiveRegistrationMode returns RegistrationMode, or when unset "approval" or "open"
+// depending on RequireAccountValidation (the behaviour before registration modes existed).
+func (s *Settings) EffectiveRegistrationMode() string {
+	if s == nil {
+		return RegistrationApproval
+	}
+	if s.RegistrationMode != "" {
+		return s.RegistrationMode
+	}
+	if s.RequireAccountValidation {
+		return RegistrationApproval
+	}
+	return RegistrationOpen
+}
+
+// defaultSMTPPort returns the conventional submission port for an SMTP security mode.
+func defaultSMTPPort(security string) int {
+	switch security {
+	case SMTPSecurityTLS:
+		return 465
+	case SMTPSecurityNone:
+		return 25
+	default:
+		return 587
+	}
 }
 
 // MinProductionSecretKeyLen is the minimum length for BOOKSTORAGE_SECRET_KEY in production.
@@ -162,6 +316,82 @@ func envOr(key, def string) string {
 	return val
 }
 
+// oidcEnvPrefix returns the BOOKSTORAGE_OIDC_<ID>_ prefix for a provider ID (upper-cased, '-' as '_').
+func oidcEnvPrefix(id string) string {
+	return "BOOKSTORAGE_OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+}
+
+// loadOIDCProviders reads the providers listed in BOOKSTORAGE_OIDC_PROVIDERS.
+func loadOIDCProviders() []OIDCProvider {
+	var out []OIDCProvider
+	for _, id := range splitList(os.Getenv("BOOKSTORAGE_OIDC_PROVIDERS")) {
+		prefix := oidcEnvPrefix(id)
+		p := OIDCProvider{
+			ID:            id,
+			Name:          strings.TrimSpace(os.Getenv(prefix + "NAME")),
+			Issuer:        strings.TrimRight(strings.TrimSpace(os.Getenv(prefix+"ISSUER")), "/"),
+			ClientID:      strings.TrimSpace(os.Getenv(prefix + "CLIENT_ID")),
+			ClientSecret:  strings.TrimSpace(os.Getenv(prefix + "CLIENT_SECRET")),
+			UsernameClaim: strings.TrimSpace(os.Getenv(prefix + "USERNAME_CLAIM")),
+		}
+		if p.Name == "" {
+			p.Name = id
+		}
+		if p.UsernameClaim == "" {
+			p.UsernameClaim = "preferred_username"
+		}
+		p.Scopes = []string{"openid"}
+		scopes := strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " "))
+		if len(scopes) == 0 {
+			scopes = []string{"email", "profile"}
+		}
+		for _, sc := range scopes {
+			if sc != "openid" {
+				p.Scopes = append(p.Scopes, sc)
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs or single addresses.
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			p, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("BOOKSTORAGE_TRUSTED_PROXIES: %q is not a CIDR", item)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("BOOKSTORAGE_TRUSTED_PROXIES: %q is not an IP address", item)
+		}
+		ip = ip.Unmap()
+		out = append(out, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return out, nil
+}
+
+// splitList parses a comma-separated value, dropping empty items.
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func envBoolOr(key string, def bool) bool {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
@@ -257,6 +487,29 @@ func Load(rootPath string) (*Settings, error) {
 		return nil, fmt.Errorf("BOOKSTORAGE_PORT must be a valid integer: %w", err)
 	}
 
+	smtpSecurity := strings.ToLower(strings.TrimSpace(os.Getenv("BOOKSTORAGE_SMTP_SECURITY")))
+	if smtpSecurity == "" {
+		smtpSecurity = SMTPSecurityStartTLS
+	}
+	smtpPort := defaultSMTPPort(smtpSecurity)
+	if raw := strings.TrimSpace(os.Getenv("BOOKSTORAGE_SMTP_PORT")); raw != "" {
+		smtpPort, err = strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("BOOKSTORAGE_SMTP_PORT must be a valid integer: %w", err)
+		}
+	}
+	pendingExpiryDays := 0
+	if raw := strings.TrimSpace(os.Getenv("BOOKSTORAGE_PENDING_ACCOUNT_EXPIRY_DAYS")); raw != "" {
+		pendingExpiryDays, err = strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("BOOKSTORAGE_PENDING_ACCOUNT_EXPIRY_DAYS must be a valid integer: %w", err)
+		}
+	}
+	smtpAuth := strings.ToLower(strings.TrimSpace(os.Getenv("BOOKSTORAGE_SMTP_AUTH")))
+	if smtpAuth == "" {
+		smtpAuth = SMTPAuthPlain
+	}
+
 	enableHSTS := strings.EqualFold(strings.TrimSpace(os.Getenv("BOOKSTORAGE_ENABLE_HSTS")), "true") ||
 		os.Getenv("BOOKSTORAGE_ENABLE_HSTS") == "1"
 
@@ -264,6 +517,11 @@ func Load(rootPath string) (*Settings, error) {
 
 	publicOrigin := strings.TrimRight(strings.TrimSpace(os.Getenv("BOOKSTORAGE_PUBLIC_ORIGIN")), "/")
 
+	trustedProxies, err := parseTrustedProxies(os.Getenv("BOOKSTORAGE_TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Settings{
 		SecretKey:                secret,
 		Database:                 dbPath,
@@ -281,16 +539,36 @@ func Load(rootPath string) (*Settings, error) {
 		Port:                     port,
 		EnableHSTS:               enableHSTS,
 		RequireAccountValidation: envBoolOr("BOOKSTORAGE_REQUIRE_ACCOUNT_VALIDATION", true),
+		RegistrationMode:         strings.ToLower(strings.TrimSpace(os.Getenv("BOOKSTORAGE_REGISTRATION_MODE"))),
+		RequireAdmin2FA:          envBoolOr("BOOKSTORAGE_REQUIRE_ADMIN_2FA", false),
+		PendingAccountExpiryDays: pendingExpiryDays,
 		TranslateURL:             strings.TrimSpace(os.Getenv("BOOKSTORAGE_TRANSLATE_URL")),
 		TranslateAPIKey:          strings.TrimSpace(os.Getenv("BOOKSTORAGE_TRANSLATE_API_KEY")),
+		CatalogSources:           splitList(os.Getenv("BOOKSTORAGE_CATALOG_SOURCES")),
+		OpenLibraryURL:           strings.TrimRight(strings.TrimSpace(os.Getenv("BOOKSTORAGE_OPENLIBRARY_URL")), "/"),
+		ChapterFeedLanguages:     splitList(os.Getenv("BOOKSTORAGE_CHAPTER_FEED_LANGUAGES")),
 		MetricsToken:             strings.TrimSpace(os.Getenv("BOOKSTORAGE_METRICS_TOKEN")),
 		TrustProxy:               envBoolOr("BOOKSTORAGE_TRUST_PROXY", false),
+		TrustedProxies:           trustedProxies,
+		ProxyAuthHeader:          strings.TrimSpace(os.Getenv("BOOKSTORAGE_PROXY_AUTH_HEADER")),
+		ProxyAuthEmailHeader:     strings.TrimSpace(os.Getenv("BOOKSTORAGE_PROXY_AUTH_EMAIL_HEADER")),
+		ProxyAuthGroupsHeader:    strings.TrimSpace(os.Getenv("BOOKSTORAGE_PROXY_AUTH_GROUPS_HEADER")),
+		ProxyAuthAdminGroups:     splitList(os.Getenv("BOOKSTORAGE_PROXY_AUTH_ADMIN_GROUPS")),
+		ProxyAuthAutoCreate:      envBoolOr("BOOKSTORAGE_PROXY_AUTH_AUTO_CREATE", false),
+		ProxyAuthLogoutURL:       strings.TrimSpace(os.Getenv("BOOKSTORAGE_PROXY_AUTH_LOGOUT_URL")),
 		PublicOrigin:             publicOrigin,
 		GoogleClientID:           strings.TrimSpace(os.Getenv("BOOKSTORAGE_GOOGLE_CLIENT_ID")),
 		GoogleClientSecret:       strings.TrimSpace(os.Getenv("BOOKSTORAGE_GOOGLE_CLIENT_SECRET")),
+		OIDCProviders:            loadOIDCProviders(),
 		MailjetAPIKeyPublic:      strings.TrimSpace(os.Getenv("BOOKSTORAGE_MAILJET_API_KEY_PUBLIC")),
 		MailjetAPIKeyPrivate:     strings.TrimSpace(os.Getenv("BOOKSTORAGE_MAILJET_API_KEY_PRIVATE")),
 		MailFrom:                 strings.TrimSpace(os.Getenv("BOOKSTORAGE_MAIL_FROM")),
+		SMTPHost:                 strings.TrimSpace(os.Getenv("BOOKSTORAGE_SMTP_HOST")),
+		SMTPPort:                 smtpPort,
+		SMTPSecurity:             smtpSecurity,
+		SMTPUsername:             strings.TrimSpace(os.Getenv("BOOKSTORAGE_SMTP_USERNAME")),
+		SMTPPassword:             os.Getenv("BOOKSTORAGE_SMTP_PASSWORD"),
+		SMTPAuth:                 smtpAuth,
 		Timezone:                 detectTimezone(),
 	}
 	if err := validateSettings(s); err != nil {
@@ -424,22 +702,64 @@ func validateSettings(s *Settings) error {
 	if googleSpecific == 2 && strings.TrimSpace(s.PublicOrigin) == "" {
 		return fmt.Errorf("google OAuth requires BOOKSTORAGE_PUBLIC_ORIGIN when Google client credentials are set")
 	}
+	if err := validateOIDCProviders(s); err != nil {
+		return err
+	}
+	if s.ProxyAuthHeader != "" && len(s.TrustedProxies) == 0 {
+		return fmt.Errorf("BOOKSTORAGE_PROXY_AUTH_HEADER requires BOOKSTORAGE_TRUSTED_PROXIES (the reverse proxy addresses)")
+	}
+	if len(s.ProxyAuthAdminGroups) > 0 && s.ProxyAuthGroupsHeader == "" {
+		return fmt.Errorf("BOOKSTORAGE_PROXY_AUTH_ADMIN_GROUPS requires BOOKSTORAGE_PROXY_AUTH_GROUPS_HEADER")
+	}
+	if s.ProxyAuthLogoutURL != "" {
+		if u, err := url.Parse(s.ProxyAuthLogoutURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("BOOKSTORAGE_PROXY_AUTH_LOGOUT_URL must be an http(s) URL")
+		}
+	}
+
+	switch s.RegistrationMode {
+	case "", RegistrationOpen, RegistrationInvite, RegistrationClosed:
+	case RegistrationApproval:
+		if !s.RequireAccountValidation {
+			return fmt.Errorf("BOOKSTORAGE_REGISTRATION_MODE=approval requires BOOKSTORAGE_REQUIRE_ACCOUNT_VALIDATION=true")
+		}
+	default:
+		return fmt.Errorf("BOOKSTORAGE_REGISTRATION_MODE must be open, approval, invite or closed")
+	}
+	if s.PendingAccountExpiryDays < 0 {
+		return fmt.Errorf("BOOKSTORAGE_PENDING_ACCOUNT_EXPIRY_DAYS must be 0 (never) or a number of days")
+	}
 
-	mailSpecific := 0
-	if strings.TrimSpace(s.MailjetAPIKeyPublic) != "" {
-		mailSpecific++
+	if (strings.TrimSpace(s.MailjetAPIKeyPublic) != "") != (strings.TrimSpace(s.MailjetAPIKeyPrivate) != "") {
+		return fmt.Errorf("mail requires both BOOKSTORAGE_MAILJET_API_KEY_PUBLIC and BOOKSTORAGE_MAILJET_API_KEY_PRIVATE")
 	}
-	if strings.TrimSpace(s.MailjetAPIKeyPrivate) != "" {
-		mailSpecific++
+	if s.MailjetConfigured() && s.SMTPConfigured() {
+		return fmt.Errorf("mail: set either the Mailjet keys or BOOKSTORAGE_SMTP_HOST, not both")
 	}
-	if strings.TrimSpace(s.MailFrom) != "" {
-		mailSpecific++
+	if s.SMTPConfigured() {
+		switch s.SMTPSecurity {
+		case "", SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+		default:
+			return fmt.Errorf("BOOKSTORAGE_SMTP_SECURITY must be starttls, tls or none")
+		}
+		switch s.SMTPAuth {
+		case "", SMTPAuthPlain, SMTPAuthLogin:
+		default:
+			return fmt.Errorf("BOOKSTORAGE_SMTP_AUTH must be plain or login")
+		}
+		if s.SMTPPort <= 0 || s.SMTPPort > 65535 {
+			return fmt.Errorf("BOOKSTORAGE_SMTP_PORT must be between 1 and 65535")
+		}
+		if (strings.TrimSpace(s.SMTPUsername) != "") != (s.SMTPPassword != "") {
+			return fmt.Errorf("SMTP authentication requires both BOOKSTORAGE_SMTP_USERNAME and BOOKSTORAGE_SMTP_PASSWORD")
+		}
 	}
-	if mailSpecific != 0 && mailSpecific != 3 {
-		return fmt.Errorf("mail requires all of BOOKSTORAGE_MAILJET_API_KEY_PUBLIC, BOOKSTORAGE_MAILJET_API_KEY_PRIVATE, and BOOKSTORAGE_MAIL_FROM")
+	hasBackend := s.MailjetConfigured() || s.SMTPConfigured()
+	if hasBackend != (strings.TrimSpace(s.MailFrom) != "") {
+		return fmt.Errorf("mail requires BOOKSTORAGE_MAIL_FROM and one backend (BOOKSTORAGE_MAILJET_API_KEY_PUBLIC/PRIVATE or BOOKSTORAGE_SMTP_HOST)")
 	}
-	if mailSpecific == 3 && strings.TrimSpace(s.PublicOrigin) == "" {
-		return fmt.Errorf("mail requires BOOKSTORAGE_PUBLIC_ORIGIN when Mailjet keys and BOOKSTORAGE_MAIL_FROM are set")
+	if hasBackend && strings.TrimSpace(s.PublicOrigin) == "" {
+		return fmt.Errorf("mail requires BOOKSTORAGE_PUBLIC_ORIGIN when a mail backend and BOOKSTORAGE_MAIL_FROM are set")
 	}
 
 	if strings.TrimSpace(s.PostgresURL) != "" {
@@ -496,5 +816,59 @@ func validateSettings(s *Settings) error {
 			return fmt.Errorf("BOOKSTORAGE_PUBLIC_ORIGIN must be an https URL with host when BOOKSTORAGE_ENV=production and mail is configured")
 		}
 	}
+	if s.OIDCConfigured() {
+		u, err := url.Parse(s.PublicOrigin)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("BOOKSTORAGE_PUBLIC_ORIGIN must be an https URL with host when BOOKSTORAGE_ENV=production and OIDC is configured")
+		}
+		for _, p := range s.OIDCProviders {
+			if u, err := url.Parse(p.Issuer); err != nil || u.Scheme != "https" {
+				return fmt.Errorf("%sISSUER must be an https URL when BOOKSTORAGE_ENV=production", oidcEnvPrefix(p.ID))
+			}
+		}
+	}
 	return nil
 }
+
+func validateOIDCProviders(s *Settings) error {
+	if len(s.OIDCProviders) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(s.PublicOrigin) == "" {
+		return fmt.Errorf("OIDC login requires BOOKSTORAGE_PUBLIC_ORIGIN when BOOKSTORAGE_OIDC_PROVIDERS is set")
+	}
+	seen := map[string]bool{}
+	for _, p := range s.OIDCProviders {
+		if !validOIDCProviderID(p.ID) {
+			return fmt.Errorf("BOOKSTORAGE_OIDC_PROVIDERS: %q must be 1-32 lowercase letters, digits, '-' or '_'", p.ID)
+		}
+		if p.ID == "proxy" {
+			return fmt.Errorf("BOOKSTORAGE_OIDC_PROVIDERS: %q is reserved for reverse-proxy authentication", p.ID)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("BOOKSTORAGE_OIDC_PROVIDERS lists %q twice", p.ID)
+		}
+		seen[p.ID] = true
+		prefix := oidcEnvPrefix(p.ID)
+		u, err := url.Parse(p.Issuer)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%sISSUER must be an http(s) URL", prefix)
+		}
+		if p.ClientID == "" {
+			return fmt.Errorf("%sCLIENT_ID is required", prefix)
+		}
+	}
+	return nil
+}
+
+func validOIDCProviderID(id string) bool {
+	if id == "" || len(id) > 32 {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -333,3 +333,160 @@ func TestMailConfigured(t *testing.T) {
 		t.Fatal("expected mail configured")
 	}
 }
+
+func TestValidateSettingsSMTP(t *testing.T) {
+	base := func() *Settings {
+		return &Settings{
+			Environment:  "development",
+			SecretKey:    defaultSecretKey,
+			PublicOrigin: "https://books.example.com",
+			MailFrom:     "noreply@books.example.com",
+			SMTPHost:     "relay.lan",
+			SMTPPort:     587,
+			SMTPSecurity: SMTPSecurityStartTLS,
+			SMTPAuth:     SMTPAuthPlain,
+		}
+	}
+	s := base()
+	if err := validateSettings(s); err != nil {
+		t.Fatal(err)
+	}
+	if !s.MailConfigured() || s.MailjetConfigured() {
+		t.Fatal("expected SMTP-only mail configuration")
+	}
+
+	s = base()
+	s.MailjetAPIKeyPublic, s.MailjetAPIKeyPrivate = "pub", "priv"
+	if validateSettings(s) == nil {
+		t.Fatal("expected error when both Mailjet and SMTP are set")
+	}
+	s = base()
+	s.SMTPSecurity = "ssl"
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for unknown SMTP security")
+	}
+	s = base()
+	s.SMTPAuth = "cram-md5"
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for unknown SMTP auth")
+	}
+	s = base()
+	s.SMTPUsername = "bookstorage"
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for SMTP username without password")
+	}
+	s = base()
+	s.MailFrom = ""
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for SMTP host without BOOKSTORAGE_MAIL_FROM")
+	}
+}
+
+func TestLoadSMTPDefaultPort(t *testing.T) {
+	t.Setenv("BOOKSTORAGE_PUBLIC_ORIGIN", "https://books.example.com")
+	t.Setenv("BOOKSTORAGE_MAIL_FROM", "noreply@books.example.com")
+	t.Setenv("BOOKSTORAGE_SMTP_HOST", "relay.lan")
+	t.Setenv("BOOKSTORAGE_SMTP_SECURITY", "TLS")
+	s, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SMTPPort != 465 || s.SMTPSecurity != SMTPSecurityTLS || s.SMTPAuth != SMTPAuthPlain {
+		t.Fatalf("smtp settings: port=%d security=%q auth=%q", s.SMTPPort, s.SMTPSecurity, s.SMTPAuth)
+	}
+}
+
+func TestRegistrationMode(t *testing.T) {
+	if got := (&Settings{RequireAccountValidation: true}).EffectiveRegistrationMode(); got != RegistrationApproval {
+		t.Fatalf("default with validation: %q", got)
+	}
+	if got := (&Settings{}).EffectiveRegistrationMode(); got != RegistrationOpen {
+		t.Fatalf("default without validation: %q", got)
+	}
+	if got := (&Settings{RegistrationMode: RegistrationInvite}).EffectiveRegistrationMode(); got != RegistrationInvite {
+		t.Fatalf("explicit mode: %q", got)
+	}
+
+	s := &Settings{Environment: "development", SecretKey: defaultSecretKey, RegistrationMode: "invite-only"}
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for unknown registration mode")
+	}
+	s.RegistrationMode = RegistrationApproval
+	if validateSettings(s) == nil {
+		t.Fatal("expected error for approval mode without account validation")
+	}
+	s.RequireAccountValidation = true
+	if err := validateSettings(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	t.Setenv("BOOKSTORAGE_PUBLIC_ORIGIN", "https://books.example.com")
+	t.Setenv("BOOKSTORAGE_OIDC_PROVIDERS", "authelia, Home-SSO")
+	t.Setenv("BOOKSTORAGE_OIDC_AUTHELIA_ISSUER", "https://auth.example.com/")
+	t.Setenv("BOOKSTORAGE_OIDC_AUTHELIA_CLIENT_ID", "bookstorage")
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_ISSUER", "https://sso.example.com/realms/home")
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_CLIENT_ID", "books")
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_NAME", "Keycloak")
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_SCOPES", "openid,email groups")
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_USERNAME_CLAIM", "email")
+	s, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ok := s.OIDCProvider("authelia")
+	if !ok || a.Name != "authelia" || a.Issuer != "https://auth.example.com" || a.UsernameClaim != "preferred_username" ||
+		strings.Join(a.Scopes, " ") != "openid email profile" {
+		t.Fatalf("authelia: %+v", a)
+	}
+	k, ok := s.OIDCProvider("home-sso")
+	if !ok || k.Name != "Keycloak" || k.UsernameClaim != "email" || strings.Join(k.Scopes, " ") != "openid email groups" {
+		t.Fatalf("home-sso: %+v", k)
+	}
+
+	t.Setenv("BOOKSTORAGE_OIDC_HOME_SSO_CLIENT_ID", "")
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected error for a provider without client ID")
+	}
+	t.Setenv("BOOKSTORAGE_OIDC_PROVIDERS", "bad id")
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected error for an invalid provider ID")
+	}
+}
+
+func TestLoadProxyAuth(t *testing.T) {
+	t.Setenv("BOOKSTORAGE_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5,::1")
+	t.Setenv("BOOKSTORAGE_PROXY_AUTH_HEADER", "Remote-User")
+	t.Setenv("BOOKSTORAGE_PROXY_AUTH_GROUPS_HEADER", "Remote-Groups")
+	t.Setenv("BOOKSTORAGE_PROXY_AUTH_ADMIN_GROUPS", "Admins")
+	s, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.ProxyAuthConfigured() || strings.Join(s.ProxyAuthAdminGroups, ",") != "admins" {
+		t.Fatalf("settings: %+v", s)
+	}
+	for addr, want := range map[string]bool{
+		"10.1.2.3:4567":       true,
+		"192.168.1.5:80":      true,
+		"192.168.1.6:80":      false,
+		"[::1]:8080":          true,
+		"[::ffff:10.0.0.1]:1": true,
+		"8.8.8.8:443":         false,
+		"garbage":             false,
+	} {
+		if got := s.TrustedProxy(addr); got != want {
+			t.Errorf("TrustedProxy(%q) = %v", addr, got)
+		}
+	}
+
+	t.Setenv("BOOKSTORAGE_TRUSTED_PROXIES", "10.0.0.0/33")
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected error for an invalid CIDR")
+	}
+	t.Setenv("BOOKSTORAGE_TRUSTED_PROXIES", "")
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected error for a proxy header without trusted proxies")
+	}
+}
@@ -68,6 +68,21 @@ func (c *Conn) QueryRow(query string, args ...any) *sql.Row {
 	return c.sql.QueryRow(c.rebind(query), args...)
 }
 
+// InsertID runs an INSERT into a table with an "id" primary key and returns the new id
+// (RETURNING id on PostgreSQL, where sql.Result.LastInsertId is not supported).
+func (c *Conn) InsertID(query string, args ...any) (int64, error) {
+	if c.B == BackendPostgres {
+		var id int64
+		err := c.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	res, err := c.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
 func (c *Conn) Begin() (*Tx, error) {
 	tx, err := c.sql.Begin()
 	if err != nil {
@@ -93,6 +108,20 @@ func (t *Tx) Exec(query string, args ...any) (sql.Result, error) {
 	return t.Tx.Exec(t.rebind(query), args...)
 }
 
+// InsertID is Conn.InsertID within the transaction.
+func (t *Tx) InsertID(query string, args ...any) (int64, error) {
+	if t.b == BackendPostgres {
+		var id int64
+		err := t.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	res, err := t.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
 func (t *Tx) QueryRow(query string, args ...any) *sql.Row {
 	return t.Tx.QueryRow(t.rebind(query), args...)
 }
@@ -142,6 +142,8 @@ var workColumns = map[string]string{
 	"link_probe_at":          "DATETIME",
 	"link_probe_http_status": "INTEGER",
 	"link_probe_detail":      "TEXT",
+	// Volume atteint (unité de progression des light novels) ; chapter accepte aussi les décimales (45.5).
+	"volume": "REAL DEFAULT 0",
 }
 
 // sqliteDataSourceName appends go-sqlite3 DSN options (WAL, busy wait, foreign keys).
@@ -38,7 +38,7 @@ func MigrateSQLiteToPostgres(sqliteConn *Conn, pgDSN string) (normalizedDSN stri
 		return "", fmt.Errorf("target schema: %w", err)
 	}
 	clearPostgresUserData := []string{
-		`TRUNCATE oauth_states, csv_import_sessions, translation_cache, sessions, dismissed_recommendations, works, reading_sites, catalog, users, schema_migrations RESTART IDENTITY CASCADE`,
+		`TRUNCATE work_copies, reading_goals, work_reads, work_tags, tags, oauth_states, csv_import_sessions, translation_cache, sessions, dismissed_recommendations, works, reading_sites, catalog, users, schema_migrations RESTART IDENTITY CASCADE`,
 	}
 	for _, q := range clearPostgresUserData {
 		if _, err := pgConn.Exec(q); err != nil {
@@ -60,6 +60,21 @@ func MigrateSQLiteToPostgres(sqliteConn *Conn, pgDSN string) (normalizedDSN stri
 	if err := copyWorks(sl, pgConn); err != nil {
 		return "", err
 	}
+	if err := copyTags(sl, pgConn); err != nil {
+		return "", err
+	}
+	if err := copyWorkTags(sl, pgConn); err != nil {
+		return "", err
+	}
+	if err := copyWorkReads(sl, pgConn); err != nil {
+		return "", err
+	}
+	if err := copyReadingGoals(sl, pgConn); err != nil {
+		return "", err
+	}
+	if err := copyWorkCopies(sl, pgConn); err != nil {
+		return "", err
+	}
 	if err := copyDismissed(sl, pgConn); err != nil {
 		return "", err
 	}
@@ -94,7 +109,7 @@ func MigrateSQLiteToPostgres(sqliteConn *Conn, pgDSN string) (normalizedDSN stri
 }
 
 func verifyMigrationCounts(sl *sql.DB, pg *Conn) error {
-	tables := []string{"users", "catalog", "reading_sites", "works", "dismissed_recommendations", "sessions", "translation_cache", "csv_import_sessions", "oauth_states"}
+	tables := []string{"users", "catalog", "reading_sites", "works", "tags", "work_tags", "work_reads", "reading_goals", "work_copies", "dismissed_recommendations", "sessions", "translation_cache", "csv_import_sessions", "oauth_states"}
 	for _, t := range tables {
 		var a, b int
 		if err := sl.QueryRow(`SELECT COUNT(*) FROM ` + quoteSQLiteIdentRaw(t)).Scan(&a); err != nil {
@@ -111,7 +126,7 @@ func verifyMigrationCounts(sl *sql.DB, pg *Conn) error {
 }
 
 func syncPostgresSequences(pg *Conn) error {
-	for _, tbl := range []string{"users", "catalog", "reading_sites", "works", "dismissed_recommendations", "sessions"} {
+	for _, tbl := range []string{"users", "catalog", "reading_sites", "works", "tags", "work_reads", "reading_goals", "work_copies", "dismissed_recommendations", "sessions"} {
 		q := fmt.Sprintf(
 			`SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 1), true)`,
 			tbl, quoteSQLiteIdentRaw(tbl),
@@ -131,7 +146,7 @@ func quoteSQLiteIdentRaw(name string) string {
 }
 
 func copyUsers(sl *sql.DB, pg *Conn) error {
-	rows, err := sl.Query(`SELECT id, username, password, validated, is_admin, is_superadmin, display_name, email, bio, avatar_path, is_public, google_sub, google_email FROM users`)
+	rows, err := sl.Query(`SELECT id, username, password, validated, is_admin, is_superadmin, display_name, email, bio, avatar_path, is_public, google_sub, google_email, digest_frequency, digest_lang, digest_token, email_verified_at, pending_email, registered_at, signup_lang FROM users`)
 	if err != nil {
 		return err
 	}
@@ -142,13 +157,16 @@ func copyUsers(sl *sql.DB, pg *Conn) error {
 		var password sql.NullString
 		var validated, isAdmin, isSuper, isPublic int
 		var displayName, email, bio, avatarPath, googleSub, googleEmail sql.NullString
-		if err := rows.Scan(&id, &username, &password, &validated, &isAdmin, &isSuper, &displayName, &email, &bio, &avatarPath, &isPublic, &googleSub, &googleEmail); err != nil {
+		var digestFrequency, digestLang, digestToken, emailVerifiedAt, pendingEmail, registeredAt, signupLang sql.NullString
+		if err := rows.Scan(&id, &username, &password, &validated, &isAdmin, &isSuper, &displayName, &email, &bio, &avatarPath, &isPublic, &googleSub, &googleEmail, &digestFrequency, &digestLang, &digestToken, &emailVerifiedAt, &pendingEmail, &registeredAt, &signupLang); err != nil {
 			return err
 		}
 		_, err := pg.Exec(
-			`INSERT INTO users (id, username, password, validated, is_admin, is_superadmin, display_name, email, bio, avatar_path, is_public, google_sub, google_email)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO users (id, username, password, validated, is_admin, is_superadmin, display_name, email, bio, avatar_path, is_public, google_sub, google_email, digest_frequency, digest_lang, digest_token, email_verified_at, pending_email, registered_at, signup_lang)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			id, username, nullStr(password), validated, isAdmin, isSuper, nullStr(displayName), nullStr(email), nullStr(bio), nullStr(avatarPath), isPublic, nullStr(googleSub), nullStr(googleEmail),
+			nullStr(digestFrequency), nullStr(digestLang), nullStr(digestToken), nullStr(emailVerifiedAt), nullStr(pendingEmail),
+			nullStr(registeredAt), nullStr(signupLang),
 		)
 		if err != nil {
 			return fmt.Errorf("insert users: %w", err)
@@ -184,7 +202,7 @@ func copyCatalog(sl *sql.DB, pg *Conn) error {
 }
 
 func copyReadingSites(sl *sql.DB, pg *Conn) error {
-	rows, err := sl.Query(`SELECT id, user_id, name, base_url, last_probe_at, probe_status, probe_http_status, probe_detail FROM reading_sites`)
+	rows, err := sl.Query(`SELECT id, user_id, name, base_url, last_probe_at, probe_status, probe_http_status, probe_detail, feed_template FROM reading_sites`)
 	if err != nil {
 		return err
 	}
@@ -192,15 +210,15 @@ func copyReadingSites(sl *sql.DB, pg *Conn) error {
 	for rows.Next() {
 		var id, userID int64
 		var name, baseURL string
-		var lastProbeAt, probeStatus, probeDetail sql.NullString
+		var lastProbeAt, probeStatus, probeDetail, feedTemplate sql.NullString
 		var probeHTTPStatus sql.NullInt64
-		if err := rows.Scan(&id, &userID, &name, &baseURL, &lastProbeAt, &probeStatus, &probeHTTPStatus, &probeDetail); err != nil {
+		if err := rows.Scan(&id, &userID, &name, &baseURL, &lastProbeAt, &probeStatus, &probeHTTPStatus, &probeDetail, &feedTemplate); err != nil {
 			return err
 		}
 		_, err := pg.Exec(
-			`INSERT INTO reading_sites (id, user_id, name, base_url, last_probe_at, probe_status, probe_http_status, probe_detail)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			id, userID, name, baseURL, nullStr(lastProbeAt), nullStr(probeStatus), nullInt64(probeHTTPStatus), nullStr(probeDetail),
+			`INSERT INTO reading_sites (id, user_id, name, base_url, last_probe_at, probe_status, probe_http_status, probe_detail, feed_template)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, userID, name, baseURL, nullStr(lastProbeAt), nullStr(probeStatus), nullInt64(probeHTTPStatus), nullStr(probeDetail), nullStr(feedTemplate),
 		)
 		if err != nil {
 			return fmt.Errorf("insert reading_sites id=%d: %w", id, err)
@@ -210,23 +228,24 @@ func copyReadingSites(sl *sql.DB, pg *Conn) error {
 }
 
 func copyWorks(sl *sql.DB, pg *Conn) error {
-	rows, err := sl.Query(`SELECT id, title, chapter, link, status, image_path, reading_type, user_id, rating, notes, updated_at, is_adult, catalog_id, anilist_enrich_opt_out, parent_work_id, series_sort, COALESCE(notify_new_chapters, 1), reading_site_id FROM works`)
+	rows, err := sl.Query(`SELECT id, title, chapter, link, status, image_path, reading_type, user_id, rating, notes, updated_at, is_adult, catalog_id, anilist_enrich_opt_out, parent_work_id, series_sort, COALESCE(notify_new_chapters, 1), reading_site_id, COALESCE(volume, 0), feed_url FROM works`)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = rows.Close() }()
 	for rows.Next() {
-		var id, chapter, userID, rating, isAdult, anilistOpt, seriesSort, notifyCh int64
+		var id, userID, rating, isAdult, anilistOpt, seriesSort, notifyCh int64
+		var chapter, volume float64
 		var title string
-		var link, status, imagePath, readingType, notes, updatedAt sql.NullString
+		var link, status, imagePath, readingType, notes, updatedAt, feedURL sql.NullString
 		var catalogID, parentID, readingSiteID sql.NullInt64
-		if err := rows.Scan(&id, &title, &chapter, &link, &status, &imagePath, &readingType, &userID, &rating, &notes, &updatedAt, &isAdult, &catalogID, &anilistOpt, &parentID, &seriesSort, &notifyCh, &readingSiteID); err != nil {
+		if err := rows.Scan(&id, &title, &chapter, &link, &status, &imagePath, &readingType, &userID, &rating, &notes, &updatedAt, &isAdult, &catalogID, &anilistOpt, &parentID, &seriesSort, &notifyCh, &readingSiteID, &volume, &feedURL); err != nil {
 			return err
 		}
 		_, err := pg.Exec(
-			`INSERT INTO works (id, title, chapter, link, status, image_path, reading_type, user_id, rating, notes, updated_at, is_adult, catalog_id, anilist_enrich_opt_out, parent_work_id, series_sort, notify_new_chapters, reading_site_id)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			id, title, chapter, nullStr(link), nullStr(status), nullStr(imagePath), nullStr(readingType), userID, rating, nullStr(notes), nullStr(updatedAt), isAdult, nullInt64(catalogID), anilistOpt, nullInt64(parentID), seriesSort, notifyCh, nullInt64(readingSiteID),
+			`INSERT INTO works (id, title, chapter, link, status, image_path, reading_type, user_id, rating, notes, updated_at, is_adult, catalog_id, anilist_enrich_opt_out, parent_work_id, series_sort, notify_new_chapters, reading_site_id, volume, feed_url)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, title, chapter, nullStr(link), nullStr(status), nullStr(imagePath), nullStr(readingType), userID, rating, nullStr(notes), nullStr(updatedAt), isAdult, nullInt64(catalogID), anilistOpt, nullInt64(parentID), seriesSort, notifyCh, nullInt64(readingSiteID), volume, nullStr(feedURL),
 		)
 		if err != nil {
 			return fmt.Errorf("insert works id=%d: %w", id, err)
@@ -235,6 +254,126 @@ func copyWorks(sl *sql.DB, pg *Conn) error {
 	return rows.Err()
 }
 
+func copyTags(sl *sql.DB, pg *Conn) error {
+	rows, err := sl.Query(`SELECT id, user_id, name, created_at FROM tags`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id, userID int64
+		var name string
+		var createdAt sql.NullString
+		if err := rows.Scan(&id, &userID, &name, &createdAt); err != nil {
+			return err
+		}
+		if _, err := pg.Exec(
+			`INSERT INTO tags (id, user_id, name, created_at) VALUES (?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`,
+			id, userID, name, nullStr(createdAt),
+		); err != nil {
+			return fmt.Errorf("insert tags id=%d: %w", id, err)
+		}
+	}
+	return rows.Err()
+}
+
+func copyWorkTags(sl *sql.DB, pg *Conn) error {
+	rows, err := sl.Query(`SELECT work_id, tag_id FROM work_tags`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var workID, tagID int64
+		if err := rows.Scan(&workID, &tagID); err != nil {
+			return err
+		}
+		if _, err := pg.Exec(`INSERT INTO work_tags (work_id, tag_id) VALUES (?, ?)`, workID, tagID); err != nil {
+			return fmt.Errorf("insert work_tags: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+func copyWorkReads(sl *sql.DB, pg *Conn) error {
+	rows, err := sl.Query(`SELECT id, user_id, work_id, read_number, started_at, finished_at, COALESCE(rating, 0), notes, created_at FROM work_reads`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id, userID, workID int64
+		var readNumber, rating int
+		var startedAt, finishedAt, notes, createdAt sql.NullString
+		if err := rows.Scan(&id, &userID, &workID, &readNumber, &startedAt, &finishedAt, &rating, &notes, &createdAt); err != nil {
+			return err
+		}
+		if _, err := pg.Exec(
+			`INSERT INTO work_reads (id, user_id, work_id, read_number, started_at, finished_at, rating, notes, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`,
+			id, userID, workID, readNumber, nullStr(startedAt), nullStr(finishedAt), rating, nullStr(notes), nullStr(createdAt),
+		); err != nil {
+			return fmt.Errorf("insert work_reads id=%d: %w", id, err)
+		}
+	}
+	return rows.Err()
+}
+
+func copyReadingGoals(sl *sql.DB, pg *Conn) error {
+	rows, err := sl.Query(`SELECT id, user_id, kind, period, target, reading_type, reached_period, created_at FROM reading_goals`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id, userID int64
+		var target int
+		var kind, period string
+		var readingType, reachedPeriod, createdAt sql.NullString
+		if err := rows.Scan(&id, &userID, &kind, &period, &target, &readingType, &reachedPeriod, &createdAt); err != nil {
+			return err
+		}
+		if _, err := pg.Exec(
+			`INSERT INTO reading_goals (id, user_id, kind, period, target, reading_type, reached_period, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`,
+			id, userID, kind, period, target, nullStr(readingType), nullStr(reachedPeriod), nullStr(createdAt),
+		); err != nil {
+			return fmt.Errorf("insert reading_goals id=%d: %w", id, err)
+		}
+	}
+	return rows.Err()
+}
+
+func copyWorkCopies(sl *sql.DB, pg *Conn) error {
+	rows, err := sl.Query(`SELECT id, user_id, work_id, volume, format, isbn, purchased_at, price, created_at FROM work_copies`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var id, userID, workID int64
+		var volume int
+		var format string
+		var isbn, purchasedAt, createdAt sql.NullString
+		var price sql.NullFloat64
+		if err := rows.Scan(&id, &userID, &workID, &volume, &format, &isbn, &purchasedAt, &price, &createdAt); err != nil {
+			return err
+		}
+		var priceArg any
+		if price.Valid {
+			priceArg = price.Float64
+		}
+		if _, err := pg.Exec(
+			`INSERT INTO work_copies (id, user_id, work_id, volume, format, isbn, purchased_at, price, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`,
+			id, userID, workID, volume, format, nullStr(isbn), nullStr(purchasedAt), priceArg, nullStr(createdAt),
+		); err != nil {
+			return fmt.Errorf("insert work_copies id=%d: %w", id, err)
+		}
+	}
+	return rows.Err()
+}
+
 func copyDismissed(sl *sql.DB, pg *Conn) error {
 	rows, err := sl.Query(`SELECT id, user_id, source, external_id, created_at FROM dismissed_recommendations`)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const createSchemaMigrationsTableSQL = `
@@ -240,11 +241,327 @@ CREATE TABLE IF NOT EXISTS login_attempts (
 	fail_count INTEGER NOT NULL DEFAULT 0,
 	locked_until DATETIME
 );
+`},
+	{Version: 26, Name: "work_progress_events", Up: `
+CREATE TABLE IF NOT EXISTS work_progress_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	work_id INTEGER NOT NULL,
+	chapter_before INTEGER NOT NULL,
+	chapter_after INTEGER NOT NULL,
+	source TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	undone_at DATETIME,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_work_progress_events_work ON work_progress_events(work_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_work_progress_events_user ON work_progress_events(user_id);
+`},
+	// works.volume is added by ensureColumnsSQLite; INTEGER affinity already stores fractional chapters (45.5) as REAL.
+	{Version: 27, Name: "fractional_chapters_volume", Up: `
+ALTER TABLE work_progress_events ADD COLUMN unit TEXT NOT NULL DEFAULT 'chapter';
+`},
+	{Version: 28, Name: "work_tags", Up: `
+CREATE TABLE IF NOT EXISTS tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	UNIQUE (user_id, name)
+);
+CREATE TABLE IF NOT EXISTS work_tags (
+	work_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (work_id, tag_id),
+	FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE,
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_work_tags_tag ON work_tags(tag_id);
+`},
+	{Version: 29, Name: "canonical_status_codes", Up: strings.Join(statusCodeUpdates, ";\n") + ";"},
+	{Version: 30, Name: "work_reads", Up: `
+CREATE TABLE IF NOT EXISTS work_reads (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	work_id INTEGER NOT NULL,
+	read_number INTEGER NOT NULL,
+	started_at DATETIME,
+	finished_at DATETIME,
+	rating INTEGER DEFAULT 0,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_work_reads_work ON work_reads(work_id, read_number);
+`},
+	{Version: 31, Name: "reading_goals", Up: `
+CREATE TABLE IF NOT EXISTS reading_goals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	period TEXT NOT NULL,
+	target INTEGER NOT NULL,
+	reading_type TEXT,
+	reached_period TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_reading_goals_user ON reading_goals(user_id);
+`},
+	{Version: 32, Name: "work_copies", Up: `
+CREATE TABLE IF NOT EXISTS work_copies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	work_id INTEGER NOT NULL,
+	volume INTEGER NOT NULL,
+	format TEXT NOT NULL DEFAULT 'paperback',
+	isbn TEXT,
+	purchased_at TEXT,
+	price REAL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_work_copies_volume ON work_copies(work_id, volume, format);
+CREATE INDEX IF NOT EXISTS idx_work_copies_user ON work_copies(user_id);
+`},
+	// Filled by the chapter feed poller for MangaDex entries followed with works.notify_new_chapters.
+	{Version: 33, Name: "catalog_latest_chapter", Up: `
+ALTER TABLE catalog ADD COLUMN latest_chapter REAL;
+ALTER TABLE catalog ADD COLUMN latest_chapter_checked_at DATETIME;
+`},
+	// RSS/Atom release feeds: per-work URL, or a reading site template expanded from the work link.
+	{Version: 34, Name: "release_feeds", Up: `
+ALTER TABLE works ADD COLUMN feed_url TEXT;
+ALTER TABLE works ADD COLUMN feed_checked_at DATETIME;
+ALTER TABLE works ADD COLUMN feed_status TEXT;
+ALTER TABLE reading_sites ADD COLUMN feed_template TEXT;
+CREATE TABLE IF NOT EXISTS work_releases (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	work_id INTEGER NOT NULL,
+	chapter REAL NOT NULL,
+	title TEXT,
+	url TEXT,
+	released_at DATETIME,
+	detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_work_releases_chapter ON work_releases(work_id, chapter);
+`},
+	{Version: 35, Name: "notifications", Up: `
+CREATE TABLE IF NOT EXISTS notifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	subject TEXT,
+	detail TEXT,
+	link TEXT,
+	dedupe_key TEXT,
+	read_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(user_id, dedupe_key);
+`},
+	{Version: 36, Name: "email_digest", Up: `
+ALTER TABLE users ADD COLUMN digest_frequency TEXT;
+ALTER TABLE users ADD COLUMN digest_lang TEXT;
+ALTER TABLE users ADD COLUMN digest_token TEXT;
+ALTER TABLE users ADD COLUMN digest_sent_at DATETIME;
+`},
+	{Version: 37, Name: "mail_outbox", Up: `
+CREATE TABLE IF NOT EXISTS mail_outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	to_addr TEXT NOT NULL,
+	subject TEXT NOT NULL DEFAULT '',
+	text_body TEXT NOT NULL DEFAULT '',
+	html_body TEXT NOT NULL DEFAULT '',
+	custom_id TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_retry_at DATETIME,
+	expires_at DATETIME,
+	sent_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_status ON mail_outbox(status, next_retry_at);
+`},
+	{Version: 38, Name: "email_verification", Up: `
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+ALTER TABLE users ADD COLUMN pending_email TEXT;
+` + emailVerifiedBackfill + ";"},
+	// admin_audit_log.actor_user_id becomes nullable: NULL marks actions taken by the server itself
+	// (expiry of pending accounts). Pending accounts get an expiry clock starting now.
+	{Version: 39, Name: "pending_registrations", OutsideTx: true, Up: `
+ALTER TABLE users ADD COLUMN registered_at DATETIME;
+ALTER TABLE users ADD COLUMN signup_lang TEXT;
+UPDATE users SET registered_at = CURRENT_TIMESTAMP WHERE COALESCE(validated, 0) = 0;
+CREATE TABLE admin_audit_log_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	actor_user_id INTEGER,
+	action TEXT NOT NULL,
+	target_type TEXT,
+	target_id TEXT,
+	detail_json TEXT,
+	ip TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (actor_user_id) REFERENCES users(id)
+);
+INSERT INTO admin_audit_log_new (id, actor_user_id, action, target_type, target_id, detail_json, ip, created_at)
+SELECT id, actor_user_id, action, target_type, target_id, detail_json, ip, created_at FROM admin_audit_log;
+DROP TABLE admin_audit_log;
+ALTER TABLE admin_audit_log_new RENAME TO admin_audit_log;
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor ON admin_audit_log(actor_user_id);
+`},
+	{Version: 40, Name: "invites", Up: `
+CREATE TABLE IF NOT EXISTS invites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code TEXT NOT NULL UNIQUE,
+	created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	max_uses INTEGER NOT NULL DEFAULT 1,
+	uses INTEGER NOT NULL DEFAULT 0,
+	pre_approved INTEGER NOT NULL DEFAULT 0,
+	note TEXT,
+	expires_at DATETIME,
+	revoked_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE users ADD COLUMN invite_id INTEGER REFERENCES invites(id) ON DELETE SET NULL;
+ALTER TABLE oauth_states ADD COLUMN invite_code TEXT;
+`},
+	// totp_pending_secret holds an enrollment not yet confirmed with a code; totp_last_step is the
+	// last accepted time step so a code cannot be replayed within its window.
+	{Version: 41, Name: "totp_two_factor", Up: `
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_pending_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled_at DATETIME;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER;
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	code_hash TEXT NOT NULL,
+	used_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id);
+CREATE TABLE IF NOT EXISTS login_challenges (
+	challenge_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	next TEXT,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires_at ON login_challenges(expires_at);
+`},
+	{Version: 42, Name: "oidc_user_identities", Up: `
+CREATE TABLE IF NOT EXISTS user_identities (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_login_at DATETIME,
+	UNIQUE (provider, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+ALTER TABLE oauth_states ADD COLUMN provider TEXT;
+ALTER TABLE oauth_states ADD COLUMN nonce TEXT;
+`},
+	{Version: 43, Name: "oauth_authorization_server", Up: `
+CREATE TABLE IF NOT EXISTS oauth_clients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	secret_hash TEXT,
+	redirect_uris TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	revoked_at DATETIME
+);
+CREATE TABLE IF NOT EXISTS oauth_grants (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	scopes TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (client_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_grants_user_id ON oauth_grants(user_id);
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+	code_hash TEXT PRIMARY KEY,
+	grant_id INTEGER NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+	redirect_uri TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	code_challenge TEXT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME
+);
+CREATE TABLE IF NOT EXISTS oauth_refresh_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	grant_id INTEGER NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+	token_hash TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_refresh_tokens_grant_id ON oauth_refresh_tokens(grant_id);
+ALTER TABLE api_tokens ADD COLUMN oauth_grant_id INTEGER REFERENCES oauth_grants(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_api_tokens_oauth_grant_id ON api_tokens(oauth_grant_id);
+`},
+	{Version: 44, Name: "api_token_restrictions", Up: strings.Join(apiScopeSplitUpdates, ";\n") + `;
+ALTER TABLE api_tokens ADD COLUMN allowed_ips TEXT;
+ALTER TABLE api_tokens ADD COLUMN work_ids TEXT;
+ALTER TABLE api_tokens ADD COLUMN reading_types TEXT;
+ALTER TABLE api_tokens ADD COLUMN rate_limit_per_minute INTEGER;
 `},
 }
 
+// statusCodeUpdates rewrite legacy display strings (French, or English from older imports) in
+// works.status to the language-neutral codes. SQLite LOWER() only folds ASCII, hence both « à »/« À ».
+// Shared with Postgres (postgresSchemaAfterExtraColumns) so both backends converge.
+var statusCodeUpdates = []string{
+	`UPDATE works SET status = 'reading' WHERE LOWER(TRIM(status)) IN ('en cours', 'reading', 'current')`,
+	`UPDATE works SET status = 'completed' WHERE LOWER(TRIM(status)) IN ('terminé', 'termine', 'completed')`,
+	`UPDATE works SET status = 'on_hold' WHERE LOWER(TRIM(status)) IN ('en pause', 'on hold', 'on_hold', 'on-hold', 'paused')`,
+	`UPDATE works SET status = 'dropped' WHERE LOWER(TRIM(status)) IN ('abandonné', 'abandonne', 'dropped', 'dropped/abandoned')`,
+	`UPDATE works SET status = 'planned' WHERE LOWER(TRIM(status)) IN ('à lire', 'À lire', 'a lire', 'plan to read', 'plan_to_read', 'planned', 'planning')`,
+}
+
+// emailVerifiedBackfill marks addresses saved before migration 38 as verified, so existing accounts
+// keep receiving password resets and digests. Shared with Postgres, where it runs once alongside the
+// new users columns.
+const emailVerifiedBackfill = `UPDATE users SET email_verified_at = CURRENT_TIMESTAMP
+	WHERE email_verified_at IS NULL AND TRIM(COALESCE(email, '')) <> ''`
+
+// apiScopeSplitUpdates add the scopes split off works:read and works:write in migration 44 to every
+// token, OAuth client and grant holding them, so existing integrations keep reaching stats, goals,
+// reading sites and notifications. Shared with Postgres, where it runs once alongside the new
+// api_tokens columns.
+var apiScopeSplitUpdates = func() []string {
+	var out []string
+	for _, table := range []string{"api_tokens", "oauth_clients", "oauth_grants", "oauth_authorization_codes", "oauth_refresh_tokens"} {
+		out = append(out,
+			`UPDATE `+table+` SET scopes = REPLACE(scopes, '"works:read"', '"works:read","stats:read","reading_sites:read","notifications:read"')
+	WHERE scopes LIKE '%"works:read"%'`,
+			`UPDATE `+table+` SET scopes = REPLACE(scopes, '"works:write"', '"works:write","stats:write","notifications:write"')
+	WHERE scopes LIKE '%"works:write"%'`,
+		)
+	}
+	return out
+}()
+
 // LatestSchemaMigrationVersion is the highest numbered migration (SQLite and Postgres logical version).
-const LatestSchemaMigrationVersion = 25
+const LatestSchemaMigrationVersion = 44
 
 // ApplyMigrations runs dialect-specific migration bookkeeping.
 func ApplyMigrations(c *Conn) error {
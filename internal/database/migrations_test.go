@@ -41,6 +41,7 @@ CREATE TABLE works (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	title TEXT NOT NULL,
 	user_id INTEGER NOT NULL,
+	status TEXT,
 	reading_type TEXT,
 	FOREIGN KEY (user_id) REFERENCES users(id)
 );
@@ -82,3 +83,95 @@ INSERT INTO works (title, user_id) VALUES ('One work', 1);
 		t.Fatalf("works still linked: count = %d", n)
 	}
 }
+
+func TestStatusCodeUpdates_convertLegacyValues(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:statuscodes?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(`CREATE TABLE works (id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"En cours":     "reading",
+		"Terminé":      "completed",
+		"En pause":     "on_hold",
+		"Abandonné":    "dropped",
+		"À lire":       "planned",
+		"Plan to Read": "planned",
+		"Completed":    "completed",
+		"reading":      "reading",
+		"Custom":       "Custom",
+	}
+	for legacy := range cases {
+		if _, err := db.Exec(`INSERT INTO works (status) VALUES (?)`, legacy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, stmt := range statusCodeUpdates {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	rows, err := db.Query(`SELECT status FROM works ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rows.Close() }()
+	got := map[string]int{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		got[s]++
+	}
+	want := map[string]int{}
+	for _, code := range cases {
+		want[code]++
+	}
+	for code, n := range want {
+		if got[code] != n {
+			t.Fatalf("status %q: got %d want %d (all=%v)", code, got[code], n, got)
+		}
+	}
+}
+
+func TestMigration38_backfillsExistingEmails(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:mig38verify?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT);
+INSERT INTO users (email) VALUES ('reader@example.com'), ('  '), (NULL);
+`); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if m.Version != 38 {
+			continue
+		}
+		if _, err := db.Exec(m.Up); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := db.Query(`SELECT email_verified_at IS NOT NULL FROM users ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rows.Close() }()
+	var got []bool
+	for rows.Next() {
+		var verified bool
+		if err := rows.Scan(&verified); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, verified)
+	}
+	if len(got) != 3 || !got[0] || got[1] || got[2] {
+		t.Fatalf("verified = %v, want only the user with an address", got)
+	}
+}
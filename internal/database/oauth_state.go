@@ -40,24 +40,49 @@ func NewOAuthStatePlain() (string, error) {
 
 // InsertOAuthState stores a one-time OAuth CSRF/PKCE row.
 func InsertOAuthState(c *Conn, statePlain string, purpose OAuthStatePurpose, userID sql.NullInt64, next string, codeVerifier string) error {
-	if c == nil || statePlain == "" || codeVerifier == "" {
+	return InsertOAuthStateWithInvite(c, statePlain, purpose, userID, next, codeVerifier, "")
+}
+
+// InsertOAuthStateWithInvite is InsertOAuthState for a login that may create an account:
+// inviteCode (may be empty) is handed back on the callback for invite-only registration.
+func InsertOAuthStateWithInvite(c *Conn, statePlain string, purpose OAuthStatePurpose, userID sql.NullInt64, next, codeVerifier, inviteCode string) error {
+	return InsertOAuthStateRow(c, statePlain, OAuthStateRow{
+		Purpose: purpose, UserID: userID, Next: next, CodeVerifier: codeVerifier, InviteCode: inviteCode,
+	})
+}
+
+// InsertOAuthStateRow stores a one-time state with every field, including the OIDC provider and nonce.
+func InsertOAuthStateRow(c *Conn, statePlain string, row OAuthStateRow) error {
+	if c == nil || statePlain == "" || row.CodeVerifier == "" {
 		return fmt.Errorf("oauth state: empty state or verifier")
 	}
 	exp := time.Now().UTC().Add(oauthStateTTL).Unix()
 	_, err := c.Exec(
-		`INSERT INTO oauth_states (state_hash, purpose, user_id, next, expires_at_unix, code_verifier)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		HashOAuthState(statePlain), string(purpose), userID, next, exp, codeVerifier,
+		`INSERT INTO oauth_states (state_hash, purpose, user_id, next, expires_at_unix, code_verifier, invite_code, provider, nonce)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		HashOAuthState(statePlain), string(row.Purpose), row.UserID, row.Next, exp, row.CodeVerifier,
+		nullIfEmpty(row.InviteCode), nullIfEmpty(row.Provider), nullIfEmpty(row.Nonce),
 	)
 	return err
 }
 
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // OAuthStateRow is returned when consuming a valid oauth_states row.
 type OAuthStateRow struct {
 	Purpose      OAuthStatePurpose
 	UserID       sql.NullInt64
 	Next         string
 	CodeVerifier string
+	InviteCode   string
+	// Provider is the OIDC provider ID; empty for Google.
+	Provider string
+	Nonce    string
 }
 
 // DeleteExpiredOAuthStates removes stale rows (best-effort).
@@ -88,11 +113,12 @@ func ConsumeOAuthState(c *Conn, statePlain string) (OAuthStateRow, error) {
 	var uid sql.NullInt64
 	var next sql.NullString
 	var verifier string
+	var invite, provider, nonce sql.NullString
 	err = tx.QueryRow(
-		`SELECT purpose, user_id, next, code_verifier FROM oauth_states
+		`SELECT purpose, user_id, next, code_verifier, invite_code, provider, nonce FROM oauth_states
 		 WHERE state_hash = ? AND expires_at_unix >= ?`,
 		hash, now,
-	).Scan(&purpose, &uid, &next, &verifier)
+	).Scan(&purpose, &uid, &next, &verifier, &invite, &provider, &nonce)
 	if err != nil {
 		return out, err
 	}
@@ -108,5 +134,8 @@ func ConsumeOAuthState(c *Conn, statePlain string) (OAuthStateRow, error) {
 		out.Next = next.String
 	}
 	out.CodeVerifier = verifier
+	out.InviteCode = invite.String
+	out.Provider = provider.String
+	out.Nonce = nonce.String
 	return out, nil
 }
@@ -45,12 +45,13 @@ var postgresSchemaStatements = []string{
 		last_probe_at TIMESTAMPTZ,
 		probe_status TEXT DEFAULT 'unknown',
 		probe_http_status INTEGER,
-		probe_detail TEXT
+		probe_detail TEXT,
+		feed_template TEXT
 	)`,
 	`CREATE TABLE IF NOT EXISTS works (
 		id BIGSERIAL PRIMARY KEY,
 		title TEXT NOT NULL,
-		chapter INTEGER NOT NULL DEFAULT 0,
+		chapter DOUBLE PRECISION NOT NULL DEFAULT 0,
 		link TEXT,
 		status TEXT,
 		image_path TEXT,
@@ -164,7 +165,7 @@ var postgresSchemaStatements = []string{
 	)`,
 	`CREATE TABLE IF NOT EXISTS admin_audit_log (
 		id BIGSERIAL PRIMARY KEY,
-		actor_user_id BIGINT NOT NULL REFERENCES users(id),
+		actor_user_id BIGINT REFERENCES users(id),
 		action TEXT NOT NULL,
 		target_type TEXT,
 		target_id TEXT,
@@ -197,6 +198,170 @@ var postgresSchemaStatements = []string{
 		session_data TEXT NOT NULL,
 		expires_at TIMESTAMPTZ NOT NULL
 	)`,
+	`CREATE TABLE IF NOT EXISTS work_progress_events (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		work_id BIGINT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+		chapter_before DOUBLE PRECISION NOT NULL,
+		chapter_after DOUBLE PRECISION NOT NULL,
+		unit TEXT NOT NULL DEFAULT 'chapter',
+		source TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		undone_at TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS work_tags (
+		work_id BIGINT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+		tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		PRIMARY KEY (work_id, tag_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS work_reads (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		work_id BIGINT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+		read_number INTEGER NOT NULL,
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ,
+		rating INTEGER DEFAULT 0,
+		notes TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS reading_goals (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind TEXT NOT NULL,
+		period TEXT NOT NULL,
+		target INTEGER NOT NULL,
+		reading_type TEXT,
+		reached_period TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS work_copies (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		work_id BIGINT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+		volume INTEGER NOT NULL,
+		format TEXT NOT NULL DEFAULT 'paperback',
+		isbn TEXT,
+		purchased_at TEXT,
+		price DOUBLE PRECISION,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS work_releases (
+		id BIGSERIAL PRIMARY KEY,
+		work_id BIGINT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+		chapter DOUBLE PRECISION NOT NULL,
+		title TEXT,
+		url TEXT,
+		released_at TIMESTAMPTZ,
+		detected_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS notifications (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind TEXT NOT NULL,
+		subject TEXT,
+		detail TEXT,
+		link TEXT,
+		dedupe_key TEXT,
+		read_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS mail_outbox (
+		id BIGSERIAL PRIMARY KEY,
+		to_addr TEXT NOT NULL,
+		subject TEXT NOT NULL DEFAULT '',
+		text_body TEXT NOT NULL DEFAULT '',
+		html_body TEXT NOT NULL DEFAULT '',
+		custom_id TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		next_retry_at TIMESTAMPTZ,
+		expires_at TIMESTAMPTZ,
+		sent_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS invites (
+		id BIGSERIAL PRIMARY KEY,
+		code TEXT NOT NULL UNIQUE,
+		created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+		max_uses INTEGER NOT NULL DEFAULT 1,
+		uses INTEGER NOT NULL DEFAULT 0,
+		pre_approved INTEGER NOT NULL DEFAULT 0,
+		note TEXT,
+		expires_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash TEXT NOT NULL,
+		used_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS login_challenges (
+		challenge_hash TEXT PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		next TEXT,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_identities (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_login_at TIMESTAMPTZ,
+		UNIQUE (provider, subject)
+	)`,
+	`CREATE TABLE IF NOT EXISTS oauth_clients (
+		id BIGSERIAL PRIMARY KEY,
+		client_id TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		secret_hash TEXT,
+		redirect_uris TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS oauth_grants (
+		id BIGSERIAL PRIMARY KEY,
+		client_id BIGINT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (client_id, user_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+		code_hash TEXT PRIMARY KEY,
+		grant_id BIGINT NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+		redirect_uri TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		code_challenge TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS oauth_refresh_tokens (
+		id BIGSERIAL PRIMARY KEY,
+		grant_id BIGINT NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ
+	)`,
 	`CREATE TABLE IF NOT EXISTS schema_migrations (
 		version INTEGER PRIMARY KEY,
 		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -230,6 +395,22 @@ var postgresSchemaStatements = []string{
 	`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id)`,
 	`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at)`,
 	`CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_work_progress_events_work ON work_progress_events(work_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_work_progress_events_user ON work_progress_events(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_work_tags_tag ON work_tags(tag_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_work_reads_work ON work_reads(work_id, read_number)`,
+	`CREATE INDEX IF NOT EXISTS idx_reading_goals_user ON reading_goals(user_id)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_work_copies_volume ON work_copies(work_id, volume, format)`,
+	`CREATE INDEX IF NOT EXISTS idx_work_copies_user ON work_copies(user_id)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_work_releases_chapter ON work_releases(work_id, chapter)`,
+	`CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(user_id, dedupe_key)`,
+	`CREATE INDEX IF NOT EXISTS idx_mail_outbox_status ON mail_outbox(status, next_retry_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_login_challenges_expires_at ON login_challenges(expires_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_oauth_grants_user_id ON oauth_grants(user_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_oauth_refresh_tokens_grant_id ON oauth_refresh_tokens(grant_id)`,
 }
 
 // postgresSchemaAfterExtraColumns runs after ALTER TABLE ... ADD COLUMN for works, so indexes
@@ -242,6 +423,58 @@ var postgresSchemaAfterExtraColumns = []string{
 	`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ`,
 	`UPDATE api_tokens SET expires_at = created_at + INTERVAL '90 days'
 		WHERE expires_at IS NULL AND revoked_at IS NULL`,
+	// Migration 27 parity (SQLite): fractional chapters (45.5) and per-event progress unit.
+	`DO $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = 'works' AND column_name = 'chapter' AND data_type = 'integer') THEN
+			ALTER TABLE works ALTER COLUMN chapter TYPE DOUBLE PRECISION;
+		END IF;
+		IF EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = 'work_progress_events' AND column_name = 'chapter_before' AND data_type = 'integer') THEN
+			ALTER TABLE work_progress_events ALTER COLUMN chapter_before TYPE DOUBLE PRECISION;
+			ALTER TABLE work_progress_events ALTER COLUMN chapter_after TYPE DOUBLE PRECISION;
+		END IF;
+	END $$`,
+	`ALTER TABLE work_progress_events ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT 'chapter'`,
+	// Migration 34 parity (SQLite): reading site feed templates.
+	`ALTER TABLE reading_sites ADD COLUMN IF NOT EXISTS feed_template TEXT`,
+	// Migration 38 parity (SQLite): email verification; addresses saved before the upgrade count as
+	// verified (once, keyed on the new columns).
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = 'users' AND column_name = 'email_verified_at') THEN
+			ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+			ALTER TABLE users ADD COLUMN pending_email TEXT;
+			` + emailVerifiedBackfill + `;
+		END IF;
+	END $$`,
+	// Migration 39 parity (SQLite): system entries in the audit log have no actor; pending
+	// accounts from before the upgrade start their expiry clock now.
+	`ALTER TABLE admin_audit_log ALTER COLUMN actor_user_id DROP NOT NULL`,
+	`UPDATE users SET registered_at = CURRENT_TIMESTAMP WHERE registered_at IS NULL AND COALESCE(validated, 0) = 0`,
+	// Migration 40 parity (SQLite): invite code carried through Google sign-up.
+	`ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS invite_code TEXT`,
+	// Migration 42 parity (SQLite): OIDC provider and nonce for generic identity providers.
+	`ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS provider TEXT`,
+	`ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS nonce TEXT`,
+	// Migration 43 parity (SQLite): access tokens issued to OAuth clients hang off their grant.
+	`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS oauth_grant_id BIGINT REFERENCES oauth_grants(id) ON DELETE CASCADE`,
+	`CREATE INDEX IF NOT EXISTS idx_api_tokens_oauth_grant_id ON api_tokens(oauth_grant_id)`,
+	// Migration 44 parity (SQLite): scopes split off works:read (once, keyed on the new column) and
+	// per-token restrictions.
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = 'api_tokens' AND column_name = 'rate_limit_per_minute') THEN
+			` + strings.Join(apiScopeSplitUpdates, ";\n\t\t\t") + `;
+			ALTER TABLE api_tokens ADD COLUMN rate_limit_per_minute INTEGER;
+		END IF;
+	END $$`,
+	`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS allowed_ips TEXT`,
+	`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS work_ids TEXT`,
+	`ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS reading_types TEXT`,
 }
 
 var postgresFTSStatements = []string{
@@ -271,6 +504,12 @@ func ensurePostgresSchema(c *Conn) error {
 			return fmt.Errorf("postgres schema: %w", err)
 		}
 	}
+	// Migration 29 parity (SQLite): legacy status strings → canonical codes (no-op once converted).
+	for _, stmt := range statusCodeUpdates {
+		if _, err := c.Exec(stmt); err != nil {
+			return fmt.Errorf("postgres status codes: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -280,6 +519,21 @@ var postgresProfileColumns = map[string]string{
 	"bio":          "TEXT",
 	"avatar_path":  "TEXT",
 	"is_public":    "INTEGER DEFAULT 1",
+	// Migration 36 parity (SQLite).
+	"digest_frequency": "TEXT",
+	"digest_lang":      "TEXT",
+	"digest_token":     "TEXT",
+	"digest_sent_at":   "TIMESTAMPTZ",
+	// Migration 39 parity (SQLite).
+	"registered_at": "TIMESTAMPTZ",
+	"signup_lang":   "TEXT",
+	// Migration 40 parity (SQLite).
+	"invite_id": "BIGINT REFERENCES invites(id) ON DELETE SET NULL",
+	// Migration 41 parity (SQLite).
+	"totp_secret":         "TEXT",
+	"totp_pending_secret": "TEXT",
+	"totp_enabled_at":     "TIMESTAMPTZ",
+	"totp_last_step":      "BIGINT",
 }
 
 var postgresCatalogColumns = map[string]string{
@@ -288,6 +542,9 @@ var postgresCatalogColumns = map[string]string{
 	"genres":     "TEXT",
 	"tags":       "TEXT",
 	"fetched_at": "TIMESTAMPTZ",
+	// Migration 33 parity (SQLite).
+	"latest_chapter":            "DOUBLE PRECISION",
+	"latest_chapter_checked_at": "TIMESTAMPTZ",
 }
 
 var postgresWorkColumns = map[string]string{
@@ -309,6 +566,10 @@ var postgresWorkColumns = map[string]string{
 	"link_probe_at":          "TIMESTAMPTZ",
 	"link_probe_http_status": "INTEGER",
 	"link_probe_detail":      "TEXT",
+	"volume":                 "DOUBLE PRECISION DEFAULT 0",
+	"feed_url":               "TEXT",
+	"feed_checked_at":        "TIMESTAMPTZ",
+	"feed_status":            "TEXT",
 }
 
 func ensurePostgresExtraColumns(c *Conn) error {
@@ -41,13 +41,13 @@ var (
 	languages       []LangInfo
 )
 
-// statusKeyMap maps DB-stored status values (French) to i18n keys.
+// statusKeyMap maps DB-stored status codes to i18n keys.
 var statusKeyMap = map[string]string{
-	"En cours":  "status.reading",
-	"Terminé":   "status.completed",
-	"En pause":  "status.on_hold",
-	"Abandonné": "status.dropped",
-	"À lire":    "status.plan_to_read",
+	"reading":   "status.reading",
+	"completed": "status.completed",
+	"on_hold":   "status.on_hold",
+	"dropped":   "status.dropped",
+	"planned":   "status.plan_to_read",
 }
 
 func init() {
@@ -118,7 +118,7 @@ func ValidLang(lang string) bool {
 	return ok
 }
 
-// TranslateStatus converts a DB-stored status (French) to the target language
+// TranslateStatus converts a DB-stored status code to the target language
 // using the translations map.
 func TranslateStatus(status string, t Translations) string {
 	if key, ok := statusKeyMap[status]; ok {
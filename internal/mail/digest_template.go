@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// DigestSection is one titled list of a reading digest (chapters read, works finished...).
+type DigestSection struct {
+	Title string
+	Items []string
+}
+
+// DigestContent holds localized strings for a reading digest email.
+type DigestContent struct {
+	Subject     string
+	Greeting    string
+	Intro       string
+	Sections    []DigestSection
+	Button      string
+	Unsubscribe string
+	Footer      string
+}
+
+// BuildDigestText renders the plain-text reading digest email.
+func BuildDigestText(content DigestContent, dashboardLink, unsubscribeLink string) string {
+	parts := []string{content.Greeting, "", content.Intro}
+	for _, sec := range content.Sections {
+		parts = append(parts, "", sec.Title)
+		for _, item := range sec.Items {
+			parts = append(parts, "- "+item)
+		}
+	}
+	parts = append(parts, "", content.Button, dashboardLink, "", content.Unsubscribe, unsubscribeLink)
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		parts = append(parts, "", f)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// BuildDigestHTML renders the reading digest with the same branding as BuildPasswordResetHTML.
+func BuildDigestHTML(content DigestContent, branding PasswordResetBranding, dashboardLink, unsubscribeLink string) string {
+	var b strings.Builder
+	color := writeBrandedHeader(&b, branding)
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Intro))
+	for _, sec := range content.Sections {
+		fmt.Fprintf(&b, `<p style="margin-bottom:0.25rem;font-weight:bold;color:%s;">%s</p><ul style="margin-top:0;">`, color, html.EscapeString(sec.Title))
+		for _, item := range sec.Items {
+			fmt.Fprintf(&b, `<li>%s</li>`, html.EscapeString(item))
+		}
+		b.WriteString(`</ul>`)
+	}
+	fmt.Fprintf(&b, `<p style="text-align:center;"><a href="%s" style="display:inline-block;padding:0.75rem 1.25rem;background:%s;color:#fff;text-decoration:none;border-radius:0.5rem;">%s</a></p>`,
+		html.EscapeString(dashboardLink), color, html.EscapeString(content.Button))
+	fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;"><a href="%s" style="color:#777;">%s</a></p>`,
+		html.EscapeString(unsubscribeLink), html.EscapeString(content.Unsubscribe))
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;">%s</p>`, html.EscapeString(f))
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildDigest(t *testing.T) {
+	content := DigestContent{
+		Greeting:    "Hello reader,",
+		Intro:       "Your week on BookStorage.",
+		Sections:    []DigestSection{{Title: "Works finished", Items: []string{"Berserk <deluxe>"}}},
+		Button:      "Open my dashboard",
+		Unsubscribe: "Stop these emails",
+		Footer:      "Support: admin@example.com",
+	}
+	branding := PasswordResetBranding{SiteName: "My Library", BrandColor: "#ff0000"}
+
+	htmlBody := BuildDigestHTML(content, branding, "https://books.example/dashboard", "https://books.example/digest/unsubscribe?token=abc")
+	for _, want := range []string{"My Library", "#ff0000", "Berserk &lt;deluxe&gt;", "digest/unsubscribe?token=abc", "admin@example.com"} {
+		if !strings.Contains(htmlBody, want) {
+			t.Fatalf("html digest missing %q", want)
+		}
+	}
+
+	text := BuildDigestText(content, "https://books.example/dashboard", "https://books.example/digest/unsubscribe?token=abc")
+	if !strings.Contains(text, "Works finished\n- Berserk <deluxe>") || !strings.Contains(text, "Stop these emails\nhttps://books.example/digest/unsubscribe?token=abc") {
+		t.Fatalf("text digest:\n%s", text)
+	}
+}
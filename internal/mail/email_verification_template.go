@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// EmailVerificationContent holds localized strings for an address verification email
+// (registration or email change).
+type EmailVerificationContent struct {
+	Subject  string
+	Greeting string
+	Body     string
+	Button   string
+	Expiry   string
+	Ignore   string
+	Footer   string
+}
+
+// BuildEmailVerificationText renders the plain-text verification email.
+func BuildEmailVerificationText(content EmailVerificationContent, verifyLink string) string {
+	parts := []string{content.Greeting, "", content.Body, "", verifyLink, content.Button, "", content.Expiry, "", content.Ignore}
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		parts = append(parts, "", f)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// BuildEmailVerificationHTML renders the verification email with the same branding as BuildPasswordResetHTML.
+func BuildEmailVerificationHTML(content EmailVerificationContent, branding PasswordResetBranding, verifyLink string) string {
+	var b strings.Builder
+	color := writeBrandedHeader(&b, branding)
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Body))
+	fmt.Fprintf(&b, `<p style="text-align:center;"><a href="%s" style="display:inline-block;padding:0.75rem 1.25rem;background:%s;color:#fff;text-decoration:none;border-radius:0.5rem;">%s</a></p>`,
+		html.EscapeString(verifyLink), color, html.EscapeString(content.Button))
+	fmt.Fprintf(&b, `<p style="font-size:0.9rem;color:#555;">%s</p>`, html.EscapeString(content.Expiry))
+	fmt.Fprintf(&b, `<p style="font-size:0.9rem;color:#555;">%s</p>`, html.EscapeString(content.Ignore))
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;">%s</p>`, html.EscapeString(f))
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
+
+// EmailChangeNoticeContent holds localized strings for the notice sent to the previous
+// address when an email change is requested.
+type EmailChangeNoticeContent struct {
+	Subject  string
+	Greeting string
+	Body     string
+	Warning  string
+	Footer   string
+}
+
+// BuildEmailChangeNoticeText renders the plain-text email change notice.
+func BuildEmailChangeNoticeText(content EmailChangeNoticeContent) string {
+	parts := []string{content.Greeting, "", content.Body, "", content.Warning}
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		parts = append(parts, "", f)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// BuildEmailChangeNoticeHTML renders the email change notice with the shared branding.
+func BuildEmailChangeNoticeHTML(content EmailChangeNoticeContent, branding PasswordResetBranding) string {
+	var b strings.Builder
+	writeBrandedHeader(&b, branding)
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Body))
+	fmt.Fprintf(&b, `<p style="font-size:0.9rem;color:#555;">%s</p>`, html.EscapeString(content.Warning))
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;">%s</p>`, html.EscapeString(f))
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
@@ -17,11 +17,15 @@ type Sender interface {
 	Send(ctx context.Context, msg Message) error
 }
 
-// NewSender returns a Mailjet client when configured, otherwise a no-op sender.
+// NewSender returns an SMTP or Mailjet client depending on which backend is configured,
+// otherwise a no-op sender.
 func NewSender(s *config.Settings) Sender {
 	if s == nil || !s.MailConfigured() {
 		return noopSender{}
 	}
+	if s.SMTPConfigured() {
+		return newSMTPClient(s)
+	}
 	return &mailjetClient{
 		publicKey:  s.MailjetAPIKeyPublic,
 		privateKey: s.MailjetAPIKeyPrivate,
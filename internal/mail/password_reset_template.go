@@ -62,19 +62,8 @@ func BuildPasswordResetText(content PasswordResetContent, resetLink string) stri
 
 // BuildPasswordResetHTML renders a minimal HTML password reset email compatible with Gmail.
 func BuildPasswordResetHTML(content PasswordResetContent, branding PasswordResetBranding, resetLink string) string {
-	siteName := html.EscapeString(strings.TrimSpace(branding.SiteName))
-	if siteName == "" {
-		siteName = "BookStorage"
-	}
-	color := html.EscapeString(normalizeBrandColor(branding.BrandColor))
-	logo := html.EscapeString(EmailSafeLogoURL(branding.LogoURL))
-
 	var b strings.Builder
-	b.WriteString(`<!DOCTYPE html><html><body style="font-family:sans-serif;line-height:1.5;color:#111;">`)
-	if logo != "" {
-		fmt.Fprintf(&b, `<p style="text-align:center;"><img src="%s" alt="%s" width="48" height="48"></p>`, logo, siteName)
-	}
-	fmt.Fprintf(&b, `<p style="text-align:center;font-weight:bold;color:%s;">%s</p>`, color, siteName)
+	color := writeBrandedHeader(&b, branding)
 	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
 	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Body))
 	fmt.Fprintf(&b, `<p style="text-align:center;"><a href="%s" style="display:inline-block;padding:0.75rem 1.25rem;background:%s;color:#fff;text-decoration:none;border-radius:0.5rem;">%s</a></p>`,
@@ -91,6 +80,24 @@ func BuildPasswordResetHTML(content PasswordResetContent, branding PasswordReset
 	return b.String()
 }
 
+// writeBrandedHeader opens the HTML document with the site logo and name shared by every
+// BookStorage email, and returns the escaped brand color for buttons.
+func writeBrandedHeader(b *strings.Builder, branding PasswordResetBranding) string {
+	siteName := html.EscapeString(strings.TrimSpace(branding.SiteName))
+	if siteName == "" {
+		siteName = "BookStorage"
+	}
+	color := html.EscapeString(normalizeBrandColor(branding.BrandColor))
+	logo := html.EscapeString(EmailSafeLogoURL(branding.LogoURL))
+
+	b.WriteString(`<!DOCTYPE html><html><body style="font-family:sans-serif;line-height:1.5;color:#111;">`)
+	if logo != "" {
+		fmt.Fprintf(b, `<p style="text-align:center;"><img src="%s" alt="%s" width="48" height="48"></p>`, logo, siteName)
+	}
+	fmt.Fprintf(b, `<p style="text-align:center;font-weight:bold;color:%s;">%s</p>`, color, siteName)
+	return color
+}
+
 func normalizeBrandColor(c string) string {
 	c = strings.TrimSpace(c)
 	if c == "" {
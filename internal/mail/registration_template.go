@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// RegistrationPendingContent holds localized strings for the email telling an admin that an
+// account awaits approval.
+type RegistrationPendingContent struct {
+	Subject  string
+	Greeting string
+	Body     string
+	Approve  string
+	Reject   string
+	Footer   string
+}
+
+// BuildRegistrationPendingText renders the plain-text pending registration email.
+func BuildRegistrationPendingText(content RegistrationPendingContent, approveLink, rejectLink string) string {
+	parts := []string{content.Greeting, "", content.Body, "", content.Approve, approveLink, "", content.Reject, rejectLink}
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		parts = append(parts, "", f)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// BuildRegistrationPendingHTML renders the pending registration email with approve and reject buttons.
+func BuildRegistrationPendingHTML(content RegistrationPendingContent, branding PasswordResetBranding, approveLink, rejectLink string) string {
+	var b strings.Builder
+	color := writeBrandedHeader(&b, branding)
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Body))
+	fmt.Fprintf(&b, `<p style="text-align:center;"><a href="%s" style="display:inline-block;padding:0.75rem 1.25rem;background:%s;color:#fff;text-decoration:none;border-radius:0.5rem;">%s</a> `,
+		html.EscapeString(approveLink), color, html.EscapeString(content.Approve))
+	fmt.Fprintf(&b, `<a href="%s" style="display:inline-block;padding:0.75rem 1.25rem;border:1px solid %s;color:%s;text-decoration:none;border-radius:0.5rem;">%s</a></p>`,
+		html.EscapeString(rejectLink), color, color, html.EscapeString(content.Reject))
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;">%s</p>`, html.EscapeString(f))
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
+
+// RegistrationRejectedContent holds localized strings for the email sent to a rejected applicant.
+// Reason is optional free text from the admin.
+type RegistrationRejectedContent struct {
+	Subject     string
+	Greeting    string
+	Body        string
+	ReasonLabel string
+	Reason      string
+	Footer      string
+}
+
+// BuildRegistrationRejectedText renders the plain-text rejection email.
+func BuildRegistrationRejectedText(content RegistrationRejectedContent) string {
+	parts := []string{content.Greeting, "", content.Body}
+	if reason := strings.TrimSpace(content.Reason); reason != "" {
+		parts = append(parts, "", content.ReasonLabel, reason)
+	}
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		parts = append(parts, "", f)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// BuildRegistrationRejectedHTML renders the rejection email with the shared branding.
+func BuildRegistrationRejectedHTML(content RegistrationRejectedContent, branding PasswordResetBranding) string {
+	var b strings.Builder
+	writeBrandedHeader(&b, branding)
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Greeting))
+	fmt.Fprintf(&b, `<p>%s</p>`, html.EscapeString(content.Body))
+	if reason := strings.TrimSpace(content.Reason); reason != "" {
+		fmt.Fprintf(&b, `<p><strong>%s</strong><br>%s</p>`,
+			html.EscapeString(content.ReasonLabel), strings.ReplaceAll(html.EscapeString(reason), "\n", "<br>"))
+	}
+	if f := strings.TrimSpace(content.Footer); f != "" {
+		fmt.Fprintf(&b, `<p style="font-size:0.85rem;color:#777;">%s</p>`, html.EscapeString(f))
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
@@ -0,0 +1,259 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/config"
+)
+
+const defaultSMTPTimeout = 15 * time.Second
+
+type smtpClient struct {
+	host     string
+	port     int
+	security string // config.SMTPSecurity*
+	username string
+	password string
+	auth     string // config.SMTPAuth*
+	from     string
+	// tlsConfig overrides the default (ServerName = host) TLS settings; used by tests.
+	tlsConfig *tls.Config
+}
+
+func newSMTPClient(s *config.Settings) *smtpClient {
+	return &smtpClient{
+		host:     strings.TrimSpace(s.SMTPHost),
+		port:     s.SMTPPort,
+		security: s.SMTPSecurity,
+		username: strings.TrimSpace(s.SMTPUsername),
+		password: s.SMTPPassword,
+		auth:     s.SMTPAuth,
+		from:     s.MailFrom,
+	}
+}
+
+func (c *smtpClient) Send(ctx context.Context, msg Message) error {
+	if mailSendHook != nil {
+		return mailSendHook(ctx, msg)
+	}
+	to := strings.TrimSpace(msg.To)
+	if to == "" {
+		return fmt.Errorf("mail: empty recipient")
+	}
+	fromName, fromEmail := parseFromAddress(c.from)
+	if fromEmail == "" {
+		return fmt.Errorf("mail: invalid From address")
+	}
+	body, err := buildMIMEMessage(fromName, fromEmail, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultSMTPTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+	addr := net.JoinHostPort(c.host, strconv.Itoa(c.port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial %s: %w", addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(deadline)
+
+	if c.security == config.SMTPSecurityTLS {
+		tc := tls.Client(conn, c.tlsConfigFor())
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("smtp tls: %w", err)
+		}
+		conn = tc
+	}
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if c.security == config.SMTPSecurityStartTLS || c.security == "" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp: %s does not offer STARTTLS", addr)
+		}
+		if err := client.StartTLS(c.tlsConfigFor()); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if c.username != "" {
+		var auth smtp.Auth = plainAuth{username: c.username, password: c.password}
+		if c.auth == config.SMTPAuthLogin {
+			auth = loginAuth{username: c.username, password: c.password}
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(fromEmail); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+func (c *smtpClient) tlsConfigFor() *tls.Config {
+	if c.tlsConfig != nil {
+		return c.tlsConfig.Clone()
+	}
+	return &tls.Config{ServerName: c.host, MinVersion: tls.VersionTLS12}
+}
+
+// plainAuth implements AUTH PLAIN (RFC 4616). Unlike smtp.PlainAuth it does not refuse
+// unencrypted connections: BOOKSTORAGE_SMTP_SECURITY=none is an explicit admin choice.
+type plainAuth struct {
+	username, password string
+}
+
+func (a plainAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a plainAuth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, errors.New("unexpected AUTH PLAIN challenge")
+	}
+	return nil, nil
+}
+
+// loginAuth implements the AUTH LOGIN exchange still required by some relays (Exchange, older Postfix setups).
+type loginAuth struct {
+	username, password string
+}
+
+func (a loginAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:", "user name", "username":
+		return []byte(a.username), nil
+	case "password:", "password":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected AUTH LOGIN challenge %q", fromServer)
+	}
+}
+
+// buildMIMEMessage renders msg as an RFC 5322 message: multipart/alternative when both bodies
+// are set, quoted-printable UTF-8 parts, with Date and Message-ID headers.
+func buildMIMEMessage(fromName, fromEmail, to string, msg Message, now time.Time) ([]byte, error) {
+	messageID, err := newMessageID(fromEmail)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	writeHeader := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	writeHeader("From", (&netmail.Address{Name: fromName, Address: fromEmail}).String())
+	writeHeader("To", (&netmail.Address{Address: to}).String())
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	if id := strings.TrimSpace(msg.CustomID); id != "" {
+		writeHeader("X-BookStorage-ID", id)
+	}
+	writeHeader("MIME-Version", "1.0")
+
+	switch {
+	case msg.TextBody != "" && msg.HTMLBody != "":
+		boundary, err := randomHex(12)
+		if err != nil {
+			return nil, err
+		}
+		boundary = "bookstorage-" + boundary
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		b.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", msg.TextBody},
+			{"text/html", msg.HTMLBody},
+		} {
+			fmt.Fprintf(&b, "--%s\r\n", boundary)
+			if err := writeQuotedPrintablePart(&b, part.contentType, part.body); err != nil {
+				return nil, err
+			}
+		}
+		fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	case msg.HTMLBody != "":
+		if err := writeQuotedPrintablePart(&b, "text/html", msg.HTMLBody); err != nil {
+			return nil, err
+		}
+	default:
+		if err := writeQuotedPrintablePart(&b, "text/plain", msg.TextBody); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+func writeQuotedPrintablePart(b *bytes.Buffer, contentType, body string) error {
+	fmt.Fprintf(b, "Content-Type: %s; charset=UTF-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", contentType)
+	qp := quotedprintable.NewWriter(b)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	b.WriteString("\r\n")
+	return nil
+}
+
+func newMessageID(fromEmail string) (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	domain := "bookstorage.local"
+	if i := strings.LastIndex(fromEmail, "@"); i >= 0 && i < len(fromEmail)-1 {
+		domain = fromEmail[i+1:]
+	}
+	return "<" + id + "@" + domain + ">", nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
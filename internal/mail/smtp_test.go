@@ -0,0 +1,260 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"strings"
+	"sync"
+	"testing"
+
+	"bookstorage/internal/config"
+)
+
+// smtpStandIn is a minimal in-process SMTP server: EHLO, STARTTLS, AUTH PLAIN/LOGIN, MAIL, RCPT, DATA, QUIT.
+type smtpStandIn struct {
+	ln          net.Listener
+	cert        tls.Certificate
+	implicitTLS bool
+	rejectRcpt  bool
+
+	mu       sync.Mutex
+	tlsUsed  bool
+	auth     string // "PLAIN user pass" or "LOGIN user pass"
+	mailFrom string
+	rcptTo   string
+	data     string
+	done     chan struct{}
+}
+
+// newSMTPStandIn starts the stand-in and returns a client TLS config trusting its certificate.
+func newSMTPStandIn(t *testing.T, implicitTLS, rejectRcpt bool) (*smtpStandIn, *tls.Config) {
+	t.Helper()
+	// Reuse the httptest certificate (valid for 127.0.0.1) instead of generating one.
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(ts.Close)
+	clientTLS := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	clientTLS.ServerName = "127.0.0.1"
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	s := &smtpStandIn{ln: ln, cert: ts.TLS.Certificates[0], implicitTLS: implicitTLS, rejectRcpt: rejectRcpt, done: make(chan struct{})}
+	go s.serve()
+	return s, clientTLS
+}
+
+func (s *smtpStandIn) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	tlsActive := false
+	if s.implicitTLS {
+		conn = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+		tlsActive = true
+	}
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+	decode := func(b64 string) string {
+		raw, _ := base64.StdEncoding.DecodeString(b64)
+		return string(raw)
+	}
+
+	reply("220 stand-in ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-stand-in")
+			if !tlsActive {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN LOGIN")
+		case cmd == "STARTTLS":
+			reply("220 ready to start TLS")
+			conn = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{s.cert}})
+			r = bufio.NewReader(conn)
+			tlsActive = true
+		case strings.HasPrefix(cmd, "AUTH PLAIN "):
+			parts := strings.Split(decode(line[len("AUTH PLAIN "):]), "\x00")
+			s.record(func() { s.auth = "PLAIN " + strings.Join(parts[1:], " ") })
+			reply("235 authenticated")
+		case cmd == "AUTH LOGIN":
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+			user, _ := readLine()
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+			pass, _ := readLine()
+			s.record(func() { s.auth = "LOGIN " + decode(user) + " " + decode(pass) })
+			reply("235 authenticated")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.record(func() { s.mailFrom = line[len("MAIL FROM:"):] })
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			if s.rejectRcpt {
+				reply("550 no such user")
+				continue
+			}
+			s.record(func() { s.rcptTo = line[len("RCPT TO:"):] })
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var b strings.Builder
+			for {
+				l, ok := readLine()
+				if !ok || l == "." {
+					break
+				}
+				b.WriteString(strings.TrimPrefix(l, "."))
+				b.WriteString("\r\n")
+			}
+			s.record(func() { s.data = b.String(); s.tlsUsed = tlsActive })
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpStandIn) record(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+}
+
+func newTestSMTPClient(s *smtpStandIn, clientTLS *tls.Config, security, auth string) *smtpClient {
+	return &smtpClient{
+		host:      "127.0.0.1",
+		port:      s.port(),
+		security:  security,
+		username:  "bookstorage",
+		password:  "s3cret",
+		auth:      auth,
+		from:      "BookStorage <noreply@books.example>",
+		tlsConfig: clientTLS,
+	}
+}
+
+func TestSMTPClientSend_startTLSPlainMultipart(t *testing.T) {
+	srv, clientTLS := newSMTPStandIn(t, false, false)
+	client := newTestSMTPClient(srv, clientTLS, config.SMTPSecurityStartTLS, config.SMTPAuthPlain)
+	err := client.Send(context.Background(), Message{
+		To:       "reader@example.com",
+		Subject:  "Réinitialisation",
+		TextBody: "Bonjour,\nvoici le lien.",
+		HTMLBody: "<p>Bonjour</p>",
+		CustomID: "password-reset-abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+
+	if !srv.tlsUsed || srv.auth != "PLAIN bookstorage s3cret" {
+		t.Fatalf("tls=%v auth=%q", srv.tlsUsed, srv.auth)
+	}
+	if srv.mailFrom != "<noreply@books.example>" || srv.rcptTo != "<reader@example.com>" {
+		t.Fatalf("envelope from=%q to=%q", srv.mailFrom, srv.rcptTo)
+	}
+	msg, err := netmail.ReadMessage(strings.NewReader(srv.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); got != "Réinitialisation" {
+		t.Fatalf("subject %q", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Fatalf("date header: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@books.example>") {
+		t.Fatalf("message-id %q", id)
+	}
+	if from := msg.Header.Get("From"); from != `"BookStorage" <noreply@books.example>` {
+		t.Fatalf("from %q", from)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content-type %q: %v", msg.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(part) // multipart decodes quoted-printable
+		bodies = append(bodies, part.Header.Get("Content-Type")+"|"+string(b))
+	}
+	want := []string{"text/plain; charset=UTF-8|Bonjour,\r\nvoici le lien.", "text/html; charset=UTF-8|<p>Bonjour</p>"}
+	if len(bodies) != 2 || bodies[0] != want[0] || bodies[1] != want[1] {
+		t.Fatalf("parts %q", bodies)
+	}
+}
+
+func TestSMTPClientSend_implicitTLSLogin(t *testing.T) {
+	srv, clientTLS := newSMTPStandIn(t, true, false)
+	client := newTestSMTPClient(srv, clientTLS, config.SMTPSecurityTLS, config.SMTPAuthLogin)
+	if err := client.Send(context.Background(), Message{To: "reader@example.com", Subject: "s", TextBody: "only text"}); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+	if !srv.tlsUsed || srv.auth != "LOGIN bookstorage s3cret" {
+		t.Fatalf("tls=%v auth=%q", srv.tlsUsed, srv.auth)
+	}
+	if !strings.Contains(srv.data, "Content-Type: text/plain; charset=UTF-8") || strings.Contains(srv.data, "multipart") {
+		t.Fatalf("data:\n%s", srv.data)
+	}
+}
+
+func TestSMTPClientSend_rejectedRecipient(t *testing.T) {
+	srv, clientTLS := newSMTPStandIn(t, false, true)
+	client := newTestSMTPClient(srv, clientTLS, config.SMTPSecurityStartTLS, config.SMTPAuthPlain)
+	err := client.Send(context.Background(), Message{To: "nobody@example.com", Subject: "s", TextBody: "x"})
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("expected 550 error, got %v", err)
+	}
+}
+
+func TestNewSenderSelectsSMTP(t *testing.T) {
+	s := &config.Settings{
+		PublicOrigin: "https://books.example",
+		MailFrom:     "noreply@books.example",
+		SMTPHost:     "relay.lan",
+		SMTPPort:     587,
+	}
+	if _, ok := NewSender(s).(*smtpClient); !ok {
+		t.Fatalf("NewSender = %T, want *smtpClient", NewSender(s))
+	}
+}
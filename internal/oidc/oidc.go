@@ -0,0 +1,381 @@
+// Package oidc implements the relying-party side of OpenID Connect for self-hosted
+// identity providers (Authelia, Keycloak, Authentik...): discovery, authorization
+// code flow with PKCE and nonce, and ID-token validation against the provider JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bookstorage/internal/config"
+	"bookstorage/internal/oauthgoogle"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	discoveryTTL = time.Hour
+	jwksTTL      = time.Hour
+	// jwksMinRefresh bounds refetches triggered by an unknown key ID (key rotation).
+	jwksMinRefresh = time.Minute
+	clockLeeway    = 2 * time.Minute
+)
+
+// signingMethods are the asymmetric JWS algorithms accepted for ID tokens; HMAC and "none" never are.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Discovery is the subset of the OpenID Provider metadata the login flow needs.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is one configured identity provider with its cached metadata and signing keys.
+type Provider struct {
+	Config      config.OIDCProvider
+	RedirectURL string
+	client      *http.Client
+
+	mu        sync.Mutex
+	disc      *Discovery
+	discAt    time.Time
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+	keysTried time.Time
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Provider{}
+)
+
+// For returns the shared Provider for p, so discovery and JWKS are fetched once per process.
+// The callback URL is {publicOrigin}/auth/oidc/{id}/callback.
+func For(p config.OIDCProvider, publicOrigin string) *Provider {
+	redirect := strings.TrimRight(strings.TrimSpace(publicOrigin), "/") + "/auth/oidc/" + p.ID + "/callback"
+	key := p.ID + "\x00" + p.Issuer + "\x00" + p.ClientID + "\x00" + redirect
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if prov, ok := registry[key]; ok {
+		return prov
+	}
+	prov := &Provider{Config: p, RedirectURL: redirect, client: &http.Client{Timeout: 15 * time.Second}}
+	registry[key] = prov
+	return prov
+}
+
+// NewNonce returns a random value bound to the ID token through the nonce claim.
+func NewNonce() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s: status %d", rawURL, resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// Discover returns the provider metadata from {issuer}/.well-known/openid-configuration.
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.discoverLocked(ctx)
+}
+
+func (p *Provider) discoverLocked(ctx context.Context) (*Discovery, error) {
+	if p.disc != nil && time.Since(p.discAt) < discoveryTTL {
+		return p.disc, nil
+	}
+	var d Discovery
+	if err := p.getJSON(ctx, p.Config.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	// OIDC Discovery §4.3: the issuer in the document must be the one we asked for.
+	if strings.TrimRight(d.Issuer, "/") != p.Config.Issuer {
+		return nil, fmt.Errorf("oidc: discovery issuer %q does not match %q", d.Issuer, p.Config.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document lacks authorization, token or jwks endpoint")
+	}
+	p.disc, p.discAt = &d, time.Now()
+	return p.disc, nil
+}
+
+func (p *Provider) oauth2Config(d *Discovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.Config.ClientID,
+		ClientSecret: p.Config.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       p.Config.Scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: d.AuthorizationEndpoint, TokenURL: d.TokenEndpoint},
+	}
+}
+
+// AuthCodeURL returns the authorization URL with PKCE (S256) and the nonce.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, codeVerifier, nonce string) (string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2Config(d).AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.SetAuthURLParam("code_challenge", oauthgoogle.S256Challenge(codeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange redeems the authorization code and returns the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	tok, err := p.oauth2Config(d).Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return "", err
+	}
+	raw, _ := tok.Extra("id_token").(string)
+	if raw == "" {
+		return "", errors.New("oidc: token response has no id_token")
+	}
+	return raw, nil
+}
+
+// Claims are the validated ID-token claims.
+type Claims map[string]any
+
+// String returns a top-level string claim, or "".
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return strings.TrimSpace(s)
+}
+
+// Subject returns the sub claim, the stable identifier of the user at the provider.
+func (c Claims) Subject() string { return c.String("sub") }
+
+// Email returns the email claim when the provider asserts it is verified.
+func (c Claims) Email() string {
+	switch v := c["email_verified"].(type) {
+	case bool:
+		if v {
+			return c.String("email")
+		}
+	case string:
+		// Some providers serialize the flag as a string.
+		if v == "true" {
+			return c.String("email")
+		}
+	}
+	return ""
+}
+
+// Username returns the value of the configured username claim, falling back to the email local part.
+func (p *Provider) Username(c Claims) string {
+	if v := c.String(p.Config.UsernameClaim); v != "" {
+		return v
+	}
+	email := c.String("email")
+	if i := strings.LastIndex(email, "@"); i > 0 {
+		return email[:i]
+	}
+	return ""
+}
+
+// VerifyIDToken checks the signature, iss, aud/azp, exp, iat and nonce of an ID token.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string, now time.Time) (Claims, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.signingKey(ctx, d, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockLeeway),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: id token: %w", err)
+	}
+	out := Claims(claims)
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp := out.String("azp"); azp != p.Config.ClientID {
+			return nil, errors.New("oidc: id token azp does not name this client")
+		}
+	}
+	if nonce == "" || out.String("nonce") != nonce {
+		return nil, errors.New("oidc: id token nonce mismatch")
+	}
+	if out.Subject() == "" {
+		return nil, errors.New("oidc: id token has no sub")
+	}
+	return out, nil
+}
+
+// signingKey returns the JWKS key with the given kid (or the only key when kid is empty),
+// refetching the key set once when the kid is unknown so provider key rotation just works.
+func (p *Provider) signingKey(ctx context.Context, d *Discovery, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stale := p.keys == nil || time.Since(p.keysAt) > jwksTTL
+	if !stale {
+		if k, ok := pickKey(p.keys, kid); ok {
+			return k, nil
+		}
+		if time.Since(p.keysTried) < jwksMinRefresh {
+			return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+		}
+	}
+	p.keysTried = time.Now()
+	keys, err := p.fetchKeys(ctx, d.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys, p.keysAt = keys, time.Now()
+	if k, ok := pickKey(keys, kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+func pickKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid != "" {
+		k, ok := keys[kid]
+		return k, ok
+	}
+	if len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the whole set.
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("oidc: jwks has no usable signing key")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("oidc: bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("oidc: bad EC key coordinates")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("oidc: bad Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+}
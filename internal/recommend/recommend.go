@@ -58,15 +58,15 @@ func DefaultOptions() Options {
 
 func statusMultiplier(st string, o Options) float64 {
 	switch st {
-	case "Terminé", "Completed":
+	case "completed":
 		return o.StatusCompleted
-	case "En cours", "Reading":
+	case "reading":
 		return o.StatusReading
-	case "À lire", "Plan to Read":
+	case "planned":
 		return o.StatusPlanned
-	case "Abandonné", "Dropped":
+	case "dropped":
 		return o.StatusDropped
-	case "En pause", "On Hold":
+	case "on_hold":
 		return o.StatusOnHold
 	default:
 		return 0.7
@@ -9,9 +9,9 @@ import (
 
 func TestCollectKnownAnilistIDs(t *testing.T) {
 	works := []userWork{
-		{AnilistID: " 42 ", Rating: 5, Status: "En cours"},
+		{AnilistID: " 42 ", Rating: 5, Status: "reading"},
 		{AnilistID: "bad", Rating: 0, Status: ""},
-		{AnilistID: "100", Rating: 3, Status: "Terminé"},
+		{AnilistID: "100", Rating: 3, Status: "completed"},
 	}
 	got := CollectKnownAnilistIDs(works)
 	if len(got) != 2 {
@@ -63,8 +63,8 @@ func TestIntersectOrdered(t *testing.T) {
 func TestBuildWeightedListOrdering(t *testing.T) {
 	o := DefaultOptions()
 	works := []userWork{
-		{AnilistID: "2", Rating: 5, Status: "Terminé"},
-		{AnilistID: "1", Rating: 5, Status: "Terminé"},
+		{AnilistID: "2", Rating: 5, Status: "completed"},
+		{AnilistID: "1", Rating: 5, Status: "completed"},
 	}
 	list := buildWeightedList(works, o)
 	if len(list) != 2 {
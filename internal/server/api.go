@@ -11,23 +11,31 @@ import (
 )
 
 type apiWork struct {
-	ID                int    `json:"id"`
-	Title             string `json:"title"`
-	Chapter           int    `json:"chapter"`
-	Link              string `json:"link,omitempty"`
-	Status            string `json:"status,omitempty"`
-	ReadingType       string `json:"reading_type,omitempty"`
-	Rating            int    `json:"rating"`
-	Notes             string `json:"notes,omitempty"`
-	UpdatedAt         string `json:"updated_at,omitempty"`
-	ParentWorkID      *int   `json:"parent_work_id,omitempty"`
-	SeriesSort        int    `json:"series_sort,omitempty"`
-	NotifyNewChapters int    `json:"notify_new_chapters"`
-	ReadingSiteID     *int   `json:"reading_site_id,omitempty"`
-	StartedAt         string `json:"started_at,omitempty"`
-	LastChapterAt     string `json:"last_chapter_at,omitempty"`
-	FinishedAt        string `json:"finished_at,omitempty"`
-	LinkStatus        string `json:"link_status,omitempty"`
+	ID                int      `json:"id"`
+	Title             string   `json:"title"`
+	Chapter           float64  `json:"chapter"`
+	Volume            float64  `json:"volume"`
+	ProgressUnit      string   `json:"progress_unit"`
+	Link              string   `json:"link,omitempty"`
+	Status            string   `json:"status,omitempty"`
+	ReadingType       string   `json:"reading_type,omitempty"`
+	Rating            int      `json:"rating"`
+	Notes             string   `json:"notes,omitempty"`
+	UpdatedAt         string   `json:"updated_at,omitempty"`
+	ParentWorkID      *int     `json:"parent_work_id,omitempty"`
+	SeriesSort        int      `json:"series_sort,omitempty"`
+	NotifyNewChapters int      `json:"notify_new_chapters"`
+	LatestChapter     *float64 `json:"latest_chapter,omitempty"`
+	LatestReleaseAt   string   `json:"latest_release_at,omitempty"`
+	ChaptersBehind    int      `json:"chapters_behind"`
+	FeedURL           string   `json:"feed_url,omitempty"`
+	ReadingSiteID     *int     `json:"reading_site_id,omitempty"`
+	StartedAt         string   `json:"started_at,omitempty"`
+	LastChapterAt     string   `json:"last_chapter_at,omitempty"`
+	FinishedAt        string   `json:"finished_at,omitempty"`
+	LinkStatus        string   `json:"link_status,omitempty"`
+	Tags              []string `json:"tags"`
+	RereadCount       int      `json:"reread_count"`
 }
 
 func workRowToAPIWork(w workRow, siteMap map[int]readingSite) apiWork {
@@ -35,9 +43,17 @@ func workRowToAPIWork(w workRow, siteMap map[int]readingSite) apiWork {
 		ID:                w.ID,
 		Title:             w.Title,
 		Chapter:           w.Chapter,
+		Volume:            w.Volume,
+		ProgressUnit:      w.ProgressUnit(),
 		Rating:            w.Rating,
 		SeriesSort:        w.SeriesSort,
 		NotifyNewChapters: w.NotifyNewChapters,
+		ChaptersBehind:    w.ChaptersBehind(),
+		Tags:              w.Tags,
+		RereadCount:       w.RereadCount,
+	}
+	if out.Tags == nil {
+		out.Tags = []string{}
 	}
 	if w.Link.Valid {
 		out.Link = w.Link.String
@@ -62,6 +78,15 @@ func workRowToAPIWork(w workRow, siteMap map[int]readingSite) apiWork {
 		v := int(w.ReadingSiteID.Int64)
 		out.ReadingSiteID = &v
 	}
+	if v, ok := w.LatestReleasedChapter(); ok {
+		out.LatestChapter = &v
+	}
+	if w.FeedLatestReleaseAt.Valid {
+		out.LatestReleaseAt = w.FeedLatestReleaseAt.String
+	}
+	if w.FeedURL.Valid {
+		out.FeedURL = w.FeedURL.String
+	}
 	if w.StartedAt.Valid {
 		out.StartedAt = w.StartedAt.String
 	}
@@ -124,6 +149,7 @@ func (a *App) HandleAPIWorksList(w http.ResponseWriter, r *http.Request) {
 		typeFilter = ""
 	}
 	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	tagFilter := normalizeTagName(r.URL.Query().Get("tag"))
 	sortBy := strings.TrimSpace(r.URL.Query().Get("sort"))
 	if sortBy == "" {
 		sortBy = "recent"
@@ -170,6 +196,14 @@ func (a *App) HandleAPIWorksList(w http.ResponseWriter, r *http.Request) {
 			args = append(args, siteID, siteID, userID)
 		}
 	}
+	if tagFilter != "" {
+		whereParts = append(whereParts, tagFilterSQL)
+		args = append(args, userID, tagFilter)
+	}
+	if clause, tokenArgs := apiTokenWorksFilter(r); clause != "" {
+		whereParts = append(whereParts, clause)
+		args = append(args, tokenArgs...)
+	}
 	if search != "" {
 		usedFTS := false
 		if database.WorksFTSEnabled(a.DB) {
@@ -210,12 +244,16 @@ func (a *App) HandleAPIWorksList(w http.ResponseWriter, r *http.Request) {
 	defer func() { _ = rows.Close() }()
 
 	siteMap := a.loadReadingSiteStatusMap(userID)
+	tagsByWork := a.tagNamesByWork(userID)
+	rereads := a.rereadCountsByWork(userID)
 	var works []apiWork
 	for rows.Next() {
 		var wr workRow
 		if err := scanFullWorkRow(&wr, rows); err != nil {
 			continue
 		}
+		wr.Tags = tagsByWork[wr.ID]
+		wr.RereadCount = rereads[wr.ID]
 		works = append(works, workRowToAPIWork(wr, siteMap))
 	}
 
@@ -234,6 +272,7 @@ func (a *App) HandleAPIWorksList(w http.ResponseWriter, r *http.Request) {
 			"has_prev":    page > 1,
 			"sort":        sortBy,
 			"search":      search,
+			"tag":         tagFilter,
 		},
 	})
 }
@@ -260,6 +299,8 @@ func (a *App) HandleAPIWorksDetail(w http.ResponseWriter, r *http.Request) {
 		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
 		return
 	}
+	wr.Tags, _ = a.workTagNames(userID, workID)
+	wr.RereadCount = a.workRereadCount(userID, workID)
 
 	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": workRowToAPIWork(wr, a.loadReadingSiteStatusMap(userID))})
 }
@@ -272,16 +313,19 @@ func (a *App) HandleAPIWorksCreate(w http.ResponseWriter, r *http.Request) {
 	userID, _ := a.currentUserID(r)
 
 	var req struct {
-		Title             string `json:"title"`
-		Chapter           int    `json:"chapter"`
-		Link              string `json:"link"`
-		Status            string `json:"status"`
-		ReadingType       string `json:"reading_type"`
-		Rating            int    `json:"rating"`
-		Notes             string `json:"notes"`
-		ParentWorkID      *int   `json:"parent_work_id"`
-		SeriesSort        int    `json:"series_sort"`
-		NotifyNewChapters *int   `json:"notify_new_chapters"`
+		Title             string   `json:"title"`
+		Chapter           float64  `json:"chapter"`
+		Volume            float64  `json:"volume"`
+		Link              string   `json:"link"`
+		Status            string   `json:"status"`
+		ReadingType       string   `json:"reading_type"`
+		Rating            int      `json:"rating"`
+		Notes             string   `json:"notes"`
+		ParentWorkID      *int     `json:"parent_work_id"`
+		SeriesSort        int      `json:"series_sort"`
+		NotifyNewChapters *int     `json:"notify_new_chapters"`
+		FeedURL           string   `json:"feed_url"`
+		Tags              []string `json:"tags"`
 	}
 	if err := decodeAPIJSONBody(w, r, &req); err != nil {
 		a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
@@ -293,14 +337,24 @@ func (a *App) HandleAPIWorksCreate(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Title = sanitizeTitle(req.Title)
 	req.Chapter = clampChapter(req.Chapter)
+	req.Volume = clampVolume(req.Volume)
 	req.Rating = clampRating(req.Rating)
 	readingType := normalizeReadingTypeForWrite(req.ReadingType)
+	if !apiTokenAllowsNewWork(r, readingType, true) {
+		a.apiWriteError(w, http.StatusForbidden, "token_restricted")
+		return
+	}
 	status := normalizeStatusForWrite(req.Status)
 	suivi := true
 	if req.NotifyNewChapters != nil {
 		suivi = *req.NotifyNewChapters != 0
 	}
 	notifyCh := notifyNewChaptersDB(status, suivi)
+	feedURL, ok := validateFeedURL(req.FeedURL)
+	if !ok {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_feed_url")
+		return
+	}
 
 	if req.ParentWorkID != nil && *req.ParentWorkID > 0 {
 		if err := a.validateWorkParent(userID, 0, *req.ParentWorkID); err != nil {
@@ -320,21 +374,29 @@ func (a *App) HandleAPIWorksCreate(w http.ResponseWriter, r *http.Request) {
 		readingSiteArg = siteID
 	}
 
-	res, err := a.DB.Exec(
-		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, parent_work_id, series_sort, notify_new_chapters, reading_site_id, updated_at)
-         VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
-		req.Title, req.Chapter, nullIfEmpty(strings.TrimSpace(req.Link)), status, readingType, req.Rating, nullIfEmpty(strings.TrimSpace(req.Notes)), userID, parentArg, req.SeriesSort, notifyCh, readingSiteArg,
+	id, err := a.DB.InsertID(
+		`INSERT INTO works (title, chapter, volume, link, status, reading_type, rating, notes, user_id, parent_work_id, series_sort, notify_new_chapters, reading_site_id, feed_url, updated_at)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		req.Title, req.Chapter, req.Volume, nullIfEmpty(strings.TrimSpace(req.Link)), status, readingType, req.Rating, nullIfEmpty(strings.TrimSpace(req.Notes)), userID, parentArg, req.SeriesSort, notifyCh, readingSiteArg, nullIfEmpty(feedURL),
 	)
 	if err != nil {
 		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
 		return
 	}
-	id, _ := res.LastInsertId()
+	tags := normalizeTagList(req.Tags)
+	if len(tags) > 0 {
+		if err := a.setWorkTags(userID, int(id), tags); err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+	}
 
 	work := apiWork{
 		ID:                int(id),
 		Title:             req.Title,
 		Chapter:           req.Chapter,
+		Volume:            req.Volume,
+		ProgressUnit:      progressUnitForReadingType(readingType),
 		Link:              strings.TrimSpace(req.Link),
 		Status:            status,
 		ReadingType:       readingType,
@@ -343,6 +405,8 @@ func (a *App) HandleAPIWorksCreate(w http.ResponseWriter, r *http.Request) {
 		SeriesSort:        req.SeriesSort,
 		ParentWorkID:      req.ParentWorkID,
 		NotifyNewChapters: notifyCh,
+		FeedURL:           feedURL,
+		Tags:              tags,
 	}
 
 	a.apiWriteJSON(w, http.StatusCreated, map[string]any{"data": work})
@@ -375,15 +439,20 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 		setParts = append(setParts, "title = ?")
 		args = append(args, strings.TrimSpace(v))
 	}
-	var newChapter int
-	var chapterChanged bool
-	var chapterDelta int
+	var newChapter, newVolume float64
+	var chapterChanged, volumeChanged bool
 	if v, ok := req["chapter"].(float64); ok {
-		newChapter = clampChapter(int(v))
+		newChapter = clampChapter(v)
 		chapterChanged = true
 		setParts = append(setParts, "chapter = ?")
 		args = append(args, newChapter)
 	}
+	if v, ok := req["volume"].(float64); ok {
+		newVolume = clampVolume(v)
+		volumeChanged = true
+		setParts = append(setParts, "volume = ?")
+		args = append(args, newVolume)
+	}
 	if v, ok := req["link"].(string); ok {
 		setParts = append(setParts, "link = ?")
 		args = append(args, nullIfEmpty(strings.TrimSpace(v)))
@@ -394,6 +463,15 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 			setParts = append(setParts, "reading_site_id = NULL")
 		}
 	}
+	if v, ok := req["feed_url"].(string); ok {
+		feedURL, valid := validateFeedURL(v)
+		if !valid {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_feed_url")
+			return
+		}
+		setParts = append(setParts, "feed_url = ?", "feed_checked_at = NULL")
+		args = append(args, nullIfEmpty(feedURL))
+	}
 	if v, ok := req["status"].(string); ok && v != "" {
 		newStatus := normalizeStatusForWrite(v)
 		setParts = append(setParts, "status = ?")
@@ -402,17 +480,22 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 		var startedAtNull, finishedAtNull bool
 		err := a.DB.QueryRow(`SELECT COALESCE(status, ''), (started_at IS NULL), (finished_at IS NULL) FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldStatus, &startedAtNull, &finishedAtNull)
 		if err == nil {
-			if newStatus == "En cours" && oldStatus != "En cours" && startedAtNull {
+			if newStatus == statusReading && oldStatus != statusReading && startedAtNull {
 				setParts = append(setParts, "started_at = CURRENT_TIMESTAMP")
 			}
-			if newStatus == "Terminé" && oldStatus != "Terminé" && finishedAtNull {
+			if newStatus == statusCompleted && oldStatus != statusCompleted && finishedAtNull {
 				setParts = append(setParts, "finished_at = CURRENT_TIMESTAMP")
 			}
 		}
 	}
 	if v, ok := req["reading_type"].(string); ok && v != "" {
+		readingType := normalizeReadingTypeForWrite(v)
+		if !apiTokenAllowsNewWork(r, readingType, false) {
+			a.apiWriteError(w, http.StatusForbidden, "token_restricted")
+			return
+		}
 		setParts = append(setParts, "reading_type = ?")
-		args = append(args, normalizeReadingTypeForWrite(v))
+		args = append(args, readingType)
 	}
 	if v, ok := req["rating"].(float64); ok {
 		rating := clampRating(int(v))
@@ -481,6 +564,16 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var newTags []string
+	_, tagsChanged := req["tags"]
+	if tagsChanged {
+		var ok bool
+		if newTags, ok = tagsFromJSON(req["tags"]); !ok {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_tags")
+			return
+		}
+	}
+
 	if v, ok := req["started_at"].(string); ok {
 		setParts = append(setParts, "started_at = ?")
 		args = append(args, nullIfEmpty(strings.TrimSpace(v)))
@@ -496,16 +589,17 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var lastChapterAtBefore nullFlexTime
-	if chapterChanged {
-		var oldChapter int
-		_ = a.DB.QueryRow(`SELECT chapter, last_chapter_at FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldChapter, &lastChapterAtBefore)
-		chapterDelta = newChapter - oldChapter
-		if newChapter > oldChapter && !lastChapterAtExplicit {
+	var oldChapter, oldVolume float64
+	var oldReadingType sql.NullString
+	if chapterChanged || volumeChanged {
+		_ = a.DB.QueryRow(`SELECT chapter, COALESCE(volume, 0), reading_type, last_chapter_at FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldChapter, &oldVolume, &oldReadingType, &lastChapterAtBefore)
+		forward := (chapterChanged && newChapter > oldChapter) || (volumeChanged && newVolume > oldVolume)
+		if forward && !lastChapterAtExplicit {
 			setParts = append(setParts, "last_chapter_at = CURRENT_TIMESTAMP")
 		}
 	}
 
-	if len(setParts) == 0 {
+	if len(setParts) == 0 && !tagsChanged {
 		a.apiWriteError(w, http.StatusBadRequest, "no_fields_to_update")
 		return
 	}
@@ -523,21 +617,36 @@ func (a *App) HandleAPIWorksUpdate(w http.ResponseWriter, r *http.Request) {
 		a.apiWriteError(w, http.StatusNotFound, "not_found")
 		return
 	}
+	if tagsChanged {
+		if err := a.setWorkTags(userID, workID, newTags); err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+	}
 	if chapterChanged {
-		a.applyChapterDeltaToReadingStats(userID, chapterDelta, lastChapterAtBefore)
+		a.applyProgressChangeToReadingStats(userID, progressUnitChapter, oldReadingType.String, oldChapter, newChapter, lastChapterAtBefore)
+		a.recordWorkProgressEvent(userID, workID, progressUnitChapter, oldChapter, newChapter, progressSourceAPI)
+	}
+	if volumeChanged {
+		a.applyProgressChangeToReadingStats(userID, progressUnitVolume, oldReadingType.String, oldVolume, newVolume, lastChapterAtBefore)
+		a.recordWorkProgressEvent(userID, workID, progressUnitVolume, oldVolume, newVolume, progressSourceAPI)
 	}
 
 	var wr workRow
 	if err := scanFullWorkRow(&wr, a.DB.QueryRow(
 		`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ? AND user_id = ?`, workID, userID,
 	)); err == nil {
+		wr.Tags, _ = a.workTagNames(userID, workID)
+		wr.RereadCount = a.workRereadCount(userID, workID)
 		a.EmitWebhookEvent(userID, webhookEventWorkUpdated, map[string]any{"work": workRowToAPIWork(wr, nil)})
-		if chapterChanged {
+		if chapterChanged || volumeChanged {
 			a.EmitWebhookEvent(userID, webhookEventWorkChapterChanged, map[string]any{
 				"work_id": workID,
 				"chapter": wr.Chapter,
+				"volume":  wr.Volume,
 			})
 		}
+		a.refreshGoals(userID)
 	}
 
 	// Reuse detail payload while forcing a GET method.
@@ -577,20 +686,35 @@ func (a *App) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 	}
 	userID, _ := a.currentUserID(r)
 
-	var totalWorks, totalChapters int
+	var totalWorks int
+	var totalChapters, totalVolumes float64
 	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ?`, userID).Scan(&totalWorks)
-	_ = a.DB.QueryRow(`SELECT COALESCE(SUM(chapter), 0) FROM works WHERE user_id = ?`, userID).Scan(&totalChapters)
+	_ = a.DB.QueryRow(`SELECT COALESCE(SUM(chapter), 0), COALESCE(SUM(volume), 0) FROM works WHERE user_id = ?`, userID).Scan(&totalChapters, &totalVolumes)
 
 	var avgRating float64
 	var ratedCount int
 	_ = a.DB.QueryRow(`SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM works WHERE user_id = ? AND rating > 0`, userID).Scan(&avgRating, &ratedCount)
 
+	var totalRereads, rereadWorks int
+	_ = a.DB.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT work_id) FROM work_reads WHERE user_id = ?`, userID).Scan(&totalRereads, &rereadWorks)
+
+	_, collection := a.collectionReport(userID)
+
 	a.apiWriteJSON(w, http.StatusOK, map[string]any{
 		"data": map[string]any{
-			"total_works":    totalWorks,
-			"total_chapters": totalChapters,
-			"avg_rating":     avgRating,
-			"rated_count":    ratedCount,
+			"total_works":      totalWorks,
+			"total_chapters":   totalChapters,
+			"total_volumes":    totalVolumes,
+			"avg_rating":       avgRating,
+			"rated_count":      ratedCount,
+			"total_rereads":    totalRereads,
+			"reread_works":     rereadWorks,
+			"collection_works": collection.Works,
+			"owned_copies":     collection.Copies,
+			"owned_paperback":  collection.Paperback,
+			"owned_digital":    collection.Digital,
+			"missing_volumes":  collection.MissingVolumes,
+			"collection_spent": collection.TotalSpent,
 		},
 	})
 }
@@ -52,7 +52,7 @@ func BenchmarkHandleAPIWorksList_Search(b *testing.B) {
 			title = "Alpha " + strconv.Itoa(i)
 			notes = "alpha keyword " + strconv.Itoa(i)
 		}
-		if _, err := stmt.Exec(title, i%200, "En cours", "Manga", i%5, notes, 1); err != nil {
+		if _, err := stmt.Exec(title, i%200, statusReading, "Manga", i%5, notes, 1); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,6 +14,9 @@ type bulkWorksRequest struct {
 	Patch       map[string]any   `json:"patch"`
 	LinkReplace *bulkLinkReplace `json:"link_replace"`
 	Delete      bool             `json:"delete"`
+	// AddTags / RemoveTags attach or detach tags without touching the others; patch.tags replaces the set.
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
 }
 
 type bulkLinkReplace struct {
@@ -59,7 +63,20 @@ func (a *App) HandleAPIWorksBulk(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if !req.Delete && len(req.Patch) == 0 && req.LinkReplace == nil {
+	addTags := normalizeTagList(req.AddTags)
+	removeTags := normalizeTagList(req.RemoveTags)
+	var replaceTags []string
+	_, tagsReplaced := req.Patch["tags"]
+	if tagsReplaced {
+		var ok bool
+		if replaceTags, ok = tagsFromJSON(req.Patch["tags"]); !ok {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_tags")
+			return
+		}
+	}
+	hasTagOps := tagsReplaced || len(addTags) > 0 || len(removeTags) > 0
+
+	if !req.Delete && len(req.Patch) == 0 && req.LinkReplace == nil && !hasTagOps {
 		a.apiWriteError(w, http.StatusBadRequest, "no_operation")
 		return
 	}
@@ -88,6 +105,15 @@ func (a *App) HandleAPIWorksBulk(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		newChapter, chapterPatched := bulkPatchProgress(req.Patch, progressUnitChapter)
+		newVolume, volumePatched := bulkPatchProgress(req.Patch, progressUnitVolume)
+		var oldChapter, oldVolume float64
+		var oldReadingType sql.NullString
+		var lastChapterAtBefore nullFlexTime
+		if chapterPatched || volumePatched {
+			_ = a.DB.QueryRow(`SELECT chapter, COALESCE(volume, 0), reading_type, last_chapter_at FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldChapter, &oldVolume, &oldReadingType, &lastChapterAtBefore)
+		}
+
 		setParts, args, buildErr := a.buildBulkWorkPatch(userID, workID, req.Patch)
 		if buildErr != nil {
 			errs = append(errs, bulkWorkError{ID: workID, Error: buildErr.Error()})
@@ -99,7 +125,7 @@ func (a *App) HandleAPIWorksBulk(w http.ResponseWriter, r *http.Request) {
 			args = append(args, req.LinkReplace.From, req.LinkReplace.To)
 		}
 
-		if len(setParts) == 0 {
+		if len(setParts) == 0 && !hasTagOps {
 			errs = append(errs, bulkWorkError{ID: workID, Error: "no_fields_to_update"})
 			continue
 		}
@@ -117,8 +143,30 @@ func (a *App) HandleAPIWorksBulk(w http.ResponseWriter, r *http.Request) {
 			errs = append(errs, bulkWorkError{ID: workID, Error: "not_found"})
 			continue
 		}
+		chapterMoved := chapterPatched && newChapter != oldChapter
+		volumeMoved := volumePatched && newVolume != oldVolume
+		if chapterMoved {
+			a.applyProgressChangeToReadingStats(userID, progressUnitChapter, oldReadingType.String, oldChapter, newChapter, lastChapterAtBefore)
+			a.recordWorkProgressEvent(userID, workID, progressUnitChapter, oldChapter, newChapter, progressSourceBulk)
+		}
+		if volumeMoved {
+			a.applyProgressChangeToReadingStats(userID, progressUnitVolume, oldReadingType.String, oldVolume, newVolume, lastChapterAtBefore)
+			a.recordWorkProgressEvent(userID, workID, progressUnitVolume, oldVolume, newVolume, progressSourceBulk)
+		}
+		if chapterMoved || volumeMoved {
+			a.emitWorkProgressWebhook(userID, workID)
+		}
+		if hasTagOps {
+			if err := a.applyBulkTagOps(userID, workID, tagsReplaced, replaceTags, addTags, removeTags); err != nil {
+				errs = append(errs, bulkWorkError{ID: workID, Error: "internal_error"})
+				continue
+			}
+		}
 		updated++
 	}
+	if updated > 0 {
+		a.refreshGoals(userID)
+	}
 
 	a.apiWriteJSON(w, http.StatusOK, map[string]any{
 		"updated": updated,
@@ -139,15 +187,33 @@ func (a *App) buildBulkWorkPatch(userID, workID int, patch map[string]any) (setP
 		var startedAtNull, finishedAtNull bool
 		qErr := a.DB.QueryRow(`SELECT COALESCE(status, ''), (started_at IS NULL), (finished_at IS NULL) FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldStatus, &startedAtNull, &finishedAtNull)
 		if qErr == nil {
-			if newStatus == "En cours" && oldStatus != "En cours" && startedAtNull {
+			if newStatus == statusReading && oldStatus != statusReading && startedAtNull {
 				setParts = append(setParts, "started_at = CURRENT_TIMESTAMP")
 			}
-			if newStatus == "Terminé" && oldStatus != "Terminé" && finishedAtNull {
+			if newStatus == statusCompleted && oldStatus != statusCompleted && finishedAtNull {
 				setParts = append(setParts, "finished_at = CURRENT_TIMESTAMP")
 			}
 		}
 	}
 
+	chapter, chapterOK := bulkPatchProgress(patch, progressUnitChapter)
+	volume, volumeOK := bulkPatchProgress(patch, progressUnitVolume)
+	if chapterOK {
+		setParts = append(setParts, "chapter = ?")
+		args = append(args, chapter)
+	}
+	if volumeOK {
+		setParts = append(setParts, "volume = ?")
+		args = append(args, volume)
+	}
+	if chapterOK || volumeOK {
+		var oldChapter, oldVolume float64
+		if a.DB.QueryRow(`SELECT chapter, COALESCE(volume, 0) FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldChapter, &oldVolume) == nil &&
+			((chapterOK && chapter > oldChapter) || (volumeOK && volume > oldVolume)) {
+			setParts = append(setParts, "last_chapter_at = CURRENT_TIMESTAMP")
+		}
+	}
+
 	if v, ok := patch["reading_type"].(string); ok && v != "" {
 		setParts = append(setParts, "reading_type = ?")
 		args = append(args, normalizeReadingTypeForWrite(v))
@@ -196,3 +262,32 @@ func (a *App) buildBulkWorkPatch(userID, workID int, patch map[string]any) (setP
 
 	return setParts, args, nil
 }
+
+func (a *App) applyBulkTagOps(userID, workID int, replace bool, replaceTags, addTags, removeTags []string) error {
+	if replace {
+		if err := a.setWorkTags(userID, workID, replaceTags); err != nil {
+			return err
+		}
+	}
+	if len(addTags) > 0 {
+		if err := a.addWorkTags(userID, workID, addTags); err != nil {
+			return err
+		}
+	}
+	if len(removeTags) > 0 {
+		return a.removeWorkTags(userID, workID, removeTags)
+	}
+	return nil
+}
+
+// bulkPatchProgress returns the clamped chapter or volume (unit) carried by a bulk patch, if any.
+func bulkPatchProgress(patch map[string]any, unit string) (float64, bool) {
+	v, ok := patch[progressUnitColumn(unit)].(float64)
+	if !ok {
+		return 0, false
+	}
+	if unit == progressUnitVolume {
+		return clampVolume(v), true
+	}
+	return clampChapter(v), true
+}
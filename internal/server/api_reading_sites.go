@@ -1,7 +1,13 @@
 package server
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type apiReadingSite struct {
@@ -12,6 +18,7 @@ type apiReadingSite struct {
 	LastProbeAt     string `json:"last_probe_at,omitempty"`
 	ProbeHTTPStatus *int   `json:"probe_http_status,omitempty"`
 	ProbeDetail     string `json:"probe_detail,omitempty"`
+	FeedTemplate    string `json:"feed_template,omitempty"`
 }
 
 func readingSiteToAPI(s readingSite) apiReadingSite {
@@ -31,6 +38,9 @@ func readingSiteToAPI(s readingSite) apiReadingSite {
 	if s.ProbeDetail.Valid {
 		out.ProbeDetail = s.ProbeDetail.String
 	}
+	if s.FeedTemplate.Valid {
+		out.FeedTemplate = s.FeedTemplate.String
+	}
 	return out
 }
 
@@ -48,3 +58,168 @@ func (a *App) HandleAPIReadingSitesList(w http.ResponseWriter, r *http.Request)
 	}
 	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": data})
 }
+
+// apiReadingSiteInput is the body of POST /api/reading-sites and PATCH /api/reading-sites/{id};
+// fields left out of a PATCH keep their value.
+type apiReadingSiteInput struct {
+	Name         *string `json:"name"`
+	BaseURL      *string `json:"base_url"`
+	FeedTemplate *string `json:"feed_template"`
+}
+
+// apply merges the input into site and validates the result, returning an API error code on failure.
+func (in apiReadingSiteInput) apply(site *readingSite) string {
+	if in.Name != nil {
+		site.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.BaseURL != nil {
+		site.BaseURL = strings.TrimSpace(*in.BaseURL)
+	}
+	if in.FeedTemplate != nil {
+		tmpl, ok := validateFeedTemplate(*in.FeedTemplate)
+		if !ok {
+			return "invalid_feed_template"
+		}
+		site.FeedTemplate = sql.NullString{String: tmpl, Valid: tmpl != ""}
+	}
+	if site.Name == "" || site.BaseURL == "" {
+		return "name_and_url_required"
+	}
+	if !validReadingSiteBaseURL(site.BaseURL) {
+		return "invalid_url"
+	}
+	return ""
+}
+
+// HandleAPIReadingSiteCreate adds a reading site (POST /api/reading-sites) and links the user's
+// works that match it, like the web form.
+func (a *App) HandleAPIReadingSiteCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	var in apiReadingSiteInput
+	if err := decodeAPIJSONBody(w, r, &in); err != nil {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	site := readingSite{UserID: userID, ProbeStatus: "unknown"}
+	if code := in.apply(&site); code != "" {
+		a.apiWriteError(w, http.StatusBadRequest, code)
+		return
+	}
+	id, err := a.DB.InsertID(
+		`INSERT INTO reading_sites (user_id, name, base_url, probe_status, feed_template) VALUES (?, ?, ?, 'unknown', ?)`,
+		userID, site.Name, site.BaseURL, site.FeedTemplate,
+	)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	site.ID = int(id)
+	a.BackfillReadingSiteIDs()
+	a.apiWriteJSON(w, http.StatusCreated, map[string]any{"data": readingSiteToAPI(site)})
+}
+
+// HandleAPIReadingSiteUpdate edits a reading site (PATCH /api/reading-sites/{id}). Changing the base
+// URL resets the probe status; works are re-linked like after the web form.
+func (a *App) HandleAPIReadingSiteUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	site, ok := a.apiReadingSiteFromPath(w, r, userID)
+	if !ok {
+		return
+	}
+	var in apiReadingSiteInput
+	if err := decodeAPIJSONBody(w, r, &in); err != nil {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	if in.Name == nil && in.BaseURL == nil && in.FeedTemplate == nil {
+		a.apiWriteError(w, http.StatusBadRequest, "no_fields_to_update")
+		return
+	}
+	oldBaseURL := site.BaseURL
+	if code := in.apply(&site); code != "" {
+		a.apiWriteError(w, http.StatusBadRequest, code)
+		return
+	}
+	query := `UPDATE reading_sites SET name = ?, base_url = ?, feed_template = ? WHERE id = ? AND user_id = ?`
+	if site.BaseURL != oldBaseURL {
+		query = `UPDATE reading_sites SET name = ?, base_url = ?, feed_template = ?, probe_status = 'unknown', last_probe_at = NULL WHERE id = ? AND user_id = ?`
+		site.ProbeStatus, site.LastProbeAt = "unknown", sql.NullString{}
+	}
+	if _, err := a.DB.Exec(query, site.Name, site.BaseURL, site.FeedTemplate, site.ID, userID); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.BackfillReadingSiteIDs()
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": readingSiteToAPI(site)})
+}
+
+// HandleAPIReadingSiteDelete removes a reading site and unlinks its works (DELETE /api/reading-sites/{id}).
+func (a *App) HandleAPIReadingSiteDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	site, ok := a.apiReadingSiteFromPath(w, r, userID)
+	if !ok {
+		return
+	}
+	if _, err := a.DB.Exec(`UPDATE works SET reading_site_id = NULL WHERE reading_site_id = ? AND user_id = ?`, site.ID, userID); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if _, err := a.DB.Exec(`DELETE FROM reading_sites WHERE id = ? AND user_id = ?`, site.ID, userID); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAPIReadingSiteProbe checks a reading site now and returns its new status
+// (POST /api/reading-sites/{id}/probe).
+func (a *App) HandleAPIReadingSiteProbe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	site, ok := a.apiReadingSiteFromPath(w, r, userID)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	a.ProbeAndUpdateSite(ctx, site)
+	if site, err := a.loadUserReadingSite(userID, site.ID); err == nil {
+		a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": readingSiteToAPI(site)})
+		return
+	}
+	a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+}
+
+// apiReadingSiteFromPath loads the {id} site of the current user, answering 404 when it does not exist.
+func (a *App) apiReadingSiteFromPath(w http.ResponseWriter, r *http.Request, userID int) (readingSite, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_id")
+		return readingSite{}, false
+	}
+	site, err := a.loadUserReadingSite(userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return readingSite{}, false
+	}
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return readingSite{}, false
+	}
+	return site, true
+}
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -56,7 +59,7 @@ func TestAPIWorksDetail_includesLinkStatus(t *testing.T) {
 
 	_, err := db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, link_probe_status, updated_at)
-		 VALUES ('Linked', 3, 'https://read.example/manga', 'En cours', 'Manga', 0, '', 1, 'up', datetime('now'))`,
+		 VALUES ('Linked', 3, 'https://read.example/manga', 'reading', 'Manga', 0, '', 1, 'up', datetime('now'))`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -82,3 +85,91 @@ func TestAPIWorksDetail_includesLinkStatus(t *testing.T) {
 		t.Fatalf("link_status=%q want up", payload.Data.LinkStatus)
 	}
 }
+
+func TestAPIReadingSitesCRUD(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	if _, err := db.Exec(`INSERT INTO works (id, title, chapter, status, reading_type, user_id, link) VALUES
+		(41, 'A', 1, 'reading', 'Manga', 1, 'https://scan.example/manga/a'),
+		(42, 'B', 1, 'reading', 'Manga', 1, 'https://other.example/manga/b')`); err != nil {
+		t.Fatal(err)
+	}
+	readOnly, _, err := app.createAPIToken(1, "ro", []string{ScopeReadingSitesRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := tokenAPIRequest(t, app, readOnly, http.MethodPost, "/api/reading-sites", `{"name":"Scan","base_url":"https://scan.example"}`); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "insufficient_scope") {
+		t.Fatalf("read-only token: %d %s", rec.Code, rec.Body.String())
+	}
+	token, _, err := app.createAPIToken(1, "rw", []string{ScopeReadingSitesWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	linkedSite := func(workID int) int {
+		var id sql.NullInt64
+		_ = db.QueryRow(`SELECT reading_site_id FROM works WHERE id = ?`, workID).Scan(&id)
+		return int(id.Int64)
+	}
+
+	for body, code := range map[string]string{
+		`{"name":"Scan"}`: "name_and_url_required",
+		`{"name":"Scan","base_url":"ftp://scan.example"}`:                          "invalid_url",
+		`{"name":"Scan","base_url":"https://scan.example","feed_template":"/rss"}`: "invalid_feed_template",
+		`not json`: "invalid_json",
+	} {
+		if rec := tokenAPIRequest(t, app, token, http.MethodPost, "/api/reading-sites", body); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), code) {
+			t.Errorf("create %s: %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+	rec := tokenAPIRequest(t, app, token, http.MethodPost, "/api/reading-sites", `{"name":"Scan","base_url":"https://scan.example"}`)
+	var created struct {
+		Data apiReadingSite `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); rec.Code != http.StatusCreated || err != nil || created.Data.ID == 0 {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	siteID := created.Data.ID
+	site := "/api/reading-sites/" + strconv.Itoa(siteID)
+	if linkedSite(41) != siteID || linkedSite(42) != 0 {
+		t.Fatalf("after create: work 41 -> %d, work 42 -> %d", linkedSite(41), linkedSite(42))
+	}
+
+	if rec := tokenAPIRequest(t, app, token, http.MethodPatch, site, `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty patch: %d", rec.Code)
+	}
+	if _, err := db.Exec(`UPDATE reading_sites SET probe_status = 'up' WHERE id = ?`, siteID); err != nil {
+		t.Fatal(err)
+	}
+	rec = tokenAPIRequest(t, app, token, http.MethodPatch, site, `{"name":"Renamed"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"probe_status":"up"`) {
+		t.Fatalf("rename: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = tokenAPIRequest(t, app, token, http.MethodPatch, site, `{"base_url":"https://other.example"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"Renamed"`) || !strings.Contains(rec.Body.String(), `"probe_status":"unknown"`) {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	if linkedSite(42) != siteID {
+		t.Fatalf("work 42 not relinked: %d", linkedSite(42))
+	}
+
+	// Probing a private address fails without touching the network.
+	if _, err := db.Exec(`UPDATE reading_sites SET base_url = 'http://127.0.0.1' WHERE id = ?`, siteID); err != nil {
+		t.Fatal(err)
+	}
+	rec = tokenAPIRequest(t, app, token, http.MethodPost, site+"/probe", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"probe_status":"down"`) {
+		t.Fatalf("probe: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := tokenAPIRequest(t, app, token, http.MethodDelete, site, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body.String())
+	}
+	if linkedSite(41) != 0 || linkedSite(42) != 0 {
+		t.Fatal("works still linked to the deleted site")
+	}
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		if rec := tokenAPIRequest(t, app, token, method, site, `{"name":"x"}`); rec.Code != http.StatusNotFound {
+			t.Errorf("%s deleted site: %d", method, rec.Code)
+		}
+	}
+}
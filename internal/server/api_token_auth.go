@@ -9,17 +9,34 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"net/netip"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 const (
-	ScopeWorksRead  = "works:read"
-	ScopeWorksWrite = "works:write"
+	ScopeWorksRead          = "works:read"
+	ScopeWorksWrite         = "works:write"
+	ScopeStatsRead          = "stats:read"
+	ScopeStatsWrite         = "stats:write"
+	ScopeReadingSitesRead   = "reading_sites:read"
+	ScopeReadingSitesWrite  = "reading_sites:write"
+	ScopeCatalogRead        = "catalog:read"
+	ScopeWebhooksManage     = "webhooks:manage"
+	ScopeExportRead         = "export:read"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
 
 	defaultAPITokenTTL = 90 * 24 * time.Hour
+
+	maxAPITokenAllowedIPs = 20
+	maxAPITokenWorkIDs    = 200
+	maxAPITokenRateLimit  = 6000
 )
 
 // apiTokenTTL returns API token lifetime (override via BOOKSTORAGE_API_TOKEN_TTL_DAYS).
@@ -33,22 +50,199 @@ func apiTokenTTL() time.Duration {
 }
 
 var validAPIScopes = map[string]bool{
-	ScopeWorksRead:  true,
-	ScopeWorksWrite: true,
+	ScopeWorksRead:          true,
+	ScopeWorksWrite:         true,
+	ScopeStatsRead:          true,
+	ScopeStatsWrite:         true,
+	ScopeReadingSitesRead:   true,
+	ScopeReadingSitesWrite:  true,
+	ScopeCatalogRead:        true,
+	ScopeWebhooksManage:     true,
+	ScopeExportRead:         true,
+	ScopeNotificationsRead:  true,
+	ScopeNotificationsWrite: true,
+}
+
+// defaultPersonalAPIScopes are preselected on the profile form. They match what a token could do
+// before scopes were split beyond works:read and works:write.
+var defaultPersonalAPIScopes = []string{
+	ScopeWorksRead, ScopeWorksWrite, ScopeStatsRead, ScopeStatsWrite,
+	ScopeReadingSitesRead, ScopeNotificationsRead, ScopeNotificationsWrite,
+}
+
+// sortedAPIScopes lists validAPIScopes for forms and consent screens.
+func sortedAPIScopes() []string {
+	out := make([]string, 0, len(validAPIScopes))
+	for s := range validAPIScopes {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
 }
 
 type apiAuthUserIDKey struct{}
 type apiAuthScopesKey struct{}
+type apiAuthTokenKey struct{}
 
 type apiTokenRow struct {
-	ID         int
-	UserID     int
-	Name       string
-	Scopes     []string
-	CreatedAt  time.Time
-	ExpiresAt  sql.NullTime
-	LastUsedAt sql.NullTime
-	RevokedAt  sql.NullTime
+	ID           int
+	UserID       int
+	Name         string
+	Scopes       []string
+	Restrictions apiTokenRestrictions
+	CreatedAt    time.Time
+	ExpiresAt    sql.NullTime
+	LastUsedAt   sql.NullTime
+	RevokedAt    sql.NullTime
+}
+
+// apiTokenRestrictions narrow what a personal token may do beyond its scopes. The zero value
+// restricts nothing; tokens issued to OAuth apps never carry restrictions.
+type apiTokenRestrictions struct {
+	AllowedIPs   []netip.Prefix
+	WorkIDs      []int
+	ReadingTypes []string
+	// RateLimit is requests per minute for this token; 0 leaves only the instance-wide limits.
+	RateLimit int
+}
+
+// LimitsWorks reports whether the token only reaches some works (by ID or reading type).
+func (t apiTokenRestrictions) LimitsWorks() bool {
+	return len(t.WorkIDs) > 0 || len(t.ReadingTypes) > 0
+}
+
+func (t apiTokenRestrictions) Any() bool {
+	return len(t.AllowedIPs) > 0 || t.LimitsWorks() || t.RateLimit > 0
+}
+
+func (t apiTokenRestrictions) allowsIP(ip string) bool {
+	if len(t.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, p := range t.AllowedIPs {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsWork checks a work against the token's work ID and reading type lists.
+func (t apiTokenRestrictions) allowsWork(workID int, readingType string) bool {
+	if len(t.WorkIDs) > 0 && !slices.Contains(t.WorkIDs, workID) {
+		return false
+	}
+	return len(t.ReadingTypes) == 0 || slices.Contains(t.ReadingTypes, readingType)
+}
+
+// AllowedIPStrings formats AllowedIPs for the profile page (single addresses without the /32).
+func (t apiTokenRestrictions) AllowedIPStrings() []string {
+	out := make([]string, 0, len(t.AllowedIPs))
+	for _, p := range t.AllowedIPs {
+		if p.IsSingleIP() {
+			out = append(out, p.Addr().String())
+		} else {
+			out = append(out, p.String())
+		}
+	}
+	return out
+}
+
+func splitAPITokenList(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// parseAPITokenAllowedIPs reads the allowlist field: IP addresses or CIDRs separated by commas or spaces.
+func parseAPITokenAllowedIPs(raw string) ([]netip.Prefix, bool) {
+	var out []netip.Prefix
+	for _, item := range splitAPITokenList(raw) {
+		if strings.Contains(item, "/") {
+			p, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, false
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(item)
+		if err != nil || ip.Zone() != "" {
+			return nil, false
+		}
+		ip = ip.Unmap()
+		out = append(out, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return out, len(out) <= maxAPITokenAllowedIPs
+}
+
+func parseAPITokenWorkIDs(raw string) ([]int, bool) {
+	var out []int
+	for _, item := range splitAPITokenList(raw) {
+		id, err := strconv.Atoi(strings.TrimPrefix(item, "#"))
+		if err != nil || id <= 0 {
+			return nil, false
+		}
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out, len(out) <= maxAPITokenWorkIDs
+}
+
+func normalizeAPITokenReadingTypes(raw []string) []string {
+	var out []string
+	for _, rt := range raw {
+		rt = strings.TrimSpace(rt)
+		if isValidReadingType(rt) && !slices.Contains(out, rt) {
+			out = append(out, rt)
+		}
+	}
+	return out
+}
+
+// encode returns the api_tokens column values; unrestricted fields are stored as NULL.
+func (t apiTokenRestrictions) encode() (allowedIPs, workIDs, readingTypes, rateLimit any) {
+	if len(t.AllowedIPs) > 0 {
+		b, _ := json.Marshal(t.AllowedIPStrings())
+		allowedIPs = string(b)
+	}
+	if len(t.WorkIDs) > 0 {
+		b, _ := json.Marshal(t.WorkIDs)
+		workIDs = string(b)
+	}
+	if len(t.ReadingTypes) > 0 {
+		b, _ := json.Marshal(t.ReadingTypes)
+		readingTypes = string(b)
+	}
+	if t.RateLimit > 0 {
+		rateLimit = t.RateLimit
+	}
+	return
+}
+
+func decodeAPITokenRestrictions(allowedIPs, workIDs, readingTypes sql.NullString, rateLimit sql.NullInt64) apiTokenRestrictions {
+	var t apiTokenRestrictions
+	if allowedIPs.Valid {
+		var items []string
+		_ = json.Unmarshal([]byte(allowedIPs.String), &items)
+		t.AllowedIPs, _ = parseAPITokenAllowedIPs(strings.Join(items, ","))
+	}
+	if workIDs.Valid {
+		_ = json.Unmarshal([]byte(workIDs.String), &t.WorkIDs)
+	}
+	if readingTypes.Valid {
+		var items []string
+		_ = json.Unmarshal([]byte(readingTypes.String), &items)
+		t.ReadingTypes = normalizeAPITokenReadingTypes(items)
+	}
+	if rateLimit.Valid && rateLimit.Int64 > 0 {
+		t.RateLimit = int(rateLimit.Int64)
+	}
+	return t
 }
 
 func hashAPIToken(token string) string {
@@ -124,56 +318,48 @@ func apiAuthScopesFromContext(ctx context.Context) ([]string, bool) {
 	return scopes, ok
 }
 
-// apiTokenPathAllowed reports whether a bearer API token may access method+path.
-// All other routes require an interactive browser session.
-func apiTokenPathAllowed(method, path string) bool {
-	switch {
-	case path == "/api/works" && (method == http.MethodGet || method == http.MethodPost):
-		return true
-	case path == "/api/works/bulk" && method == http.MethodPost:
-		return true
-	case path == "/api/stats" && method == http.MethodGet:
-		return true
-	case path == "/api/reading-sites" && method == http.MethodGet:
-		return true
-	case strings.HasPrefix(path, "/api/works/") && len(path) > len("/api/works/"):
-		switch method {
-		case http.MethodGet, http.MethodPatch, http.MethodDelete:
-			return true
-		}
-	case strings.HasPrefix(path, "/api/increment/") && method == http.MethodPost:
-		return true
-	case strings.HasPrefix(path, "/api/decrement/") && method == http.MethodPost:
-		return true
-	case strings.HasPrefix(path, "/api/set-chapter/") && method == http.MethodPost:
-		return true
-	case strings.HasPrefix(path, "/api/delete/") && method == http.MethodPost:
-		return true
+// apiAuthTokenFromContext returns the bearer token row, including its restrictions.
+func apiAuthTokenFromContext(ctx context.Context) (apiTokenRow, bool) {
+	if ctx == nil {
+		return apiTokenRow{}, false
 	}
-	return false
+	tok, ok := ctx.Value(apiAuthTokenKey{}).(apiTokenRow)
+	return tok, ok
 }
 
 func (a *App) rejectAPITokenAuth(w http.ResponseWriter, r *http.Request) {
+	a.rejectAPITokenRequest(w, r, http.StatusForbidden, "forbidden")
+}
+
+func (a *App) rejectAPITokenRequest(w http.ResponseWriter, r *http.Request, status int, code string) {
 	if strings.HasPrefix(r.URL.Path, "/api/") {
-		a.apiWriteError(w, http.StatusForbidden, "forbidden")
+		a.apiWriteError(w, status, code)
 		return
 	}
-	w.WriteHeader(http.StatusForbidden)
-	if a.TemplatesWeb != nil {
+	w.WriteHeader(status)
+	if a.TemplatesWeb != nil && status == http.StatusForbidden {
 		a.renderTemplate(w, r, "403", a.mergeData(r, map[string]any{
 			"RequestedPath": r.URL.Path,
 		}))
 	}
 }
 
-// WithAPITokenRoutePolicy rejects bearer tokens on routes outside apiTokenPathAllowed.
+// WithAPITokenRoutePolicy rejects bearer tokens on routes outside apiTokenRoutes, without the
+// route's scope or outside the token's restrictions, and applies the token's own rate limit.
 func (a *App) WithAPITokenRoutePolicy(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if _, ok := apiAuthUserIDFromContext(r.Context()); ok {
-			if !apiTokenPathAllowed(r.Method, r.URL.Path) {
-				a.rejectAPITokenAuth(w, r)
+			if status, code := a.authorizeAPITokenRequest(r); status != 0 {
+				a.rejectAPITokenRequest(w, r, status, code)
 				return
 			}
+			if tok, ok := apiAuthTokenFromContext(r.Context()); ok && tok.Restrictions.RateLimit > 0 {
+				limit := float64(tok.Restrictions.RateLimit)
+				if !globalRateLimiter.allow("api_token:"+strconv.Itoa(tok.ID), limit, limit/60) {
+					a.rejectAPITokenRequest(w, r, http.StatusTooManyRequests, "rate_limited")
+					return
+				}
+			}
 		}
 		next.ServeHTTP(w, r)
 	})
@@ -181,9 +367,10 @@ func (a *App) WithAPITokenRoutePolicy(next http.Handler) http.Handler {
 
 func (a *App) WithAPITokenContext(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if userID, scopes, ok := a.resolveAPIToken(r); ok {
-			ctx := context.WithValue(r.Context(), apiAuthUserIDKey{}, userID)
-			ctx = context.WithValue(ctx, apiAuthScopesKey{}, scopes)
+		if tok, ok := a.lookupAPIToken(r); ok {
+			ctx := context.WithValue(r.Context(), apiAuthUserIDKey{}, tok.UserID)
+			ctx = context.WithValue(ctx, apiAuthScopesKey{}, tok.Scopes)
+			ctx = context.WithValue(ctx, apiAuthTokenKey{}, tok)
 			r = r.WithContext(ctx)
 		}
 		next.ServeHTTP(w, r)
@@ -191,40 +378,52 @@ func (a *App) WithAPITokenContext(next http.Handler) http.Handler {
 }
 
 func (a *App) resolveAPIToken(r *http.Request) (userID int, scopes []string, ok bool) {
+	tok, ok := a.lookupAPIToken(r)
+	return tok.UserID, tok.Scopes, ok
+}
+
+// lookupAPIToken loads the bearer token of r. Expired and revoked tokens, and tokens presented
+// from an address outside their allowlist, are treated as absent.
+func (a *App) lookupAPIToken(r *http.Request) (apiTokenRow, bool) {
 	if r == nil || a.DB == nil {
-		return 0, nil, false
+		return apiTokenRow{}, false
 	}
 	token := parseBearerToken(r)
 	if token == "" {
-		return 0, nil, false
+		return apiTokenRow{}, false
 	}
 
-	var uid int
+	var tok apiTokenRow
 	var scopesRaw string
-	var expiresAt sql.NullTime
-	var revokedAt sql.NullTime
+	var allowedIPs, workIDs, readingTypes sql.NullString
+	var rateLimit sql.NullInt64
 	err := a.DB.QueryRow(
-		`SELECT user_id, scopes, expires_at, revoked_at FROM api_tokens WHERE token_hash = ?`,
+		`SELECT id, user_id, name, scopes, expires_at, revoked_at, allowed_ips, work_ids, reading_types, rate_limit_per_minute
+		 FROM api_tokens WHERE token_hash = ?`,
 		hashAPIToken(token),
-	).Scan(&uid, &scopesRaw, &expiresAt, &revokedAt)
-	if err != nil || revokedAt.Valid || uid <= 0 {
-		return 0, nil, false
+	).Scan(&tok.ID, &tok.UserID, &tok.Name, &scopesRaw, &tok.ExpiresAt, &tok.RevokedAt, &allowedIPs, &workIDs, &readingTypes, &rateLimit)
+	if err != nil || tok.RevokedAt.Valid || tok.UserID <= 0 {
+		return apiTokenRow{}, false
 	}
 	now := time.Now().UTC()
-	if expiresAt.Valid && now.After(expiresAt.Time) {
-		return 0, nil, false
+	if tok.ExpiresAt.Valid && now.After(tok.ExpiresAt.Time) {
+		return apiTokenRow{}, false
 	}
 
-	scopes = decodeAPIScopes(scopesRaw)
-	if len(scopes) == 0 {
-		return 0, nil, false
+	tok.Scopes = decodeAPIScopes(scopesRaw)
+	if len(tok.Scopes) == 0 {
+		return apiTokenRow{}, false
+	}
+	tok.Restrictions = decodeAPITokenRestrictions(allowedIPs, workIDs, readingTypes, rateLimit)
+	if !tok.Restrictions.allowsIP(clientIP(r, a.Settings != nil && a.Settings.TrustProxy)) {
+		return apiTokenRow{}, false
 	}
 
 	_, _ = a.DB.Exec(
-		`UPDATE api_tokens SET last_used_at = ? WHERE token_hash = ? AND revoked_at IS NULL`,
-		now, hashAPIToken(token),
+		`UPDATE api_tokens SET last_used_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		now, tok.ID,
 	)
-	return uid, scopes, true
+	return tok, true
 }
 
 func (a *App) hasValidAPIToken(r *http.Request) bool {
@@ -236,6 +435,10 @@ func (a *App) hasValidAPIToken(r *http.Request) bool {
 }
 
 func (a *App) createAPIToken(userID int, name string, scopes []string) (token string, row apiTokenRow, err error) {
+	return a.createRestrictedAPIToken(userID, name, scopes, apiTokenRestrictions{})
+}
+
+func (a *App) createRestrictedAPIToken(userID int, name string, scopes []string, restrictions apiTokenRestrictions) (token string, row apiTokenRow, err error) {
 	if userID <= 0 {
 		return "", apiTokenRow{}, sql.ErrNoRows
 	}
@@ -254,22 +457,24 @@ func (a *App) createAPIToken(userID int, name string, scopes []string) (token st
 	}
 	now := time.Now().UTC()
 	expires := now.Add(apiTokenTTL())
+	allowedIPs, workIDs, readingTypes, rateLimit := restrictions.encode()
 	res, err := a.DB.Exec(
-		`INSERT INTO api_tokens (user_id, name, token_hash, scopes, created_at, expires_at)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		userID, name, hashAPIToken(token), encodeAPIScopes(scopes), now, expires,
+		`INSERT INTO api_tokens (user_id, name, token_hash, scopes, created_at, expires_at, allowed_ips, work_ids, reading_types, rate_limit_per_minute)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, name, hashAPIToken(token), encodeAPIScopes(scopes), now, expires, allowedIPs, workIDs, readingTypes, rateLimit,
 	)
 	if err != nil {
 		return "", apiTokenRow{}, err
 	}
 	id, _ := res.LastInsertId()
 	row = apiTokenRow{
-		ID:        int(id),
-		UserID:    userID,
-		Name:      name,
-		Scopes:    scopes,
-		CreatedAt: now,
-		ExpiresAt: sql.NullTime{Time: expires, Valid: true},
+		ID:           int(id),
+		UserID:       userID,
+		Name:         name,
+		Scopes:       scopes,
+		Restrictions: restrictions,
+		CreatedAt:    now,
+		ExpiresAt:    sql.NullTime{Time: expires, Valid: true},
 	}
 	return token, row, nil
 }
@@ -280,7 +485,7 @@ func (a *App) revokeAPIToken(userID, tokenID int) error {
 	}
 	now := time.Now().UTC()
 	res, err := a.DB.Exec(
-		`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND oauth_grant_id IS NULL`,
 		now, tokenID, userID,
 	)
 	if err != nil {
@@ -298,9 +503,9 @@ func (a *App) listAPITokens(userID int) ([]apiTokenRow, error) {
 		return nil, nil
 	}
 	rows, err := a.DB.Query(
-		`SELECT id, user_id, name, scopes, created_at, last_used_at, revoked_at
+		`SELECT id, user_id, name, scopes, created_at, last_used_at, revoked_at, allowed_ips, work_ids, reading_types, rate_limit_per_minute
 		 FROM api_tokens
-		 WHERE user_id = ? AND revoked_at IS NULL
+		 WHERE user_id = ? AND revoked_at IS NULL AND oauth_grant_id IS NULL
 		 ORDER BY created_at DESC
 		 LIMIT 50`,
 		userID,
@@ -314,10 +519,13 @@ func (a *App) listAPITokens(userID int) ([]apiTokenRow, error) {
 	for rows.Next() {
 		var row apiTokenRow
 		var scopesRaw string
-		if err := rows.Scan(&row.ID, &row.UserID, &row.Name, &scopesRaw, &row.CreatedAt, &row.LastUsedAt, &row.RevokedAt); err != nil {
+		var allowedIPs, workIDs, readingTypes sql.NullString
+		var rateLimit sql.NullInt64
+		if err := rows.Scan(&row.ID, &row.UserID, &row.Name, &scopesRaw, &row.CreatedAt, &row.LastUsedAt, &row.RevokedAt, &allowedIPs, &workIDs, &readingTypes, &rateLimit); err != nil {
 			return nil, err
 		}
 		row.Scopes = decodeAPIScopes(scopesRaw)
+		row.Restrictions = decodeAPITokenRestrictions(allowedIPs, workIDs, readingTypes, rateLimit)
 		out = append(out, row)
 	}
 	return out, rows.Err()
@@ -343,6 +551,8 @@ func (a *App) hasAPIScope(r *http.Request, scope string) bool {
 	return false
 }
 
+// RequireAPIScope checks one more scope inside a handler chain. Routes declare their scope in
+// apiTokenRoutes, which WithAPITokenRoutePolicy and RequireLogin already enforce.
 func (a *App) RequireAPIScope(scope string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
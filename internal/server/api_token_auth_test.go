@@ -3,6 +3,8 @@ package server
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 )
@@ -45,3 +47,142 @@ func TestCreateAPIToken_setsDefaultExpiry(t *testing.T) {
 		t.Fatalf("expires_at %v too soon (want ~%v)", row.ExpiresAt.Time, defaultAPITokenTTL)
 	}
 }
+
+func TestAPITokenRoutes_declareValidScopes(t *testing.T) {
+	for _, route := range apiTokenRoutes {
+		if !validAPIScopes[route.Scope] {
+			t.Errorf("%s: unknown scope %q", route.Pattern, route.Scope)
+		}
+	}
+	if route, ok := matchAPITokenRoute(http.MethodPatch, "/api/works/7/reads/3"); !ok || route.pathValue("/api/works/7/reads/3", "id") != "7" {
+		t.Fatalf("PATCH reads: %+v %v", route, ok)
+	}
+	if _, ok := matchAPITokenRoute(http.MethodGet, "/api/recommendations"); ok {
+		t.Fatal("recommendations must stay session-only")
+	}
+}
+
+func TestParseAPITokenAllowedIPs(t *testing.T) {
+	got, ok := parseAPITokenAllowedIPs("203.0.113.7, 192.168.1.9/24 ::ffff:198.51.100.1")
+	if !ok || len(got) != 3 || got[1].String() != "192.168.1.0/24" || got[2].String() != "198.51.100.1/32" {
+		t.Fatalf("got %v ok=%v", got, ok)
+	}
+	if _, ok := parseAPITokenAllowedIPs("example.com"); ok {
+		t.Fatal("hostname accepted")
+	}
+}
+
+// tokenAPIRequest sends one bearer request through the token middlewares and a mux with the work routes.
+func tokenAPIRequest(t *testing.T, app *App, token, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/works", app.RequireLogin(app.HandleAPIWorksList))
+	mux.HandleFunc("POST /api/works", app.RequireLogin(app.HandleAPIWorksCreate))
+	mux.HandleFunc("GET /api/works/{id}", app.RequireLogin(app.HandleAPIWorksDetail))
+	mux.HandleFunc("PATCH /api/works/{id}", app.RequireLogin(app.HandleAPIWorksUpdate))
+	mux.HandleFunc("GET /api/stats", app.RequireLogin(app.HandleAPIStats))
+	mux.HandleFunc("GET /api/goals", app.RequireLogin(app.HandleAPIGoals))
+	mux.HandleFunc("POST /api/goals", app.RequireLogin(app.HandleAPIGoals))
+	mux.HandleFunc("POST /api/notifications", app.RequireLogin(app.HandleAPINotifications))
+	mux.HandleFunc("POST /api/reading-sites", app.RequireLogin(app.HandleAPIReadingSiteCreate))
+	mux.HandleFunc("PATCH /api/reading-sites/{id}", app.RequireLogin(app.HandleAPIReadingSiteUpdate))
+	mux.HandleFunc("DELETE /api/reading-sites/{id}", app.RequireLogin(app.HandleAPIReadingSiteDelete))
+	mux.HandleFunc("POST /api/reading-sites/{id}/probe", app.RequireLogin(app.HandleAPIReadingSiteProbe))
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	app.WithAPITokenContext(app.WithAPITokenRoutePolicy(mux)).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPITokenScopesAndRestrictions(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	for _, w := range []struct {
+		id    int
+		title string
+		typ   string
+	}{{31, "Manga A", "Manga"}, {32, "Manga B", "Manga"}, {33, "Novel C", "Light Novel"}} {
+		if _, err := db.Exec(`INSERT INTO works (id, title, chapter, status, reading_type, user_id) VALUES (?, ?, 1, 'reading', ?, 1)`, w.id, w.title, w.typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statsOnly, _, _ := app.createAPIToken(1, "stats", []string{ScopeStatsRead})
+	if rec := tokenAPIRequest(t, app, statsOnly, http.MethodGet, "/api/stats", ""); rec.Code != http.StatusOK {
+		t.Fatalf("stats:read on /api/stats: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := tokenAPIRequest(t, app, statsOnly, http.MethodGet, "/api/works", ""); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "insufficient_scope") {
+		t.Fatalf("stats:read on /api/works: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Goals belong to the stats scopes: works:write alone cannot create them, stats:write can.
+	goal := `{"kind":"works_finished","period":"year","target":5}`
+	worksOnly, _, _ := app.createAPIToken(1, "works", []string{ScopeWorksRead, ScopeWorksWrite})
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if rec := tokenAPIRequest(t, app, worksOnly, method, "/api/goals", goal); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "insufficient_scope") {
+			t.Fatalf("works scopes on %s /api/goals: %d %s", method, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := tokenAPIRequest(t, app, statsOnly, http.MethodPost, "/api/goals", goal); rec.Code != http.StatusForbidden {
+		t.Fatalf("stats:read creating a goal: %d", rec.Code)
+	}
+	goals, _, _ := app.createAPIToken(1, "goals", []string{ScopeStatsRead, ScopeStatsWrite})
+	if rec := tokenAPIRequest(t, app, goals, http.MethodPost, "/api/goals", goal); rec.Code != http.StatusCreated {
+		t.Fatalf("stats:write creating a goal: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := tokenAPIRequest(t, app, goals, http.MethodGet, "/api/goals", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "works_finished") {
+		t.Fatalf("listing goals: %d %s", rec.Code, rec.Body.String())
+	}
+	notifRead, _, _ := app.createAPIToken(1, "notifications", []string{ScopeNotificationsRead})
+	if rec := tokenAPIRequest(t, app, notifRead, http.MethodPost, "/api/notifications", `{"all":true}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("notifications:read marking as read: %d", rec.Code)
+	}
+
+	scopes := []string{ScopeWorksRead, ScopeWorksWrite, ScopeStatsRead}
+	byID, _, err := app.createRestrictedAPIToken(1, "one work", scopes, apiTokenRestrictions{WorkIDs: []int{31}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := tokenAPIRequest(t, app, byID, http.MethodGet, "/api/works/31", ""); rec.Code != http.StatusOK {
+		t.Fatalf("allowed work: %d", rec.Code)
+	}
+	for _, c := range []struct{ method, path, body string }{
+		{http.MethodGet, "/api/works/32", ""},
+		{http.MethodGet, "/api/stats", ""},
+		{http.MethodPost, "/api/works", `{"title":"New"}`},
+	} {
+		if rec := tokenAPIRequest(t, app, byID, c.method, c.path, c.body); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "token_restricted") {
+			t.Fatalf("%s %s: %d %s", c.method, c.path, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := tokenAPIRequest(t, app, byID, http.MethodGet, "/api/works", ""); !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Fatalf("list with one work: %s", rec.Body.String())
+	}
+
+	byType, _, _ := app.createRestrictedAPIToken(1, "manga", scopes, apiTokenRestrictions{ReadingTypes: []string{"Manga"}})
+	if rec := tokenAPIRequest(t, app, byType, http.MethodGet, "/api/works", ""); !strings.Contains(rec.Body.String(), `"total":2`) {
+		t.Fatalf("list by type: %s", rec.Body.String())
+	}
+	if rec := tokenAPIRequest(t, app, byType, http.MethodGet, "/api/works/33", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("other type: %d", rec.Code)
+	}
+	if rec := tokenAPIRequest(t, app, byType, http.MethodPatch, "/api/works/31", `{"reading_type":"Light Novel"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("moved out of the allowed types: %d", rec.Code)
+	}
+	if rec := tokenAPIRequest(t, app, byType, http.MethodPost, "/api/works", `{"title":"New","reading_type":"Manga"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create allowed type: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// httptest requests come from 192.0.2.1.
+	elsewhere, _, _ := app.createRestrictedAPIToken(1, "office", scopes, apiTokenRestrictions{AllowedIPs: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}})
+	if rec := tokenAPIRequest(t, app, elsewhere, http.MethodGet, "/api/works", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("outside the allowlist: %d", rec.Code)
+	}
+	here, _, _ := app.createRestrictedAPIToken(1, "lab", scopes, apiTokenRestrictions{AllowedIPs: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}, RateLimit: 2})
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if rec := tokenAPIRequest(t, app, here, http.MethodGet, "/api/works", ""); rec.Code != want {
+			t.Fatalf("request %d: %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// apiTokenWorkAccess says how a route reaches works, so tokens limited to some work IDs or
+// reading types can be checked before the handler runs.
+type apiTokenWorkAccess int
+
+const (
+	// worksAny routes read or change works across the library; restricted tokens are refused.
+	worksAny apiTokenWorkAccess = iota
+	// worksInPath routes name one work in the {id} wildcard, which is checked against the token.
+	worksInPath
+	// worksChecked routes narrow their own queries with apiTokenWorksFilter and apiTokenAllowsNewWork.
+	worksChecked
+	// worksNone routes do not expose works.
+	worksNone
+)
+
+// apiTokenRoute grants bearer tokens access to one route. Pattern uses http.ServeMux syntax and
+// must match the pattern registered in main.go.
+type apiTokenRoute struct {
+	Pattern string
+	Scope   string
+	Works   apiTokenWorkAccess
+}
+
+// apiTokenRoutes lists every route a bearer token may call. All other routes require an
+// interactive browser session.
+var apiTokenRoutes = []apiTokenRoute{
+	{"GET /api/works", ScopeWorksRead, worksChecked},
+	{"POST /api/works", ScopeWorksWrite, worksChecked},
+	{"POST /api/works/bulk", ScopeWorksWrite, worksAny},
+	{"GET /api/works/{id}", ScopeWorksRead, worksInPath},
+	{"PATCH /api/works/{id}", ScopeWorksWrite, worksInPath},
+	{"DELETE /api/works/{id}", ScopeWorksWrite, worksInPath},
+	{"GET /api/works/{id}/history", ScopeWorksRead, worksInPath},
+	{"POST /api/works/{id}/undo", ScopeWorksWrite, worksInPath},
+	{"GET /api/works/{id}/reads", ScopeWorksRead, worksInPath},
+	{"POST /api/works/{id}/reread", ScopeWorksWrite, worksInPath},
+	{"PATCH /api/works/{id}/reads/{readID}", ScopeWorksWrite, worksInPath},
+	{"DELETE /api/works/{id}/reads/{readID}", ScopeWorksWrite, worksInPath},
+	{"GET /api/works/{id}/collection", ScopeWorksRead, worksInPath},
+	{"POST /api/works/{id}/collection", ScopeWorksWrite, worksInPath},
+	{"PATCH /api/works/{id}/collection/{copyID}", ScopeWorksWrite, worksInPath},
+	{"DELETE /api/works/{id}/collection/{copyID}", ScopeWorksWrite, worksInPath},
+	{"GET /api/works/{id}/releases", ScopeWorksRead, worksInPath},
+	{"POST /api/increment/{id}", ScopeWorksWrite, worksInPath},
+	{"POST /api/decrement/{id}", ScopeWorksWrite, worksInPath},
+	{"POST /api/set-chapter/{id}", ScopeWorksWrite, worksInPath},
+	{"POST /api/delete/{id}", ScopeWorksWrite, worksInPath},
+	{"GET /api/collection/missing", ScopeWorksRead, worksAny},
+	{"GET /api/tags", ScopeWorksRead, worksAny},
+	{"GET /api/series/{id}", ScopeWorksRead, worksAny},
+	{"POST /api/series/{id}", ScopeWorksWrite, worksAny},
+
+	{"GET /api/stats", ScopeStatsRead, worksAny},
+	{"GET /api/goals", ScopeStatsRead, worksAny},
+	{"POST /api/goals", ScopeStatsWrite, worksAny},
+	{"DELETE /api/goals/{id}", ScopeStatsWrite, worksAny},
+
+	{"GET /api/reading-sites", ScopeReadingSitesRead, worksNone},
+	{"GET /api/reading-sites/match", ScopeReadingSitesRead, worksNone},
+	{"POST /api/reading-sites", ScopeReadingSitesWrite, worksNone},
+	{"PATCH /api/reading-sites/{id}", ScopeReadingSitesWrite, worksNone},
+	{"DELETE /api/reading-sites/{id}", ScopeReadingSitesWrite, worksNone},
+	{"POST /api/reading-sites/{id}/probe", ScopeReadingSitesWrite, worksNone},
+
+	{"GET /api/catalog/browse", ScopeCatalogRead, worksNone},
+	{"GET /api/catalog/search", ScopeCatalogRead, worksNone},
+
+	{"GET /api/notifications", ScopeNotificationsRead, worksAny},
+	{"POST /api/notifications", ScopeNotificationsWrite, worksAny},
+
+	{"GET /api/webhooks", ScopeWebhooksManage, worksAny},
+	{"POST /api/webhooks", ScopeWebhooksManage, worksAny},
+	{"DELETE /api/webhooks/{id}", ScopeWebhooksManage, worksAny},
+
+	{"GET /export", ScopeExportRead, worksAny},
+}
+
+// apiTokenRouteMux matches requests against apiTokenRoutes with the same precedence rules as the
+// application mux; registering an invalid or conflicting pattern panics at startup.
+var apiTokenRouteMux, apiTokenRoutesByPattern = func() (*http.ServeMux, map[string]apiTokenRoute) {
+	mux := http.NewServeMux()
+	byPattern := make(map[string]apiTokenRoute, len(apiTokenRoutes))
+	for _, route := range apiTokenRoutes {
+		mux.Handle(route.Pattern, http.NotFoundHandler())
+		byPattern[route.Pattern] = route
+	}
+	return mux, byPattern
+}()
+
+// matchAPITokenRoute returns the apiTokenRoutes entry for method+path.
+func matchAPITokenRoute(method, path string) (apiTokenRoute, bool) {
+	_, pattern := apiTokenRouteMux.Handler(&http.Request{Method: method, URL: &url.URL{Path: path}})
+	route, ok := apiTokenRoutesByPattern[pattern]
+	return route, ok
+}
+
+// pathValue extracts a {name} wildcard of the route from a request path that matched it.
+func (route apiTokenRoute) pathValue(path, name string) string {
+	_, patternPath, _ := strings.Cut(route.Pattern, " ")
+	patternSegs := strings.Split(strings.Trim(patternPath, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range patternSegs {
+		if seg == "{"+name+"}" && i < len(pathSegs) {
+			return pathSegs[i]
+		}
+	}
+	return ""
+}
+
+// authorizeAPITokenRequest applies apiTokenRoutes to a bearer-token request. It returns the status
+// and API error code to reject with, or 0 when the request may go on.
+func (a *App) authorizeAPITokenRequest(r *http.Request) (int, string) {
+	route, ok := matchAPITokenRoute(r.Method, r.URL.Path)
+	if !ok {
+		return http.StatusForbidden, "forbidden"
+	}
+	if !a.hasAPIScope(r, route.Scope) {
+		return http.StatusForbidden, "insufficient_scope"
+	}
+	tok, _ := apiAuthTokenFromContext(r.Context())
+	if !tok.Restrictions.LimitsWorks() {
+		return 0, ""
+	}
+	switch route.Works {
+	case worksNone, worksChecked:
+		return 0, ""
+	case worksInPath:
+		workID, err := strconv.Atoi(route.pathValue(r.URL.Path, "id"))
+		if err != nil {
+			return http.StatusForbidden, "token_restricted"
+		}
+		var readingType sql.NullString
+		err = a.DB.QueryRow(`SELECT reading_type FROM works WHERE id = ? AND user_id = ?`, workID, tok.UserID).Scan(&readingType)
+		if errors.Is(err, sql.ErrNoRows) && len(tok.Restrictions.WorkIDs) == 0 {
+			// Unknown work: let the handler answer 404 as it would for any token.
+			return 0, ""
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return http.StatusInternalServerError, "internal_error"
+		}
+		if !tok.Restrictions.allowsWork(workID, readingType.String) {
+			return http.StatusForbidden, "token_restricted"
+		}
+		return 0, ""
+	default:
+		return http.StatusForbidden, "token_restricted"
+	}
+}
+
+// apiTokenWorksFilter returns a WHERE clause on works limiting a worksChecked route to the works the
+// request's token may reach, or "" for sessions and unrestricted tokens.
+func apiTokenWorksFilter(r *http.Request) (string, []any) {
+	tok, ok := apiAuthTokenFromContext(r.Context())
+	if !ok || !tok.Restrictions.LimitsWorks() {
+		return "", nil
+	}
+	var parts []string
+	var args []any
+	if ids := tok.Restrictions.WorkIDs; len(ids) > 0 {
+		parts = append(parts, "id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+")")
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	if types := tok.Restrictions.ReadingTypes; len(types) > 0 {
+		parts = append(parts, "reading_type IN ("+strings.TrimSuffix(strings.Repeat("?,", len(types)), ",")+")")
+		for _, rt := range types {
+			args = append(args, rt)
+		}
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// apiTokenAllowsNewWork reports whether the request may create, or move a work to, readingType.
+// Tokens limited to a list of work IDs cannot create works.
+func apiTokenAllowsNewWork(r *http.Request, readingType string, creating bool) bool {
+	tok, ok := apiAuthTokenFromContext(r.Context())
+	if !ok {
+		return true
+	}
+	if creating && len(tok.Restrictions.WorkIDs) > 0 {
+		return false
+	}
+	return len(tok.Restrictions.ReadingTypes) == 0 || slices.Contains(tok.Restrictions.ReadingTypes, readingType)
+}
@@ -19,10 +19,17 @@ type auditLogEntry struct {
 	CreatedAt   string
 }
 
+// logAdminAction records an admin action performed in request r. A nil r records an action taken
+// by the server itself (e.g. expiry of pending accounts): no actor, no IP.
 func (a *App) logAdminAction(r *http.Request, action, targetType, targetID string, detail any) {
-	actorID, ok := a.currentUserID(r)
-	if !ok || actorID <= 0 {
-		return
+	var actorArg, ipArg any
+	if r != nil {
+		actorID, ok := a.currentUserID(r)
+		if !ok || actorID <= 0 {
+			return
+		}
+		trustProxy := a.Settings != nil && a.Settings.TrustProxy
+		actorArg, ipArg = actorID, clientIP(r, trustProxy)
 	}
 	var detailArg any
 	if detail != nil {
@@ -31,8 +38,6 @@ func (a *App) logAdminAction(r *http.Request, action, targetType, targetID strin
 			detailArg = string(b)
 		}
 	}
-	trustProxy := a.Settings != nil && a.Settings.TrustProxy
-	ip := clientIP(r, trustProxy)
 	var targetTypeArg, targetIDArg any
 	if targetType != "" {
 		targetTypeArg = targetType
@@ -42,7 +47,7 @@ func (a *App) logAdminAction(r *http.Request, action, targetType, targetID strin
 	}
 	_, _ = a.DB.Exec(
 		`INSERT INTO admin_audit_log (actor_user_id, action, target_type, target_id, detail_json, ip) VALUES (?, ?, ?, ?, ?, ?)`,
-		actorID, action, targetTypeArg, targetIDArg, detailArg, ip,
+		actorArg, action, targetTypeArg, targetIDArg, detailArg, ipArg,
 	)
 }
 
@@ -54,7 +59,7 @@ func (a *App) listAuditLog(limit int) ([]auditLogEntry, error) {
 		limit = 500
 	}
 	rows, err := a.DB.Query(
-		`SELECT l.id, l.actor_user_id, COALESCE(u.username, ''), l.action, l.target_type, l.target_id, l.detail_json, l.ip, l.created_at
+		`SELECT l.id, COALESCE(l.actor_user_id, 0), COALESCE(u.username, ''), l.action, l.target_type, l.target_id, l.detail_json, l.ip, l.created_at
 		 FROM admin_audit_log l
 		 LEFT JOIN users u ON u.id = l.actor_user_id
 		 ORDER BY l.id DESC
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"bookstorage/internal/catalog"
+	"bookstorage/internal/database"
+)
+
+const (
+	// chapterFeedQuota bounds MangaDex calls per cycle (each one waits on mangadexThrottle).
+	chapterFeedQuota = 40
+	// chapterFeedRecheck is the minimum age of latest_chapter before a title is fetched again.
+	chapterFeedRecheck = 6 * time.Hour
+)
+
+// StartChapterFeedPoller launches a goroutine that looks for new chapters of followed works
+// (works.notify_new_chapters) every interval: MangaDex feeds for titles linked to the catalog,
+// and RSS/Atom release feeds (see PollReleaseFeeds). It stops when ctx is cancelled.
+func (a *App) StartChapterFeedPoller(ctx context.Context, interval time.Duration) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[chapters] recovered from panic: %v — restarting in 30s", r)
+				time.Sleep(30 * time.Second)
+				a.StartChapterFeedPoller(ctx, interval)
+			}
+		}()
+
+		log.Printf("[chapters] started — interval %v", interval)
+
+		a.runChapterFeedCycle(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("[chapters] stopped (context cancelled)")
+				return
+			case <-ticker.C:
+				a.runChapterFeedCycle(ctx)
+			}
+		}
+	}()
+}
+
+func (a *App) runChapterFeedCycle(ctx context.Context) {
+	a.PollChapterFeeds(ctx, chapterFeedQuota)
+	a.PollReleaseFeeds(ctx, releaseFeedQuota)
+}
+
+// PollChapterFeeds fetches the latest chapter of up to limit followed MangaDex titles (never
+// checked or oldest check first) and stores it on their catalog row.
+func (a *App) PollChapterFeeds(ctx context.Context, limit int) {
+	if limit <= 0 {
+		return
+	}
+	if _, ok := catalog.EnabledProvider("mangadex"); !ok {
+		return
+	}
+	orderClause := "ORDER BY c.latest_chapter_checked_at ASC NULLS FIRST, c.id ASC"
+	if a.DB != nil && a.DB.B != database.BackendPostgres {
+		orderClause = "ORDER BY CASE WHEN c.latest_chapter_checked_at IS NULL THEN 0 ELSE 1 END, c.latest_chapter_checked_at ASC, c.id ASC"
+	}
+	cutoff := time.Now().UTC().Add(-chapterFeedRecheck).Format("2006-01-02 15:04:05")
+	rows, err := a.DB.Query(
+		`SELECT c.id, c.external_id, c.latest_chapter FROM catalog c
+		 WHERE c.source = 'mangadex' AND COALESCE(c.external_id, '') != ''
+		   AND (c.latest_chapter_checked_at IS NULL OR c.latest_chapter_checked_at < ?)
+		   AND EXISTS (SELECT 1 FROM works w WHERE w.catalog_id = c.id AND COALESCE(w.notify_new_chapters, 1) = 1)
+		 `+orderClause+` LIMIT ?`,
+		cutoff, limit,
+	)
+	if err != nil {
+		log.Printf("[chapters] failed to list followed titles: %v", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	type followedTitle struct {
+		CatalogID  int64
+		ExternalID string
+		Previous   sql.NullFloat64
+	}
+	var pending []followedTitle
+	for rows.Next() {
+		var f followedTitle
+		if err := rows.Scan(&f.CatalogID, &f.ExternalID, &f.Previous); err != nil {
+			continue
+		}
+		pending = append(pending, f)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	log.Printf("[chapters] checking %d titles", len(pending))
+	for _, f := range pending {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		latest, err := catalog.LatestMangaDexChapter(f.ExternalID, a.Settings.ChapterFeedLanguages)
+		now := time.Now().UTC().Format("2006-01-02 15:04:05")
+		switch {
+		case err == nil && latest > 0:
+			_, _ = a.DB.Exec(`UPDATE catalog SET latest_chapter = ?, latest_chapter_checked_at = ? WHERE id = ?`, latest, now, f.CatalogID)
+			// The first value found is a baseline, not news.
+			if f.Previous.Valid && latest > f.Previous.Float64 {
+				a.notifyCatalogFollowers(f.CatalogID)
+			}
+		case err == nil || errors.Is(err, catalog.ErrMediaNotFound):
+			// Nothing numbered (or the entry is gone): keep the previous value, retry after chapterFeedRecheck.
+			_, _ = a.DB.Exec(`UPDATE catalog SET latest_chapter_checked_at = ? WHERE id = ?`, now, f.CatalogID)
+		default:
+			log.Printf("[chapters] catalog %d: %v", f.CatalogID, err)
+		}
+	}
+}
+
+// notifyCatalogFollowers sends new_chapters notifications to every reader following catalogID.
+func (a *App) notifyCatalogFollowers(catalogID int64) {
+	rows, err := a.DB.Query(`SELECT id FROM works WHERE catalog_id = ? AND COALESCE(notify_new_chapters, 1) = 1`, catalogID)
+	if err != nil {
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	_ = rows.Close()
+	for _, id := range ids {
+		a.notifyNewChapters(id)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"bookstorage/internal/catalog"
+)
+
+func TestPollChapterFeeds_chaptersBehind(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/manga/md-followed/feed" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"attributes": {"chapter": "14", "translatedLanguage": "en"}}]}`))
+	}))
+	catalog.SetMangaDexBaseURL(srv.URL)
+	t.Cleanup(func() {
+		catalog.SetMangaDexBaseURL("")
+		srv.Close()
+	})
+
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	res, err := db.Exec(`INSERT INTO catalog (title, reading_type, source, external_id) VALUES ('Followed', 'Manga', 'mangadex', 'md-followed')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogID, _ := res.LastInsertId()
+	followed := insertTestWork(t, app, "Followed", 10)
+	muted := insertTestWork(t, app, "Followed (muted)", 10)
+	if _, err := db.Exec(`UPDATE works SET catalog_id = ? WHERE id IN (?, ?)`, catalogID, followed, muted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE works SET chapter = 10.5, notify_new_chapters = 1 WHERE id = ?`, followed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE works SET notify_new_chapters = 0 WHERE id = ?`, muted); err != nil {
+		t.Fatal(err)
+	}
+
+	app.PollChapterFeeds(context.Background(), 10)
+	// A fresh check is not repeated before chapterFeedRecheck.
+	app.PollChapterFeeds(context.Background(), 10)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("mangadex calls=%d", got)
+	}
+
+	detail := func(id int) apiWork {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/works/"+strconv.Itoa(id), nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", strconv.Itoa(id))
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorksDetail(rec, req)
+		var payload struct {
+			Data apiWork `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+		}
+		return payload.Data
+	}
+	if w := detail(followed); w.LatestChapter == nil || *w.LatestChapter != 14 || w.ChaptersBehind != 4 {
+		t.Fatalf("followed work=%+v", w)
+	}
+	if w := detail(muted); w.ChaptersBehind != 0 {
+		t.Fatalf("muted work behind=%d", w.ChaptersBehind)
+	}
+}
@@ -0,0 +1,604 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/i18n"
+)
+
+// The collection tracks owned copies, one work_copies row per volume and format, independently of
+// reading progress: a volume can be owned but unread, or read online and never bought.
+
+const (
+	copyFormatPaperback = "paperback"
+	copyFormatDigital   = "digital"
+
+	maxCopyVolume      = 9999
+	maxVolumesPerBatch = 500
+	maxCopyPrice       = 100000
+)
+
+var validCopyFormats = map[string]bool{copyFormatPaperback: true, copyFormatDigital: true}
+
+var (
+	errInvalidVolumes      = errors.New("invalid_volumes")
+	errInvalidISBN         = errors.New("invalid_isbn")
+	errInvalidPurchaseDate = errors.New("invalid_date")
+	errInvalidPrice        = errors.New("invalid_price")
+)
+
+type workCopy struct {
+	ID          int      `json:"id"`
+	Volume      int      `json:"volume"`
+	Format      string   `json:"format"`
+	ISBN        string   `json:"isbn,omitempty"`
+	PurchasedAt string   `json:"purchased_at,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+
+	workID int
+}
+
+// PriceInput is the price as the edit form shows it ("" when unknown).
+func (c workCopy) PriceInput() string {
+	if c.Price == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*c.Price, 'f', 2, 64)
+}
+
+type collectionSummary struct {
+	Owned        string  `json:"owned"`
+	OwnedCount   int     `json:"owned_count"`
+	Missing      string  `json:"missing"`
+	MissingCount int     `json:"missing_count"`
+	Paperback    int     `json:"paperback"`
+	Digital      int     `json:"digital"`
+	TotalSpent   float64 `json:"total_spent"`
+}
+
+// parseVolumeRanges reads "1-12, 14" into sorted distinct volume numbers.
+func parseVolumeRanges(raw string) ([]int, error) {
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			lo, hi = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		from, err1 := strconv.Atoi(lo)
+		to, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || from < 1 || to > maxCopyVolume || from > to || to-from >= maxVolumesPerBatch {
+			return nil, errInvalidVolumes
+		}
+		for v := from; v <= to; v++ {
+			seen[v] = true
+		}
+	}
+	if len(seen) == 0 || len(seen) > maxVolumesPerBatch {
+		return nil, errInvalidVolumes
+	}
+	out := make([]int, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// formatVolumeRanges is the inverse of parseVolumeRanges; vols must be sorted.
+func formatVolumeRanges(vols []int) string {
+	var parts []string
+	for i := 0; i < len(vols); {
+		j := i
+		for j+1 < len(vols) && vols[j+1] <= vols[j]+1 {
+			j++
+		}
+		if vols[i] == vols[j] {
+			parts = append(parts, strconv.Itoa(vols[i]))
+		} else {
+			parts = append(parts, strconv.Itoa(vols[i])+"-"+strconv.Itoa(vols[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// normalizeISBN strips separators and checks an ISBN-10 or ISBN-13 checksum.
+func normalizeISBN(raw string) (string, error) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+	switch len(s) {
+	case 0:
+		return "", nil
+	case 10:
+		sum := 0
+		for i, c := range s {
+			d := int(c - '0')
+			if c == 'X' && i == 9 {
+				d = 10
+			} else if c < '0' || c > '9' {
+				return "", errInvalidISBN
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 == 0 {
+			return s, nil
+		}
+	case 13:
+		sum := 0
+		for i, c := range s {
+			if c < '0' || c > '9' {
+				return "", errInvalidISBN
+			}
+			d := int(c - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		if sum%10 == 0 {
+			return s, nil
+		}
+	}
+	return "", errInvalidISBN
+}
+
+func normalizePurchaseDate(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", nil
+	}
+	if len(s) > 10 {
+		s = s[:10]
+	}
+	if _, err := time.Parse("2006-01-02", s); err != nil {
+		return "", errInvalidPurchaseDate
+	}
+	return s, nil
+}
+
+// normalizePrice rounds to cents; nil means no price.
+func normalizePrice(p *float64) (any, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if *p < 0 || *p > maxCopyPrice || math.IsNaN(*p) {
+		return nil, errInvalidPrice
+	}
+	return math.Round(*p*100) / 100, nil
+}
+
+func scanWorkCopies(rows *sql.Rows) ([]workCopy, error) {
+	defer func() { _ = rows.Close() }()
+	var out []workCopy
+	for rows.Next() {
+		var c workCopy
+		var isbn, purchasedAt sql.NullString
+		var price sql.NullFloat64
+		if err := rows.Scan(&c.ID, &c.workID, &c.Volume, &c.Format, &isbn, &purchasedAt, &price); err != nil {
+			return nil, err
+		}
+		c.ISBN, c.PurchasedAt = isbn.String, purchasedAt.String
+		if price.Valid {
+			v := price.Float64
+			c.Price = &v
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+const sqlWorkCopyColumns = `id, work_id, volume, format, isbn, purchased_at, price`
+
+func (a *App) listWorkCopies(userID, workID int) ([]workCopy, error) {
+	rows, err := a.DB.Query(
+		`SELECT `+sqlWorkCopyColumns+` FROM work_copies WHERE user_id = ? AND work_id = ? ORDER BY volume, format`,
+		userID, workID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWorkCopies(rows)
+}
+
+func (a *App) workCopiesByWork(userID int) map[int][]workCopy {
+	out := map[int][]workCopy{}
+	rows, err := a.DB.Query(`SELECT `+sqlWorkCopyColumns+` FROM work_copies WHERE user_id = ? ORDER BY work_id, volume, format`, userID)
+	if err != nil {
+		log.Printf("work_copies (user %d): %v", userID, err)
+		return out
+	}
+	copies, err := scanWorkCopies(rows)
+	if err != nil {
+		log.Printf("work_copies (user %d): %v", userID, err)
+	}
+	for _, c := range copies {
+		out[c.workID] = append(out[c.workID], c)
+	}
+	return out
+}
+
+// summarizeCollection reports owned volumes and the gaps up to the highest volume owned or read.
+func summarizeCollection(copies []workCopy, readVolume float64) collectionSummary {
+	var s collectionSummary
+	owned := map[int]bool{}
+	highest := int(math.Floor(readVolume))
+	for _, c := range copies {
+		owned[c.Volume] = true
+		highest = max(highest, c.Volume)
+		if c.Format == copyFormatDigital {
+			s.Digital++
+		} else {
+			s.Paperback++
+		}
+		if c.Price != nil {
+			s.TotalSpent += *c.Price
+		}
+	}
+	var have, missing []int
+	for v := 1; v <= highest; v++ {
+		if owned[v] {
+			have = append(have, v)
+		} else {
+			missing = append(missing, v)
+		}
+	}
+	s.TotalSpent = math.Round(s.TotalSpent*100) / 100
+	s.Owned, s.OwnedCount = formatVolumeRanges(have), len(have)
+	s.Missing, s.MissingCount = formatVolumeRanges(missing), len(missing)
+	return s
+}
+
+// addWorkCopies records the volumes in one format; volumes already owned in that format are skipped.
+func (a *App) addWorkCopies(userID, workID int, volumes []int, format, isbn, purchasedAt string, price any) (int, error) {
+	added := 0
+	for _, v := range volumes {
+		var one int
+		err := a.DB.QueryRow(`SELECT 1 FROM work_copies WHERE work_id = ? AND volume = ? AND format = ?`, workID, v, format).Scan(&one)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return added, err
+		}
+		if _, err := a.DB.Exec(
+			`INSERT INTO work_copies (user_id, work_id, volume, format, isbn, purchased_at, price) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, workID, v, format, nullIfEmpty(isbn), nullIfEmpty(purchasedAt), price,
+		); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// importWorkCopies adds the exported copies that are not owned yet; invalid rows are dropped.
+func (a *App) importWorkCopies(userID, workID int, copies []exportCopy) error {
+	for _, c := range copies {
+		format := strings.TrimSpace(c.Format)
+		if format == "" {
+			format = copyFormatPaperback
+		}
+		if c.Volume < 1 || c.Volume > maxCopyVolume || !validCopyFormats[format] {
+			continue
+		}
+		isbn, _ := normalizeISBN(c.ISBN)
+		purchasedAt, _ := normalizePurchaseDate(c.PurchasedAt)
+		price, err := normalizePrice(c.Price)
+		if err != nil {
+			price = nil
+		}
+		if _, err := a.addWorkCopies(userID, workID, []int{c.Volume}, format, isbn, purchasedAt, price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *App) workCollectionPayload(userID, workID int) (map[string]any, error) {
+	copies, err := a.listWorkCopies(userID, workID)
+	if err != nil {
+		return nil, err
+	}
+	var volume float64
+	_ = a.DB.QueryRow(`SELECT COALESCE(volume, 0) FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&volume)
+	if copies == nil {
+		copies = []workCopy{}
+	}
+	return map[string]any{"copies": copies, "summary": summarizeCollection(copies, volume)}, nil
+}
+
+// HandleAPIWorkCollection serves GET and POST /api/works/{id}/collection.
+// POST body: {"volumes": "1-12, 14", "format": "paperback", "isbn": "...", "purchased_at": "2026-01-31", "price": 7.5};
+// an ISBN is only accepted for a single volume.
+func (a *App) HandleAPIWorkCollection(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	if ok, err := a.userOwnsWork(userID, workID); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	} else if !ok {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+
+	status := http.StatusOK
+	added := 0
+	if r.Method == http.MethodPost {
+		var req struct {
+			Volumes     string   `json:"volumes"`
+			Format      string   `json:"format"`
+			ISBN        string   `json:"isbn"`
+			PurchasedAt string   `json:"purchased_at"`
+			Price       *float64 `json:"price"`
+		}
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+		volumes, err := parseVolumeRanges(req.Volumes)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		format := strings.TrimSpace(req.Format)
+		if format == "" {
+			format = copyFormatPaperback
+		}
+		if !validCopyFormats[format] {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_format")
+			return
+		}
+		isbn, err := normalizeISBN(req.ISBN)
+		if err == nil && isbn != "" && len(volumes) > 1 {
+			err = errInvalidISBN
+		}
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		purchasedAt, err := normalizePurchaseDate(req.PurchasedAt)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		price, err := normalizePrice(req.Price)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if added, err = a.addWorkCopies(userID, workID, volumes, format, isbn, purchasedAt, price); err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	payload, err := a.workCollectionPayload(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if r.Method == http.MethodPost {
+		payload["added"] = added
+	}
+	a.apiWriteJSON(w, status, map[string]any{"data": payload})
+}
+
+// HandleAPIWorkCopy serves PATCH and DELETE /api/works/{id}/collection/{copyID}.
+// PATCH accepts format, isbn, purchased_at and price; empty strings and null clear the optional fields.
+func (a *App) HandleAPIWorkCopy(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	copyID, _ := strconv.Atoi(r.PathValue("copyID"))
+	switch r.Method {
+	case http.MethodDelete:
+		res, err := a.DB.Exec(`DELETE FROM work_copies WHERE id = ? AND work_id = ? AND user_id = ?`, copyID, workID, userID)
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			a.apiWriteError(w, http.StatusNotFound, "not_found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPatch:
+	default:
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+
+	var req map[string]any
+	if err := decodeAPIJSONBody(w, r, &req); err != nil {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	var setParts []string
+	var args []any
+	if v, ok := req["format"].(string); ok {
+		if !validCopyFormats[v] {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_format")
+			return
+		}
+		setParts, args = append(setParts, "format = ?"), append(args, v)
+	}
+	if v, ok := req["isbn"]; ok {
+		s, _ := v.(string)
+		isbn, err := normalizeISBN(s)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		setParts, args = append(setParts, "isbn = ?"), append(args, nullIfEmpty(isbn))
+	}
+	if v, ok := req["purchased_at"]; ok {
+		s, _ := v.(string)
+		date, err := normalizePurchaseDate(s)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		setParts, args = append(setParts, "purchased_at = ?"), append(args, nullIfEmpty(date))
+	}
+	if v, ok := req["price"]; ok {
+		var p *float64
+		if f, isNum := v.(float64); isNum {
+			p = &f
+		} else if v != nil {
+			a.apiWriteError(w, http.StatusBadRequest, errInvalidPrice.Error())
+			return
+		}
+		price, err := normalizePrice(p)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		setParts, args = append(setParts, "price = ?"), append(args, price)
+	}
+	if len(setParts) == 0 {
+		a.apiWriteError(w, http.StatusBadRequest, "no_fields_to_update")
+		return
+	}
+	if format, ok := req["format"].(string); ok {
+		var other int
+		err := a.DB.QueryRow(
+			`SELECT c2.id FROM work_copies c1 JOIN work_copies c2 ON c2.work_id = c1.work_id AND c2.volume = c1.volume
+			 WHERE c1.id = ? AND c1.user_id = ? AND c2.format = ? AND c2.id <> c1.id`,
+			copyID, userID, format,
+		).Scan(&other)
+		if err == nil {
+			a.apiWriteError(w, http.StatusConflict, "duplicate_copy")
+			return
+		}
+	}
+	args = append(args, copyID, workID, userID)
+	res, err := a.DB.Exec(`UPDATE work_copies SET `+strings.Join(setParts, ", ")+` WHERE id = ? AND work_id = ? AND user_id = ?`, args...)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	copies, err := a.listWorkCopies(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	for _, c := range copies {
+		if c.ID == copyID {
+			a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": c})
+			return
+		}
+	}
+	a.apiWriteError(w, http.StatusNotFound, "not_found")
+}
+
+type collectionWork struct {
+	WorkID  int               `json:"work_id"`
+	Title   string            `json:"title"`
+	Summary collectionSummary `json:"summary"`
+}
+
+type collectionTotals struct {
+	Works          int     `json:"collection_works"`
+	Copies         int     `json:"owned_copies"`
+	Paperback      int     `json:"owned_paperback"`
+	Digital        int     `json:"owned_digital"`
+	MissingVolumes int     `json:"missing_volumes"`
+	TotalSpent     float64 `json:"collection_spent"`
+}
+
+// collectionReport lists every work with at least one owned copy, by title, plus the totals.
+func (a *App) collectionReport(userID int) ([]collectionWork, collectionTotals) {
+	var totals collectionTotals
+	byWork := a.workCopiesByWork(userID)
+	if len(byWork) == 0 {
+		return nil, totals
+	}
+	rows, err := a.DB.Query(`SELECT id, title, COALESCE(volume, 0) FROM works WHERE user_id = ? ORDER BY LOWER(title), id`, userID)
+	if err != nil {
+		log.Printf("collection report (user %d): %v", userID, err)
+		return nil, totals
+	}
+	defer func() { _ = rows.Close() }()
+	var out []collectionWork
+	for rows.Next() {
+		var cw collectionWork
+		var volume float64
+		if rows.Scan(&cw.WorkID, &cw.Title, &volume) != nil || len(byWork[cw.WorkID]) == 0 {
+			continue
+		}
+		cw.Summary = summarizeCollection(byWork[cw.WorkID], volume)
+		totals.Works++
+		totals.Copies += cw.Summary.Paperback + cw.Summary.Digital
+		totals.Paperback += cw.Summary.Paperback
+		totals.Digital += cw.Summary.Digital
+		totals.MissingVolumes += cw.Summary.MissingCount
+		totals.TotalSpent += cw.Summary.TotalSpent
+		out = append(out, cw)
+	}
+	totals.TotalSpent = math.Round(totals.TotalSpent*100) / 100
+	return out, totals
+}
+
+// HandleAPICollectionMissing serves GET /api/collection/missing: works with gaps in the owned volumes.
+func (a *App) HandleAPICollectionMissing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	works, _ := a.collectionReport(userID)
+	out := []collectionWork{}
+	for _, cw := range works {
+		if cw.Summary.MissingCount > 0 {
+			out = append(out, cw)
+		}
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
+// HandleCollectionPage renders /collection: owned volumes per work with the missing-volumes report.
+func (a *App) HandleCollectionPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	works, totals := a.collectionReport(userID)
+	missingOnly := r.URL.Query().Get("missing") == "1"
+	if missingOnly {
+		filtered := works[:0]
+		for _, cw := range works {
+			if cw.Summary.MissingCount > 0 {
+				filtered = append(filtered, cw)
+			}
+		}
+		works = filtered
+	}
+	lang := a.currentLang(r)
+	a.renderTemplate(w, r, "collection", a.mergeData(r, map[string]any{
+		"CollectionWorks":   works,
+		"Totals":            totals,
+		"MissingOnly":       missingOnly,
+		"MobileTopbarTitle": i18n.T(lang)["collection.title"],
+	}))
+}
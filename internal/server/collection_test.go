@@ -0,0 +1,224 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeRanges(t *testing.T) {
+	vols, err := parseVolumeRanges(" 3-5, 1, 4 ,10")
+	if err != nil || formatVolumeRanges(vols) != "1, 3-5, 10" || len(vols) != 5 {
+		t.Fatalf("vols=%v err=%v", vols, err)
+	}
+	for _, bad := range []string{"", "0", "5-3", "a-b", "1-600", "-2"} {
+		if _, err := parseVolumeRanges(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	cases := map[string]string{
+		"978-2-7560-9876-5": "",
+		"978-1-4215-8025-8": "9781421580258",
+		"0-306-40615-2":     "0306406152",
+		"080442957X":        "080442957X",
+		"":                  "",
+	}
+	for in, want := range cases {
+		got, err := normalizeISBN(in)
+		if want == "" && in != "" {
+			if err == nil {
+				t.Fatalf("%q: expected checksum error, got %q", in, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Fatalf("%q: got %q err=%v", in, got, err)
+		}
+	}
+}
+
+func collectionRequest(t *testing.T, app *App, session, method string, workID int, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.Itoa(workID)
+	req := httptest.NewRequest(method, "/api/works/"+id+"/collection", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkCollection(rec, req)
+	return rec
+}
+
+func TestHandleAPIWorkCollection_addSummaryAndMissing(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Owned", 0)
+	if _, err := db.Exec(`UPDATE works SET volume = 16 WHERE id = ?`, workID); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := collectionRequest(t, app, session, http.MethodPost, workID, `{"volumes": "1-12, 14", "price": 6.999, "purchased_at": "2026-02-03"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	rec = collectionRequest(t, app, session, http.MethodPost, workID, `{"volumes": "14", "format": "digital", "isbn": "978-1-4215-8025-8"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add digital status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data struct {
+			Copies  []workCopy        `json:"copies"`
+			Summary collectionSummary `json:"summary"`
+			Added   int               `json:"added"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	sum := payload.Data.Summary
+	if payload.Data.Added != 1 || len(payload.Data.Copies) != 14 || sum.Owned != "1-12, 14" || sum.Missing != "13, 15-16" ||
+		sum.Paperback != 13 || sum.Digital != 1 || sum.TotalSpent != 91 {
+		t.Fatalf("added=%d summary=%+v", payload.Data.Added, sum)
+	}
+
+	for _, body := range []string{`{"volumes": "1-3", "isbn": "9781421580258"}`, `{"volumes": "2", "format": "vinyl"}`, `{"volumes": "2", "price": -1}`, `{"volumes": "2", "purchased_at": "03/02/2026"}`} {
+		if rec := collectionRequest(t, app, session, http.MethodPost, workID, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s status=%d", body, rec.Code)
+		}
+	}
+	if rec := collectionRequest(t, app, session, http.MethodGet, 999, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing work status=%d", rec.Code)
+	}
+
+	missing := httptest.NewRequest(http.MethodGet, "/api/collection/missing", nil)
+	missing.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec = httptest.NewRecorder()
+	app.HandleAPICollectionMissing(rec, missing)
+	var report struct {
+		Data []collectionWork `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Data) != 1 || report.Data[0].Summary.MissingCount != 3 {
+		t.Fatalf("missing report=%+v", report.Data)
+	}
+}
+
+func TestHandleAPIWorkCopy_patchAndDelete(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Copies", 0)
+	if _, err := app.addWorkCopies(1, workID, []int{1}, copyFormatPaperback, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.addWorkCopies(1, workID, []int{1}, copyFormatDigital, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	copies, _ := app.listWorkCopies(1, workID)
+	var digitalID int
+	for _, c := range copies {
+		if c.Format == copyFormatDigital {
+			digitalID = c.ID
+		}
+	}
+	id, copyID := strconv.Itoa(workID), strconv.Itoa(digitalID)
+
+	call := func(method, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/works/"+id+"/collection/"+copyID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", id)
+		req.SetPathValue("copyID", copyID)
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorkCopy(rec, req)
+		return rec
+	}
+	rec := call(http.MethodPatch, `{"isbn": "0-306-40615-2", "price": 12.5, "purchased_at": "2025-12-24"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data workCopy `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.ISBN != "0306406152" || payload.Data.Price == nil || *payload.Data.Price != 12.5 || payload.Data.PurchasedAt != "2025-12-24" {
+		t.Fatalf("patched copy=%+v", payload.Data)
+	}
+	if rec := call(http.MethodPatch, `{"format": "paperback"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate format status=%d", rec.Code)
+	}
+	if rec := call(http.MethodPatch, `{"price": null}`); rec.Code != http.StatusOK {
+		t.Fatalf("clear price status=%d", rec.Code)
+	}
+	if rec := call(http.MethodDelete, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status=%d", rec.Code)
+	}
+	if rec := call(http.MethodDelete, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete status=%d", rec.Code)
+	}
+}
+
+func TestCollection_exportImportRoundTrip(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Shelf", 0)
+	price := 9.9
+	if _, err := app.addWorkCopies(1, workID, []int{1, 2}, copyFormatPaperback, "", "2024-06-01", price); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=json", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleExport(rec, req)
+	data := rec.Body.Bytes()
+	var payload struct {
+		ExportVersion int          `json:"export_version"`
+		Works         []exportWork `json:"works"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ExportVersion != 2 || len(payload.Works) != 1 || len(payload.Works[0].Collection) != 2 {
+		t.Fatalf("export=%+v", payload)
+	}
+
+	if _, err := db.Exec(`DELETE FROM works WHERE user_id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	imp := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(string(data)))
+	imp.Header.Set("Content-Type", "application/json")
+	imp.AddCookie(&http.Cookie{Name: "session", Value: session})
+	app.HandleImport(httptest.NewRecorder(), imp)
+	if err := db.QueryRow(`SELECT id FROM works WHERE user_id = 1 AND title = 'Shelf'`).Scan(&workID); err != nil {
+		t.Fatal(err)
+	}
+	copies, err := app.listWorkCopies(1, workID)
+	if err != nil || len(copies) != 2 || copies[0].PurchasedAt != "2024-06-01" || copies[0].Price == nil || *copies[0].Price != 9.9 {
+		t.Fatalf("imported copies=%+v err=%v", copies, err)
+	}
+
+	// A file written by a newer version is refused rather than partially imported.
+	newer := strings.Replace(string(data), `"export_version": 2`, `"export_version": 99`, 1)
+	imp = httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(newer))
+	imp.Header.Set("Content-Type", "application/json")
+	imp.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec = httptest.NewRecorder()
+	app.HandleImport(rec, imp)
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=import") {
+		t.Fatalf("newer export location=%q", loc)
+	}
+}
@@ -148,17 +148,16 @@ func (a *App) handleCSVImportConfirm(w http.ResponseWriter, r *http.Request, use
 		if title == "" {
 			continue
 		}
-		ch := 0
+		ch := 0.0
 		if chCol >= 0 && chCol < len(row) {
-			ch, _ = strconv.Atoi(strings.TrimSpace(row[chCol]))
-			ch = clampChapter(ch)
+			ch = clampChapter(parseProgressValue(row[chCol]))
 		}
 		status := ""
 		if stCol >= 0 && stCol < len(row) {
 			status = normalizeStatusForWrite(strings.TrimSpace(row[stCol]))
 		}
 		if status == "" {
-			status = normalizeStatusForWrite("En cours")
+			status = statusReading
 		}
 		rt := ""
 		if rtCol >= 0 && rtCol < len(row) {
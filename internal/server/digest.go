@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bookstorage/internal/i18n"
+	"bookstorage/internal/mail"
+)
+
+// Digest frequencies stored in users.digest_frequency (NULL or "" means no digest).
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+)
+
+// digestSlack lets an hourly scheduler send a "daily" digest at roughly the same hour every day
+// instead of drifting one tick later each time.
+const digestSlack = 30 * time.Minute
+
+func digestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case digestDaily:
+		return 24 * time.Hour
+	case digestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// StartDigestScheduler launches a goroutine that sends due reading digests every interval.
+// It does nothing while outbound mail is not configured. It stops when ctx is cancelled.
+func (a *App) StartDigestScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[digest] recovered from panic: %v — restarting in 30s", r)
+				time.Sleep(30 * time.Second)
+				a.StartDigestScheduler(ctx, interval)
+			}
+		}()
+
+		log.Printf("[digest] started — interval %v", interval)
+
+		a.SendDueDigests(ctx, time.Now().UTC())
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("[digest] stopped (context cancelled)")
+				return
+			case <-ticker.C:
+				a.SendDueDigests(ctx, time.Now().UTC())
+			}
+		}
+	}()
+}
+
+type digestRecipient struct {
+	UserID    int
+	Name      string
+	Email     string
+	Frequency string
+	Lang      string
+	Token     string
+	SentAt    nullFlexTime
+}
+
+// SendDueDigests emails every opted-in user whose period has elapsed since their last digest
+// and returns how many emails were queued. Users with nothing to report are skipped but their
+// period still restarts.
+func (a *App) SendDueDigests(ctx context.Context, now time.Time) int {
+	if a.Settings == nil || !a.Settings.MailConfigured() {
+		return 0
+	}
+	rows, err := a.DB.Query(
+		`SELECT id, COALESCE(NULLIF(display_name, ''), username), email, digest_frequency, COALESCE(digest_lang, ''), COALESCE(digest_token, ''), digest_sent_at
+		 FROM users
+		 WHERE digest_frequency IN (?, ?) AND validated = 1 AND COALESCE(email, '') != '' AND email_verified_at IS NOT NULL AND COALESCE(digest_token, '') != ''`,
+		digestDaily, digestWeekly,
+	)
+	if err != nil {
+		log.Printf("[digest] failed to list recipients: %v", err)
+		return 0
+	}
+	var due []digestRecipient
+	for rows.Next() {
+		var d digestRecipient
+		if err := rows.Scan(&d.UserID, &d.Name, &d.Email, &d.Frequency, &d.Lang, &d.Token, &d.SentAt); err != nil {
+			continue
+		}
+		if last, ok := parseDigestTime(d.SentAt); ok && now.Sub(last) < digestPeriod(d.Frequency)-digestSlack {
+			continue
+		}
+		due = append(due, d)
+	}
+	_ = rows.Close()
+	if len(due) == 0 {
+		return 0
+	}
+
+	sender := a.mailOutbox(mailOutboxDefaultTTL)
+	sent := 0
+	for _, d := range due {
+		select {
+		case <-ctx.Done():
+			return sent
+		default:
+		}
+		since, ok := parseDigestTime(d.SentAt)
+		if !ok {
+			since = now.Add(-digestPeriod(d.Frequency))
+		}
+		sections := a.buildDigestSections(d.UserID, i18n.T(d.Lang), since, now)
+		if len(sections) > 0 {
+			if err := a.sendDigestEmail(ctx, sender, d, sections); err != nil {
+				// Retried on the next tick: digest_sent_at is left untouched.
+				log.Printf("[digest] user %d: %v", d.UserID, err)
+				continue
+			}
+			sent++
+		}
+		_, _ = a.DB.Exec(`UPDATE users SET digest_sent_at = ? WHERE id = ?`, now.Format("2006-01-02 15:04:05"), d.UserID)
+	}
+	if sent > 0 {
+		log.Printf("[digest] queued %d digests", sent)
+	}
+	return sent
+}
+
+func parseDigestTime(v nullFlexTime) (time.Time, bool) {
+	if !v.Valid {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, v.String); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// buildDigestSections gathers what happened in the user's library between since and now:
+// chapters read (reading_activity_daily, whole UTC days), works finished, followed works with
+// new chapters, reading sites currently down (probe_status) and failing work links
+// (link_probe_status). Empty sections are left out.
+func (a *App) buildDigestSections(userID int, tr i18n.Translations, since, now time.Time) []mail.DigestSection {
+	var sections []mail.DigestSection
+	add := func(titleKey string, items []string) {
+		if len(items) > 0 {
+			sections = append(sections, mail.DigestSection{Title: tr[titleKey], Items: items})
+		}
+	}
+
+	var chapters int
+	_ = a.DB.QueryRow(
+		`SELECT COALESCE(SUM(chapter_increments), 0) FROM reading_activity_daily WHERE user_id = ? AND day >= ? AND day < ?`,
+		userID, since.Format("2006-01-02"), now.Format("2006-01-02"),
+	).Scan(&chapters)
+	if chapters > 0 {
+		add("mail.digest.section.activity", []string{fmt.Sprintf(tr["mail.digest.chapters_read"], chapters)})
+	}
+
+	add("mail.digest.section.finished", a.digestStrings(
+		`SELECT title FROM works WHERE user_id = ? AND finished_at >= ? AND finished_at < ? ORDER BY finished_at`,
+		userID, since.Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"),
+	))
+
+	var behind []string
+	if rows, err := a.DB.Query(`SELECT `+sqlWorkRowFull+` FROM works WHERE user_id = ? AND status = ? ORDER BY title`, userID, statusReading); err == nil {
+		for rows.Next() {
+			var wr workRow
+			if scanFullWorkRow(&wr, rows) != nil {
+				continue
+			}
+			if n := wr.ChaptersBehind(); n > 0 {
+				behind = append(behind, fmt.Sprintf(tr["mail.digest.new_chapters_item"], wr.Title, n))
+			}
+		}
+		_ = rows.Close()
+	}
+	add("mail.digest.section.new_chapters", behind)
+
+	add("mail.digest.section.sites_down", a.digestStrings(
+		`SELECT name FROM reading_sites WHERE user_id = ? AND probe_status IN (?, ?) ORDER BY name`,
+		userID, string(ProbeStatusDown), string(ProbeStatusDegraded),
+	))
+	add("mail.digest.section.links_failing", a.digestStrings(
+		`SELECT title FROM works WHERE user_id = ? AND status = ? AND COALESCE(link, '') != '' AND link_probe_status IN (?, ?) ORDER BY title`,
+		userID, statusReading, string(ProbeStatusDown), string(ProbeStatusDegraded),
+	))
+	return sections
+}
+
+func (a *App) digestStrings(query string, args ...any) []string {
+	rows, err := a.DB.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+	var out []string
+	for rows.Next() {
+		var s string
+		if rows.Scan(&s) == nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func digestUnsubscribeURL(origin, token string) string {
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	return origin + "/digest/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+func (a *App) sendDigestEmail(ctx context.Context, sender mail.Sender, d digestRecipient, sections []mail.DigestSection) error {
+	branding, footer := a.mailBranding()
+	tr := i18n.T(d.Lang)
+	content := mail.DigestContent{
+		Subject:     fmt.Sprintf(tr["mail.digest.subject."+d.Frequency], branding.SiteName),
+		Greeting:    fmt.Sprintf(tr["mail.digest.greeting"], d.Name),
+		Intro:       tr["mail.digest.intro."+d.Frequency],
+		Sections:    sections,
+		Button:      tr["mail.digest.button"],
+		Unsubscribe: tr["mail.digest.unsubscribe"],
+		Footer:      footer,
+	}
+	dashboardLink := strings.TrimRight(strings.TrimSpace(a.Settings.PublicOrigin), "/") + "/dashboard"
+	unsubscribeLink := digestUnsubscribeURL(a.Settings.PublicOrigin, d.Token)
+	return sender.Send(ctx, mail.Message{
+		To:       d.Email,
+		Subject:  content.Subject,
+		TextBody: mail.BuildDigestText(content, dashboardLink, unsubscribeLink),
+		HTMLBody: mail.BuildDigestHTML(content, branding, dashboardLink, unsubscribeLink),
+		CustomID: fmt.Sprintf("digest-%d-%s", d.UserID, time.Now().UTC().Format("20060102")),
+	})
+}
+
+// HandleProfileDigest saves the digest preference (POST frequency = "", daily or weekly).
+// The first digest goes out one full period after opting in.
+func (a *App) HandleProfileDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	frequency := strings.TrimSpace(r.FormValue("frequency"))
+	if digestPeriod(frequency) == 0 {
+		_, _ = a.DB.Exec(`UPDATE users SET digest_frequency = NULL WHERE id = ?`, userID)
+		http.Redirect(w, r, "/profile?tab=account&digest=saved", http.StatusFound)
+		return
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		http.Redirect(w, r, "/profile?tab=account&digest=error", http.StatusFound)
+		return
+	}
+	// Keep an existing unsubscribe token (links in digests already sent stay valid) and only
+	// restart the period when the digest was off.
+	if _, err := a.DB.Exec(
+		`UPDATE users SET
+			digest_sent_at = CASE WHEN COALESCE(digest_frequency, '') = '' THEN ? ELSE digest_sent_at END,
+			digest_frequency = ?, digest_lang = ?, digest_token = COALESCE(NULLIF(digest_token, ''), ?)
+		 WHERE id = ?`,
+		time.Now().UTC().Format("2006-01-02 15:04:05"), frequency, a.currentLang(r), token, userID,
+	); err != nil {
+		http.Redirect(w, r, "/profile?tab=account&digest=error", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/profile?tab=account&digest=saved", http.StatusFound)
+}
+
+// HandleDigestUnsubscribe serves the unsubscribe link of digest emails without requiring a
+// login: GET asks for confirmation (mail scanners prefetch links), POST turns the digest off.
+func (a *App) HandleDigestUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(r.FormValue("token"))
+	var userID int
+	if token != "" {
+		_ = a.DB.QueryRow(`SELECT id FROM users WHERE digest_token = ?`, token).Scan(&userID)
+	}
+	data := map[string]any{
+		"DigestToken":   token,
+		"DigestInvalid": userID == 0,
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if userID != 0 {
+			_, _ = a.DB.Exec(`UPDATE users SET digest_frequency = NULL WHERE id = ?`, userID)
+			data["DigestUnsubscribed"] = true
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	a.renderTemplate(w, r, "digest_unsubscribe", a.mergeData(r, data))
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bookstorage/internal/mail"
+)
+
+func TestSendDueDigests_weeklyThenUnsubscribe(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	tpl := template.Must(template.New("").Parse(`{{ define "digest_unsubscribe" }}{{ if .DigestUnsubscribed }}done{{ else }}confirm{{ end }}{{ end }}`))
+	app := &App{Settings: s, DB: db, TemplatesWeb: tpl, TemplatesMobile: tpl}
+
+	session := mustCreateSession(t, app, 1)
+	if _, err := db.Exec(`UPDATE users SET email = 'reader@example.com', email_verified_at = CURRENT_TIMESTAMP, validated = 1 WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"frequency": {"weekly"}}
+	req := httptest.NewRequest(http.MethodPost, "/profile/digest", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleProfileDigest(rec, req)
+	if loc := rec.Header().Get("Location"); loc != "/profile?tab=account&digest=saved" {
+		t.Fatalf("redirect=%q", loc)
+	}
+
+	var messages []mail.Message
+	mail.SetSendHook(func(_ context.Context, msg mail.Message) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	t.Cleanup(func() { mail.SetSendHook(nil) })
+
+	now := time.Now().UTC()
+	if n := app.SendDueDigests(context.Background(), now); n != 0 {
+		t.Fatalf("digest sent %d emails right after opting in", n)
+	}
+
+	lastWeek := now.Add(-7 * 24 * time.Hour)
+	if _, err := db.Exec(`UPDATE users SET digest_sent_at = ? WHERE id = 1`, lastWeek.Format("2006-01-02 15:04:05")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO reading_activity_daily (user_id, day, chapter_increments) VALUES (1, ?, 12)`, now.Add(-48*time.Hour).Format("2006-01-02")); err != nil {
+		t.Fatal(err)
+	}
+	workID := insertTestWork(t, app, "Vinland Saga", 54)
+	if _, err := db.Exec(`UPDATE works SET status = ?, finished_at = ? WHERE id = ?`, statusCompleted, now.Add(-time.Hour).Format("2006-01-02 15:04:05"), workID); err != nil {
+		t.Fatal(err)
+	}
+
+	n := app.SendDueDigests(context.Background(), now)
+	app.runMailOutboxCycle(context.Background())
+	if n != 1 || len(messages) != 1 {
+		t.Fatalf("sent=%d messages=%d", n, len(messages))
+	}
+	msg := messages[0]
+	if msg.To != "reader@example.com" || !strings.Contains(msg.TextBody, "12") || !strings.Contains(msg.TextBody, "- Vinland Saga") {
+		t.Fatalf("digest: to=%q\n%s", msg.To, msg.TextBody)
+	}
+	if n := app.SendDueDigests(context.Background(), now.Add(time.Hour)); n != 0 {
+		t.Fatalf("digest resent %d emails within the same week", n)
+	}
+
+	var token string
+	if err := db.QueryRow(`SELECT digest_token FROM users WHERE id = 1`).Scan(&token); err != nil || token == "" {
+		t.Fatalf("token=%q err=%v", token, err)
+	}
+	if !strings.Contains(msg.HTMLBody, "/digest/unsubscribe?token="+url.QueryEscape(token)) {
+		t.Fatal("digest is missing the unsubscribe link")
+	}
+
+	// The unsubscribe link works without a session; GET only asks for confirmation.
+	rec = httptest.NewRecorder()
+	app.HandleDigestUnsubscribe(rec, httptest.NewRequest(http.MethodGet, "/digest/unsubscribe?token="+url.QueryEscape(token), nil))
+	if rec.Body.String() != "confirm" {
+		t.Fatalf("GET body=%q", rec.Body.String())
+	}
+	var frequency string
+	_ = db.QueryRow(`SELECT COALESCE(digest_frequency, '') FROM users WHERE id = 1`).Scan(&frequency)
+	if frequency != digestWeekly {
+		t.Fatalf("GET unsubscribed the user: frequency=%q", frequency)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/digest/unsubscribe", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	app.HandleDigestUnsubscribe(rec, req)
+	if rec.Body.String() != "done" {
+		t.Fatalf("POST body=%q", rec.Body.String())
+	}
+	_ = db.QueryRow(`SELECT COALESCE(digest_frequency, '') FROM users WHERE id = 1`).Scan(&frequency)
+	if frequency != "" {
+		t.Fatalf("frequency=%q after unsubscribe", frequency)
+	}
+}
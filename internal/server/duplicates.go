@@ -150,10 +150,8 @@ func (a *App) HandleMergeDuplicate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mergedChapter := into.Chapter
-	if from.Chapter > mergedChapter {
-		mergedChapter = from.Chapter
-	}
+	mergedChapter := max(into.Chapter, from.Chapter)
+	mergedVolume := max(into.Volume, from.Volume)
 	mergedRating := into.Rating
 	if from.Rating > mergedRating {
 		mergedRating = from.Rating
@@ -191,9 +189,10 @@ func (a *App) HandleMergeDuplicate(w http.ResponseWriter, r *http.Request) {
 	now := time.Now().UTC()
 	_, err = tx.Exec(
 		`UPDATE works
-		 SET chapter = ?, link = ?, status = ?, image_path = ?, rating = ?, notes = ?, reading_site_id = ?, updated_at = ?
+		 SET chapter = ?, volume = ?, link = ?, status = ?, image_path = ?, rating = ?, notes = ?, reading_site_id = ?, updated_at = ?
 		 WHERE id = ? AND user_id = ?`,
 		mergedChapter,
+		mergedVolume,
 		nullStringOrNil(mergedLink),
 		nullStringOrNil(mergedStatus),
 		nullStringOrNil(mergedImage),
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/i18n"
+	"bookstorage/internal/mail"
+)
+
+// emailVerificationTTL bounds how long a verification link stays valid.
+const emailVerificationTTL = 48 * time.Hour
+
+// signEmailVerificationToken returns a stateless link token binding userID to email until exp,
+// signed with the server secret. Changing the address again invalidates earlier links.
+func (a *App) signEmailVerificationToken(userID int, email string, exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(userID) + "|" + strconv.FormatInt(exp.Unix(), 10) + "|" + email))
+	return payload + "." + a.emailVerificationMAC(payload)
+}
+
+func (a *App) emailVerificationMAC(payload string) string {
+	mac := hmac.New(sha256.New, []byte("email-verification:"+a.Settings.SecretKey))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseEmailVerificationToken checks the signature and expiry and returns the bound user and address.
+func (a *App) parseEmailVerificationToken(token string, now time.Time) (userID int, email string, ok bool) {
+	payload, sig, found := strings.Cut(strings.TrimSpace(token), ".")
+	if !found || payload == "" || !hmac.Equal([]byte(sig), []byte(a.emailVerificationMAC(payload))) {
+		return 0, "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, "", false
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return 0, "", false
+	}
+	userID, err = strconv.Atoi(parts[0])
+	if err != nil || userID <= 0 {
+		return 0, "", false
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > exp {
+		return 0, "", false
+	}
+	return userID, parts[2], parts[2] != ""
+}
+
+func emailVerificationURL(origin, token string) string {
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	return origin + "/verify-email?token=" + url.QueryEscape(token)
+}
+
+// sendEmailVerification queues a verification link for email (the account address or a pending change).
+func (a *App) sendEmailVerification(ctx context.Context, userID int, email, lang string) error {
+	if a.Settings == nil || !a.Settings.MailConfigured() {
+		return fmt.Errorf("mail not configured")
+	}
+	branding, footer := a.mailBranding()
+	tr := i18n.T(lang)
+	content := mail.EmailVerificationContent{
+		Subject:  fmt.Sprintf(tr["mail.verify_email.subject"], branding.SiteName),
+		Greeting: tr["mail.verify_email.greeting"],
+		Body:     fmt.Sprintf(tr["mail.verify_email.body"], branding.SiteName),
+		Button:   tr["mail.verify_email.button"],
+		Expiry:   tr["mail.verify_email.expiry"],
+		Ignore:   tr["mail.verify_email.ignore"],
+		Footer:   footer,
+	}
+	token := a.signEmailVerificationToken(userID, email, time.Now().UTC().Add(emailVerificationTTL))
+	link := emailVerificationURL(a.Settings.PublicOrigin, token)
+	return a.mailOutbox(emailVerificationTTL).Send(ctx, mail.Message{
+		To:       email,
+		Subject:  content.Subject,
+		TextBody: mail.BuildEmailVerificationText(content, link),
+		HTMLBody: mail.BuildEmailVerificationHTML(content, branding, link),
+		CustomID: fmt.Sprintf("verify-email-%d", userID),
+	})
+}
+
+// sendEmailChangeNotice tells the previous address that a change to newEmail was requested.
+func (a *App) sendEmailChangeNotice(ctx context.Context, oldEmail, newEmail, lang string) error {
+	if a.Settings == nil || !a.Settings.MailConfigured() {
+		return fmt.Errorf("mail not configured")
+	}
+	branding, footer := a.mailBranding()
+	tr := i18n.T(lang)
+	content := mail.EmailChangeNoticeContent{
+		Subject:  fmt.Sprintf(tr["mail.email_change.subject"], branding.SiteName),
+		Greeting: tr["mail.email_change.greeting"],
+		Body:     fmt.Sprintf(tr["mail.email_change.body"], branding.SiteName, newEmail),
+		Warning:  tr["mail.email_change.warning"],
+		Footer:   footer,
+	}
+	return a.mailOutbox(mailOutboxDefaultTTL).Send(ctx, mail.Message{
+		To:       oldEmail,
+		Subject:  content.Subject,
+		TextBody: mail.BuildEmailChangeNoticeText(content),
+		HTMLBody: mail.BuildEmailChangeNoticeHTML(content, branding),
+		CustomID: "email-change-notice",
+	})
+}
+
+// requestEmailChange records newEmail for userID. With mail configured the current address
+// stays in place until the new one is confirmed (and the current one is told about the request);
+// without mail the address is replaced directly and stays unverified.
+// It reports whether a confirmation is now pending.
+func (a *App) requestEmailChange(ctx context.Context, userID int, oldEmail, newEmail, lang string) (bool, error) {
+	if a.Settings == nil || !a.Settings.MailConfigured() {
+		_, err := a.DB.Exec(
+			`UPDATE users SET email = ?, email_verified_at = NULL, pending_email = NULL WHERE id = ?`,
+			newEmail, userID,
+		)
+		return false, err
+	}
+	if _, err := a.DB.Exec(`UPDATE users SET pending_email = ? WHERE id = ?`, newEmail, userID); err != nil {
+		return false, err
+	}
+	if err := a.sendEmailVerification(ctx, userID, newEmail, lang); err != nil {
+		log.Printf("email verification for user %d: %v", userID, err)
+	}
+	if oldEmail != "" {
+		if err := a.sendEmailChangeNotice(ctx, oldEmail, newEmail, lang); err != nil {
+			log.Printf("email change notice for user %d: %v", userID, err)
+		}
+	}
+	return true, nil
+}
+
+// applyEmailVerification marks the address bound in token as verified: either the current
+// account address or a pending change, which then replaces it. Reset links sent to the
+// previous address are revoked on a change.
+func (a *App) applyEmailVerification(token string) (int, bool) {
+	userID, email, ok := a.parseEmailVerificationToken(token, time.Now().UTC())
+	if !ok {
+		return 0, false
+	}
+	var current, pending sql.NullString
+	if err := a.DB.QueryRow(`SELECT email, pending_email FROM users WHERE id = ?`, userID).Scan(&current, &pending); err != nil {
+		return 0, false
+	}
+	now := time.Now().UTC()
+	switch email {
+	case normalizeAccountEmail(pending.String):
+		if _, err := a.DB.Exec(
+			`UPDATE users SET email = ?, pending_email = NULL, email_verified_at = ? WHERE id = ?`,
+			email, now, userID,
+		); err != nil {
+			return 0, false
+		}
+		a.invalidatePasswordResetTokensForUser(userID)
+	case normalizeAccountEmail(current.String):
+		if _, err := a.DB.Exec(
+			`UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?) WHERE id = ?`,
+			now, userID,
+		); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return userID, true
+}
+
+// HandleVerifyEmail serves the link of verification emails (GET /verify-email?token=).
+func (a *App) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	_, verified := a.applyEmailVerification(r.URL.Query().Get("token"))
+	flag := "email_verified=1"
+	if !verified {
+		flag = "email_verified=invalid"
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if _, ok := a.currentUserID(r); ok {
+		http.Redirect(w, r, "/profile?tab=account&"+flag, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/login?"+flag, http.StatusFound)
+}
+
+// HandleProfileEmailResend sends a new link for the pending address, or for the current
+// address while it is unverified (POST /profile/email/resend).
+func (a *App) HandleProfileEmailResend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	var email, pending sql.NullString
+	var verifiedAt nullFlexTime
+	if err := a.DB.QueryRow(
+		`SELECT email, pending_email, email_verified_at FROM users WHERE id = ?`, userID,
+	).Scan(&email, &pending, &verifiedAt); err != nil {
+		http.Redirect(w, r, "/profile?tab=account", http.StatusFound)
+		return
+	}
+	target := strings.TrimSpace(pending.String)
+	if target == "" && !verifiedAt.Valid {
+		target = strings.TrimSpace(email.String)
+	}
+	if target == "" || !a.Settings.MailConfigured() {
+		http.Redirect(w, r, "/profile?tab=account", http.StatusFound)
+		return
+	}
+	if err := a.sendEmailVerification(r.Context(), userID, target, a.currentLang(r)); err != nil {
+		log.Printf("email verification for user %d: %v", userID, err)
+		http.Redirect(w, r, "/profile?tab=account&email_verify=error", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/profile?tab=account&email_verify=sent", http.StatusFound)
+}
+
+// HandleProfileEmailCancel drops a pending address change (POST /profile/email/cancel).
+func (a *App) HandleProfileEmailCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	_, _ = a.DB.Exec(`UPDATE users SET pending_email = NULL WHERE id = ?`, userID)
+	http.Redirect(w, r, "/profile?tab=account", http.StatusFound)
+}
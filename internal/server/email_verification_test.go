@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bookstorage/internal/mail"
+)
+
+// captureMail records messages delivered by the outbox worker.
+func captureMail(t *testing.T) *[]mail.Message {
+	t.Helper()
+	var sent []mail.Message
+	mail.SetSendHook(func(_ context.Context, msg mail.Message) error {
+		sent = append(sent, msg)
+		return nil
+	})
+	t.Cleanup(func() { mail.SetSendHook(nil) })
+	return &sent
+}
+
+func verificationTokenFrom(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	const prefix = "https://books.example.com/verify-email?token="
+	for _, line := range strings.Split(msg.TextBody, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			token, _ := url.QueryUnescape(strings.TrimPrefix(line, prefix))
+			return token
+		}
+	}
+	t.Fatalf("no verification link in %q", msg.TextBody)
+	return ""
+}
+
+func TestEmailVerificationToken(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	now := time.Now().UTC()
+	token := app.signEmailVerificationToken(7, "reader@example.com", now.Add(time.Hour))
+
+	if uid, email, ok := app.parseEmailVerificationToken(token, now); !ok || uid != 7 || email != "reader@example.com" {
+		t.Fatalf("uid=%d email=%q ok=%v", uid, email, ok)
+	}
+	if _, _, ok := app.parseEmailVerificationToken(token, now.Add(2*time.Hour)); ok {
+		t.Fatal("expired token accepted")
+	}
+	forged := app.signEmailVerificationToken(7, "attacker@example.com", now.Add(time.Hour))
+	payload, _, _ := strings.Cut(forged, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	if _, _, ok := app.parseEmailVerificationToken(payload+"."+sig, now); ok {
+		t.Fatal("token with swapped payload accepted")
+	}
+	otherSettings := *s
+	otherSettings.SecretKey = "another-secret"
+	other := &App{Settings: &otherSettings, DB: db}
+	if _, _, ok := other.parseEmailVerificationToken(token, now); ok {
+		t.Fatal("token accepted under a different secret")
+	}
+}
+
+func TestHandleRegister_verificationGatesPasswordReset(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	s.RequireAccountValidation = false
+	app := &App{Settings: s, DB: db}
+	sent := captureMail(t)
+
+	form := url.Values{"username": {"newreader"}, "email": {"New.Reader@Example.com"}, "password": {"LongEnough!1"}}
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	app.HandleRegister(rec, req)
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "verify=1") {
+		t.Fatalf("redirect %q", loc)
+	}
+	if users, _ := app.findUsersByEmailForPasswordReset("new.reader@example.com"); len(users) != 0 {
+		t.Fatalf("unverified address trusted for reset: %+v", users)
+	}
+
+	app.runMailOutboxCycle(context.Background())
+	if len(*sent) != 1 || (*sent)[0].To != "new.reader@example.com" {
+		t.Fatalf("sent %+v", *sent)
+	}
+	rec = httptest.NewRecorder()
+	app.HandleVerifyEmail(rec, httptest.NewRequest(http.MethodGet, "/verify-email?token="+url.QueryEscape(verificationTokenFrom(t, (*sent)[0])), nil))
+	if loc := rec.Header().Get("Location"); loc != "/login?email_verified=1" {
+		t.Fatalf("verify redirect %q", loc)
+	}
+	if users, _ := app.findUsersByEmailForPasswordReset("new.reader@example.com"); len(users) != 1 {
+		t.Fatalf("verified address not usable for reset: %+v", users)
+	}
+
+	rec = httptest.NewRecorder()
+	app.HandleVerifyEmail(rec, httptest.NewRequest(http.MethodGet, "/verify-email?token=bogus.token", nil))
+	if loc := rec.Header().Get("Location"); loc != "/login?email_verified=invalid" {
+		t.Fatalf("invalid token redirect %q", loc)
+	}
+}
+
+func TestHandleProfile_emailChangeNeedsConfirmation(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	app := &App{Settings: s, DB: db}
+	sent := captureMail(t)
+
+	if _, err := db.Exec(
+		`INSERT INTO users (id, username, password, validated, is_admin, email, email_verified_at) VALUES (20, 'mover', 'x', 1, 0, 'old@example.com', CURRENT_TIMESTAMP)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	session := mustCreateSession(t, app, 20)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("username", "mover")
+	_ = mw.WriteField("email", "new@example.com")
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/profile", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleProfile(rec, req)
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "email_verify=pending") {
+		t.Fatalf("redirect %q", loc)
+	}
+
+	var email, pending string
+	_ = db.QueryRow(`SELECT email, COALESCE(pending_email, '') FROM users WHERE id = 20`).Scan(&email, &pending)
+	if email != "old@example.com" || pending != "new@example.com" {
+		t.Fatalf("email=%q pending=%q", email, pending)
+	}
+
+	app.runMailOutboxCycle(context.Background())
+	var verifyMsg mail.Message
+	notified := false
+	for _, m := range *sent {
+		switch m.To {
+		case "new@example.com":
+			verifyMsg = m
+		case "old@example.com":
+			notified = strings.Contains(m.TextBody, "new@example.com")
+		}
+	}
+	if verifyMsg.To == "" || !notified {
+		t.Fatalf("sent %+v", *sent)
+	}
+
+	verifyReq := httptest.NewRequest(http.MethodGet, "/verify-email?token="+url.QueryEscape(verificationTokenFrom(t, verifyMsg)), nil)
+	verifyReq.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec = httptest.NewRecorder()
+	app.HandleVerifyEmail(rec, verifyReq)
+	if loc := rec.Header().Get("Location"); loc != "/profile?tab=account&email_verified=1" {
+		t.Fatalf("verify redirect %q", loc)
+	}
+	var verified nullFlexTime
+	_ = db.QueryRow(`SELECT email, COALESCE(pending_email, ''), email_verified_at FROM users WHERE id = 20`).Scan(&email, &pending, &verified)
+	if email != "new@example.com" || pending != "" || !verified.Valid {
+		t.Fatalf("email=%q pending=%q verified=%v", email, pending, verified)
+	}
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reading goals are per-user targets over the current UTC year or month:
+// works_finished counts finished_at on works plus archived reads, chapters_read sums the
+// daily reading rollup (or the progress log when the goal is scoped to one reading type).
+
+const (
+	goalKindWorksFinished = "works_finished"
+	goalKindChaptersRead  = "chapters_read"
+
+	goalPeriodYear  = "year"
+	goalPeriodMonth = "month"
+
+	maxGoalsPerUser = 20
+	maxGoalTarget   = 100000
+)
+
+var validGoalKinds = map[string]bool{goalKindWorksFinished: true, goalKindChaptersRead: true}
+var validGoalPeriods = map[string]bool{goalPeriodYear: true, goalPeriodMonth: true}
+
+type readingGoal struct {
+	ID          int    `json:"id"`
+	Kind        string `json:"kind"`
+	Period      string `json:"period"`
+	Target      int    `json:"target"`
+	ReadingType string `json:"reading_type,omitempty"`
+	PeriodKey   string `json:"period_key"`
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+	Progress    int    `json:"progress"`
+	Percent     int    `json:"percent"`
+	Reached     bool   `json:"reached"`
+	CreatedAt   string `json:"created_at,omitempty"`
+
+	reachedPeriod string
+}
+
+// goalPeriodBounds returns the period key ("2026" or "2026-03") and its UTC days [start, end).
+func goalPeriodBounds(period string, now time.Time) (key, start, end string) {
+	now = now.UTC()
+	if period == goalPeriodMonth {
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first.Format("2006-01"), first.Format("2006-01-02"), first.AddDate(0, 1, 0).Format("2006-01-02")
+	}
+	first := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	return first.Format("2006"), first.Format("2006-01-02"), first.AddDate(1, 0, 0).Format("2006-01-02")
+}
+
+func (a *App) goalDayExpr(col string) string {
+	if a.Settings != nil && a.Settings.UsePostgres() {
+		return `TO_CHAR(` + col + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+	}
+	return `strftime('%Y-%m-%d', ` + col + `)`
+}
+
+func (a *App) listReadingGoals(userID int) ([]readingGoal, error) {
+	rows, err := a.DB.Query(
+		`SELECT id, kind, period, target, COALESCE(reading_type, ''), COALESCE(reached_period, ''), created_at
+		 FROM reading_goals WHERE user_id = ? ORDER BY id`, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []readingGoal
+	for rows.Next() {
+		var g readingGoal
+		var createdAt nullFlexTime
+		if err := rows.Scan(&g.ID, &g.Kind, &g.Period, &g.Target, &g.ReadingType, &g.reachedPeriod, &createdAt); err != nil {
+			return nil, err
+		}
+		g.CreatedAt = createdAt.String
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// fillGoalProgress computes progress for the period containing now.
+func (a *App) fillGoalProgress(userID int, g *readingGoal, now time.Time) {
+	g.PeriodKey, g.PeriodStart, g.PeriodEnd = goalPeriodBounds(g.Period, now)
+	if g.Kind == goalKindChaptersRead {
+		g.Progress = a.goalChaptersRead(userID, g.ReadingType, g.PeriodStart, g.PeriodEnd)
+	} else {
+		g.Progress = a.goalWorksFinished(userID, g.ReadingType, g.PeriodStart, g.PeriodEnd)
+	}
+	g.Reached = g.Target > 0 && g.Progress >= g.Target
+	if g.Target > 0 {
+		g.Percent = min(100, g.Progress*100/g.Target)
+	}
+}
+
+// goalWorksFinished counts current finishes plus finishes archived by a reread, so rereading
+// a book does not take it back out of the yearly challenge.
+func (a *App) goalWorksFinished(userID int, readingType, start, end string) int {
+	typeFilter, args := "", []any{userID, start, end}
+	if readingType != "" {
+		typeFilter, args = ` AND reading_type = ?`, append(args, readingType)
+	}
+	var current, archived int
+	if err := a.DB.QueryRow(
+		`SELECT COUNT(*) FROM works WHERE user_id = ? AND finished_at IS NOT NULL
+		 AND `+a.goalDayExpr("finished_at")+` >= ? AND `+a.goalDayExpr("finished_at")+` < ?`+typeFilter,
+		args...,
+	).Scan(&current); err != nil {
+		log.Printf("goal works_finished (user %d): %v", userID, err)
+	}
+	readsTypeFilter := ""
+	if readingType != "" {
+		readsTypeFilter = ` AND w.reading_type = ?`
+	}
+	if err := a.DB.QueryRow(
+		`SELECT COUNT(*) FROM work_reads r JOIN works w ON w.id = r.work_id
+		 WHERE r.user_id = ? AND r.finished_at IS NOT NULL
+		 AND `+a.goalDayExpr("r.finished_at")+` >= ? AND `+a.goalDayExpr("r.finished_at")+` < ?`+readsTypeFilter,
+		args...,
+	).Scan(&archived); err != nil {
+		log.Printf("goal works_finished reads (user %d): %v", userID, err)
+	}
+	return current + archived
+}
+
+// goalChaptersRead uses reading_activity_daily, which has no reading type; scoped goals sum the
+// progress log instead, in the type's own unit and without imports, rereads or undone moves.
+func (a *App) goalChaptersRead(userID int, readingType, start, end string) int {
+	if readingType == "" {
+		var n int
+		if err := a.DB.QueryRow(
+			`SELECT COALESCE(SUM(chapter_increments), 0) FROM reading_activity_daily WHERE user_id = ? AND day >= ? AND day < ?`,
+			userID, start, end,
+		).Scan(&n); err != nil {
+			log.Printf("goal chapters_read (user %d): %v", userID, err)
+		}
+		return n
+	}
+	var sum float64
+	if err := a.DB.QueryRow(
+		`SELECT COALESCE(SUM(e.chapter_after - e.chapter_before), 0)
+		 FROM work_progress_events e JOIN works w ON w.id = e.work_id
+		 WHERE e.user_id = ? AND w.reading_type = ? AND e.unit = ? AND e.undone_at IS NULL AND e.source NOT IN (?, ?)
+		 AND `+a.goalDayExpr("e.created_at")+` >= ? AND `+a.goalDayExpr("e.created_at")+` < ?`,
+		userID, readingType, progressUnitForReadingType(readingType), progressSourceImport, progressSourceReread, start, end,
+	).Scan(&sum); err != nil {
+		log.Printf("goal chapters_read by type (user %d): %v", userID, err)
+	}
+	return max(0, int(math.Floor(sum)))
+}
+
+// loadGoalsWithProgress returns every goal with its progress and sends goal.reached once per
+// period for goals that have just crossed their target.
+func (a *App) loadGoalsWithProgress(userID int) ([]readingGoal, error) {
+	goals, err := a.listReadingGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range goals {
+		g := &goals[i]
+		a.fillGoalProgress(userID, g, now)
+		if !g.Reached || g.reachedPeriod == g.PeriodKey {
+			continue
+		}
+		res, err := a.DB.Exec(
+			`UPDATE reading_goals SET reached_period = ? WHERE id = ? AND user_id = ? AND (reached_period IS NULL OR reached_period <> ?)`,
+			g.PeriodKey, g.ID, userID, g.PeriodKey,
+		)
+		if err != nil {
+			log.Printf("reading_goals reached (goal %d): %v", g.ID, err)
+			continue
+		}
+		g.reachedPeriod = g.PeriodKey
+		if n, _ := res.RowsAffected(); n > 0 {
+			a.EmitWebhookEvent(userID, webhookEventGoalReached, map[string]any{"goal": *g})
+		}
+	}
+	return goals, nil
+}
+
+// refreshGoals re-evaluates goals after progress or status changes (webhook side effect only).
+func (a *App) refreshGoals(userID int) {
+	if a.DB == nil || userID <= 0 {
+		return
+	}
+	if _, err := a.loadGoalsWithProgress(userID); err != nil {
+		log.Printf("reading goals (user %d): %v", userID, err)
+	}
+}
+
+// HandleAPIGoals serves GET /api/goals (goals with progress) and POST /api/goals.
+// POST body: {"kind": "works_finished", "period": "year", "target": 50, "reading_type": "Manga"}.
+func (a *App) HandleAPIGoals(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Kind        string `json:"kind"`
+			Period      string `json:"period"`
+			Target      int    `json:"target"`
+			ReadingType string `json:"reading_type"`
+		}
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+		kind, period := strings.TrimSpace(req.Kind), strings.TrimSpace(req.Period)
+		if !validGoalKinds[kind] {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_kind")
+			return
+		}
+		if !validGoalPeriods[period] {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_period")
+			return
+		}
+		if req.Target <= 0 || req.Target > maxGoalTarget {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_target")
+			return
+		}
+		var readingType any
+		if rt := strings.TrimSpace(req.ReadingType); rt != "" {
+			rt = normalizeReadingType(rt)
+			if !isValidReadingType(rt) {
+				a.apiWriteError(w, http.StatusBadRequest, "invalid_reading_type")
+				return
+			}
+			readingType = rt
+		}
+		var count int
+		_ = a.DB.QueryRow(`SELECT COUNT(*) FROM reading_goals WHERE user_id = ?`, userID).Scan(&count)
+		if count >= maxGoalsPerUser {
+			a.apiWriteError(w, http.StatusBadRequest, "too_many_goals")
+			return
+		}
+		if _, err := a.DB.Exec(
+			`INSERT INTO reading_goals (user_id, kind, period, target, reading_type) VALUES (?, ?, ?, ?, ?)`,
+			userID, kind, period, req.Target, readingType,
+		); err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+	default:
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+
+	goals, err := a.loadGoalsWithProgress(userID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if goals == nil {
+		goals = []readingGoal{}
+	}
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	a.apiWriteJSON(w, status, map[string]any{"data": goals})
+}
+
+// HandleAPIGoalDelete serves DELETE /api/goals/{id}.
+func (a *App) HandleAPIGoalDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	goalID, _ := strconv.Atoi(r.PathValue("id"))
+	res, err := a.DB.Exec(`DELETE FROM reading_goals WHERE id = ? AND user_id = ?`, goalID, userID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// goalsForPage feeds the dashboard and stats templates; errors only hide the widget.
+func (a *App) goalsForPage(userID int) []readingGoal {
+	goals, err := a.loadGoalsWithProgress(userID)
+	if err != nil {
+		log.Printf("reading goals (user %d): %v", userID, err)
+	}
+	return goals
+}
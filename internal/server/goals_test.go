@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func goalsRequest(t *testing.T, app *App, session, method, body string) (*httptest.ResponseRecorder, []readingGoal) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/goals", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleAPIGoals(rec, req)
+	var payload struct {
+		Data []readingGoal `json:"data"`
+	}
+	if rec.Code == http.StatusOK || rec.Code == http.StatusCreated {
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, payload.Data
+}
+
+func TestGoalPeriodBounds(t *testing.T) {
+	now := time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC)
+	if key, start, end := goalPeriodBounds(goalPeriodYear, now); key != "2026" || start != "2026-01-01" || end != "2027-01-01" {
+		t.Fatalf("year bounds=%s %s %s", key, start, end)
+	}
+	if key, start, end := goalPeriodBounds(goalPeriodMonth, now); key != "2026-12" || start != "2026-12-01" || end != "2027-01-01" {
+		t.Fatalf("month bounds=%s %s %s", key, start, end)
+	}
+}
+
+func TestHandleAPIGoals_progressAndReachedWebhook(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	if _, err := db.Exec(`INSERT INTO webhook_endpoints (user_id, url, secret, events) VALUES (1, 'https://example.com/hook', 'whsec_x', '["goal.reached"]')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec, _ := goalsRequest(t, app, session, http.MethodPost, `{"kind": "pages", "period": "year", "target": 2}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid kind status=%d", rec.Code)
+	}
+	if rec, _ := goalsRequest(t, app, session, http.MethodPost, `{"kind": "works_finished", "period": "year", "target": 0}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid target status=%d", rec.Code)
+	}
+	rec, goals := goalsRequest(t, app, session, http.MethodPost, `{"kind": "works_finished", "period": "year", "target": 2}`)
+	if rec.Code != http.StatusCreated || len(goals) != 1 || goals[0].Progress != 0 || goals[0].Reached {
+		t.Fatalf("create status=%d goals=%+v", rec.Code, goals)
+	}
+	if _, goals = goalsRequest(t, app, session, http.MethodPost, `{"kind": "chapters_read", "period": "month", "target": 10, "reading_type": "webtoon"}`); len(goals) != 2 || goals[1].ReadingType != "Webtoon" {
+		t.Fatalf("scoped goal=%+v", goals)
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	finished := insertTestWork(t, app, "Done this year", 30)
+	if _, err := db.Exec(`UPDATE works SET status = ?, finished_at = ? WHERE id = ?`, statusCompleted, now, finished); err != nil {
+		t.Fatal(err)
+	}
+	old := insertTestWork(t, app, "Done long ago", 5)
+	if _, err := db.Exec(`UPDATE works SET status = ?, finished_at = '2001-05-01 00:00:00' WHERE id = ?`, statusCompleted, old); err != nil {
+		t.Fatal(err)
+	}
+	// A finish archived by a reread still counts toward the year.
+	if _, err := app.startWorkReread(1, finished, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE works SET status = ?, finished_at = ? WHERE id = ?`, statusCompleted, now, finished); err != nil {
+		t.Fatal(err)
+	}
+	webtoon := insertTestWork(t, app, "Webtoon", 0)
+	if _, err := db.Exec(`UPDATE works SET reading_type = 'Webtoon' WHERE id = ?`, webtoon); err != nil {
+		t.Fatal(err)
+	}
+	app.recordWorkProgressEvent(1, webtoon, progressUnitChapter, 0, 4, progressSourceIncrement)
+	app.recordWorkProgressEvent(1, webtoon, progressUnitChapter, 4, 50, progressSourceImport)
+	app.recordWorkProgressEvent(1, finished, progressUnitChapter, 0, 30, progressSourceEdit)
+
+	_, goals = goalsRequest(t, app, session, http.MethodGet, "")
+	if len(goals) != 2 {
+		t.Fatalf("goals=%+v", goals)
+	}
+	if g := goals[0]; g.Progress != 2 || !g.Reached || g.Percent != 100 {
+		t.Fatalf("works goal=%+v", g)
+	}
+	if g := goals[1]; g.Progress != 4 || g.Reached || g.Percent != 40 {
+		t.Fatalf("chapters goal=%+v", g)
+	}
+
+	// goal.reached is queued once per period, not on every evaluation.
+	app.refreshGoals(1)
+	var deliveries int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE event = ?`, webhookEventGoalReached).Scan(&deliveries); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries != 1 {
+		t.Fatalf("goal.reached deliveries=%d", deliveries)
+	}
+}
+
+func TestHandleAPIGoalDelete(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	_, goals := goalsRequest(t, app, session, http.MethodPost, `{"kind": "chapters_read", "period": "month", "target": 100}`)
+	if len(goals) != 1 {
+		t.Fatalf("goals=%+v", goals)
+	}
+
+	del := func() int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/goals/1", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+		app.HandleAPIGoalDelete(rec, req)
+		return rec.Code
+	}
+	if code := del(); code != http.StatusNoContent {
+		t.Fatalf("delete status=%d", code)
+	}
+	if code := del(); code != http.StatusNotFound {
+		t.Fatalf("second delete status=%d", code)
+	}
+}
@@ -269,7 +269,7 @@ func quoteSQLiteIdent(name string) string {
 func (a *App) HandleAdminAccounts(w http.ResponseWriter, r *http.Request) {
 	rows, err := a.DB.Query(
 		`SELECT id, username, password, validated, is_admin, is_superadmin,
-                display_name, email, bio, avatar_path, is_public
+                display_name, email, bio, avatar_path, is_public, email_verified_at, pending_email, totp_enabled_at
          FROM users`,
 	)
 	if err != nil {
@@ -279,16 +279,19 @@ func (a *App) HandleAdminAccounts(w http.ResponseWriter, r *http.Request) {
 	defer func() { _ = rows.Close() }()
 
 	type adminUser struct {
-		ID           int
-		Username     string
-		Validated    int
-		IsAdmin      int
-		IsSuperadmin int
-		DisplayName  sql.NullString
-		Email        sql.NullString
-		Bio          sql.NullString
-		AvatarPath   sql.NullString
-		IsPublic     sql.NullInt64
+		ID              int
+		Username        string
+		Validated       int
+		IsAdmin         int
+		IsSuperadmin    int
+		DisplayName     sql.NullString
+		Email           sql.NullString
+		Bio             sql.NullString
+		AvatarPath      sql.NullString
+		IsPublic        sql.NullInt64
+		EmailVerifiedAt nullFlexTime
+		PendingEmail    sql.NullString
+		TOTPEnabledAt   nullFlexTime
 	}
 
 	var users []adminUser
@@ -307,6 +310,9 @@ func (a *App) HandleAdminAccounts(w http.ResponseWriter, r *http.Request) {
 			&u.Bio,
 			&u.AvatarPath,
 			&u.IsPublic,
+			&u.EmailVerifiedAt,
+			&u.PendingEmail,
+			&u.TOTPEnabledAt,
 		); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -333,6 +339,8 @@ func (a *App) HandleApproveAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	a.logAdminAction(r, "approve_account", "user", strconv.Itoa(userID), nil)
+	a.clearRegistrationNotifications(userID)
+	a.notify(userID, notification{Kind: notificationAccountApproved, Link: "/dashboard"})
 	http.Redirect(w, r, "/admin/accounts", http.StatusFound)
 }
 
@@ -379,6 +387,7 @@ func (a *App) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	a.logAdminAction(r, "delete_account", "user", strconv.Itoa(targetID), nil)
+	a.clearRegistrationNotifications(targetID)
 	http.Redirect(w, r, "/admin/accounts", http.StatusFound)
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,18 +12,68 @@ import (
 
 const integrationFeatureName = "Intégration"
 
+// revokeAllUserAPITokens revokes the user's personal tokens; tokens issued to OAuth apps stay valid.
 func (a *App) revokeAllUserAPITokens(userID int) error {
 	if userID <= 0 {
 		return nil
 	}
 	now := time.Now().UTC()
 	_, err := a.DB.Exec(
-		`UPDATE api_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		`UPDATE api_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL AND oauth_grant_id IS NULL`,
 		now, userID,
 	)
 	return err
 }
 
+// apiScopeChoice is one scope checkbox of the profile token form.
+type apiScopeChoice struct {
+	Scope   string
+	Default bool
+}
+
+func personalAPIScopeChoices() []apiScopeChoice {
+	var out []apiScopeChoice
+	for _, scope := range sortedAPIScopes() {
+		out = append(out, apiScopeChoice{Scope: scope, Default: slices.Contains(defaultPersonalAPIScopes, scope)})
+	}
+	return out
+}
+
+// parseAPITokenRestrictionsForm reads the optional restriction fields of the token form
+// (allowed_ips, work_ids, reading_types, rate_limit). Work IDs must belong to the user.
+func (a *App) parseAPITokenRestrictionsForm(r *http.Request, userID int) (apiTokenRestrictions, bool) {
+	var t apiTokenRestrictions
+	var ok bool
+	if t.AllowedIPs, ok = parseAPITokenAllowedIPs(r.FormValue("allowed_ips")); !ok {
+		return apiTokenRestrictions{}, false
+	}
+	if t.WorkIDs, ok = parseAPITokenWorkIDs(r.FormValue("work_ids")); !ok {
+		return apiTokenRestrictions{}, false
+	}
+	if len(t.WorkIDs) > 0 {
+		args := []any{userID}
+		for _, id := range t.WorkIDs {
+			args = append(args, id)
+		}
+		var owned int
+		if err := a.DB.QueryRow(
+			`SELECT COUNT(*) FROM works WHERE user_id = ? AND id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(t.WorkIDs)), ",")+`)`,
+			args...,
+		).Scan(&owned); err != nil || owned != len(t.WorkIDs) {
+			return apiTokenRestrictions{}, false
+		}
+	}
+	t.ReadingTypes = normalizeAPITokenReadingTypes(r.Form["reading_types"])
+	if raw := strings.TrimSpace(r.FormValue("rate_limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxAPITokenRateLimit {
+			return apiTokenRestrictions{}, false
+		}
+		t.RateLimit = n
+	}
+	return t, true
+}
+
 func (a *App) applyAPITokenFlashFromRequest(r *http.Request, userID int, extra map[string]any) {
 	if extra == nil || r == nil || userID <= 0 {
 		return
@@ -52,10 +103,20 @@ func (a *App) HandleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, "/profile?tab=integrations&api_token_error=1", http.StatusFound)
+		return
+	}
+	scopes := normalizeAPIScopes(r.Form["scopes"])
+	restrictions, ok := a.parseAPITokenRestrictionsForm(r, userID)
+	if len(scopes) == 0 || !ok {
+		http.Redirect(w, r, "/profile?tab=integrations&api_token_error=1", http.StatusFound)
+		return
+	}
+
 	_ = a.revokeAllUserAPITokens(userID)
 
-	scopes := []string{ScopeWorksRead, ScopeWorksWrite}
-	token, _, err := a.createAPIToken(userID, integrationFeatureName, scopes)
+	token, _, err := a.createRestrictedAPIToken(userID, integrationFeatureName, scopes, restrictions)
 	if err != nil {
 		http.Redirect(w, r, "/profile?tab=integrations", http.StatusFound)
 		return
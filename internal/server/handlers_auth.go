@@ -1,9 +1,11 @@
 package server
 
 import (
+	"bookstorage/internal/config"
 	"bookstorage/internal/i18n"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"net/url"
@@ -16,28 +18,43 @@ func (a *App) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		// Messages de feedback via query string
 		q := r.URL.Query()
+		inviteCode := normalizeInviteCode(q.Get("invite"))
 		data := a.mergeData(r, map[string]any{
-			"RegisterError":      q.Get("error") == "1",
-			"RegisterErrorEmpty": q.Get("error") == "empty",
-			"RegisterErrorWeak":  q.Get("error") == "weak",
-			"RegisterErrorEmail": q.Get("error") == "email",
+			"RegisterError":       q.Get("error") == "1",
+			"RegisterErrorEmpty":  q.Get("error") == "empty",
+			"RegisterErrorWeak":   q.Get("error") == "weak",
+			"RegisterErrorEmail":  q.Get("error") == "email",
+			"RegisterErrorInvite": q.Get("error") == "invite",
+			"RegistrationMode":    a.Settings.EffectiveRegistrationMode(),
+			"InviteCode":          inviteCode,
+			"OIDCProviders":       a.oidcLoginButtons("", inviteCode),
 		})
 		a.renderTemplate(w, r, "register", data)
 	case http.MethodPost:
 		username := strings.TrimSpace(r.FormValue("username"))
 		email := strings.TrimSpace(r.FormValue("email"))
 		password := r.FormValue("password")
+		inviteCode := normalizeInviteCode(r.FormValue("invite"))
+		// Error redirects keep the invite so the form does not have to be pasted again.
+		inviteQuery := ""
+		if inviteCode != "" {
+			inviteQuery = "&invite=" + url.QueryEscape(inviteCode)
+		}
 
+		if a.Settings.EffectiveRegistrationMode() == config.RegistrationClosed {
+			http.Redirect(w, r, "/register", http.StatusFound)
+			return
+		}
 		if username == "" || password == "" || email == "" {
-			http.Redirect(w, r, "/register?error=empty", http.StatusFound)
+			http.Redirect(w, r, "/register?error=empty"+inviteQuery, http.StatusFound)
 			return
 		}
 		if !validAccountEmail(email) {
-			http.Redirect(w, r, "/register?error=email", http.StatusFound)
+			http.Redirect(w, r, "/register?error=email"+inviteQuery, http.StatusFound)
 			return
 		}
 		if len(password) < minPasswordLen {
-			http.Redirect(w, r, "/register?error=weak", http.StatusFound)
+			http.Redirect(w, r, "/register?error=weak"+inviteQuery, http.StatusFound)
 			return
 		}
 
@@ -46,25 +63,49 @@ func (a *App) HandleRegister(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		validated := 0
-		if a.Settings != nil && !a.Settings.RequireAccountValidation {
-			validated = 1
+		now := time.Now().UTC()
+		adm, err := a.admitRegistration(inviteCode, now)
+		switch {
+		case errors.Is(err, errRegistrationClosed):
+			http.Redirect(w, r, "/register", http.StatusFound)
+			return
+		case errors.Is(err, errInviteRequired), errors.Is(err, errInviteInvalid):
+			http.Redirect(w, r, "/register?error=invite"+inviteQuery, http.StatusFound)
+			return
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
-		_, err = a.DB.Exec(
-			`INSERT INTO users (username, password, validated, is_admin, email)
-             VALUES (?, ?, ?, 0, ?)`,
-			username, hashedPassword, validated, normalizeAccountEmail(email),
+		validated := adm.Validated
+		email = normalizeAccountEmail(email)
+		userID, err := a.DB.InsertID(
+			`INSERT INTO users (username, password, validated, is_admin, email, registered_at, signup_lang, invite_id)
+             VALUES (?, ?, ?, 0, ?, ?, ?, ?)`,
+			username, hashedPassword, validated, email, now.Format("2006-01-02 15:04:05"), a.currentLang(r), inviteIDArg(adm.InviteID),
 		)
 		if err != nil {
-			http.Redirect(w, r, "/register?error=1", http.StatusFound)
+			a.releaseInvite(adm.InviteID)
+			http.Redirect(w, r, "/register?error=1"+inviteQuery, http.StatusFound)
 			return
 		}
+		// The address stays unverified (no password reset, no digest) until the link is followed.
+		verifyFlag := ""
+		if a.Settings != nil && a.Settings.MailConfigured() {
+			if err := a.sendEmailVerification(r.Context(), int(userID), email, a.currentLang(r)); err != nil {
+				log.Printf("email verification for user %d: %v", userID, err)
+			} else {
+				verifyFlag = "&verify=1"
+			}
+		}
+		if validated == 0 {
+			a.notifyPendingRegistration(r.Context(), int(userID), username)
+		}
 		// Success: account created.
 		if validated == 1 {
-			http.Redirect(w, r, "/login?registered=1&auto=1", http.StatusFound)
+			http.Redirect(w, r, "/login?registered=1&auto=1"+verifyFlag, http.StatusFound)
 			return
 		}
-		http.Redirect(w, r, "/login?registered=1", http.StatusFound)
+		http.Redirect(w, r, "/login?registered=1"+verifyFlag, http.StatusFound)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -97,10 +138,15 @@ func (a *App) HandleLogin(w http.ResponseWriter, r *http.Request) {
 			"RegisterAuto":     q.Get("auto") == "1",
 			"SessionExpired":   q.Get("expired") != "",
 			"PasswordResetOK":  q.Get("reset") != "",
+			"RegisterVerify":   q.Get("verify") == "1",
+			"EmailVerified":    strings.TrimSpace(q.Get("email_verified")),
 			"LoginNext":        loginNext,
 			"GoogleAuthURL":    googleAuthURL,
 			"GoogleOAuthError": strings.TrimSpace(q.Get("google_error")),
+			"OIDCProviders":    a.oidcLoginButtons(loginNext, ""),
+			"OIDCError":        strings.TrimSpace(q.Get("oidc_error")),
 			"WebAuthnError":    strings.TrimSpace(q.Get("webauthn_error")),
+			"TwoFactorOff":     q.Get("twofa_disabled") == "1",
 		})
 		a.renderTemplate(w, r, "login", data)
 	case http.MethodPost:
@@ -129,6 +175,10 @@ func (a *App) HandleLogin(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/login?google_error=use_google", http.StatusFound)
 			return
 		}
+		if (!u.Password.Valid || strings.TrimSpace(u.Password.String) == "") && a.userIdentityCount(u.ID) > 0 {
+			http.Redirect(w, r, "/login?oidc_error=use_sso", http.StatusFound)
+			return
+		}
 		if a.userPasskeyOnly(u.ID) {
 			http.Redirect(w, r, "/login?webauthn_error=use_passkey", http.StatusFound)
 			return
@@ -140,7 +190,12 @@ func (a *App) HandleLogin(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/login?error=1", http.StatusFound)
 			return
 		}
-		a.clearLoginFailures(username)
+		twoFactor := a.userTOTPEnabled(u.ID)
+		if !twoFactor {
+			// With TOTP the failure count is only cleared once the second step succeeds, so the
+			// lockout keeps covering guesses of the code.
+			a.clearLoginFailures(username)
+		}
 		if u.Password.Valid && passwordHashNeedsUpgrade(u.Password.String) {
 			if upgraded, err := hashPassword(password); err == nil {
 				_, _ = a.DB.Exec(`UPDATE users SET password = ? WHERE id = ?`, upgraded, u.ID)
@@ -152,17 +207,10 @@ func (a *App) HandleLogin(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		token, err := a.createSession(r, u.ID)
-		if err != nil {
+		if err := a.completeLogin(w, r, u.ID, strings.TrimSpace(r.FormValue("next"))); err != nil {
 			http.Redirect(w, r, "/login?error=1", http.StatusFound)
 			return
 		}
-		a.setSessionCookie(w, token, sessionSlidingTTL)
-		dest := safePostLoginRedirect(strings.TrimSpace(r.FormValue("next")))
-		if dest == "" {
-			dest = "/dashboard"
-		}
-		http.Redirect(w, r, dest, http.StatusFound)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -204,6 +252,11 @@ func (a *App) HandleLogout(w http.ResponseWriter, r *http.Request) {
 		a.revokeSession(tok)
 	}
 	a.clearSession(w)
+	// Behind forward auth the next request would sign the user straight back in.
+	if a.Settings != nil && a.Settings.ProxyAuthConfigured() && a.Settings.ProxyAuthLogoutURL != "" {
+		http.Redirect(w, r, a.Settings.ProxyAuthLogoutURL, http.StatusFound)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
@@ -2,13 +2,15 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"bookstorage/internal/catalog"
 	"bookstorage/internal/database"
-	"bookstorage/internal/recommend"
+	"bookstorage/internal/i18n"
 )
 
 type catalogBrowseItem struct {
@@ -56,13 +58,37 @@ func filterValidCatalogReadingTypes(raw []string) []string {
 	return out
 }
 
+// parseCatalogSource returns the enabled provider named by raw, falling back to the first
+// enabled provider (AniList unless disabled). It returns "" when no source is enabled.
 func parseCatalogSource(raw string) string {
-	switch strings.ToLower(strings.TrimSpace(raw)) {
-	case "mangadex":
-		return "mangadex"
-	default:
-		return "anilist"
+	if p, ok := catalog.EnabledProvider(raw); ok {
+		return p.Name()
 	}
+	if p := catalog.FirstEnabledProvider(nil); p != nil {
+		return p.Name()
+	}
+	return ""
+}
+
+type catalogSourceOption struct {
+	Name  string
+	Label string
+}
+
+// catalogBrowseSources lists enabled providers that can browse, for the catalog source picker.
+func catalogBrowseSources(t i18n.Translations) []catalogSourceOption {
+	var out []catalogSourceOption
+	for _, p := range catalog.EnabledProviders() {
+		if !p.Capabilities().Browse {
+			continue
+		}
+		label := p.Label()
+		if v, ok := t["catalog.explore.source_"+p.Name()]; ok {
+			label = v
+		}
+		out = append(out, catalogSourceOption{Name: p.Name(), Label: label})
+	}
+	return out
 }
 
 func hitToBrowseItem(h catalog.CatalogMediaHit) catalogBrowseItem {
@@ -89,9 +115,10 @@ func (a *App) HandleCatalog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	data := map[string]any{
-		"Genres":        catalog.AnilistGenres(),
-		"ReadingTypes":  catalogBrowseReadingTypes,
-		"CatalogSource": parseCatalogSource(r.URL.Query().Get("source")),
+		"Genres":         catalog.AnilistGenres(),
+		"ReadingTypes":   catalogBrowseReadingTypes,
+		"CatalogSource":  parseCatalogSource(r.URL.Query().Get("source")),
+		"CatalogSources": catalogBrowseSources(i18n.T(a.currentLang(r))),
 	}
 	a.renderTemplate(w, r, "catalog", a.mergeData(r, data))
 }
@@ -108,6 +135,13 @@ func (a *App) HandleCatalogBrowse(w http.ResponseWriter, r *http.Request) {
 	}
 
 	source := parseCatalogSource(r.URL.Query().Get("source"))
+	provider, ok := catalog.EnabledProvider(source)
+	if !ok || !provider.Capabilities().Browse {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "unsupported_source", "results": []any{}})
+		return
+	}
 
 	rawGenres := r.URL.Query()["genre"]
 	genres := catalog.FilterValidAnilistGenres(rawGenres, 3)
@@ -147,46 +181,17 @@ func (a *App) HandleCatalogBrowse(w http.ResponseWriter, r *http.Request) {
 		fetchPerPage   = 25
 	)
 
-	var (
-		hits    []catalog.CatalogMediaHit
-		hasNext bool
-		err     error
-	)
-
-	switch source {
-	case "mangadex":
-		knownMD := loadKnownCatalogExternalIDs(a.DB, int64(userID), "mangadex")
-		hits, hasNext, err = catalog.BrowseMangaDexCollect(catalog.BrowseMangaDexParams{
-			GenreIn:        genres,
-			PerPage:        fetchPerPage,
-			Sort:           sort,
-			NotInIDs:       knownMD,
-			IsAdult:        &isAdult,
-			ReadingTypesIn: readingTypes,
-			TagNotIn:       filter.TagNotIn,
-			MediaMatch:     filter.MatchMedia,
-		}, (page-1)*displayPerPage, displayPerPage)
-	default:
-		known := map[int]struct{}{}
-		if works, werr := recommend.LoadUserAnilistWorks(a.DB, int64(userID)); werr == nil {
-			known = recommend.CollectKnownAnilistIDs(works)
-		}
-		var anilist []catalog.AnilistResult
-		anilist, hasNext, err = catalog.BrowseMediaCollect(catalog.BrowseMediaParams{
-			GenreIn:        genres,
-			PerPage:        fetchPerPage,
-			Sort:           sort,
-			NotInIDs:       known,
-			IsAdult:        &isAdult,
-			ReadingTypesIn: readingTypes,
-			TagIn:          filter.TagIn,
-			TagNotIn:       filter.TagNotIn,
-			MediaMatch:     filter.MatchMedia,
-		}, (page-1)*displayPerPage, displayPerPage)
-		if err == nil {
-			hits = anilistResultsToHits(anilist, "anilist")
-		}
-	}
+	hits, hasNext, err := provider.Browse(catalog.BrowseParams{
+		GenreIn:        genres,
+		TagIn:          filter.TagIn,
+		TagNotIn:       filter.TagNotIn,
+		PerPage:        fetchPerPage,
+		Sort:           sort,
+		NotInIDs:       loadKnownCatalogExternalIDs(a.DB, int64(userID), source),
+		IsAdult:        &isAdult,
+		ReadingTypesIn: readingTypes,
+		MediaMatch:     filter.MatchMedia,
+	}, (page-1)*displayPerPage, displayPerPage)
 
 	if err != nil {
 		writeAnilistUpstreamJSON(w, "catalog browse ("+source+")", err, map[string]any{"results": []any{}})
@@ -219,23 +224,6 @@ func (a *App) HandleCatalogBrowse(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func anilistResultsToHits(items []catalog.AnilistResult, source string) []catalog.CatalogMediaHit {
-	out := make([]catalog.CatalogMediaHit, 0, len(items))
-	for _, r := range items {
-		out = append(out, catalog.CatalogMediaHit{
-			Source:      source,
-			ExternalID:  strconv.Itoa(r.ID),
-			Title:       r.Title,
-			ReadingType: r.ReadingType,
-			ImageURL:    r.ImageURL,
-			Genres:      r.Genres,
-			Tags:        r.Tags,
-			IsAdult:     r.IsAdult,
-		})
-	}
-	return out
-}
-
 // Add a work (with basic image upload support)
 type catalogSearchResult struct {
 	Source      string `json:"source"`
@@ -270,7 +258,7 @@ func (a *App) HandleCatalogSearch(w http.ResponseWriter, r *http.Request) {
 	filter := catalog.MergeBlocklistFilter(blocklist, catalog.AdultOrientationFilter{})
 
 	var results []catalogSearchResult
-	var anilistSearchErr error
+	var searchErr error
 
 	if database.CatalogFTSEnabled(a.DB) {
 		matchExpr := ""
@@ -329,11 +317,19 @@ func (a *App) HandleCatalogSearch(w http.ResponseWriter, r *http.Request) {
 
 	if len(results) < 15 {
 		remaining := 15 - len(results)
-		switch source {
-		case "mangadex":
-			mdHits, err := catalog.SearchMangaDex(q, remaining)
-			if err == nil {
-				for _, h := range mdHits {
+		provider, ok := catalog.EnabledProvider(source)
+		// An ISBN goes to the first enabled provider that can look one up.
+		if catalog.NormalizeISBNQuery(q) != "" && (!ok || !provider.Capabilities().ISBN) {
+			if p := catalog.FirstEnabledProvider(func(c catalog.Capabilities) bool { return c.ISBN }); p != nil {
+				provider, ok, source = p, true, p.Name()
+			}
+		}
+		if ok && provider.Capabilities().Search {
+			hits, err := provider.Search(q, remaining)
+			if err != nil {
+				searchErr = err
+			} else {
+				for _, h := range hits {
 					if filter.MatchMedia != nil && !filter.MatchMedia(h.Genres, h.Tags) {
 						continue
 					}
@@ -349,40 +345,46 @@ func (a *App) HandleCatalogSearch(w http.ResponseWriter, r *http.Request) {
 						break
 					}
 				}
-				a.cacheCatalogHits(mdHits)
-			}
-		default:
-			anilistResults, err := catalog.SearchAnilist(q, remaining)
-			if err != nil {
-				if anilistSearchErr == nil {
-					anilistSearchErr = err
-				}
-			} else {
-				for _, m := range anilistResults {
-					if filter.MatchMedia != nil && !filter.MatchMedia(m.Genres, m.Tags) {
-						continue
-					}
-					results = append(results, catalogSearchResult{
-						Source:      "anilist",
-						ExternalID:  strconv.Itoa(m.ID),
-						Title:       m.Title,
-						ReadingType: m.ReadingType,
-						ImageURL:    m.ImageURL,
-						IsAdult:     m.IsAdult,
-					})
-					if len(results) >= 15 {
-						break
-					}
-				}
-				a.cacheCatalogHits(anilistResultsToHits(anilistResults, "anilist"))
+				a.cacheCatalogHits(hits)
 			}
 		}
 	}
 
 	resp := map[string]any{"results": results, "source": source}
-	if anilistSearchErr != nil {
-		resp["anilist_error"] = catalog.AnilistErrorCode(anilistSearchErr)
+	if searchErr != nil {
+		// The add-work form reads anilist_error; the code is the same for every source.
+		resp["anilist_error"] = catalog.AnilistErrorCode(searchErr)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// prefillCatalogWork fills the add form from a provider entry and caches its metadata
+// (browse and search hits do not carry the synopsis).
+func (a *App) prefillCatalogWork(data map[string]any, source, externalID string) bool {
+	provider, ok := catalog.EnabledProvider(source)
+	if !ok || !provider.Capabilities().GetByID {
+		return false
+	}
+	media, err := provider.GetByID(externalID)
+	if err != nil {
+		if !errors.Is(err, catalog.ErrMediaNotFound) {
+			log.Printf("catalog %s entry %s: %v", provider.Name(), externalID, err)
+		}
+		return false
+	}
+	hit := media.Hit
+	if hit.Title == "" {
+		return false
+	}
+	data["PrefillCatalogSource"] = hit.Source
+	data["PrefillCatalogExternalID"] = hit.ExternalID
+	data["PrefillTitle"] = hit.Title
+	data["PrefillImageURL"] = hit.ImageURL
+	data["PrefillReadingType"] = hit.ReadingType
+	data["PrefillIsAdult"] = hit.IsAdult
+	if _, err := a.ensureCatalogID(hit.Source, hit.ExternalID, hit.Title, hit.ReadingType, hit.ImageURL); err == nil {
+		a.upsertCatalogMetadata(hit, media.Description, media.AltTitles)
+	}
+	return true
+}
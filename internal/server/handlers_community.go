@@ -233,17 +233,18 @@ func (a *App) HandleImportWork(w http.ResponseWriter, r *http.Request) {
 	if src.ReadingType.Valid && src.ReadingType.String != "" {
 		readingType = src.ReadingType.String
 	}
-	stCopy := "En cours"
+	stCopy := statusReading
 	if src.Status.Valid {
 		stCopy = normalizeStatusForWrite(src.Status.String)
 	}
 	notifyCh := notifyNewChaptersDB(stCopy, src.NotifyNewChapters != 0)
 
 	_, err = a.DB.Exec(
-		`INSERT INTO works (title, chapter, link, status, image_path, reading_type, rating, notes, user_id, notify_new_chapters)
-         VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO works (title, chapter, volume, link, status, image_path, reading_type, rating, notes, user_id, notify_new_chapters)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		src.Title,
 		src.Chapter,
+		src.Volume,
 		nullableString(src.Link),
 		nullableString(src.Status),
 		nullableString(src.ImagePath),
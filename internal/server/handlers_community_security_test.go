@@ -33,8 +33,8 @@ func TestHandleUserDetail_hidesAdultWorksFromVisitors(t *testing.T) {
 	_, err = db.Exec(
 		`INSERT INTO works (title, chapter, user_id, status, reading_type, is_adult, updated_at)
 		 VALUES
-		 ('SafeWork', 1, ?, 'En cours', 'Manga', 0, CURRENT_TIMESTAMP),
-		 ('AdultWork', 1, ?, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 ('SafeWork', 1, ?, 'reading', 'Manga', 0, CURRENT_TIMESTAMP),
+		 ('AdultWork', 1, ?, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 		ownerID, ownerID,
 	)
 	if err != nil {
@@ -91,7 +91,7 @@ func TestHandleImportWork_blocksAdultWork(t *testing.T) {
 
 	_, err = db.Exec(
 		`INSERT INTO works (title, chapter, user_id, status, reading_type, is_adult, updated_at)
-		 VALUES ('AdultOnly', 1, ?, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('AdultOnly', 1, ?, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 		donorID,
 	)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"html/template"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,6 +28,23 @@ type App struct {
 }
 
 func NewApp(settings *config.Settings, siteConfig *config.SiteConfig, db *database.Conn, version string) *App {
+	fmtLocalTime := func(s sql.NullString) string {
+		if !s.Valid || s.String == "" {
+			return "—"
+		}
+		t, err := time.Parse("2006-01-02 15:04:05", s.String)
+		if err != nil {
+			t2, err2 := time.Parse("2006-01-02T15:04:05Z", s.String)
+			if err2 != nil {
+				return s.String
+			}
+			t = t2
+		}
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			t = t.In(loc)
+		}
+		return t.Format("02/01/2006 15:04")
+	}
 	funcMap := template.FuncMap{
 		"work_image_url": func(stored string) string {
 			return workImageURL(settings, stored)
@@ -123,22 +141,10 @@ func NewApp(settings *config.Settings, siteConfig *config.SiteConfig, db *databa
 			}
 			return n.String
 		},
-		"fmtProbeTime": func(s sql.NullString) string {
-			if !s.Valid || s.String == "" {
-				return "—"
-			}
-			t, err := time.Parse("2006-01-02 15:04:05", s.String)
-			if err != nil {
-				t2, err2 := time.Parse("2006-01-02T15:04:05Z", s.String)
-				if err2 != nil {
-					return s.String
-				}
-				t = t2
-			}
-			if loc, err := time.LoadLocation(settings.Timezone); err == nil {
-				t = t.In(loc)
-			}
-			return t.Format("02/01/2006 15:04")
+		"fmtProgress":  formatProgress,
+		"fmtProbeTime": fmtLocalTime,
+		"fmtEventTime": func(s string) string {
+			return fmtLocalTime(sql.NullString{String: s, Valid: s != ""})
 		},
 		"probeTimeISO": func(s sql.NullString) string {
 			if !s.Valid || s.String == "" {
@@ -176,6 +182,13 @@ func NewApp(settings *config.Settings, siteConfig *config.SiteConfig, db *databa
 	}
 }
 
+// Handler wraps the application mux in the middleware chain served in production. Proxy auth runs
+// before request policies and API tokens so the session it sets is seen by everything after it.
+func (a *App) Handler(mux http.Handler) http.Handler {
+	return a.WithAccessLog(a.WithRequestID(a.SecurityHeaders(a.WithErrorPages(a.WithDatabaseUnavailable(
+		a.WithProxyAuth(a.WithRequestPolicies(a.WithAPITokenContext(a.WithAPITokenRoutePolicy(mux)))))))))
+}
+
 func mustLoadTemplates(funcMap template.FuncMap, directories []string) *template.Template {
 	files := collectTemplateFiles(directories...)
 	if len(files) == 0 {
@@ -52,10 +52,18 @@ func (a *App) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 		whereClause += " AND COALESCE(is_adult, 0) = 0"
 	}
 
+	// Tag filter narrows the work list only; the cover lookups below are keyed by work id.
+	tagFilter := normalizeTagName(r.URL.Query().Get("tag"))
+	listWhere, listArgs := whereClause, args
+	if tagFilter != "" {
+		listWhere += " AND " + tagFilterSQL
+		listArgs = append(append([]any{}, args...), userID, tagFilter)
+	}
+
 	query := `SELECT ` + sqlWorkRowFull + `
-        FROM works ` + whereClause + " " + orderClause
+        FROM works ` + listWhere + " " + orderClause
 
-	rows, err := a.DB.Query(query, args...)
+	rows, err := a.DB.Query(query, listArgs...)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -71,6 +79,10 @@ func (a *App) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 		}
 		works = append(works, wRow)
 	}
+	tagsByWorkID := a.tagNamesByWork(userID)
+	for i := range works {
+		works[i].Tags = tagsByWorkID[works[i].ID]
+	}
 
 	catalogCoverByWorkID := map[int]string{}
 	coverQuery := `SELECT w.id, COALESCE(c.image_url, '') FROM works w INNER JOIN catalog c ON c.id = w.catalog_id ` + whereClause
@@ -140,6 +152,13 @@ func (a *App) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 		userID,
 	).Scan(&sitesDownCount)
 
+	tagNames := a.userTagNames(userID)
+	for _, name := range tagNames {
+		if strings.EqualFold(name, tagFilter) {
+			tagFilter = name // select the stored spelling in the filter
+		}
+	}
+
 	data := map[string]any{
 		"Works":                 works,
 		"CatalogCoverByWorkID":  catalogCoverByWorkID,
@@ -149,12 +168,15 @@ func (a *App) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 		"IsAdmin":               isAdmin == 1,
 		"SortBy":                sortBy,
 		"AdultFilter":           adultFilter,
+		"TagFilter":             tagFilter,
+		"Tags":                  tagNames,
 		"SearchQuery":           r.URL.Query().Get("q"),
 		"ReadingSiteMap":        readingSiteStatusMap,
 		"ReadingSites":          a.loadUserReadingSites(userID),
 		"LinkDotStatusByWorkID": linkDotStatusByWorkID,
 		"SitesDownCount":        sitesDownCount,
 		"LinkDeadCount":         linkDeadCount,
+		"Goals":                 a.goalsForPage(userID),
 	}
 	if enc := r.URL.Query().Get("import_report"); enc != "" {
 		raw, err := base64.RawURLEncoding.DecodeString(enc)
@@ -32,19 +32,23 @@ func (a *App) HandleLegal(w http.ResponseWriter, r *http.Request) {
 func (a *App) HandleStats(w http.ResponseWriter, r *http.Request) {
 	userID, _ := a.currentUserID(r)
 
-	var totalWorks, totalChapters, ratedCount int
-	var avgRating float64
+	var totalWorks, ratedCount int
+	var totalChapters, totalVolumes, avgRating float64
 	if err := a.DB.QueryRow(`
 		SELECT
 			(SELECT COUNT(*) FROM works WHERE user_id = ?),
 			(SELECT COALESCE(SUM(chapter), 0) FROM works WHERE user_id = ?),
+			(SELECT COALESCE(SUM(volume), 0) FROM works WHERE user_id = ?),
 			(SELECT COALESCE(AVG(rating), 0) FROM works WHERE user_id = ? AND rating > 0),
 			(SELECT COUNT(*) FROM works WHERE user_id = ? AND rating > 0)
-	`, userID, userID, userID, userID).Scan(&totalWorks, &totalChapters, &avgRating, &ratedCount); err != nil {
+	`, userID, userID, userID, userID, userID).Scan(&totalWorks, &totalChapters, &totalVolumes, &avgRating, &ratedCount); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	var totalRereads, rereadWorks int
+	_ = a.DB.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT work_id) FROM work_reads WHERE user_id = ?`, userID).Scan(&totalRereads, &rereadWorks)
+
 	// By status
 	type statusCount struct {
 		Status string
@@ -101,17 +105,24 @@ func (a *App) HandleStats(w http.ResponseWriter, r *http.Request) {
 
 	lang := a.currentLang(r)
 	tr := i18n.T(lang)
+	_, collection := a.collectionReport(userID)
 
 	a.renderTemplate(w, r, "stats", a.mergeData(r, map[string]any{
 		"StatsUserID":     userID,
 		"TotalWorks":      totalWorks,
 		"TotalChapters":   totalChapters,
+		"TotalVolumes":    totalVolumes,
 		"ByStatus":        byStatus,
 		"ByType":          byType,
 		"AvgRating":       avgRating,
 		"RatedCount":      ratedCount,
+		"TotalRereads":    totalRereads,
+		"RereadWorks":     rereadWorks,
 		"TopRated":        topRated,
 		"ReadingTimeline": a.readingTimelineForCharts(userID),
 		"StatusDistrib":   a.statusDistribForCharts(userID, tr),
+		"Goals":           a.goalsForPage(userID),
+		"Collection":      collection,
+		"ReadingTypes":    readingTypes,
 	}))
 }
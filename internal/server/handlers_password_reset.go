@@ -37,7 +37,7 @@ func (a *App) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
 						lang = l
 					}
 				}
-				sender := mail.NewSender(a.Settings)
+				sender := a.mailOutbox(passwordResetTokenTTL)
 				for _, u := range users {
 					if a.recentPasswordResetEmailSent(u.ID) {
 						continue
@@ -166,19 +166,8 @@ func (a *App) sendPasswordResetEmail(ctx context.Context, sender mail.Sender, to
 	if a.Settings == nil {
 		return fmt.Errorf("settings unavailable")
 	}
-	siteName := "BookStorage"
-	var brandColor, mailFooter string
-	if a.SiteConfig != nil {
-		if n := strings.TrimSpace(a.SiteConfig.SiteName); n != "" {
-			siteName = n
-		}
-		brandColor = a.SiteConfig.Mail.BrandColor
-		mailFooter = a.SiteConfig.Mail.Footer
-	}
-	logoURL := ""
-	if a.SiteConfig != nil {
-		logoURL = a.SiteConfig.MailLogoURL(a.Settings.PublicOrigin)
-	}
+	branding, mailFooter := a.mailBranding()
+	siteName := branding.SiteName
 
 	tr := i18n.T(lang)
 	subjectKey := tr["mail.password_reset.subject"]
@@ -212,11 +201,6 @@ func (a *App) sendPasswordResetEmail(ctx context.Context, sender mail.Sender, to
 		Ignore:      ignore,
 		Footer:      mailFooter,
 	}
-	branding := mail.PasswordResetBranding{
-		SiteName:   siteName,
-		BrandColor: brandColor,
-		LogoURL:    mail.EmailSafeLogoURL(logoURL),
-	}
 	return sender.Send(ctx, mail.Message{
 		To:       to,
 		Subject:  subject,
@@ -225,3 +209,19 @@ func (a *App) sendPasswordResetEmail(ctx context.Context, sender mail.Sender, to
 		CustomID: "password-reset-" + hashSessionToken(rawToken)[:12],
 	})
 }
+
+// mailBranding returns the site name, color and logo (site config) shared by every outbound
+// email, plus the configured mail footer. Requires a.Settings.
+func (a *App) mailBranding() (mail.PasswordResetBranding, string) {
+	branding := mail.PasswordResetBranding{SiteName: "BookStorage"}
+	footer := ""
+	if a.SiteConfig != nil {
+		if n := strings.TrimSpace(a.SiteConfig.SiteName); n != "" {
+			branding.SiteName = n
+		}
+		branding.BrandColor = a.SiteConfig.Mail.BrandColor
+		branding.LogoURL = mail.EmailSafeLogoURL(a.SiteConfig.MailLogoURL(a.Settings.PublicOrigin))
+		footer = a.SiteConfig.Mail.Footer
+	}
+	return branding, footer
+}
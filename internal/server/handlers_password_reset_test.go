@@ -41,7 +41,7 @@ func TestHandleForgotPassword_fullFlow(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := db.Exec(
-		`INSERT INTO users (username, password, validated, is_admin, email) VALUES ('resetuser', ?, 1, 0, ?)`,
+		`INSERT INTO users (username, password, validated, is_admin, email, email_verified_at) VALUES ('resetuser', ?, 1, 0, ?, CURRENT_TIMESTAMP)`,
 		hashed, "reset@example.com",
 	); err != nil {
 		t.Fatal(err)
@@ -87,6 +87,7 @@ func TestHandleForgotPassword_fullFlow(t *testing.T) {
 	if rec.Code != http.StatusFound || !strings.Contains(rec.Header().Get("Location"), "sent=1") {
 		t.Fatalf("redirect %q status %d", rec.Header().Get("Location"), rec.Code)
 	}
+	app.runMailOutboxCycle(context.Background())
 	if capturedToken == "" {
 		t.Fatal("expected reset token in email")
 	}
@@ -146,7 +147,7 @@ func TestHandleForgotPassword_emailCooldown(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := db.Exec(
-		`INSERT INTO users (username, password, validated, is_admin, email) VALUES ('cooldownuser', ?, 1, 0, ?)`,
+		`INSERT INTO users (username, password, validated, is_admin, email, email_verified_at) VALUES ('cooldownuser', ?, 1, 0, ?, CURRENT_TIMESTAMP)`,
 		hashed, "cooldown@example.com",
 	); err != nil {
 		t.Fatal(err)
@@ -170,6 +171,7 @@ func TestHandleForgotPassword_emailCooldown(t *testing.T) {
 		if rec.Code != http.StatusFound {
 			t.Fatalf("status %d", rec.Code)
 		}
+		app.runMailOutboxCycle(context.Background())
 	}
 
 	postForgot()
@@ -24,6 +24,9 @@ type profileUser struct {
 	Bio         sql.NullString
 	AvatarPath  sql.NullString
 	IsPublic    sql.NullInt64
+	// EmailVerifiedAt is set once Email was confirmed; PendingEmail awaits confirmation.
+	EmailVerifiedAt nullFlexTime
+	PendingEmail    sql.NullString
 }
 
 // readingTimelineDay holds sparse daily aggregates for Chart.js (started / finished / last activity).
@@ -207,6 +210,15 @@ func (a *App) applyChapterDeltaToReadingStats(userID int, delta int, lastChapter
 	log.Printf("reading_activity_daily: chapter correction delta %d not applied for user %d (no matching day row)", delta, userID)
 }
 
+// applyProgressChangeToReadingStats feeds reading_activity_daily with whole units of the work's own progress
+// unit (volumes for light novels, chapters otherwise); moves in the other unit are not counted.
+func (a *App) applyProgressChangeToReadingStats(userID int, unit, readingType string, before, after float64, lastChapterAtBefore nullFlexTime) {
+	if unit != progressUnitForReadingType(readingType) {
+		return
+	}
+	a.applyChapterDeltaToReadingStats(userID, wholeUnitDelta(before, after), lastChapterAtBefore)
+}
+
 // recordReadingChapterIncrements adds a positive delta to today's UTC rollup (+ button, etc.).
 func (a *App) recordReadingChapterIncrements(userID int, delta int) {
 	if delta <= 0 {
@@ -251,12 +263,14 @@ func (a *App) renderProfilePage(w http.ResponseWriter, r *http.Request, userID i
 		u = v
 	} else {
 		err := a.DB.QueryRow(
-			`SELECT id, username, password, google_sub, google_email, display_name, email, bio, avatar_path, is_public
+			`SELECT id, username, password, google_sub, google_email, display_name, email, bio, avatar_path, is_public,
+			        email_verified_at, pending_email
 			 FROM users WHERE id = ?`,
 			userID,
 		).Scan(
 			&u.ID, &u.Username, &u.Password, &u.GoogleSub, &u.GoogleEmail,
 			&u.DisplayName, &u.Email, &u.Bio, &u.AvatarPath, &u.IsPublic,
+			&u.EmailVerifiedAt, &u.PendingEmail,
 		)
 		if err != nil {
 			http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
@@ -266,23 +280,27 @@ func (a *App) renderProfilePage(w http.ResponseWriter, r *http.Request, userID i
 
 	var totalWorks int
 	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ?`, userID).Scan(&totalWorks)
-	var totalChapters int
+	var totalChapters float64
 	_ = a.DB.QueryRow(`SELECT COALESCE(SUM(chapter), 0) FROM works WHERE user_id = ?`, userID).Scan(&totalChapters)
 	var completedCount int
-	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ? AND (status = 'Terminé' OR status = 'Completed')`, userID).Scan(&completedCount)
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ? AND status = ?`, userID, statusCompleted).Scan(&completedCount)
 	var readingCount int
-	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ? AND (status = 'En cours' OR status = 'Reading')`, userID).Scan(&readingCount)
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ? AND status = ?`, userID, statusReading).Scan(&readingCount)
 
 	sessions, _ := a.listActiveSessions(userID)
 	apiTokens, _ := a.listAPITokens(userID)
 	webhooks, _ := a.listWebhookEndpoints(userID)
 	passkeys, _ := a.listWebAuthnCredentials(userID)
+	identities, _ := a.listUserIdentities(userID)
+	oauthApps, _ := a.listOAuthAuthorizedApps(userID)
 	_, tok, _ := a.currentSession(r)
 	currentSessionHash := ""
 	if tok != "" {
 		currentSessionHash = hashSessionToken(tok)
 	}
 	blocklist, _ := catalog.LoadUserBlocklist(a.DB, int64(userID))
+	var digestFrequency string
+	_ = a.DB.QueryRow(`SELECT COALESCE(digest_frequency, '') FROM users WHERE id = ?`, userID).Scan(&digestFrequency)
 	q := r.URL.Query()
 	data := map[string]any{
 		"User":               u,
@@ -295,6 +313,7 @@ func (a *App) renderProfilePage(w http.ResponseWriter, r *http.Request, userID i
 		"APITokens":          apiTokens,
 		"Webhooks":           webhooks,
 		"WebAuthnPasskeys":   passkeys,
+		"TwoFactorEnabled":   a.userTOTPEnabled(userID),
 		"BlocklistGenres":    blocklist.Genres,
 		"BlocklistTags":      blocklist.Tags,
 		"BlocklistAdded":     q.Get("blocklist_added") == "1",
@@ -304,9 +323,21 @@ func (a *App) renderProfilePage(w http.ResponseWriter, r *http.Request, userID i
 		"GoogleLinked":       q.Get("google_linked") == "1",
 		"GoogleUnlinked":     q.Get("google_unlinked") == "1",
 		"GoogleOAuthError":   strings.TrimSpace(q.Get("google_error")),
+		"OIDCProviders":      a.oidcProviderConfigs(),
+		"UserIdentities":     identities,
+		"OIDCCanUnlink":      a.oidcCanUnlink(userID),
+		"OIDCLinked":         q.Get("oidc_linked") == "1",
+		"OIDCUnlinked":       q.Get("oidc_unlinked") == "1",
+		"OIDCError":          strings.TrimSpace(q.Get("oidc_error")),
 		"ReadingStatsReset":  strings.TrimSpace(q.Get("reading_stats_reset")),
 		"APITokenRevoked":    q.Get("api_token_revoked") == "1",
 		"HasAPIToken":        len(apiTokens) > 0,
+		"APITokenError":      q.Get("api_token_error") == "1",
+		"APIScopeChoices":    personalAPIScopeChoices(),
+		"APIReadingTypes":    readingTypes,
+		"MaxAPITokenRate":    maxAPITokenRateLimit,
+		"OAuthApps":          oauthApps,
+		"OAuthAppRevoked":    q.Get("app_revoked") == "1",
 		"WebhookUpdated":     q.Get("webhook_updated") == "1",
 		"WebhookDeleted":     q.Get("webhook_deleted") == "1",
 		"WebhookTestSent":    q.Get("webhook_test") == "1",
@@ -315,6 +346,13 @@ func (a *App) renderProfilePage(w http.ResponseWriter, r *http.Request, userID i
 		"WebAuthnRegistered": q.Get("webauthn_registered") == "1",
 		"WebAuthnError":      strings.TrimSpace(q.Get("webauthn_error")),
 		"ProfileEmailError":  q.Get("profile_error") == "email",
+		"EmailVerifyStatus":  strings.TrimSpace(q.Get("email_verify")),
+		"EmailVerified":      strings.TrimSpace(q.Get("email_verified")),
+		"EmailVerifyMail":    a.Settings.MailConfigured(),
+		"DigestAvailable":    a.Settings.MailConfigured(),
+		"DigestFrequency":    digestFrequency,
+		"DigestSaved":        q.Get("digest") == "saved",
+		"DigestError":        q.Get("digest") == "error",
 	}
 	for k, v := range extra {
 		data[k] = v
@@ -331,7 +369,8 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 
 	var u profileUser
 	err := a.DB.QueryRow(
-		`SELECT id, username, password, google_sub, google_email, display_name, email, bio, avatar_path, is_public
+		`SELECT id, username, password, google_sub, google_email, display_name, email, bio, avatar_path, is_public,
+                email_verified_at, pending_email
          FROM users WHERE id = ?`,
 		userID,
 	).Scan(
@@ -345,6 +384,8 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 		&u.Bio,
 		&u.AvatarPath,
 		&u.IsPublic,
+		&u.EmailVerifiedAt,
+		&u.PendingEmail,
 	)
 	if err != nil {
 		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
@@ -432,7 +473,8 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 			updates["display_name"] = nil
 		}
 
-		updates["email"] = email
+		// The address itself goes through requestEmailChange once the other fields are saved.
+		emailChanged := email != normalizeAccountEmail(u.Email.String) && email != normalizeAccountEmail(u.PendingEmail.String)
 
 		if bio != "" {
 			updates["bio"] = bio
@@ -456,7 +498,7 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 			updates["avatar_path"] = newAvatarPath
 		}
 
-		if len(updates) == 0 {
+		if len(updates) == 0 && !emailChanged {
 			http.Redirect(w, r, "/profile", http.StatusFound)
 			return
 		}
@@ -475,9 +517,11 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 			passwordChanged = true
 		}
 
-		if _, err := a.DB.Exec(stmt, args...); err != nil {
-			http.Redirect(w, r, "/profile", http.StatusFound)
-			return
+		if len(updates) > 0 {
+			if _, err := a.DB.Exec(stmt, args...); err != nil {
+				http.Redirect(w, r, "/profile", http.StatusFound)
+				return
+			}
 		}
 
 		if passwordChanged {
@@ -497,6 +541,18 @@ func (a *App) HandleProfile(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if emailChanged {
+			pending, err := a.requestEmailChange(r.Context(), userID, normalizeAccountEmail(u.Email.String), email, a.currentLang(r))
+			if err != nil {
+				http.Redirect(w, r, "/profile", http.StatusFound)
+				return
+			}
+			if pending {
+				http.Redirect(w, r, "/profile?tab=account&email_verify=pending", http.StatusFound)
+				return
+			}
+		}
+
 		http.Redirect(w, r, "/profile", http.StatusFound)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -537,8 +593,9 @@ func (a *App) HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	}
 	hasLocalPassword := storedPassword.Valid && strings.TrimSpace(storedPassword.String) != ""
 	googleLinked := googleSub.Valid && strings.TrimSpace(googleSub.String) != ""
-	isGoogleOnly := !hasLocalPassword && googleLinked
-	if isGoogleOnly {
+	// Accounts created through Google or an OIDC provider have no password to confirm with.
+	isSSOOnly := !hasLocalPassword && (googleLinked || a.userIdentityCount(userID) > 0)
+	if isSSOOnly {
 		if strings.TrimSpace(currentPassword) != "" {
 			http.Redirect(w, r, "/profile?delete_error=1", http.StatusFound)
 			return
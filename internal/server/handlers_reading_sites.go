@@ -31,6 +31,12 @@ func (a *App) HandleReadingSites(w http.ResponseWriter, r *http.Request) {
 	a.renderTemplate(w, r, "reading_sites", a.mergeData(r, data))
 }
 
+// validReadingSiteBaseURL accepts absolute http(s) URLs with a host.
+func validReadingSiteBaseURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
 func (a *App) handleReadingSiteCreate(w http.ResponseWriter, r *http.Request, userID int) {
 	name := strings.TrimSpace(r.FormValue("name"))
 	baseURL := strings.TrimSpace(r.FormValue("base_url"))
@@ -40,15 +46,20 @@ func (a *App) handleReadingSiteCreate(w http.ResponseWriter, r *http.Request, us
 		return
 	}
 
-	parsed, err := url.Parse(baseURL)
-	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+	if !validReadingSiteBaseURL(baseURL) {
 		http.Redirect(w, r, "/reading-sites?err=invalid+URL", http.StatusFound)
 		return
 	}
 
-	_, err = a.DB.Exec(
-		`INSERT INTO reading_sites (user_id, name, base_url, probe_status) VALUES (?, ?, ?, 'unknown')`,
-		userID, name, baseURL,
+	feedTemplate, ok := validateFeedTemplate(r.FormValue("feed_template"))
+	if !ok {
+		http.Redirect(w, r, "/reading-sites?err=invalid+feed+template", http.StatusFound)
+		return
+	}
+
+	_, err := a.DB.Exec(
+		`INSERT INTO reading_sites (user_id, name, base_url, probe_status, feed_template) VALUES (?, ?, ?, 'unknown', ?)`,
+		userID, name, baseURL, nullIfEmpty(feedTemplate),
 	)
 	if err != nil {
 		http.Redirect(w, r, "/reading-sites?err=save+failed", http.StatusFound)
@@ -76,15 +87,20 @@ func (a *App) HandleReadingSiteEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsed, err := url.Parse(baseURL)
-	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+	if !validReadingSiteBaseURL(baseURL) {
 		http.Redirect(w, r, "/reading-sites?err=invalid+URL", http.StatusFound)
 		return
 	}
 
+	feedTemplate, ok := validateFeedTemplate(r.FormValue("feed_template"))
+	if !ok {
+		http.Redirect(w, r, "/reading-sites?err=invalid+feed+template", http.StatusFound)
+		return
+	}
+
 	_, err = a.DB.Exec(
-		`UPDATE reading_sites SET name = ?, base_url = ?, probe_status = 'unknown', last_probe_at = NULL WHERE id = ? AND user_id = ?`,
-		name, baseURL, id, userID,
+		`UPDATE reading_sites SET name = ?, base_url = ?, feed_template = ?, probe_status = 'unknown', last_probe_at = NULL WHERE id = ? AND user_id = ?`,
+		name, baseURL, nullIfEmpty(feedTemplate), id, userID,
 	)
 	if err != nil {
 		http.Redirect(w, r, "/reading-sites?err=update+failed", http.StatusFound)
@@ -118,11 +134,7 @@ func (a *App) HandleReadingSiteProbe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var site readingSite
-	err = a.DB.QueryRow(
-		`SELECT id, user_id, name, base_url, last_probe_at, COALESCE(probe_status, 'unknown'), probe_http_status, probe_detail FROM reading_sites WHERE id = ? AND user_id = ?`,
-		id, userID,
-	).Scan(&site.ID, &site.UserID, &site.Name, &site.BaseURL, &site.LastProbeAt, &site.ProbeStatus, &site.ProbeHTTPStatus, &site.ProbeDetail)
+	site, err := a.loadUserReadingSite(userID, id)
 	if err != nil {
 		http.Redirect(w, r, "/reading-sites?err=not+found", http.StatusFound)
 		return
@@ -26,6 +26,12 @@ func (a *App) HandleRecommendations(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	// Suggestions walk the AniList recommendation graph; none when the instance disables AniList.
+	if _, enabled := catalog.EnabledProvider("anilist"); !enabled {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&recommend.ForUserResult{Results: []recommend.Suggestion{}, Profile: recommend.ProfileSummary{}})
+		return
+	}
 	dismissedIDs := map[int]struct{}{}
 	if dismissed, err := loadDismissedRecommendations(a.DB, userID, "anilist"); err == nil {
 		for idStr := range dismissed {
@@ -65,6 +71,12 @@ func (a *App) HandleRecommendationMedia(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	if _, enabled := catalog.EnabledProvider("anilist"); !enabled {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "unsupported_source"})
+		return
+	}
 	aid := strings.TrimSpace(r.URL.Query().Get("anilist_id"))
 	id, err := strconv.Atoi(aid)
 	if err != nil || id <= 0 {
@@ -122,10 +134,12 @@ func (a *App) HandleRecommendationMedia(w http.ResponseWriter, r *http.Request)
 // ensureCatalogID returns the catalog row id for (source, external_id), creating the row if needed.
 // Uses RETURNING on PostgreSQL because lib/pq does not support sql.Result.LastInsertId.
 func (a *App) ensureCatalogID(source, externalID, title, readingType, imgURL string) (int64, error) {
-	source = strings.TrimSpace(source)
 	externalID = strings.TrimSpace(externalID)
-	if source == "" {
-		source = "manual"
+	// Only registered catalog providers get linked rows; any other source is a manual entry.
+	if p, ok := catalog.LookupProvider(source); ok {
+		source = p.Name()
+	} else {
+		source, externalID = "manual", ""
 	}
 	if externalID != "" {
 		var existingID int64
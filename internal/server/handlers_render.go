@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 func (a *App) currentUserID(r *http.Request) (int, bool) {
@@ -118,12 +119,31 @@ func (a *App) RequireWebOnly(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// lazyCount runs its query the first time a template reads Count, so error pages and partials
+// rendered without the nav skip it, and a page reading it twice queries once.
+type lazyCount struct {
+	once sync.Once
+	load func() int
+	n    int
+}
+
+func (c *lazyCount) Count() int {
+	c.once.Do(func() { c.n = c.load() })
+	return c.n
+}
+
 // mergeData merges additional data into base data
 func (a *App) mergeData(r *http.Request, extra map[string]any) map[string]any {
 	data := a.baseData(r)
 	for k, v := range extra {
 		data[k] = v
 	}
+	// Nav bell: unread notifications of the signed-in user, counted only if the page shows the nav.
+	if _, ok := data["UnreadNotifications"]; !ok && r != nil && a.DB != nil {
+		if userID, ok := a.currentUserID(r); ok {
+			data["UnreadNotifications"] = &lazyCount{load: func() int { return a.unreadNotificationCount(userID) }}
+		}
+	}
 	// Admin nav: SQLite → PostgreSQL tab (superadmin, not already on Postgres).
 	if r != nil && r.URL != nil && strings.HasPrefix(r.URL.Path, "/admin/") {
 		if _, ok := data["ShowPostgresMigrate"]; !ok {
@@ -230,8 +250,8 @@ func isMobileRequest(r *http.Request) bool {
 func (a *App) RequireLogin(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if userID, ok := apiAuthUserIDFromContext(r.Context()); ok && userID > 0 {
-			if !apiTokenPathAllowed(r.Method, r.URL.Path) {
-				a.rejectAPITokenAuth(w, r)
+			if status, code := a.authorizeAPITokenRequest(r); status != 0 {
+				a.rejectAPITokenRequest(w, r, status, code)
 				return
 			}
 			if !strings.HasPrefix(r.URL.Path, "/api/") {
@@ -268,14 +288,6 @@ var readingTypes = []string{
 	"Light Novel",
 }
 
-var readingStatuses = []string{
-	"En cours",
-	"Terminé",
-	"En pause",
-	"Abandonné",
-	"À lire",
-}
-
 func buildMediaRelativePath(filename, urlPath string) string {
 	urlPath = strings.Trim(urlPath, "/")
 	if urlPath == "" {
@@ -356,6 +368,16 @@ func (a *App) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
 			}))
 			return
 		}
+		if a.adminNeedsTwoFactor(userID) {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error":"two_factor_required"}`))
+				return
+			}
+			http.Redirect(w, r, "/profile/2fa?required=1", http.StatusFound)
+			return
+		}
 
 		// Prevent stale admin pages/status after a self-update / restart.
 		// Applies to admin HTML and admin APIs (polling endpoints).
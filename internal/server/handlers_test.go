@@ -161,7 +161,7 @@ func TestHandleExportJSON(t *testing.T) {
 	app := &App{Settings: s, DB: db}
 	_, err := db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, updated_at, is_adult)
-		 VALUES ('Alpha', 3, 'https://x.test', 'En cours', 'Manga', 4, 'note', 1, CURRENT_TIMESTAMP, 0)`,
+		 VALUES ('Alpha', 3, 'https://x.test', 'reading', 'Manga', 4, 'note', 1, CURRENT_TIMESTAMP, 0)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -186,7 +186,7 @@ func TestHandleExportCSV(t *testing.T) {
 	db, s := openTestDB(t)
 	app := &App{Settings: s, DB: db}
 	_, err := db.Exec(
-		`INSERT INTO works (title, chapter, user_id, status, reading_type) VALUES ('Beta', 1, 1, 'En cours', 'Roman')`,
+		`INSERT INTO works (title, chapter, user_id, status, reading_type) VALUES ('Beta', 1, 1, 'reading', 'Roman')`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -267,8 +267,8 @@ func TestHandleDashboard_AdultFilter(t *testing.T) {
 	_, err := db.Exec(
 		`INSERT INTO works (title, chapter, user_id, status, reading_type, is_adult, updated_at)
 		 VALUES
-		 ('Safe', 1, 1, 'En cours', 'Manga', 0, CURRENT_TIMESTAMP),
-		 ('Adult', 1, 1, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 ('Safe', 1, 1, 'reading', 'Manga', 0, CURRENT_TIMESTAMP),
+		 ('Adult', 1, 1, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -313,9 +313,9 @@ func TestHandleAPIWorksList_WithFiltersAndMeta(t *testing.T) {
 	_, err := db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, updated_at)
 		 VALUES
-		 ('Alpha', 3, NULL, 'En cours', 'Manga', 4, 'note alpha', 1, CURRENT_TIMESTAMP),
-		 ('Bravo', 10, NULL, 'Terminé', 'Roman', 5, 'note bravo', 1, CURRENT_TIMESTAMP),
-		 ('Charlie', 2, NULL, 'En cours', 'Manga', 2, 'note charlie', 1, CURRENT_TIMESTAMP)`,
+		 ('Alpha', 3, NULL, 'reading', 'Manga', 4, 'note alpha', 1, CURRENT_TIMESTAMP),
+		 ('Bravo', 10, NULL, 'completed', 'Roman', 5, 'note bravo', 1, CURRENT_TIMESTAMP),
+		 ('Charlie', 2, NULL, 'reading', 'Manga', 2, 'note charlie', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -369,8 +369,8 @@ func TestHandleAPIWorksList_ReadingSiteFilter(t *testing.T) {
 	_, err = db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, reading_site_id, updated_at)
 		 VALUES
-		 ('WithSite', 1, 'https://example.com/manga/1', 'En cours', 'Manga', 0, '', 1, ?, CURRENT_TIMESTAMP),
-		 ('NoSite', 1, NULL, 'En cours', 'Manga', 0, '', 1, NULL, CURRENT_TIMESTAMP)`,
+		 ('WithSite', 1, 'https://example.com/manga/1', 'reading', 'Manga', 0, '', 1, ?, CURRENT_TIMESTAMP),
+		 ('NoSite', 1, NULL, 'reading', 'Manga', 0, '', 1, NULL, CURRENT_TIMESTAMP)`,
 		siteID,
 	)
 	if err != nil {
@@ -483,6 +483,49 @@ func TestHandleCatalogBrowse_noGenreAllowed(t *testing.T) {
 	}
 }
 
+func TestHandleCatalogBrowse_disabledSource(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	catalog.SetEnabledProviders([]string{"mangadex"})
+	t.Cleanup(func() { catalog.SetEnabledProviders(nil) })
+
+	if got := parseCatalogSource("anilist"); got != "mangadex" {
+		t.Fatalf("disabled source should fall back to the first enabled one, got %q", got)
+	}
+	catalog.SetEnabledProviders([]string{"nope"})
+	req := httptest.NewRequest(http.MethodGet, "/api/catalog/browse?source=anilist", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleCatalogBrowse(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unsupported_source") {
+		t.Fatalf("status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestEnsureCatalogID_unknownSourceIsManual(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	id, err := app.ensureCatalogID("myspace", "42", "Odd", "Manga", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source, ext string
+	if err := db.QueryRow(`SELECT source, COALESCE(external_id, '') FROM catalog WHERE id = ?`, id).Scan(&source, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if source != "manual" || ext != "" {
+		t.Fatalf("source=%q ext=%q", source, ext)
+	}
+	linked, err := app.ensureCatalogID("OpenLibrary", "OL1W", "Known", "Light Novel", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := app.ensureCatalogID("openlibrary", "OL1W", "Known", "Light Novel", ""); again != linked {
+		t.Fatalf("provider name should be canonical: %d vs %d", again, linked)
+	}
+}
+
 func TestFilterValidCatalogReadingTypes(t *testing.T) {
 	got := filterValidCatalogReadingTypes([]string{"Manga", "Bad", "Manga", " Manhwa ", "Roman"})
 	want := []string{"Manga"}
@@ -596,7 +639,7 @@ func TestAPIWorksCRUDFlow(t *testing.T) {
 	if err := json.NewDecoder(detailRec.Body).Decode(&detail); err != nil {
 		t.Fatal(err)
 	}
-	if detail.Data.Chapter != 12 || detail.Data.Status != "Terminé" || detail.Data.Rating != 5 {
+	if detail.Data.Chapter != 12 || detail.Data.Status != statusCompleted || detail.Data.Rating != 5 {
 		t.Fatalf("detail inattendu: %+v", detail.Data)
 	}
 
@@ -631,9 +674,9 @@ func TestHandleAPIStats_UserScopedAggregates(t *testing.T) {
 	_, err = db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, rating, user_id, updated_at)
 		 VALUES
-		 ('A', 10, 'En cours', 'Manga', 4, 1, CURRENT_TIMESTAMP),
-		 ('B', 5, 'Terminé', 'Roman', 2, 1, CURRENT_TIMESTAMP),
-		 ('C', 99, 'En cours', 'Manga', 5, 2, CURRENT_TIMESTAMP)`,
+		 ('A', 10, 'reading', 'Manga', 4, 1, CURRENT_TIMESTAMP),
+		 ('B', 5, 'completed', 'Roman', 2, 1, CURRENT_TIMESTAMP),
+		 ('C', 99, 'reading', 'Manga', 5, 2, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -797,7 +840,7 @@ func TestHandleAddWork_AnilistNotInCatalog(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if workStatus != "À lire" {
+	if workStatus != statusPlanned {
 		t.Fatalf("status: got %q", workStatus)
 	}
 	if !workCatalogID.Valid || workCatalogID.Int64 != catalogID {
@@ -835,7 +878,7 @@ func TestImportFromCSV_MAL(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if gotTitle != "Berserk" || gotStatus != "En cours" || gotType != "Manga" || gotChapter != 42 || gotRating != 5 {
+	if gotTitle != "Berserk" || gotStatus != statusReading || gotType != "Manga" || gotChapter != 42 || gotRating != 5 {
 		t.Fatalf("work MAL inattendue: %s %s %s %d %d", gotTitle, gotStatus, gotType, gotChapter, gotRating)
 	}
 }
@@ -895,7 +938,7 @@ func TestMergeDuplicate_MergesAndDeletesFrom(t *testing.T) {
 	_, err := db.Exec(
 		`INSERT INTO works (id, title, chapter, link, status, reading_type, rating, notes, user_id, updated_at)
 		 VALUES
-		 (10, 'Same', 3, NULL, 'En cours', 'Manga', 2, 'into notes', 1, CURRENT_TIMESTAMP),
+		 (10, 'Same', 3, NULL, 'reading', 'Manga', 2, 'into notes', 1, CURRENT_TIMESTAMP),
 		 (11, 'Same', 7, 'https://x.test', NULL, 'Manga', 5, 'from notes', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
@@ -1169,7 +1212,7 @@ func TestAPIWorksBulk_StatusPatch(t *testing.T) {
 
 	res, err := db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
-		 VALUES ('Bulk A', 1, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('Bulk A', 1, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1177,7 +1220,7 @@ func TestAPIWorksBulk_StatusPatch(t *testing.T) {
 	id1, _ := res.LastInsertId()
 	res, err = db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
-		 VALUES ('Bulk B', 2, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('Bulk B', 2, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1210,8 +1253,8 @@ func TestAPIWorksBulk_StatusPatch(t *testing.T) {
 	if err := db.QueryRow(`SELECT status FROM works WHERE id = ?`, id1).Scan(&st); err != nil {
 		t.Fatal(err)
 	}
-	if st != "Terminé" {
-		t.Fatalf("status=%q want Terminé", st)
+	if st != statusCompleted {
+		t.Fatalf("status=%q want %q", st, statusCompleted)
 	}
 }
 
@@ -1228,7 +1271,7 @@ func TestAPIWorksBulk_ReadingSiteID(t *testing.T) {
 
 	res, err = db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, user_id, updated_at)
-		 VALUES ('Linked', 1, 'https://read.example/m/1', 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('Linked', 1, 'https://read.example/m/1', 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1261,7 +1304,7 @@ func TestAPIWorksBulk_LinkReplace(t *testing.T) {
 
 	res, err := db.Exec(
 		`INSERT INTO works (title, chapter, link, status, reading_type, user_id, updated_at)
-		 VALUES ('LinkWork', 1, 'https://old.example/ch/1', 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('LinkWork', 1, 'https://old.example/ch/1', 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1298,7 +1341,7 @@ func TestAPIWorksBulk_RejectsOtherUserWorks(t *testing.T) {
 	}
 	res, err := db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
-		 VALUES ('Foreign', 1, 'En cours', 'Manga', 2, CURRENT_TIMESTAMP)`,
+		 VALUES ('Foreign', 1, 'reading', 'Manga', 2, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1336,7 +1379,7 @@ func TestAPIWorksBulk_Delete(t *testing.T) {
 
 	res, err := db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
-		 VALUES ('ToDelete', 1, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('ToDelete', 1, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1375,7 +1418,7 @@ func TestAPIWorksUpdate_ReadingSiteID(t *testing.T) {
 
 	res, err = db.Exec(
 		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
-		 VALUES ('PatchSite', 1, 'En cours', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		 VALUES ('PatchSite', 1, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -1414,6 +1457,9 @@ func TestCatalogSourcePageURL(t *testing.T) {
 	if got := catalogSourcePageURL("mangadex", u); got != "https://mangadex.org/title/"+url.PathEscape(u) {
 		t.Fatalf("mangadex: got %q", got)
 	}
+	if got := catalogSourcePageURL("openlibrary", "OL45804W"); got != "https://openlibrary.org/works/OL45804W" {
+		t.Fatalf("openlibrary: got %q", got)
+	}
 	if got := catalogSourcePageURL("manual", "x"); got != "" {
 		t.Fatalf("manual: want empty, got %q", got)
 	}
@@ -1421,3 +1467,34 @@ func TestCatalogSourcePageURL(t *testing.T) {
 		t.Fatalf("empty ext: got %q", got)
 	}
 }
+
+func TestHandleEditWork_rejectsInvalidFeedURL(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	workID := insertTestWork(t, app, "Feed Work", 3)
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	_ = w.WriteField("title", "Renamed")
+	_ = w.WriteField("chapter", "9")
+	_ = w.WriteField("feed_url", "ftp://example.test/feed")
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/edit/"+strconv.Itoa(workID), &b)
+	req.SetPathValue("id", strconv.Itoa(workID))
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	rec := httptest.NewRecorder()
+	app.HandleEditWork(rec, req)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/edit/"+strconv.Itoa(workID)+"?error=invalid_feed_url" {
+		t.Fatalf("status %d location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	var title string
+	var chapter int
+	if err := db.QueryRow(`SELECT title, chapter FROM works WHERE id = ?`, workID).Scan(&title, &chapter); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Feed Work" || chapter != 3 {
+		t.Fatalf("work saved despite invalid feed: title=%q chapter=%d", title, chapter)
+	}
+}
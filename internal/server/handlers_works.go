@@ -1,11 +1,11 @@
 package server
 
 import (
-	"bookstorage/internal/catalog"
 	"bookstorage/internal/i18n"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,27 +15,21 @@ import (
 func (a *App) HandleAddWork(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		userID, _ := a.currentUserID(r)
 		data := map[string]any{
 			"ReadingTypes":  readingTypes,
 			"Statuses":      readingStatuses,
-			"DefaultStatus": "À lire",
+			"DefaultStatus": statusPlanned,
+			"AllTags":       a.userTagNames(userID),
 		}
 		if aid := strings.TrimSpace(r.URL.Query().Get("anilist_id")); aid != "" {
-			if id, err := strconv.Atoi(aid); err == nil && id > 0 {
-				if d, err := catalog.GetMediaByID(id); err == nil && d != nil && d.Title != "" {
-					data["PrefillAnilistID"] = id
-					data["PrefillCatalogSource"] = "anilist"
-					data["PrefillCatalogExternalID"] = aid
-					data["PrefillTitle"] = d.Title
-					data["PrefillImageURL"] = d.ImageURL
-					data["PrefillReadingType"] = catalog.ReadingTypeFromAnilistDetail(d)
-					data["PrefillIsAdult"] = d.RawMedia.IsAdult
-				}
+			if id, err := strconv.Atoi(aid); err == nil && id > 0 && a.prefillCatalogWork(data, "anilist", aid) {
+				data["PrefillAnilistID"] = id
 			}
 		}
 		if src := strings.TrimSpace(r.URL.Query().Get("catalog_source")); src != "" {
 			ext := strings.TrimSpace(r.URL.Query().Get("catalog_external_id"))
-			if ext != "" {
+			if ext != "" && !a.prefillCatalogWork(data, src, ext) {
 				data["PrefillCatalogSource"] = src
 				data["PrefillCatalogExternalID"] = ext
 			}
@@ -52,17 +46,14 @@ func (a *App) HandleAddWork(w http.ResponseWriter, r *http.Request) {
 		title := sanitizeTitle(r.FormValue("title"))
 		link := strings.TrimSpace(r.FormValue("link"))
 		status := normalizeStatusForWrite(r.FormValue("status"))
-		chapterStr := r.FormValue("chapter")
-		if chapterStr == "" {
-			chapterStr = "0"
-		}
-		chapter, _ := strconv.Atoi(chapterStr)
-		chapter = clampChapter(chapter)
+		chapter := clampChapter(parseProgressValue(r.FormValue("chapter")))
+		volume := clampVolume(parseProgressValue(r.FormValue("volume")))
 		readingType := normalizeReadingTypeForWrite(r.FormValue("reading_type"))
 		ratingStr := r.FormValue("rating")
 		rating, _ := strconv.Atoi(ratingStr)
 		rating = clampRating(rating)
 		notes := strings.TrimSpace(r.FormValue("notes"))
+		tags := parseTagList(r.FormValue("tags"))
 		isAdult := 0
 		if r.FormValue("is_adult") == "1" || strings.ToLower(r.FormValue("is_adult")) == "on" {
 			isAdult = 1
@@ -111,31 +102,38 @@ func (a *App) HandleAddWork(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var startedAtArg, finishedAtArg any
-		if status == "En cours" {
+		if status == statusReading {
 			startedAtArg = time.Now().UTC().Format("2006-01-02 15:04:05")
 		}
-		if status == "Terminé" {
+		if status == statusCompleted {
 			finishedAtArg = time.Now().UTC().Format("2006-01-02 15:04:05")
 		}
 
+		var workID int64
 		var dbErr error
 		if imagePath.Valid {
-			_, dbErr = a.DB.Exec(
-				`INSERT INTO works (title, chapter, link, status, image_path, reading_type, rating, is_adult, notes, user_id, catalog_id, notify_new_chapters, reading_site_id, updated_at, started_at, finished_at)
-                 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)`,
-				title, chapter, link, status, imagePath.String, readingType, rating, isAdult, notes, userID, catalogID, notifyCh, readingSiteID, startedAtArg, finishedAtArg,
+			workID, dbErr = a.DB.InsertID(
+				`INSERT INTO works (title, chapter, volume, link, status, image_path, reading_type, rating, is_adult, notes, user_id, catalog_id, notify_new_chapters, reading_site_id, updated_at, started_at, finished_at)
+                 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)`,
+				title, chapter, volume, link, status, imagePath.String, readingType, rating, isAdult, notes, userID, catalogID, notifyCh, readingSiteID, startedAtArg, finishedAtArg,
 			)
 		} else {
-			_, dbErr = a.DB.Exec(
-				`INSERT INTO works (title, chapter, link, status, image_path, reading_type, rating, is_adult, notes, user_id, catalog_id, notify_new_chapters, reading_site_id, updated_at, started_at, finished_at)
-                 VALUES (?, ?, ?, ?, NULL, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)`,
-				title, chapter, link, status, readingType, rating, isAdult, notes, userID, catalogID, notifyCh, readingSiteID, startedAtArg, finishedAtArg,
+			workID, dbErr = a.DB.InsertID(
+				`INSERT INTO works (title, chapter, volume, link, status, image_path, reading_type, rating, is_adult, notes, user_id, catalog_id, notify_new_chapters, reading_site_id, updated_at, started_at, finished_at)
+                 VALUES (?, ?, ?, ?, ?, NULL, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)`,
+				title, chapter, volume, link, status, readingType, rating, isAdult, notes, userID, catalogID, notifyCh, readingSiteID, startedAtArg, finishedAtArg,
 			)
 		}
 		if dbErr != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		if len(tags) > 0 {
+			if err := a.setWorkTags(userID, int(workID), tags); err != nil {
+				log.Printf("work tags (work %d): %v", workID, err)
+			}
+		}
+		a.refreshGoals(userID)
 		http.Redirect(w, r, "/dashboard", http.StatusFound)
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -161,6 +159,8 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	work.Tags, _ = a.workTagNames(userID, workID)
+	allTags := a.userTagNames(userID)
 	catalogPageURL := a.catalogPageURLForUserWork(userID, workID)
 	catalogAnilistImageURL, catalogAnilistImageLocked := a.catalogAnilistCoverForUserWork(userID, workID)
 
@@ -172,6 +172,7 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 				"ReadingTypes":              readingTypes,
 				"Statuses":                  readingStatuses,
 				"IsModal":                   true,
+				"AllTags":                   allTags,
 				"CatalogPageURL":            catalogPageURL,
 				"CatalogAnilistImageURL":    catalogAnilistImageURL,
 				"CatalogAnilistImageLocked": catalogAnilistImageLocked,
@@ -179,13 +180,37 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		lang := a.currentLang(r)
+		history, err := a.listWorkProgressEvents(userID, workID, 20)
+		if err != nil {
+			log.Printf("work history (work %d): %v", workID, err)
+		}
+		pace, err := a.computeWorkReadingPace(userID, workID, time.Now().UTC())
+		if err != nil {
+			log.Printf("work pace (work %d): %v", workID, err)
+		}
+		reads, err := a.listWorkReads(userID, workID)
+		if err != nil {
+			log.Printf("work reads (work %d): %v", workID, err)
+		}
+		copies, err := a.listWorkCopies(userID, workID)
+		if err != nil {
+			log.Printf("work copies (work %d): %v", workID, err)
+		}
 		a.renderTemplate(w, r, "edit_work", a.mergeData(r, map[string]any{
 			"Work":                      work,
+			"ProgressEvents":            history,
+			"WorkReads":                 reads,
+			"SeriesChildCount":          a.seriesChildCount(userID, workID),
+			"WorkCopies":                copies,
+			"CollectionSummary":         summarizeCollection(copies, work.Volume),
+			"ReadingPace":               pace,
+			"AllTags":                   allTags,
 			"ReadingTypes":              readingTypes,
 			"Statuses":                  readingStatuses,
 			"CatalogPageURL":            catalogPageURL,
 			"CatalogAnilistImageURL":    catalogAnilistImageURL,
 			"CatalogAnilistImageLocked": catalogAnilistImageLocked,
+			"FeedURLInvalid":            r.URL.Query().Get("error") == "invalid_feed_url",
 			"MobileTopbarTitle":         i18n.T(lang)["work.edit.title"],
 		}))
 	case http.MethodPost:
@@ -207,12 +232,8 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 		}
 		link := strings.TrimSpace(r.FormValue("link"))
 		status := normalizeStatusForWrite(r.FormValue("status"))
-		chapterStr := r.FormValue("chapter")
-		if chapterStr == "" {
-			chapterStr = "0"
-		}
-		chapter, _ := strconv.Atoi(chapterStr)
-		chapter = clampChapter(chapter)
+		chapter := clampChapter(parseProgressValue(r.FormValue("chapter")))
+		volume := clampVolume(parseProgressValue(r.FormValue("volume")))
 		readingType := normalizeReadingTypeForWrite(r.FormValue("reading_type"))
 		ratingStr := r.FormValue("rating")
 		rating, _ := strconv.Atoi(ratingStr)
@@ -253,6 +274,22 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 			parentArg = nil
 		}
 
+		feedURL, feedURLSet := "", false
+		if _, ok := r.Form["feed_url"]; ok {
+			var valid bool
+			if feedURL, valid = validateFeedURL(r.FormValue("feed_url")); !valid {
+				if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_feed_url"})
+					return
+				}
+				http.Redirect(w, r, "/edit/"+strconv.Itoa(workID)+"?error=invalid_feed_url", http.StatusFound)
+				return
+			}
+			feedURLSet = true
+		}
+
 		seriesSort := work.SeriesSort
 		if sortStr := strings.TrimSpace(r.FormValue("series_sort")); sortStr != "" {
 			if s, err := strconv.Atoi(sortStr); err == nil {
@@ -318,27 +355,27 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 		if work.Status.Valid {
 			oldStatus = work.Status.String
 		}
-		if status == "En cours" && oldStatus != "En cours" && startedAtArg == nil {
+		if status == statusReading && oldStatus != statusReading && startedAtArg == nil {
 			startedAtArg = time.Now().UTC().Format("2006-01-02 15:04:05")
 		}
-		if status == "Terminé" && oldStatus != "Terminé" && finishedAtArg == nil {
+		if status == statusCompleted && oldStatus != statusCompleted && finishedAtArg == nil {
 			finishedAtArg = time.Now().UTC().Format("2006-01-02 15:04:05")
 		}
-		if chapter > work.Chapter && formLastChapterAt == "" {
+		if (chapter > work.Chapter || volume > work.Volume) && formLastChapterAt == "" {
 			lastChapterAtArg = time.Now().UTC().Format("2006-01-02 15:04:05")
 		}
 
 		if newImagePath.Valid {
 			_, err = a.DB.Exec(
-				`UPDATE works SET title = ?, chapter = ?, link = ?, status = ?, image_path = ?, reading_type = ?, rating = ?, is_adult = ?, notes = ?, parent_work_id = ?, series_sort = ?, notify_new_chapters = ?, reading_site_id = ?, started_at = ?, last_chapter_at = ?, finished_at = ?, updated_at = CURRENT_TIMESTAMP
+				`UPDATE works SET title = ?, chapter = ?, volume = ?, link = ?, status = ?, image_path = ?, reading_type = ?, rating = ?, is_adult = ?, notes = ?, parent_work_id = ?, series_sort = ?, notify_new_chapters = ?, reading_site_id = ?, started_at = ?, last_chapter_at = ?, finished_at = ?, updated_at = CURRENT_TIMESTAMP
                  WHERE id = ? AND user_id = ?`,
-				title, chapter, link, status, newImagePath.String, readingType, rating, isAdult, notes, parentArg, seriesSort, notifyCh, readingSiteArg, startedAtArg, lastChapterAtArg, finishedAtArg, workID, userID,
+				title, chapter, volume, link, status, newImagePath.String, readingType, rating, isAdult, notes, parentArg, seriesSort, notifyCh, readingSiteArg, startedAtArg, lastChapterAtArg, finishedAtArg, workID, userID,
 			)
 		} else {
 			_, err = a.DB.Exec(
-				`UPDATE works SET title = ?, chapter = ?, link = ?, status = ?, reading_type = ?, rating = ?, is_adult = ?, notes = ?, parent_work_id = ?, series_sort = ?, notify_new_chapters = ?, reading_site_id = ?, started_at = ?, last_chapter_at = ?, finished_at = ?, updated_at = CURRENT_TIMESTAMP
+				`UPDATE works SET title = ?, chapter = ?, volume = ?, link = ?, status = ?, reading_type = ?, rating = ?, is_adult = ?, notes = ?, parent_work_id = ?, series_sort = ?, notify_new_chapters = ?, reading_site_id = ?, started_at = ?, last_chapter_at = ?, finished_at = ?, updated_at = CURRENT_TIMESTAMP
                  WHERE id = ? AND user_id = ?`,
-				title, chapter, link, status, readingType, rating, isAdult, notes, parentArg, seriesSort, notifyCh, readingSiteArg, startedAtArg, lastChapterAtArg, finishedAtArg, workID, userID,
+				title, chapter, volume, link, status, readingType, rating, isAdult, notes, parentArg, seriesSort, notifyCh, readingSiteArg, startedAtArg, lastChapterAtArg, finishedAtArg, workID, userID,
 			)
 		}
 		if err != nil {
@@ -351,7 +388,20 @@ func (a *App) HandleEditWork(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		a.applyChapterDeltaToReadingStats(userID, chapter-work.Chapter, work.LastChapterAt)
+		a.applyProgressChangeToReadingStats(userID, progressUnitChapter, work.ReadingType.String, work.Chapter, chapter, work.LastChapterAt)
+		a.applyProgressChangeToReadingStats(userID, progressUnitVolume, work.ReadingType.String, work.Volume, volume, work.LastChapterAt)
+		a.recordWorkProgressEvent(userID, workID, progressUnitChapter, work.Chapter, chapter, progressSourceEdit)
+		a.recordWorkProgressEvent(userID, workID, progressUnitVolume, work.Volume, volume, progressSourceEdit)
+		a.refreshGoals(userID)
+		// Forms that do not render the tags field leave the work's tags untouched.
+		if _, ok := r.Form["tags"]; ok {
+			if err := a.setWorkTags(userID, workID, parseTagList(r.FormValue("tags"))); err != nil {
+				log.Printf("work tags (work %d): %v", workID, err)
+			}
+		}
+		if feedURLSet && feedURL != work.FeedURL.String {
+			_, _ = a.DB.Exec(`UPDATE works SET feed_url = ?, feed_checked_at = NULL WHERE id = ? AND user_id = ?`, nullIfEmpty(feedURL), workID, userID)
+		}
 		if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
 			w.Header().Set("Content-Type", "application/json")
 			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
@@ -391,22 +441,15 @@ func (a *App) HandleIncrement(w http.ResponseWriter, r *http.Request) {
 	userID, _ := a.currentUserID(r)
 	workID, _ := strconv.Atoi(r.PathValue("id"))
 
-	res, err := a.DB.Exec(
-		`UPDATE works SET chapter = chapter + 1, last_chapter_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`,
-		workID, userID,
-	)
-	if err != nil {
+	// +1 moves the work's progress unit (volumes for light novels) to the next whole number: 12.5 → 13.
+	move, err := a.moveWorkProgress(userID, workID, "", nextProgressValue, progressSourceIncrement)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if n, _ := res.RowsAffected(); n > 0 {
-		a.recordReadingChapterIncrements(userID, 1)
-		var chapter int
-		_ = a.DB.QueryRow(`SELECT chapter FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&chapter)
-		a.EmitWebhookEvent(userID, webhookEventWorkChapterChanged, map[string]any{
-			"work_id": workID,
-			"chapter": chapter,
-		})
+	if err == nil && move.Changed() {
+		a.recordReadingChapterIncrements(userID, wholeUnitDelta(move.Before, move.After))
+		a.emitWorkProgressWebhook(userID, workID)
 	}
 	_, _ = w.Write([]byte("ok"))
 }
@@ -419,19 +462,15 @@ func (a *App) HandleDecrement(w http.ResponseWriter, r *http.Request) {
 	userID, _ := a.currentUserID(r)
 	workID, _ := strconv.Atoi(r.PathValue("id"))
 
-	_, err := a.DB.Exec(
-		`UPDATE works
-         SET chapter = CASE WHEN chapter > 0 THEN chapter - 1 ELSE 0 END, updated_at = CURRENT_TIMESTAMP
-         WHERE id = ? AND user_id = ?`,
-		workID, userID,
-	)
-	if err != nil {
+	if _, err := a.moveWorkProgress(userID, workID, "", prevProgressValue, progressSourceDecrement); err != nil && !errors.Is(err, sql.ErrNoRows) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	_, _ = w.Write([]byte("ok"))
 }
 
+// HandleSetChapter sets the chapter and/or the volume ("chapter" / "volume" form fields, decimals allowed).
+// Without any of them the chapter is reset to 0, as before volumes existed.
 func (a *App) HandleSetChapter(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -440,41 +479,40 @@ func (a *App) HandleSetChapter(w http.ResponseWriter, r *http.Request) {
 	userID, _ := a.currentUserID(r)
 	workID, _ := strconv.Atoi(r.PathValue("id"))
 
-	chapterStr := r.FormValue("chapter")
-	if chapterStr == "" {
-		chapterStr = "0"
+	chapterStr := strings.TrimSpace(r.FormValue("chapter"))
+	volumeStr := strings.TrimSpace(r.FormValue("volume"))
+	type progressField struct {
+		unit  string
+		value float64
 	}
-	chapter, err := strconv.Atoi(chapterStr)
-	if err != nil {
-		chapter = 0
+	var fields []progressField
+	if chapterStr != "" || volumeStr == "" {
+		fields = append(fields, progressField{progressUnitChapter, parseProgressValue(chapterStr)})
 	}
-	chapter = clampChapter(chapter)
-
-	var oldChapter int
-	var lastAt nullFlexTime
-	_ = a.DB.QueryRow(`SELECT chapter, last_chapter_at FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&oldChapter, &lastAt)
-
-	if chapter > oldChapter {
-		_, err = a.DB.Exec(
-			`UPDATE works SET chapter = ?, last_chapter_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`,
-			chapter, workID, userID,
-		)
-	} else {
-		_, err = a.DB.Exec(
-			`UPDATE works SET chapter = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`,
-			chapter, workID, userID,
-		)
+	if volumeStr != "" {
+		fields = append(fields, progressField{progressUnitVolume, parseProgressValue(volumeStr)})
 	}
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+
+	changed := false
+	for _, f := range fields {
+		value := f.value
+		move, err := a.moveWorkProgress(userID, workID, f.unit, func(float64) float64 { return value }, progressSourceSetChapter)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if move.Changed() {
+			changed = true
+			if move.Unit == move.WorkUnit {
+				a.applyChapterDeltaToReadingStats(userID, wholeUnitDelta(move.Before, move.After), move.LastChapterAtBefore)
+			}
+		}
 	}
-	a.applyChapterDeltaToReadingStats(userID, chapter-oldChapter, lastAt)
-	if chapter != oldChapter {
-		a.EmitWebhookEvent(userID, webhookEventWorkChapterChanged, map[string]any{
-			"work_id": workID,
-			"chapter": chapter,
-		})
+	if changed {
+		a.emitWorkProgressWebhook(userID, workID)
 	}
 	_, _ = w.Write([]byte("ok"))
 }
@@ -18,7 +18,8 @@ import (
 )
 
 // ExportFormatVersion is bumped when the JSON export shape changes incompatibly.
-const ExportFormatVersion = 1
+// Version 2 adds the owned copies of each work (collection).
+const ExportFormatVersion = 2
 
 const maxNotesRunes = 20000
 const maxImportReportURLLen = 1800
@@ -40,11 +41,13 @@ type aniImportMedia struct {
 	CoverImage aniImportCover `json:"coverImage"`
 }
 type aniImportEntry struct {
-	Status   string         `json:"status"`
-	Progress int            `json:"progress"`
-	Score    float64        `json:"score"`
-	Notes    string         `json:"notes"`
-	Media    aniImportMedia `json:"media"`
+	Status          string         `json:"status"`
+	Progress        float64        `json:"progress"`
+	ProgressVolumes float64        `json:"progressVolumes"`
+	Score           float64        `json:"score"`
+	Notes           string         `json:"notes"`
+	Repeat          int            `json:"repeat"`
+	Media           aniImportMedia `json:"media"`
 }
 
 func exportWorkFromAniImportEntry(e aniImportEntry) (exportWork, bool) {
@@ -65,6 +68,7 @@ func exportWorkFromAniImportEntry(e aniImportEntry) (exportWork, bool) {
 	return exportWork{
 		Title:       title,
 		Chapter:     clampChapter(e.Progress),
+		Volume:      clampVolume(e.ProgressVolumes),
 		Link:        link,
 		Status:      normalizeStatusForWrite(mapAniListStatus(e.Status)),
 		ReadingType: normalizeReadingTypeForWrite(mapAniListFormat(e.Media.Format)),
@@ -72,26 +76,55 @@ func exportWorkFromAniImportEntry(e aniImportEntry) (exportWork, bool) {
 		Notes:       strings.TrimSpace(e.Notes),
 		IsAdult:     e.Media.IsAdult,
 		ImagePath:   strings.TrimSpace(e.Media.CoverImage.Large),
+		RereadCount: max(e.Repeat, 0),
 	}, true
 }
 
 // exportWork is the portable shape for JSON export/import and CSV extended columns.
 // JSON export always emits every key (empty strings / null catalog_id when absent) for a stable object shape.
 type exportWork struct {
-	Title         string `json:"title"`
-	Chapter       int    `json:"chapter"`
-	Link          string `json:"link"`
-	Status        string `json:"status"`
-	ReadingType   string `json:"reading_type"`
-	Rating        int    `json:"rating"`
-	Notes         string `json:"notes"`
-	UpdatedAt     string `json:"updated_at"`
-	CatalogID     *int   `json:"catalog_id"`
-	IsAdult       bool   `json:"is_adult"`
-	ImagePath     string `json:"image_path"`
-	StartedAt     string `json:"started_at,omitempty"`
-	LastChapterAt string `json:"last_chapter_at,omitempty"`
-	FinishedAt    string `json:"finished_at,omitempty"`
+	Title         string   `json:"title"`
+	Chapter       float64  `json:"chapter"`
+	Volume        float64  `json:"volume,omitempty"`
+	Link          string   `json:"link"`
+	Status        string   `json:"status"`
+	ReadingType   string   `json:"reading_type"`
+	Rating        int      `json:"rating"`
+	Notes         string   `json:"notes"`
+	UpdatedAt     string   `json:"updated_at"`
+	CatalogID     *int     `json:"catalog_id"`
+	IsAdult       bool     `json:"is_adult"`
+	ImagePath     string   `json:"image_path"`
+	StartedAt     string   `json:"started_at,omitempty"`
+	LastChapterAt string   `json:"last_chapter_at,omitempty"`
+	FinishedAt    string   `json:"finished_at,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	// ID and ParentWorkID only identify works inside one export file; import relinks series through them.
+	ID           int  `json:"id,omitempty"`
+	ParentWorkID *int `json:"parent_work_id,omitempty"`
+	SeriesSort   int  `json:"series_sort,omitempty"`
+	// RereadCount is the number of earlier reads; Reads carries their details when known (BookStorage exports).
+	RereadCount int          `json:"reread_count,omitempty"`
+	Reads       []exportRead `json:"reads,omitempty"`
+	Collection  []exportCopy `json:"collection,omitempty"`
+}
+
+// exportRead is one archived read of a work (see work_reads).
+type exportRead struct {
+	ReadNumber int    `json:"read_number"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	Rating     int    `json:"rating,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// exportCopy is one owned volume of a work (see work_copies).
+type exportCopy struct {
+	Volume      int      `json:"volume"`
+	Format      string   `json:"format"`
+	ISBN        string   `json:"isbn,omitempty"`
+	PurchasedAt string   `json:"purchased_at,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
 }
 
 // DuplicateMode controls import when a work with the same title already exists.
@@ -125,43 +158,6 @@ func truncateNotes(s string) string {
 	return string(r[:maxNotesRunes])
 }
 
-var statusENtoFR = map[string]string{
-	"reading":           "En cours",
-	"completed":         "Terminé",
-	"on hold":           "En pause",
-	"dropped":           "Abandonné",
-	"plan to read":      "À lire",
-	"plan_to_read":      "À lire",
-	"paused":            "En pause",
-	"dropped/abandoned": "Abandonné",
-}
-
-func normalizeStatus(raw string) string {
-	s := strings.TrimSpace(raw)
-	if s == "" {
-		return "En cours"
-	}
-	lower := strings.ToLower(s)
-	if fr, ok := statusENtoFR[lower]; ok {
-		return fr
-	}
-	for _, st := range readingStatuses {
-		if strings.EqualFold(s, st) {
-			return st
-		}
-	}
-	return s
-}
-
-func isValidStatus(s string) bool {
-	for _, st := range readingStatuses {
-		if s == st {
-			return true
-		}
-	}
-	return false
-}
-
 var readingTypeAliases = map[string]string{
 	"comic":         "Manga",
 	"graphic novel": "Manga",
@@ -254,6 +250,7 @@ func (a *App) importOneWork(userID int, lineNum int, w exportWork, mode Duplicat
 
 	notes := truncateNotes(strings.TrimSpace(w.Notes))
 	chapter := clampChapter(w.Chapter)
+	volume := clampVolume(w.Volume)
 	link := strings.TrimSpace(w.Link)
 	imagePath := sanitizeImportImagePath(w.ImagePath)
 	catID := a.resolveCatalogIDField(&w)
@@ -263,10 +260,11 @@ func (a *App) importOneWork(userID int, lineNum int, w exportWork, mode Duplicat
 	}
 
 	var existsID int
+	var existingChapter, existingVolume float64
 	err := a.DB.QueryRow(
-		`SELECT id FROM works WHERE user_id = ? AND title = ?`,
+		`SELECT id, chapter, COALESCE(volume, 0) FROM works WHERE user_id = ? AND title = ?`,
 		userID, title,
-	).Scan(&existsID)
+	).Scan(&existsID, &existingChapter, &existingVolume)
 	if err != nil && err != sql.ErrNoRows {
 		report.SkippedInvalid++
 		appendImportError(report, lineNum, "db_lookup")
@@ -283,11 +281,11 @@ func (a *App) importOneWork(userID int, lineNum int, w exportWork, mode Duplicat
 			return
 		}
 		_, err := a.DB.Exec(
-			`UPDATE works SET chapter = ?, link = ?, status = ?, reading_type = ?, rating = ?, notes = ?, updated_at = CURRENT_TIMESTAMP,
+			`UPDATE works SET chapter = ?, volume = ?, link = ?, status = ?, reading_type = ?, rating = ?, notes = ?, updated_at = CURRENT_TIMESTAMP,
 			 catalog_id = ?, is_adult = ?, image_path = COALESCE(NULLIF(?, ''), image_path),
 			 started_at = COALESCE(?, started_at), last_chapter_at = COALESCE(?, last_chapter_at), finished_at = COALESCE(?, finished_at)
 			 WHERE id = ? AND user_id = ?`,
-			chapter, link, status, rtype, rating, notes,
+			chapter, volume, link, status, rtype, rating, notes,
 			catID, isAdult, imagePath,
 			startedAt, lastChapterAt, finishedAt,
 			existsID, userID,
@@ -297,14 +295,28 @@ func (a *App) importOneWork(userID int, lineNum int, w exportWork, mode Duplicat
 			appendImportError(report, lineNum, "db_update")
 			return
 		}
+		a.recordWorkProgressEvent(userID, existsID, progressUnitChapter, existingChapter, chapter, progressSourceImport)
+		a.recordWorkProgressEvent(userID, existsID, progressUnitVolume, existingVolume, volume, progressSourceImport)
+		if len(w.Tags) > 0 {
+			// Imported tags are merged into the existing ones rather than replacing them.
+			if err := a.addWorkTags(userID, existsID, w.Tags); err != nil {
+				appendImportError(report, lineNum, "db_tags")
+			}
+		}
+		if err := a.importWorkReads(userID, existsID, w); err != nil {
+			appendImportError(report, lineNum, "db_reads")
+		}
+		if err := a.importWorkCopies(userID, existsID, w.Collection); err != nil {
+			appendImportError(report, lineNum, "db_collection")
+		}
 		report.Updated++
 		return
 	}
 
-	_, err = a.DB.Exec(
-		`INSERT INTO works (title, chapter, link, status, reading_type, rating, notes, user_id, updated_at, catalog_id, is_adult, image_path, notify_new_chapters, started_at, last_chapter_at, finished_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?, ?, 1, ?, ?, ?)`,
-		title, chapter, link, status, rtype, rating, notes, userID,
+	newID, err := a.DB.InsertID(
+		`INSERT INTO works (title, chapter, volume, link, status, reading_type, rating, notes, user_id, updated_at, catalog_id, is_adult, image_path, notify_new_chapters, started_at, last_chapter_at, finished_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?, ?, 1, ?, ?, ?)`,
+		title, chapter, volume, link, status, rtype, rating, notes, userID,
 		catID, isAdult, imagePath, startedAt, lastChapterAt, finishedAt,
 	)
 	if err != nil {
@@ -312,6 +324,17 @@ func (a *App) importOneWork(userID int, lineNum int, w exportWork, mode Duplicat
 		appendImportError(report, lineNum, "db_insert")
 		return
 	}
+	if len(w.Tags) > 0 {
+		if err := a.addWorkTags(userID, int(newID), w.Tags); err != nil {
+			appendImportError(report, lineNum, "db_tags")
+		}
+	}
+	if err := a.importWorkReads(userID, int(newID), w); err != nil {
+		appendImportError(report, lineNum, "db_reads")
+	}
+	if err := a.importWorkCopies(userID, int(newID), w.Collection); err != nil {
+		appendImportError(report, lineNum, "db_collection")
+	}
 	report.Imported++
 }
 
@@ -466,6 +489,7 @@ func parseMALCSVRecords(records [][]string, headers []string) []exportWork {
 	idxTitle := headerIndex(headers, "series_title")
 	idxStatus := headerIndex(headers, "my_status")
 	idxProgress := headerIndex(headers, "my_read_chapters", "my_chapters_read")
+	idxVolumes := headerIndex(headers, "my_read_volumes", "my_volumes_read")
 	idxScore := headerIndex(headers, "my_score")
 	idxType := headerIndex(headers, "series_type")
 
@@ -476,11 +500,11 @@ func parseMALCSVRecords(records [][]string, headers []string) []exportWork {
 		if title == "" {
 			continue
 		}
-		ch, _ := strconv.Atoi(safeCell(row, idxProgress))
 		rating, _ := strconv.Atoi(safeCell(row, idxScore))
 		out = append(out, exportWork{
 			Title:       title,
-			Chapter:     clampChapter(ch),
+			Chapter:     clampChapter(parseProgressValue(safeCell(row, idxProgress))),
+			Volume:      clampVolume(parseProgressValue(safeCell(row, idxVolumes))),
 			Status:      normalizeStatusForWrite(mapMALStatus(safeCell(row, idxStatus))),
 			ReadingType: normalizeReadingTypeForWrite(mapMALType(safeCell(row, idxType))),
 			Rating:      clampRating(rating),
@@ -493,6 +517,7 @@ func parseAniListCSVRecords(records [][]string, headers []string) []exportWork {
 	idxTitle := headerIndex(headers, "title", "media_title")
 	idxStatus := headerIndex(headers, "status")
 	idxProgress := headerIndex(headers, "progress", "chapters_read")
+	idxVolumes := headerIndex(headers, "progress_volumes", "volumes_read", "volumes")
 	idxScore := headerIndex(headers, "score")
 	idxType := headerIndex(headers, "format", "type")
 	idxID := headerIndex(headers, "anilist_id", "media_id")
@@ -504,7 +529,6 @@ func parseAniListCSVRecords(records [][]string, headers []string) []exportWork {
 		if title == "" {
 			continue
 		}
-		ch, _ := strconv.Atoi(safeCell(row, idxProgress))
 		rating, _ := strconv.Atoi(safeCell(row, idxScore))
 		aid := safeCell(row, idxID)
 		link := ""
@@ -513,7 +537,8 @@ func parseAniListCSVRecords(records [][]string, headers []string) []exportWork {
 		}
 		out = append(out, exportWork{
 			Title:       title,
-			Chapter:     clampChapter(ch),
+			Chapter:     clampChapter(parseProgressValue(safeCell(row, idxProgress))),
+			Volume:      clampVolume(parseProgressValue(safeCell(row, idxVolumes))),
 			Link:        link,
 			Status:      normalizeStatusForWrite(mapAniListStatus(safeCell(row, idxStatus))),
 			ReadingType: normalizeReadingTypeForWrite(mapAniListFormat(safeCell(row, idxType))),
@@ -526,15 +551,15 @@ func parseAniListCSVRecords(records [][]string, headers []string) []exportWork {
 func mapMALStatus(s string) string {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "reading":
-		return "En cours"
+		return statusReading
 	case "completed":
-		return "Terminé"
+		return statusCompleted
 	case "on_hold", "on-hold":
-		return "En pause"
+		return statusOnHold
 	case "dropped":
-		return "Abandonné"
+		return statusDropped
 	case "plan_to_read", "plan to read":
-		return "À lire"
+		return statusPlanned
 	default:
 		return s
 	}
@@ -556,15 +581,15 @@ func mapMALType(s string) string {
 func mapAniListStatus(s string) string {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "current":
-		return "En cours"
+		return statusReading
 	case "completed":
-		return "Terminé"
+		return statusCompleted
 	case "paused":
-		return "En pause"
+		return statusOnHold
 	case "dropped":
-		return "Abandonné"
+		return statusDropped
 	case "planning":
-		return "À lire"
+		return statusPlanned
 	default:
 		return s
 	}
@@ -587,11 +612,11 @@ func parseCSVWorkRow(record []string) (exportWork, bool) {
 	}
 	w := exportWork{
 		Title:       strings.TrimSpace(record[0]),
-		Status:      "En cours",
+		Status:      statusReading,
 		ReadingType: "Manga",
 	}
 	if len(record) > 1 {
-		w.Chapter, _ = strconv.Atoi(strings.TrimSpace(record[1]))
+		w.Chapter = parseProgressValue(record[1])
 	}
 	if len(record) > 2 {
 		w.Link = strings.TrimSpace(record[2])
@@ -634,6 +659,12 @@ func parseCSVWorkRow(record []string) (exportWork, bool) {
 	if len(record) > 12 {
 		w.FinishedAt = strings.TrimSpace(record[12])
 	}
+	if len(record) > 13 {
+		w.Volume = parseProgressValue(record[13])
+	}
+	if len(record) > 14 {
+		w.Tags = parseTagList(record[14])
+	}
 	return w, true
 }
 
@@ -653,6 +684,11 @@ func (a *App) ImportFromJSONBytes(w http.ResponseWriter, r *http.Request, userID
 		Works         []exportWork `json:"works"`
 	}
 	report := ImportReport{}
+	if err := json.Unmarshal(data, &payload); err == nil && payload.ExportVersion > ExportFormatVersion {
+		// Written by a newer BookStorage: fields we do not know would be silently dropped.
+		http.Redirect(w, r, "/dashboard?error=import", http.StatusFound)
+		return
+	}
 	if err := json.Unmarshal(data, &payload); err != nil || len(payload.Works) == 0 {
 		var only []exportWork
 		if err2 := json.Unmarshal(data, &only); err2 != nil || len(only) == 0 {
@@ -669,9 +705,51 @@ func (a *App) ImportFromJSONBytes(w http.ResponseWriter, r *http.Request, userID
 	for i, row := range payload.Works {
 		a.importOneWork(userID, i+1, row, mode, &report)
 	}
+	a.linkImportedSeries(userID, payload.Works, mode, &report)
 	redirectWithImportReport(w, r, report)
 }
 
+// linkImportedSeries restores parent_work_id / series_sort once every row is imported. Exported ids are
+// only valid inside the file, so parents are matched by title like duplicates are. In skip mode, works
+// that already belong to a series are left alone.
+func (a *App) linkImportedSeries(userID int, works []exportWork, mode DuplicateMode, report *ImportReport) {
+	titleByExportID := make(map[int]string, len(works))
+	for _, w := range works {
+		if w.ID > 0 {
+			titleByExportID[w.ID] = strings.TrimSpace(w.Title)
+		}
+	}
+	lookup := func(title string) int {
+		var id int
+		_ = a.DB.QueryRow(`SELECT id FROM works WHERE user_id = ? AND title = ?`, userID, title).Scan(&id)
+		return id
+	}
+	for i, w := range works {
+		if w.ParentWorkID == nil {
+			continue
+		}
+		parentTitle, ok := titleByExportID[*w.ParentWorkID]
+		if !ok {
+			continue
+		}
+		childID, parentID := lookup(strings.TrimSpace(w.Title)), lookup(parentTitle)
+		if childID == 0 || parentID == 0 {
+			continue
+		}
+		if err := a.validateWorkParent(userID, childID, parentID); err != nil {
+			appendImportError(report, i+1, "invalid_parent")
+			continue
+		}
+		query := `UPDATE works SET parent_work_id = ?, series_sort = ? WHERE id = ? AND user_id = ?`
+		if mode != DuplicateUpdate {
+			query += ` AND parent_work_id IS NULL`
+		}
+		if _, err := a.DB.Exec(query, parentID, w.SeriesSort, childID, userID); err != nil {
+			appendImportError(report, i+1, "db_series")
+		}
+	}
+}
+
 func parseAniListExportJSON(data []byte) ([]exportWork, bool) {
 	type aniList struct {
 		Entries []aniImportEntry `json:"entries"`
@@ -717,9 +795,10 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	rows, err := a.DB.Query(
-		`SELECT title, chapter, link, status, reading_type, COALESCE(rating, 0), notes, `+updatedAtExpr+`,
+		`SELECT id, title, chapter, COALESCE(volume, 0), link, status, reading_type, COALESCE(rating, 0), notes, `+updatedAtExpr+`,
                 catalog_id, COALESCE(is_adult, 0), COALESCE(image_path, ''),
-                `+dateExpr("started_at")+`, `+dateExpr("last_chapter_at")+`, `+dateExpr("finished_at")+`
+                `+dateExpr("started_at")+`, `+dateExpr("last_chapter_at")+`, `+dateExpr("finished_at")+`,
+                parent_work_id, COALESCE(series_sort, 0)
          FROM works WHERE user_id = ? ORDER BY title`,
 		userID,
 	)
@@ -729,15 +808,24 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 	}
 	defer func() { _ = rows.Close() }()
 
+	tagsByWorkID := a.tagNamesByWork(userID)
+	readsByWorkID := a.workReadsByWork(userID)
+	copiesByWorkID := a.workCopiesByWork(userID)
 	var works []exportWork
 	for rows.Next() {
 		var w exportWork
+		var workID int
 		var link, status, readingType, notes, imagePath sql.NullString
-		var catalogID sql.NullInt64
+		var catalogID, parentID sql.NullInt64
 		var isAdult int
-		if err := rows.Scan(&w.Title, &w.Chapter, &link, &status, &readingType, &w.Rating, &notes, &w.UpdatedAt, &catalogID, &isAdult, &imagePath, &w.StartedAt, &w.LastChapterAt, &w.FinishedAt); err != nil {
+		if err := rows.Scan(&workID, &w.Title, &w.Chapter, &w.Volume, &link, &status, &readingType, &w.Rating, &notes, &w.UpdatedAt, &catalogID, &isAdult, &imagePath, &w.StartedAt, &w.LastChapterAt, &w.FinishedAt, &parentID, &w.SeriesSort); err != nil {
 			continue
 		}
+		w.ID = workID
+		if parentID.Valid && parentID.Int64 > 0 {
+			pid := int(parentID.Int64)
+			w.ParentWorkID = &pid
+		}
 		if link.Valid {
 			w.Link = link.String
 		}
@@ -758,6 +846,26 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 		if imagePath.Valid {
 			w.ImagePath = imagePath.String
 		}
+		w.Tags = tagsByWorkID[workID]
+		for _, rd := range readsByWorkID[workID] {
+			w.Reads = append(w.Reads, exportRead{
+				ReadNumber: rd.ReadNumber,
+				StartedAt:  rd.StartedAt,
+				FinishedAt: rd.FinishedAt,
+				Rating:     rd.Rating,
+				Notes:      rd.Notes,
+			})
+		}
+		w.RereadCount = len(w.Reads)
+		for _, c := range copiesByWorkID[workID] {
+			w.Collection = append(w.Collection, exportCopy{
+				Volume:      c.Volume,
+				Format:      c.Format,
+				ISBN:        c.ISBN,
+				PurchasedAt: c.PurchasedAt,
+				Price:       c.Price,
+			})
+		}
 		works = append(works, w)
 	}
 
@@ -783,7 +891,7 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 	writer := csv.NewWriter(w)
 	writer.Comma = ';'
 	defer writer.Flush()
-	_ = writer.Write([]string{"Title", "Chapter", "Link", "Status", "Type", "Rating", "Notes", "CatalogID", "IsAdult", "ImagePath", "StartedAt", "LastChapterAt", "FinishedAt"})
+	_ = writer.Write([]string{"Title", "Chapter", "Link", "Status", "Type", "Rating", "Notes", "CatalogID", "IsAdult", "ImagePath", "StartedAt", "LastChapterAt", "FinishedAt", "Volume", "Tags"})
 	for _, row := range works {
 		cat := ""
 		if row.CatalogID != nil {
@@ -795,7 +903,7 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 		}
 		_ = writer.Write([]string{
 			csvSafeCell(row.Title),
-			strconv.Itoa(row.Chapter),
+			formatProgress(row.Chapter),
 			csvSafeCell(row.Link),
 			csvSafeCell(row.Status),
 			csvSafeCell(row.ReadingType),
@@ -807,6 +915,8 @@ func (a *App) HandleExport(w http.ResponseWriter, r *http.Request) {
 			csvSafeCell(row.StartedAt),
 			csvSafeCell(row.LastChapterAt),
 			csvSafeCell(row.FinishedAt),
+			formatProgress(row.Volume),
+			csvSafeCell(strings.Join(row.Tags, ", ")),
 		})
 	}
 }
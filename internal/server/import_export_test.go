@@ -35,7 +35,7 @@ func TestHandleExportCSVFormulaInjection(t *testing.T) {
 	app := &App{Settings: s, DB: db}
 	_, err := db.Exec(
 		`INSERT INTO works (title, chapter, link, notes, user_id, status, reading_type)
-		 VALUES ('=HYPERLINK("http://evil")', 1, '+evil', '+note', 1, 'En cours', 'Manga')`,
+		 VALUES ('=HYPERLINK("http://evil")', 1, '+evil', '+note', 1, 'reading', 'Manga')`,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -71,3 +71,54 @@ func TestHandleExportCSVFormulaInjection(t *testing.T) {
 		t.Fatalf("notes not escaped: %q", row[6])
 	}
 }
+
+func TestParseCSVWorkRow_volumeAndDecimalChapter(t *testing.T) {
+	t.Parallel()
+	row := []string{"LN", "12,5", "", "En cours", "Light Novel", "4", "", "", "0", "", "", "", "", "3.5"}
+	w, ok := parseCSVWorkRow(row)
+	if !ok {
+		t.Fatal("expected row to parse")
+	}
+	if w.Chapter != 12.5 || w.Volume != 3.5 {
+		t.Fatalf("chapter=%v volume=%v", w.Chapter, w.Volume)
+	}
+}
+
+func TestParseMALCSVRecords_volumes(t *testing.T) {
+	t.Parallel()
+	records := [][]string{
+		{"series_title", "my_status", "my_read_chapters", "my_read_volumes", "series_type"},
+		{"Some Novel", "Reading", "54", "6", "Novel"},
+	}
+	works, ok := parseExternalCSVRecords(records)
+	if !ok || len(works) != 1 {
+		t.Fatalf("unexpected parse: %+v ok=%v", works, ok)
+	}
+	if works[0].Chapter != 54 || works[0].Volume != 6 || works[0].ReadingType != "Light Novel" {
+		t.Fatalf("unexpected work: %+v", works[0])
+	}
+}
+
+func TestHandleExportCSV_volumeColumn(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	if _, err := db.Exec(
+		`INSERT INTO works (title, chapter, volume, user_id, status, reading_type)
+		 VALUES ('Vol', 7.5, 2, 1, 'reading', 'Light Novel')`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	rec := httptest.NewRecorder()
+	app.HandleExport(rec, req)
+	r := csv.NewReader(bytes.NewReader(rec.Body.Bytes()[3:]))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil || len(records) < 2 {
+		t.Fatalf("records=%v err=%v", records, err)
+	}
+	if records[0][13] != "Volume" || records[1][1] != "7.5" || records[1][13] != "2" {
+		t.Fatalf("unexpected export: %v", records)
+	}
+}
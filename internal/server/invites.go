@@ -0,0 +1,314 @@
+package server
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/config"
+)
+
+const (
+	// inviteCodeLen is the length of generated invite codes (about 60 bits of entropy).
+	inviteCodeLen = 12
+	// maxInviteUses and maxInviteExpiryDays bound the admin form.
+	maxInviteUses       = 1000
+	maxInviteExpiryDays = 365
+	maxInviteNoteLen    = 200
+)
+
+// inviteCodeAlphabet leaves out characters that are easily confused when typed (0/O, 1/I/L).
+const inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+var (
+	errRegistrationClosed = errors.New("registration closed")
+	errInviteRequired     = errors.New("invite code required")
+	errInviteInvalid      = errors.New("invite code invalid, expired or used up")
+)
+
+func newInviteCode() (string, error) {
+	return randomCodeChars(inviteCodeLen)
+}
+
+// randomCodeChars returns n characters drawn uniformly from inviteCodeAlphabet. Bytes at or above
+// the largest multiple of the alphabet size are discarded so no character is more likely than another.
+func randomCodeChars(n int) (string, error) {
+	const limit = 256 - 256%len(inviteCodeAlphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, c := range buf {
+			if int(c) < limit && len(out) < n {
+				out = append(out, inviteCodeAlphabet[int(c)%len(inviteCodeAlphabet)])
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// normalizeInviteCode accepts codes pasted with spaces, dashes or in lower case.
+func normalizeInviteCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func inviteURL(origin, code string) string {
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	return origin + "/register?invite=" + code
+}
+
+// registrationAdmission is the outcome of admitRegistration for a new account.
+type registrationAdmission struct {
+	// InviteID is the redeemed invite, 0 when none was used.
+	InviteID  int64
+	Validated int
+}
+
+// admitRegistration applies the registration mode to a new account (form, Google or OIDC sign-up).
+// A valid invite is redeemed in every open mode, and a pre-approved one skips admin approval;
+// in invite-only mode it is required. Callers must releaseInvite when the account is not created.
+func (a *App) admitRegistration(inviteCode string, now time.Time) (registrationAdmission, error) {
+	mode := a.Settings.EffectiveRegistrationMode()
+	if mode == config.RegistrationClosed {
+		return registrationAdmission{}, errRegistrationClosed
+	}
+	inviteCode = normalizeInviteCode(inviteCode)
+	if inviteCode == "" && mode == config.RegistrationInvite {
+		return registrationAdmission{}, errInviteRequired
+	}
+	var adm registrationAdmission
+	preApproved := false
+	if inviteCode != "" {
+		id, pre, err := a.redeemInvite(inviteCode, now)
+		if err != nil {
+			return registrationAdmission{}, err
+		}
+		adm.InviteID, preApproved = id, pre
+	}
+	if mode == config.RegistrationOpen || preApproved || (a.Settings != nil && !a.Settings.RequireAccountValidation) {
+		adm.Validated = 1
+	}
+	return adm, nil
+}
+
+// redeemInvite takes one use of code if it is still valid.
+func (a *App) redeemInvite(code string, now time.Time) (int64, bool, error) {
+	res, err := a.DB.Exec(
+		`UPDATE invites SET uses = uses + 1
+		 WHERE code = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)`,
+		code, now,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, false, errInviteInvalid
+	}
+	var id int64
+	var preApproved int
+	if err := a.DB.QueryRow(`SELECT id, pre_approved FROM invites WHERE code = ?`, code).Scan(&id, &preApproved); err != nil {
+		return 0, false, err
+	}
+	return id, preApproved == 1, nil
+}
+
+// releaseInvite gives back a use taken by admitRegistration when the account was not created.
+func (a *App) releaseInvite(inviteID int64) {
+	if inviteID <= 0 {
+		return
+	}
+	_, _ = a.DB.Exec(`UPDATE invites SET uses = uses - 1 WHERE id = ? AND uses > 0`, inviteID)
+}
+
+// inviteIDArg maps "no invite" to NULL for users.invite_id.
+func inviteIDArg(id int64) any {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+type inviteRow struct {
+	ID          int
+	Code        string
+	URL         string
+	CreatedBy   sql.NullString
+	MaxUses     int
+	Uses        int
+	PreApproved bool
+	Note        sql.NullString
+	ExpiresAt   nullFlexTime
+	RevokedAt   nullFlexTime
+	CreatedAt   nullFlexTime
+	Expired     bool
+	Accounts    []string
+}
+
+// Usable reports whether the invite can still create accounts.
+func (i inviteRow) Usable() bool {
+	return !i.RevokedAt.Valid && !i.Expired && i.Uses < i.MaxUses
+}
+
+// listInvites returns the most recent invites with the usernames of the accounts they created.
+func (a *App) listInvites(limit int, now time.Time) ([]inviteRow, error) {
+	rows, err := a.DB.Query(
+		`SELECT i.id, i.code, u.username, i.max_uses, i.uses, i.pre_approved, i.note, i.expires_at, i.revoked_at, i.created_at,
+		        CASE WHEN i.expires_at IS NOT NULL AND i.expires_at <= ? THEN 1 ELSE 0 END
+		 FROM invites i
+		 LEFT JOIN users u ON u.id = i.created_by
+		 ORDER BY i.id DESC
+		 LIMIT ?`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var out []inviteRow
+	byID := map[int]int{}
+	for rows.Next() {
+		var inv inviteRow
+		var pre, expired int
+		if err := rows.Scan(&inv.ID, &inv.Code, &inv.CreatedBy, &inv.MaxUses, &inv.Uses, &pre, &inv.Note, &inv.ExpiresAt, &inv.RevokedAt, &inv.CreatedAt, &expired); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		inv.PreApproved, inv.Expired = pre == 1, expired == 1
+		inv.URL = inviteURL(a.Settings.PublicOrigin, inv.Code)
+		byID[inv.ID] = len(out)
+		out = append(out, inv)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	accounts, err := a.DB.Query(`SELECT invite_id, username FROM users WHERE invite_id IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = accounts.Close() }()
+	for accounts.Next() {
+		var inviteID int
+		var username string
+		if accounts.Scan(&inviteID, &username) != nil {
+			continue
+		}
+		if idx, ok := byID[inviteID]; ok {
+			out[idx].Accounts = append(out[idx].Accounts, username)
+		}
+	}
+	return out, accounts.Err()
+}
+
+// HandleAdminInvites lists invite codes with the form to create one (GET /admin/invites).
+func (a *App) HandleAdminInvites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	invites, err := a.listInvites(200, time.Now().UTC())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	createdID, _ := strconv.Atoi(r.URL.Query().Get("created"))
+	a.renderTemplate(w, r, "admin_invites", a.mergeData(r, map[string]any{
+		"Invites":          invites,
+		"InviteCreatedID":  createdID,
+		"InviteError":      r.URL.Query().Get("error") == "1",
+		"RegistrationMode": a.Settings.EffectiveRegistrationMode(),
+		"MaxInviteUses":    maxInviteUses,
+		"MaxInviteDays":    maxInviteExpiryDays,
+		"MaxInviteNoteLen": maxInviteNoteLen,
+	}))
+}
+
+// HandleAdminInviteCreate creates an invite (POST /admin/invites/create; form fields max_uses,
+// expires_days (0 or empty for none), pre_approved and note).
+func (a *App) HandleAdminInviteCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	actorID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	maxUses, err := strconv.Atoi(strings.TrimSpace(r.FormValue("max_uses")))
+	if err != nil || maxUses < 1 || maxUses > maxInviteUses {
+		http.Redirect(w, r, "/admin/invites?error=1", http.StatusFound)
+		return
+	}
+	days := 0
+	if raw := strings.TrimSpace(r.FormValue("expires_days")); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 0 || days > maxInviteExpiryDays {
+			http.Redirect(w, r, "/admin/invites?error=1", http.StatusFound)
+			return
+		}
+	}
+	var expiresArg any
+	if days > 0 {
+		expiresArg = time.Now().UTC().AddDate(0, 0, days)
+	}
+	preApproved := 0
+	if r.FormValue("pre_approved") != "" {
+		preApproved = 1
+	}
+	note := strings.TrimSpace(r.FormValue("note"))
+	if runes := []rune(note); len(runes) > maxInviteNoteLen {
+		note = string(runes[:maxInviteNoteLen])
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	id, err := a.DB.InsertID(
+		`INSERT INTO invites (code, created_by, max_uses, pre_approved, note, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		code, actorID, maxUses, preApproved, nullStringOrEmpty(note), expiresArg,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.logAdminAction(r, "create_invite", "invite", strconv.FormatInt(id, 10), map[string]any{
+		"max_uses":     maxUses,
+		"expires_days": days,
+		"pre_approved": preApproved == 1,
+		"note":         note,
+	})
+	http.Redirect(w, r, "/admin/invites?created="+strconv.FormatInt(id, 10), http.StatusFound)
+}
+
+// HandleAdminInviteRevoke stops an invite from creating more accounts (POST /admin/invites/{id}/revoke).
+// Accounts it already created are kept.
+func (a *App) HandleAdminInviteRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	res, err := a.DB.Exec(`UPDATE invites SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		a.logAdminAction(r, "revoke_invite", "invite", strconv.Itoa(id), nil)
+	}
+	http.Redirect(w, r, "/admin/invites", http.StatusFound)
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bookstorage/internal/config"
+	"bookstorage/internal/database"
+	"bookstorage/internal/oauthgoogle"
+
+	"golang.org/x/oauth2"
+)
+
+func postRegister(t *testing.T, app *App, form url.Values) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	app.HandleRegister(rec, req)
+	return rec.Header().Get("Location")
+}
+
+func createTestInvite(t *testing.T, app *App, form url.Values) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/invites/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	rec := httptest.NewRecorder()
+	app.HandleAdminInviteCreate(rec, req)
+	if loc := rec.Header().Get("Location"); !strings.HasPrefix(loc, "/admin/invites?created=") {
+		t.Fatalf("create invite redirect %q", loc)
+	}
+	var code string
+	if err := app.DB.QueryRow(`SELECT code FROM invites ORDER BY id DESC LIMIT 1`).Scan(&code); err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestHandleRegister_inviteOnly(t *testing.T) {
+	db, s := openTestDB(t)
+	s.RequireAccountValidation = true
+	s.RegistrationMode = config.RegistrationInvite
+	app := &App{Settings: s, DB: db}
+	preApproved := createTestInvite(t, app, url.Values{"max_uses": {"1"}, "expires_days": {"7"}, "pre_approved": {"1"}, "note": {"book club"}})
+	needsApproval := createTestInvite(t, app, url.Values{"max_uses": {"2"}})
+
+	if loc := postRegister(t, app, url.Values{"username": {"nocode"}, "email": {"a@example.com"}, "password": {"LongEnough!1"}}); !strings.Contains(loc, "error=invite") {
+		t.Fatalf("registration without invite: %q", loc)
+	}
+	if loc := postRegister(t, app, url.Values{"username": {"badcode"}, "email": {"b@example.com"}, "password": {"LongEnough!1"}, "invite": {"NOTACODE"}}); !strings.Contains(loc, "error=invite") {
+		t.Fatalf("registration with unknown invite: %q", loc)
+	}
+
+	// Codes are accepted as typed by hand: lower case, with separators.
+	typed := strings.ToLower(preApproved[:4] + "-" + preApproved[4:])
+	if loc := postRegister(t, app, url.Values{"username": {"friend"}, "email": {"c@example.com"}, "password": {"LongEnough!1"}, "invite": {typed}}); !strings.Contains(loc, "auto=1") {
+		t.Fatalf("pre-approved invite redirect %q", loc)
+	}
+	if loc := postRegister(t, app, url.Values{"username": {"second"}, "email": {"d@example.com"}, "password": {"LongEnough!1"}, "invite": {preApproved}}); !strings.Contains(loc, "error=invite") {
+		t.Fatalf("single-use invite reused: %q", loc)
+	}
+
+	// A failed account creation gives the use back.
+	if loc := postRegister(t, app, url.Values{"username": {"friend"}, "email": {"e@example.com"}, "password": {"LongEnough!1"}, "invite": {needsApproval}}); !strings.Contains(loc, "error=1") {
+		t.Fatalf("duplicate username redirect %q", loc)
+	}
+	if loc := postRegister(t, app, url.Values{"username": {"waiting"}, "email": {"f@example.com"}, "password": {"LongEnough!1"}, "invite": {needsApproval}}); strings.Contains(loc, "auto=1") || strings.Contains(loc, "error") {
+		t.Fatalf("invite needing approval redirect %q", loc)
+	}
+
+	var validated int
+	var inviteCode sql.NullString
+	if err := db.QueryRow(`SELECT u.validated, i.code FROM users u LEFT JOIN invites i ON i.id = u.invite_id WHERE u.username = 'friend'`).Scan(&validated, &inviteCode); err != nil {
+		t.Fatal(err)
+	}
+	if validated != 1 || inviteCode.String != preApproved {
+		t.Fatalf("friend: validated=%d invite=%v", validated, inviteCode)
+	}
+	if err := db.QueryRow(`SELECT u.validated, i.code FROM users u LEFT JOIN invites i ON i.id = u.invite_id WHERE u.username = 'waiting'`).Scan(&validated, &inviteCode); err != nil {
+		t.Fatal(err)
+	}
+	if validated != 0 || inviteCode.String != needsApproval {
+		t.Fatalf("waiting: validated=%d invite=%v", validated, inviteCode)
+	}
+	var uses int
+	_ = db.QueryRow(`SELECT uses FROM invites WHERE code = ?`, needsApproval).Scan(&uses)
+	if uses != 1 {
+		t.Fatalf("uses=%d after one failed and one successful registration", uses)
+	}
+
+	invites, err := app.listInvites(10, time.Now().UTC())
+	if err != nil || len(invites) != 2 {
+		t.Fatalf("invites=%+v err=%v", invites, err)
+	}
+	if got := invites[1].Accounts; len(got) != 1 || got[0] != "friend" || invites[1].Usable() {
+		t.Fatalf("pre-approved invite listing: %+v", invites[1])
+	}
+}
+
+func TestHandleRegister_closedAndRevoked(t *testing.T) {
+	db, s := openTestDB(t)
+	s.RequireAccountValidation = false
+	app := &App{Settings: s, DB: db}
+	code := createTestInvite(t, app, url.Values{"max_uses": {"5"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/invites/1/revoke", nil)
+	req.SetPathValue("id", "1")
+	req.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	app.HandleAdminInviteRevoke(httptest.NewRecorder(), req)
+	if loc := postRegister(t, app, url.Values{"username": {"late"}, "email": {"a@example.com"}, "password": {"LongEnough!1"}, "invite": {code}}); !strings.Contains(loc, "error=invite") {
+		t.Fatalf("revoked invite accepted: %q", loc)
+	}
+	var n int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM admin_audit_log WHERE action IN ('create_invite', 'revoke_invite')`).Scan(&n)
+	if n != 2 {
+		t.Fatalf("%d invite audit entries, want 2", n)
+	}
+
+	s.RegistrationMode = config.RegistrationClosed
+	postRegister(t, app, url.Values{"username": {"closed"}, "email": {"b@example.com"}, "password": {"LongEnough!1"}})
+	_ = db.QueryRow(`SELECT COUNT(*) FROM users WHERE username IN ('late', 'closed')`).Scan(&n)
+	if n != 0 {
+		t.Fatalf("%d accounts created", n)
+	}
+}
+
+func TestHandleGoogleOAuthCallback_inviteOnly(t *testing.T) {
+	oldEx := googleOAuthExchangeHook
+	oldUI := oauthgoogle.TestUserInfoHook
+	defer func() {
+		googleOAuthExchangeHook = oldEx
+		oauthgoogle.TestUserInfoHook = oldUI
+	}()
+	googleOAuthExchangeHook = func(ctx context.Context, cfg *oauth2.Config, code, codeVerifier string) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "mock-token"}, nil
+	}
+	oauthgoogle.TestUserInfoHook = func(ctx context.Context, accessToken string) (oauthgoogle.UserInfo, error) {
+		return oauthgoogle.UserInfo{Sub: "sub-invited", Email: "invited@example.com", EmailVerified: true}, nil
+	}
+
+	s := testSettingsWithGoogle(t, t.TempDir())
+	s.RequireAccountValidation = true
+	s.RegistrationMode = config.RegistrationInvite
+	db, err := database.Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.EnsureSchema(db, s); err != nil {
+		t.Fatal(err)
+	}
+	app := &App{Settings: s, DB: db}
+	code := createTestInvite(t, app, url.Values{"max_uses": {"1"}, "pre_approved": {"1"}})
+
+	callback := func(state, invite string) string {
+		if err := database.InsertOAuthStateWithInvite(db, state, database.OAuthPurposeLogin, sql.NullInt64{}, "", "verifier", invite); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		app.HandleGoogleOAuthCallback(rec, httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=abc&state="+state, nil))
+		return rec.Header().Get("Location")
+	}
+	if loc := callback("state-no-invite", ""); !strings.Contains(loc, "google_error=invite") {
+		t.Fatalf("sign-up without invite: %q", loc)
+	}
+	if loc := callback("state-invite", code); loc != "/dashboard" {
+		t.Fatalf("sign-up with invite: %q", loc)
+	}
+	var inviteID sql.NullInt64
+	if err := db.QueryRow(`SELECT invite_id FROM users WHERE google_sub = 'sub-invited'`).Scan(&inviteID); err != nil || !inviteID.Valid {
+		t.Fatalf("invite_id=%v err=%v", inviteID, err)
+	}
+
+	// Existing Google accounts still sign in once registration is closed.
+	s.RegistrationMode = config.RegistrationClosed
+	if loc := callback("state-closed", ""); loc != "/dashboard" {
+		t.Fatalf("existing account sign-in: %q", loc)
+	}
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/mail"
+)
+
+const (
+	mailStatusPending = "pending"
+	mailStatusSent    = "sent"
+	mailStatusFailed  = "failed"
+	mailStatusExpired = "expired"
+
+	mailOutboxMaxAttempts     = 8
+	mailOutboxDeliveryTimeout = 30 * time.Second
+	mailOutboxWorkerInterval  = 10 * time.Second
+	// mailOutboxDefaultTTL bounds how long a message may wait for the mail backend to come back.
+	mailOutboxDefaultTTL = 24 * time.Hour
+	// mailOutboxRetention is how long delivered, failed and expired rows are kept for the admin view.
+	mailOutboxRetention = 30 * 24 * time.Hour
+)
+
+// outboxSender is the mail.Sender handed to request handlers and schedulers: Send only
+// enqueues into mail_outbox; the mail worker delivers through mail.NewSender.
+type outboxSender struct {
+	app *App
+	ttl time.Duration
+}
+
+func (s outboxSender) Send(_ context.Context, msg mail.Message) error {
+	return s.app.enqueueMail(msg, s.ttl)
+}
+
+// mailOutbox returns a sender that queues messages which expire after ttl if still undelivered
+// (e.g. passwordResetTokenTTL, since the link is useless afterwards).
+func (a *App) mailOutbox(ttl time.Duration) mail.Sender {
+	if ttl <= 0 {
+		ttl = mailOutboxDefaultTTL
+	}
+	return outboxSender{app: a, ttl: ttl}
+}
+
+func (a *App) enqueueMail(msg mail.Message, ttl time.Duration) error {
+	to := strings.TrimSpace(msg.To)
+	if to == "" {
+		return fmt.Errorf("mail: empty recipient")
+	}
+	now := time.Now().UTC()
+	var customID any
+	if id := strings.TrimSpace(msg.CustomID); id != "" {
+		customID = id
+	}
+	_, err := a.DB.Exec(
+		`INSERT INTO mail_outbox (to_addr, subject, text_body, html_body, custom_id, status, attempts, next_retry_at, expires_at, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?, ?)`,
+		to, msg.Subject, msg.TextBody, msg.HTMLBody, customID, mailStatusPending, now, now.Add(ttl), now,
+	)
+	if err != nil {
+		return fmt.Errorf("mail outbox: %w", err)
+	}
+	return nil
+}
+
+// mailRetryDelay doubles from one minute per failed attempt, capped at one hour.
+func mailRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 7 {
+		attempts = 7
+	}
+	if d := time.Duration(1<<(attempts-1)) * time.Minute; d < time.Hour {
+		return d
+	}
+	return time.Hour
+}
+
+func (a *App) deliverMail(ctx context.Context, sender mail.Sender, id int) {
+	var msg mail.Message
+	var customID sql.NullString
+	var attempts int
+	err := a.DB.QueryRow(
+		`SELECT to_addr, subject, text_body, html_body, custom_id, attempts FROM mail_outbox WHERE id = ? AND status = ?`,
+		id, mailStatusPending,
+	).Scan(&msg.To, &msg.Subject, &msg.TextBody, &msg.HTMLBody, &customID, &attempts)
+	if err != nil {
+		return
+	}
+	msg.CustomID = customID.String
+
+	sendCtx, cancel := context.WithTimeout(ctx, mailOutboxDeliveryTimeout)
+	defer cancel()
+	if err := sender.Send(sendCtx, msg); err != nil {
+		a.scheduleMailRetry(id, attempts+1, err)
+		return
+	}
+	// Bodies may carry single-use links (password reset); they are not kept once delivered.
+	_, _ = a.DB.Exec(
+		`UPDATE mail_outbox SET status = ?, attempts = ?, sent_at = ?, last_error = NULL, text_body = '', html_body = '' WHERE id = ?`,
+		mailStatusSent, attempts+1, time.Now().UTC(), id,
+	)
+	mailDeliveries.WithLabelValues(mailStatusSent).Inc()
+}
+
+func (a *App) scheduleMailRetry(id, attempts int, sendErr error) {
+	lastError := sendErr.Error()
+	if len(lastError) > 500 {
+		lastError = lastError[:500]
+	}
+	if attempts >= mailOutboxMaxAttempts {
+		_, _ = a.DB.Exec(
+			`UPDATE mail_outbox SET status = ?, attempts = ?, last_error = ? WHERE id = ?`,
+			mailStatusFailed, attempts, lastError, id,
+		)
+		mailDeliveries.WithLabelValues(mailStatusFailed).Inc()
+		log.Printf("[mail] message %d failed permanently: %v", id, sendErr)
+		return
+	}
+	_, _ = a.DB.Exec(
+		`UPDATE mail_outbox SET attempts = ?, last_error = ?, next_retry_at = ? WHERE id = ?`,
+		attempts, lastError, time.Now().UTC().Add(mailRetryDelay(attempts)), id,
+	)
+	mailDeliveries.WithLabelValues("retry").Inc()
+	log.Printf("[mail] message %d attempt %d failed: %v", id, attempts, sendErr)
+}
+
+// runMailOutboxCycle expires stale messages, purges old rows and delivers due messages.
+// While mail is not configured, messages simply wait for their expiry.
+func (a *App) runMailOutboxCycle(ctx context.Context) {
+	now := time.Now().UTC()
+	if res, err := a.DB.Exec(
+		`UPDATE mail_outbox SET status = ?, text_body = '', html_body = '' WHERE status = ? AND expires_at <= ?`,
+		mailStatusExpired, mailStatusPending, now,
+	); err == nil {
+		if n, _ := res.RowsAffected(); n > 0 {
+			mailDeliveries.WithLabelValues(mailStatusExpired).Add(float64(n))
+			log.Printf("[mail] %d undelivered messages expired", n)
+		}
+	}
+	_, _ = a.DB.Exec(
+		`DELETE FROM mail_outbox WHERE status != ? AND created_at < ?`,
+		mailStatusPending, now.Add(-mailOutboxRetention),
+	)
+	defer a.refreshMailOutboxMetrics()
+
+	if a.Settings == nil || !a.Settings.MailConfigured() {
+		return
+	}
+	rows, err := a.DB.Query(
+		`SELECT id FROM mail_outbox
+		 WHERE status = ? AND (next_retry_at IS NULL OR next_retry_at <= ?)
+		 ORDER BY created_at ASC
+		 LIMIT 20`,
+		mailStatusPending, now,
+	)
+	if err != nil {
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	_ = rows.Close()
+	if len(ids) == 0 {
+		return
+	}
+	sender := mail.NewSender(a.Settings)
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			a.deliverMail(ctx, sender, id)
+		}
+	}
+}
+
+func (a *App) refreshMailOutboxMetrics() {
+	counts := a.mailOutboxCounts()
+	for _, status := range []string{mailStatusPending, mailStatusSent, mailStatusFailed, mailStatusExpired} {
+		mailOutboxMessages.WithLabelValues(status).Set(float64(counts[status]))
+	}
+}
+
+func (a *App) mailOutboxCounts() map[string]int {
+	counts := map[string]int{}
+	rows, err := a.DB.Query(`SELECT status, COUNT(*) FROM mail_outbox GROUP BY status`)
+	if err != nil {
+		return counts
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var status string
+		var n int
+		if rows.Scan(&status, &n) == nil {
+			counts[status] = n
+		}
+	}
+	return counts
+}
+
+// StartMailOutboxWorker launches the goroutine that delivers queued mail until ctx is cancelled.
+func (a *App) StartMailOutboxWorker(ctx context.Context) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[mail] recovered from panic: %v — restarting in 30s", r)
+				time.Sleep(30 * time.Second)
+				a.StartMailOutboxWorker(ctx)
+			}
+		}()
+
+		log.Printf("[mail] outbox worker started — interval %v", mailOutboxWorkerInterval)
+		a.runMailOutboxCycle(ctx)
+
+		ticker := time.NewTicker(mailOutboxWorkerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("[mail] outbox worker stopped")
+				return
+			case <-ticker.C:
+				a.runMailOutboxCycle(ctx)
+			}
+		}
+	}()
+}
+
+type mailOutboxRow struct {
+	ID          int
+	To          string
+	Subject     string
+	Status      string
+	Attempts    int
+	LastError   sql.NullString
+	NextRetryAt nullFlexTime
+	CreatedAt   nullFlexTime
+}
+
+// listStuckMail returns failed and expired messages plus pending ones that already failed at least once.
+func (a *App) listStuckMail(limit int) ([]mailOutboxRow, error) {
+	rows, err := a.DB.Query(
+		`SELECT id, to_addr, subject, status, attempts, last_error, next_retry_at, created_at
+		 FROM mail_outbox
+		 WHERE status IN (?, ?) OR (status = ? AND attempts > 0)
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		mailStatusFailed, mailStatusExpired, mailStatusPending, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []mailOutboxRow
+	for rows.Next() {
+		var m mailOutboxRow
+		if err := rows.Scan(&m.ID, &m.To, &m.Subject, &m.Status, &m.Attempts, &m.LastError, &m.NextRetryAt, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// HandleAdminMail lists stuck and failed outbound mail (GET /admin/mail).
+func (a *App) HandleAdminMail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	messages, err := a.listStuckMail(200)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.renderTemplate(w, r, "admin_mail", a.mergeData(r, map[string]any{
+		"MailMessages":     messages,
+		"MailCounts":       a.mailOutboxCounts(),
+		"MailConfigured":   a.Settings.MailConfigured(),
+		"MailRetryExpired": r.URL.Query().Get("error") == "expired",
+	}))
+}
+
+// HandleAdminMailRetry puts a failed message back in the queue for a fresh series of attempts.
+// Expired messages cannot be retried: their bodies are dropped on expiry. The original expiry is kept,
+// so a reset or verification link is never sent after its token has lapsed; a failed message past
+// its expiry is marked expired instead.
+func (a *App) HandleAdminMailRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	now := time.Now().UTC()
+	res, err := a.DB.Exec(
+		`UPDATE mail_outbox SET status = ?, attempts = 0, next_retry_at = ? WHERE id = ? AND status = ? AND expires_at > ?`,
+		mailStatusPending, now, id, mailStatusFailed, now,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		a.logAdminAction(r, "mail_retry", "mail_outbox", strconv.Itoa(id), nil)
+		http.Redirect(w, r, "/admin/mail", http.StatusFound)
+		return
+	}
+	res, err = a.DB.Exec(
+		`UPDATE mail_outbox SET status = ?, text_body = '', html_body = '' WHERE id = ? AND status = ? AND expires_at <= ?`,
+		mailStatusExpired, id, mailStatusFailed, now,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		http.Redirect(w, r, "/admin/mail?error=expired", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/admin/mail", http.StatusFound)
+}
+
+// HandleAdminMailDelete drops a queued, failed or expired message.
+func (a *App) HandleAdminMailDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	res, err := a.DB.Exec(`DELETE FROM mail_outbox WHERE id = ?`, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		a.logAdminAction(r, "mail_delete", "mail_outbox", strconv.Itoa(id), nil)
+	}
+	http.Redirect(w, r, "/admin/mail", http.StatusFound)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"bookstorage/internal/mail"
+)
+
+func TestMailRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 6: 32 * time.Minute, 7: time.Hour, 20: time.Hour} {
+		if got := mailRetryDelay(attempts); got != want {
+			t.Fatalf("mailRetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestMailOutbox_retryThenFailAndAdminRetry(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	app := &App{Settings: s, DB: db}
+
+	var attempts int
+	sendErr := errors.New("relay unavailable")
+	mail.SetSendHook(func(context.Context, mail.Message) error {
+		attempts++
+		return sendErr
+	})
+	t.Cleanup(func() { mail.SetSendHook(nil) })
+
+	if err := app.mailOutbox(time.Hour).Send(context.Background(), mail.Message{To: "reader@example.com", Subject: "Hi", TextBody: "body"}); err != nil {
+		t.Fatal(err)
+	}
+	var id int
+	if err := db.QueryRow(`SELECT id FROM mail_outbox`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	app.runMailOutboxCycle(context.Background())
+	var status string
+	var stored int
+	var nextRetry nullFlexTime
+	_ = db.QueryRow(`SELECT status, attempts, next_retry_at FROM mail_outbox WHERE id = ?`, id).Scan(&status, &stored, &nextRetry)
+	if attempts != 1 || status != mailStatusPending || stored != 1 || !nextRetry.Valid {
+		t.Fatalf("after first failure: sends=%d status=%q attempts=%d next=%v", attempts, status, stored, nextRetry)
+	}
+	// Not due yet: backoff keeps the worker away.
+	app.runMailOutboxCycle(context.Background())
+	if attempts != 1 {
+		t.Fatalf("retried before backoff elapsed: sends=%d", attempts)
+	}
+
+	for i := 0; i < mailOutboxMaxAttempts; i++ {
+		_, _ = db.Exec(`UPDATE mail_outbox SET next_retry_at = ? WHERE id = ?`, time.Now().UTC().Add(-time.Second), id)
+		app.runMailOutboxCycle(context.Background())
+	}
+	_ = db.QueryRow(`SELECT status, attempts FROM mail_outbox WHERE id = ?`, id).Scan(&status, &stored)
+	if status != mailStatusFailed || stored != mailOutboxMaxAttempts || attempts != mailOutboxMaxAttempts {
+		t.Fatalf("status=%q attempts=%d sends=%d", status, stored, attempts)
+	}
+	stuck, err := app.listStuckMail(10)
+	if err != nil || len(stuck) != 1 || !stuck[0].LastError.Valid || stuck[0].LastError.String != "relay unavailable" {
+		t.Fatalf("stuck=%+v err=%v", stuck, err)
+	}
+
+	// An admin puts it back in the queue; the relay is back.
+	sendErr = nil
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/mail/"+strconv.Itoa(id)+"/retry", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	app.HandleAdminMailRetry(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("retry status %d", rec.Code)
+	}
+	app.runMailOutboxCycle(context.Background())
+	var textBody string
+	_ = db.QueryRow(`SELECT status, text_body FROM mail_outbox WHERE id = ?`, id).Scan(&status, &textBody)
+	if status != mailStatusSent || textBody != "" {
+		t.Fatalf("after admin retry: status=%q body=%q", status, textBody)
+	}
+	if stuck, _ := app.listStuckMail(10); len(stuck) != 0 {
+		t.Fatalf("delivered message still listed: %+v", stuck)
+	}
+}
+
+func TestMailOutbox_expiresStaleMessages(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db} // mail not configured: messages wait in the queue
+
+	if err := app.mailOutbox(time.Minute).Send(context.Background(), mail.Message{To: "reader@example.com", Subject: "Reset", TextBody: "link"}); err != nil {
+		t.Fatal(err)
+	}
+	app.runMailOutboxCycle(context.Background())
+	if counts := app.mailOutboxCounts(); counts[mailStatusPending] != 1 {
+		t.Fatalf("counts=%v", counts)
+	}
+
+	_, _ = db.Exec(`UPDATE mail_outbox SET expires_at = ?`, time.Now().UTC().Add(-time.Second))
+	app.runMailOutboxCycle(context.Background())
+	var status, textBody string
+	_ = db.QueryRow(`SELECT status, text_body FROM mail_outbox`).Scan(&status, &textBody)
+	if status != mailStatusExpired || textBody != "" {
+		t.Fatalf("status=%q body=%q", status, textBody)
+	}
+}
+
+func TestHandleAdminMailRetry_keepsOriginalExpiry(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+
+	for _, to := range []string{"live@example.com", "lapsed@example.com"} {
+		if err := app.mailOutbox(time.Hour).Send(context.Background(), mail.Message{To: to, Subject: "Reset", TextBody: "link"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = db.Exec(`UPDATE mail_outbox SET status = ?, attempts = ?`, mailStatusFailed, mailOutboxMaxAttempts)
+	_, _ = db.Exec(`UPDATE mail_outbox SET expires_at = ? WHERE to_addr = 'lapsed@example.com'`, time.Now().UTC().Add(-time.Second))
+
+	retry := func(to string) (string, string) {
+		t.Helper()
+		var id int
+		var before nullFlexTime
+		if err := db.QueryRow(`SELECT id, expires_at FROM mail_outbox WHERE to_addr = ?`, to).Scan(&id, &before); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/mail/"+strconv.Itoa(id)+"/retry", nil)
+		req.SetPathValue("id", strconv.Itoa(id))
+		app.HandleAdminMailRetry(rec, req)
+		var status string
+		var after nullFlexTime
+		_ = db.QueryRow(`SELECT status, expires_at FROM mail_outbox WHERE id = ?`, id).Scan(&status, &after)
+		if after.String != before.String {
+			t.Fatalf("%s: expiry moved from %q to %q", to, before.String, after.String)
+		}
+		return status, rec.Header().Get("Location")
+	}
+
+	if status, loc := retry("live@example.com"); status != mailStatusPending || loc != "/admin/mail" {
+		t.Fatalf("live: status=%q location=%q", status, loc)
+	}
+	if status, loc := retry("lapsed@example.com"); status != mailStatusExpired || loc != "/admin/mail?error=expired" {
+		t.Fatalf("lapsed: status=%q location=%q", status, loc)
+	}
+}
@@ -32,6 +32,24 @@ var (
 		},
 		[]string{"method", "status_class"},
 	)
+	mailOutboxMessages = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bookstorage",
+			Subsystem: "mail",
+			Name:      "outbox_messages",
+			Help:      "Messages in mail_outbox by status (refreshed by the mail worker).",
+		},
+		[]string{"status"},
+	)
+	mailDeliveries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bookstorage",
+			Subsystem: "mail",
+			Name:      "deliveries_total",
+			Help:      "Outbound mail delivery outcomes: sent, retry (attempt failed), failed (gave up), expired.",
+		},
+		[]string{"result"},
+	)
 )
 
 func httpStatusClass(code int) string {
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/i18n"
+)
+
+// Notification kinds (notifications.kind). Each one has a notifications.kind.<kind> message in
+// the locales, formatted with the number of arguments listed in notificationKindArgs.
+const (
+	notificationAccountApproved = "account_approved"
+	notificationWebhookFailed   = "webhook_failed"
+	notificationSiteDown        = "site_down"
+	notificationSiteUp          = "site_up"
+	notificationLinkDead        = "link_dead"
+	notificationNewChapters     = "new_chapters"
+	// notificationRegistrationPending goes to admins; Subject is the applicant's username.
+	notificationRegistrationPending = "registration_pending"
+)
+
+// notificationKindArgs is how many of (subject, detail) each kind's message uses.
+var notificationKindArgs = map[string]int{
+	notificationAccountApproved:     0,
+	notificationWebhookFailed:       2,
+	notificationSiteDown:            1,
+	notificationSiteUp:              1,
+	notificationLinkDead:            1,
+	notificationNewChapters:         2,
+	notificationRegistrationPending: 1,
+}
+
+const notificationsListLimit = 100
+
+// notification is one inbox entry. Subject and Detail are stored raw and rendered with the
+// reader's language at display time (see notificationMessage).
+type notification struct {
+	ID        int    `json:"id"`
+	Kind      string `json:"kind"`
+	Subject   string `json:"subject,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Link      string `json:"link,omitempty"`
+	Message   string `json:"message"`
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"created_at"`
+	// DedupeKey groups repeated events (same site, same work...): while a notification with the
+	// same key is unread it is refreshed in place instead of piling up a new one.
+	DedupeKey string `json:"-"`
+}
+
+// notify records a notification for userID. Failures are logged, never returned: producers are
+// background jobs and admin actions that must not fail because the inbox could not be written.
+func (a *App) notify(userID int, n notification) {
+	if a.DB == nil || userID <= 0 || n.Kind == "" {
+		return
+	}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	if n.DedupeKey != "" {
+		res, err := a.DB.Exec(
+			`UPDATE notifications SET kind = ?, subject = ?, detail = ?, link = ?, created_at = ?
+			 WHERE user_id = ? AND dedupe_key = ? AND read_at IS NULL`,
+			n.Kind, nullIfEmpty(n.Subject), nullIfEmpty(n.Detail), nullIfEmpty(n.Link), now, userID, n.DedupeKey,
+		)
+		if err == nil {
+			if affected, _ := res.RowsAffected(); affected > 0 {
+				return
+			}
+		}
+	}
+	if _, err := a.DB.Exec(
+		`INSERT INTO notifications (user_id, kind, subject, detail, link, dedupe_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userID, n.Kind, nullIfEmpty(n.Subject), nullIfEmpty(n.Detail), nullIfEmpty(n.Link), nullIfEmpty(n.DedupeKey), now,
+	); err != nil {
+		log.Printf("[notifications] user %d %s: %v", userID, n.Kind, err)
+	}
+}
+
+// notifyNewChapters tells the owner of a followed work that chapters are waiting, once the
+// pollers have recorded a newer latest chapter. Nothing is sent when the reader is caught up.
+func (a *App) notifyNewChapters(workID int) {
+	var wr workRow
+	if err := scanFullWorkRow(&wr, a.DB.QueryRow(`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ?`, workID)); err != nil {
+		return
+	}
+	behind := wr.ChaptersBehind()
+	if behind <= 0 {
+		return
+	}
+	id := strconv.Itoa(wr.ID)
+	a.notify(wr.UserID, notification{
+		Kind:      notificationNewChapters,
+		Subject:   wr.Title,
+		Detail:    strconv.Itoa(behind),
+		Link:      "/edit/" + id,
+		DedupeKey: "chapters:" + id,
+	})
+}
+
+// notificationMessage renders n in the given translations; unknown kinds fall back to the kind itself.
+func notificationMessage(tr map[string]string, n notification) string {
+	format, ok := tr["notifications.kind."+n.Kind]
+	if !ok {
+		return n.Kind
+	}
+	args := []any{n.Subject, n.Detail}
+	return fmt.Sprintf(format, args[:notificationKindArgs[n.Kind]]...)
+}
+
+func (a *App) listNotifications(userID int, unreadOnly bool, tr map[string]string) ([]notification, error) {
+	filter := ""
+	if unreadOnly {
+		filter = " AND read_at IS NULL"
+	}
+	rows, err := a.DB.Query(
+		`SELECT id, kind, COALESCE(subject, ''), COALESCE(detail, ''), COALESCE(link, ''), read_at, created_at
+		 FROM notifications WHERE user_id = ?`+filter+` ORDER BY created_at DESC, id DESC LIMIT ?`,
+		userID, notificationsListLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := []notification{}
+	for rows.Next() {
+		var n notification
+		var readAt, createdAt nullFlexTime
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Subject, &n.Detail, &n.Link, &readAt, &createdAt); err != nil {
+			return nil, err
+		}
+		n.Read, n.CreatedAt = readAt.Valid, createdAt.String
+		n.Message = notificationMessage(tr, n)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (a *App) unreadNotificationCount(userID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&n)
+	return n
+}
+
+// markNotificationsRead marks the given ids (or every unread notification when ids is empty)
+// as read and returns how many changed.
+func (a *App) markNotificationsRead(userID int, ids []int) (int64, error) {
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	q := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []any{now, userID}
+	if len(ids) > 0 {
+		q += ` AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	res, err := a.DB.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// HandleAPINotifications serves GET and POST /api/notifications.
+// GET lists the newest notifications (?unread=1 for unread only) with meta.unread;
+// POST body {"ids": [1, 2]} or {"all": true} marks them as read.
+func (a *App) HandleAPINotifications(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	switch r.Method {
+	case http.MethodGet:
+		items, err := a.listNotifications(userID, r.URL.Query().Get("unread") == "1", i18n.T(a.currentLang(r)))
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		a.apiWriteJSON(w, http.StatusOK, map[string]any{
+			"data": items,
+			"meta": map[string]any{"unread": a.unreadNotificationCount(userID)},
+		})
+	case http.MethodPost:
+		var req struct {
+			IDs []int `json:"ids"`
+			All bool  `json:"all"`
+		}
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+		if len(req.IDs) == 0 && !req.All {
+			a.apiWriteError(w, http.StatusBadRequest, "missing_ids")
+			return
+		}
+		if req.All {
+			req.IDs = nil
+		}
+		updated, err := a.markNotificationsRead(userID, req.IDs)
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		a.apiWriteJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{"updated": updated, "unread": a.unreadNotificationCount(userID)},
+		})
+	default:
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+// HandleNotificationsPage renders the notification inbox.
+func (a *App) HandleNotificationsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	lang := a.currentLang(r)
+	items, _ := a.listNotifications(userID, false, i18n.T(lang))
+	a.renderTemplate(w, r, "notifications", a.mergeData(r, map[string]any{
+		"Notifications":     items,
+		"MobileTopbarTitle": i18n.T(lang)["notifications.title"],
+	}))
+}
+
+// HandleNotificationsRead marks one notification (id) or all of them as read from the inbox form.
+// With open=1 it then follows the notification's link.
+func (a *App) HandleNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	if id <= 0 {
+		_, _ = a.markNotificationsRead(userID, nil)
+		http.Redirect(w, r, "/notifications", http.StatusFound)
+		return
+	}
+	_, _ = a.markNotificationsRead(userID, []int{id})
+	target := "/notifications"
+	if r.FormValue("open") == "1" {
+		var link string
+		_ = a.DB.QueryRow(`SELECT COALESCE(link, '') FROM notifications WHERE id = ? AND user_id = ?`, id, userID).Scan(&link)
+		if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+			target = link
+		}
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
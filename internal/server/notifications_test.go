@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNotifications_approveThenMarkRead(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	adminToken := mustCreateSession(t, app, 1)
+	res, err := db.Exec(`INSERT INTO users (username, password, validated, is_admin) VALUES ('newreader', 'x', 0, 0)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id64, _ := res.LastInsertId()
+	uid := int(id64)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/approve/"+strconv.Itoa(uid), nil)
+	req.SetPathValue("id", strconv.Itoa(uid))
+	req.AddCookie(&http.Cookie{Name: "session", Value: adminToken})
+	app.HandleApproveAccount(httptest.NewRecorder(), req)
+
+	session := mustCreateSession(t, app, uid)
+	call := func(method, body string) map[string]json.RawMessage {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/notifications", strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		rec := httptest.NewRecorder()
+		app.HandleAPINotifications(rec, req)
+		var payload map[string]json.RawMessage
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &payload) != nil {
+			t.Fatalf("%s status=%d body=%s", method, rec.Code, rec.Body.String())
+		}
+		return payload
+	}
+
+	listed := call(http.MethodGet, "")
+	var items []notification
+	if err := json.Unmarshal(listed["data"], &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Kind != notificationAccountApproved || items[0].Read || !strings.Contains(items[0].Message, "approved") {
+		t.Fatalf("items=%+v", items)
+	}
+	if string(listed["meta"]) != `{"unread":1}` {
+		t.Fatalf("meta=%s", listed["meta"])
+	}
+
+	marked := call(http.MethodPost, `{"all": true}`)
+	if string(marked["data"]) != `{"unread":0,"updated":1}` {
+		t.Fatalf("mark read=%s", marked["data"])
+	}
+}
+
+func TestNotify_dedupeWhileUnread(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	site := readingSite{ID: 7, UserID: 1, Name: "Reader", ProbeStatus: string(ProbeStatusUp)}
+
+	app.notifySiteStatusChange(site, ProbeStatusDown)
+	site.ProbeStatus = string(ProbeStatusDown)
+	app.notifySiteStatusChange(site, ProbeStatusDegraded) // still down: nothing new
+	app.notifySiteStatusChange(site, ProbeStatusUp)       // recovery replaces the unread alert
+
+	items, err := app.listNotifications(1, false, map[string]string{"notifications.kind.site_up": "%s is back"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Kind != notificationSiteUp || items[0].Message != "Reader is back" {
+		t.Fatalf("items=%+v", items)
+	}
+
+	if _, err := app.markNotificationsRead(1, []int{items[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	app.notifySiteStatusChange(readingSite{ID: 7, UserID: 1, Name: "Reader", ProbeStatus: string(ProbeStatusUp)}, ProbeStatusDown)
+	if n := app.unreadNotificationCount(1); n != 1 {
+		t.Fatalf("unread=%d after a new outage", n)
+	}
+}
+
+func TestProbeWorkLinks_notifiesDeadLinkOnce(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	workID := insertTestWork(t, app, "Broken", 3)
+	// Loopback is rejected by the SSRF guard, so the probe reports "down" without any network.
+	if _, err := db.Exec(`UPDATE works SET link = 'http://127.0.0.1/broken', link_probe_status = 'up' WHERE id = ?`, workID); err != nil {
+		t.Fatal(err)
+	}
+
+	app.ProbeWorkLinks(context.Background(), 10)
+	app.ProbeWorkLinks(context.Background(), 10)
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = 1 AND kind = ?`, notificationLinkDead).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("link_dead notifications=%d err=%v", count, err)
+	}
+}
+
+func TestMergeData_unreadNotificationsCountedOnRender(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	data := app.mergeData(req, nil)
+
+	// Nothing is counted until a template reads the badge, then the count is kept for the request.
+	app.notifySiteStatusChange(readingSite{ID: 7, UserID: 1, Name: "Reader", ProbeStatus: string(ProbeStatusUp)}, ProbeStatusDown)
+	nav := template.Must(template.New("nav").Parse(`{{ with .UnreadNotifications }}{{ with .Count }}{{ . }}{{ end }}{{ end }}`))
+	var out strings.Builder
+	for range 2 {
+		if err := nav.Execute(&out, data); err != nil {
+			t.Fatal(err)
+		}
+		app.notifySiteStatusChange(readingSite{ID: 8, UserID: 1, Name: "Other", ProbeStatus: string(ProbeStatusUp)}, ProbeStatusDown)
+	}
+	if out.String() != "11" {
+		t.Fatalf("badge renders %q", out.String())
+	}
+	if _, ok := app.mergeData(httptest.NewRequest(http.MethodGet, "/", nil), nil)["UnreadNotifications"]; ok {
+		t.Fatal("badge data for a signed-out visitor")
+	}
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxOAuthClientNameLen     = 80
+	maxOAuthClientRedirectURI = 10
+)
+
+// oauthClient is an application registered by an admin to obtain API access through /oauth/authorize.
+// Public clients (no secret) rely on PKCE alone, which every client must use anyway.
+type oauthClient struct {
+	ID           int
+	ClientID     string
+	Name         string
+	SecretHash   sql.NullString
+	RedirectURIs []string
+	Scopes       []string
+	CreatedBy    sql.NullString
+	CreatedAt    time.Time
+	RevokedAt    sql.NullTime
+	// Users counts accounts that currently authorize the client.
+	Users int
+}
+
+func (c oauthClient) Confidential() bool { return c.SecretHash.Valid && c.SecretHash.String != "" }
+
+func randomOAuthString(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validOAuthRedirectURI accepts https URLs, http on a loopback address and the private-use
+// schemes of native apps (RFC 8252, e.g. com.example.app:/callback). Fragments are not allowed.
+func validOAuthRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Fragment != "" || u.Scheme == "" || len(raw) > 500 {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		return strings.Contains(u.Scheme, ".")
+	}
+}
+
+// parseOAuthRedirectURIs splits the admin form field (one URI per line or space-separated).
+func parseOAuthRedirectURIs(raw string) ([]string, bool) {
+	var out []string
+	seen := map[string]bool{}
+	for _, uri := range strings.Fields(raw) {
+		if !validOAuthRedirectURI(uri) {
+			return nil, false
+		}
+		if !seen[uri] {
+			seen[uri] = true
+			out = append(out, uri)
+		}
+	}
+	return out, len(out) > 0 && len(out) <= maxOAuthClientRedirectURI
+}
+
+func scanOAuthClient(scan func(dest ...any) error) (oauthClient, error) {
+	var c oauthClient
+	var redirectsRaw, scopesRaw string
+	if err := scan(&c.ID, &c.ClientID, &c.Name, &c.SecretHash, &redirectsRaw, &scopesRaw, &c.CreatedAt, &c.RevokedAt); err != nil {
+		return oauthClient{}, err
+	}
+	_ = json.Unmarshal([]byte(redirectsRaw), &c.RedirectURIs)
+	c.Scopes = decodeAPIScopes(scopesRaw)
+	return c, nil
+}
+
+// oauthClientByClientID returns an active client; revoked clients are reported as sql.ErrNoRows.
+func (a *App) oauthClientByClientID(clientID string) (oauthClient, error) {
+	clientID = strings.TrimSpace(clientID)
+	if clientID == "" {
+		return oauthClient{}, sql.ErrNoRows
+	}
+	c, err := scanOAuthClient(a.DB.QueryRow(
+		`SELECT id, client_id, name, secret_hash, redirect_uris, scopes, created_at, revoked_at
+		 FROM oauth_clients WHERE client_id = ?`,
+		clientID,
+	).Scan)
+	if err == nil && c.RevokedAt.Valid {
+		return oauthClient{}, sql.ErrNoRows
+	}
+	return c, err
+}
+
+// authenticate checks the client secret of a confidential client; public clients must not send one.
+func (c oauthClient) authenticate(secret string) bool {
+	if !c.Confidential() {
+		return secret == ""
+	}
+	return secret != "" && subtle.ConstantTimeCompare([]byte(hashAPIToken(secret)), []byte(c.SecretHash.String)) == 1
+}
+
+func (c oauthClient) allowsRedirect(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) listOAuthClients() ([]oauthClient, error) {
+	rows, err := a.DB.Query(
+		`SELECT c.id, c.client_id, c.name, c.secret_hash, c.redirect_uris, c.scopes, c.created_at, c.revoked_at,
+		        u.username, (SELECT COUNT(*) FROM oauth_grants g WHERE g.client_id = c.id)
+		 FROM oauth_clients c LEFT JOIN users u ON u.id = c.created_by
+		 ORDER BY c.revoked_at IS NOT NULL, c.created_at DESC, c.id DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []oauthClient
+	for rows.Next() {
+		var createdBy sql.NullString
+		var users int
+		c, err := scanOAuthClient(func(dest ...any) error {
+			return rows.Scan(append(dest, &createdBy, &users)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.CreatedBy, c.Users = createdBy, users
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// HandleAdminOAuthClients lists registered OAuth clients with the form to add one (GET /admin/oauth-clients).
+func (a *App) HandleAdminOAuthClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	clients, err := a.listOAuthClients()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	createdID, _ := strconv.Atoi(q.Get("created"))
+	data := map[string]any{
+		"OAuthClients":        clients,
+		"OAuthClientCreated":  createdID,
+		"OAuthClientError":    q.Get("error") == "1",
+		"OAuthScopes":         sortedAPIScopes(),
+		"OAuthPublicOrigin":   a.Settings.PublicOrigin,
+		"MaxOAuthClientName":  maxOAuthClientNameLen,
+		"MaxOAuthRedirectURI": maxOAuthClientRedirectURI,
+	}
+	if actorID, ok := a.currentUserID(r); ok {
+		if secret, ok := a.consumeAPITokenFlash(actorID, q.Get("secret_flash")); ok {
+			data["OAuthClientSecret"] = secret
+		}
+	}
+	a.renderTemplate(w, r, "admin_oauth_clients", a.mergeData(r, data))
+}
+
+// HandleAdminOAuthClientCreate registers a client (POST /admin/oauth-clients/create; form fields name,
+// redirect_uris, scopes and confidential). The secret is shown once on the next page.
+func (a *App) HandleAdminOAuthClientCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	actorID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(w, r, "/admin/oauth-clients?error=1", http.StatusFound)
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	redirects, ok := parseOAuthRedirectURIs(r.FormValue("redirect_uris"))
+	scopes := normalizeAPIScopes(r.Form["scopes"])
+	if name == "" || len([]rune(name)) > maxOAuthClientNameLen || !ok || len(scopes) == 0 {
+		http.Redirect(w, r, "/admin/oauth-clients?error=1", http.StatusFound)
+		return
+	}
+	clientID, err := randomOAuthString("bsc_", 16)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	secret := ""
+	var secretHash any
+	if r.FormValue("confidential") != "" {
+		if secret, err = randomOAuthString("bss_", 32); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		secretHash = hashAPIToken(secret)
+	}
+	redirectsJSON, _ := json.Marshal(redirects)
+	id, err := a.DB.InsertID(
+		`INSERT INTO oauth_clients (client_id, name, secret_hash, redirect_uris, scopes, created_by) VALUES (?, ?, ?, ?, ?, ?)`,
+		clientID, name, secretHash, string(redirectsJSON), encodeAPIScopes(scopes), actorID,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.logAdminAction(r, "create_oauth_client", "oauth_client", strconv.FormatInt(id, 10), map[string]any{
+		"name":          name,
+		"client_id":     clientID,
+		"redirect_uris": redirects,
+		"scopes":        scopes,
+		"confidential":  secret != "",
+	})
+	target := "/admin/oauth-clients?created=" + strconv.FormatInt(id, 10)
+	if secret != "" {
+		if nonce, err := a.storeAPITokenFlash(actorID, secret); err == nil {
+			target += "&secret_flash=" + url.QueryEscape(nonce)
+		}
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// HandleAdminOAuthClientRevoke disables a client and drops every authorization and token issued to it
+// (POST /admin/oauth-clients/{id}/revoke).
+func (a *App) HandleAdminOAuthClientRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	res, err := a.DB.Exec(`UPDATE oauth_clients SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if _, err := a.DB.Exec(`DELETE FROM oauth_grants WHERE client_id = ?`, id); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		a.logAdminAction(r, "revoke_oauth_client", "oauth_client", strconv.Itoa(id), nil)
+	}
+	http.Redirect(w, r, "/admin/oauth-clients", http.StatusFound)
+}
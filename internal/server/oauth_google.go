@@ -29,7 +29,8 @@ func googleExchange(ctx context.Context, cfg *oauth2.Config, code, codeVerifier
 	return oauthgoogle.Exchange(ctx, cfg, code, codeVerifier)
 }
 
-func sanitizeGoogleUsernamePart(s string) string {
+// sanitizeUsernamePart keeps the characters allowed in generated usernames (SSO sign-up).
+func sanitizeUsernamePart(s string) string {
 	var b strings.Builder
 	for _, r := range strings.ToLower(s) {
 		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
@@ -51,7 +52,12 @@ func (a *App) allocateGoogleUsername(email string) (string, error) {
 	if i := strings.LastIndex(email, "@"); i > 0 {
 		local = email[:i]
 	}
-	base := sanitizeGoogleUsernamePart(local)
+	return a.allocateUsername(local)
+}
+
+// allocateUsername returns the sanitized preferred name, suffixed with _1, _2... until it is free.
+func (a *App) allocateUsername(preferred string) (string, error) {
+	base := sanitizeUsernamePart(preferred)
 	for n := 0; n < 200; n++ {
 		candidate := base
 		if n > 0 {
@@ -66,7 +72,7 @@ func (a *App) allocateGoogleUsername(email string) (string, error) {
 			return "", err
 		}
 	}
-	return "", fmt.Errorf("no available username for google signup")
+	return "", fmt.Errorf("no available username for %q", preferred)
 }
 
 // HandleGoogleOAuthStart begins the Google login/signup flow (GET /auth/google).
@@ -98,7 +104,9 @@ func (a *App) HandleGoogleOAuthStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	next := safePostLoginRedirect(strings.TrimSpace(r.URL.Query().Get("next")))
-	if err := database.InsertOAuthState(a.DB, statePlain, database.OAuthPurposeLogin, sql.NullInt64{Valid: false}, next, verifier); err != nil {
+	// An invite (from /register?invite=) only matters if the callback creates a new account.
+	invite := normalizeInviteCode(r.URL.Query().Get("invite"))
+	if err := database.InsertOAuthStateWithInvite(a.DB, statePlain, database.OAuthPurposeLogin, sql.NullInt64{Valid: false}, next, verifier, invite); err != nil {
 		http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
 		return
 	}
@@ -178,6 +186,11 @@ func (a *App) HandleGoogleOAuthCallback(w http.ResponseWriter, r *http.Request)
 		http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
 		return
 	}
+	if row.Provider != "" {
+		// State minted for an OIDC provider must not complete a Google login.
+		http.Redirect(w, r, "/login?google_error=state", http.StatusFound)
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
@@ -208,7 +221,7 @@ func (a *App) HandleGoogleOAuthCallback(w http.ResponseWriter, r *http.Request)
 		a.handleGoogleLinkCallback(w, r, uid, info.Sub, info.Email)
 		return
 	case database.OAuthPurposeLogin:
-		a.handleGoogleLoginCallback(w, r, row.Next, info.Sub, info.Email)
+		a.handleGoogleLoginCallback(w, r, row.Next, row.InviteCode, info.Sub, info.Email)
 		return
 	default:
 		http.Redirect(w, r, "/login?google_error=state", http.StatusFound)
@@ -265,7 +278,7 @@ func nullStringOrEmpty(s string) any {
 	return s
 }
 
-func (a *App) handleGoogleLoginCallback(w http.ResponseWriter, r *http.Request, nextPath, googleSub, googleEmail string) {
+func (a *App) handleGoogleLoginCallback(w http.ResponseWriter, r *http.Request, nextPath, inviteCode, googleSub, googleEmail string) {
 	var u struct {
 		id           int
 		validated    int
@@ -282,17 +295,9 @@ func (a *App) handleGoogleLoginCallback(w http.ResponseWriter, r *http.Request,
 			http.Redirect(w, r, "/login?pending=1", http.StatusFound)
 			return
 		}
-		token, err := a.createSession(r, u.id)
-		if err != nil {
+		if err := a.completeLogin(w, r, u.id, nextPath); err != nil {
 			http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
-			return
-		}
-		a.setSessionCookie(w, token, sessionSlidingTTL)
-		dest := safePostLoginRedirect(nextPath)
-		if dest == "" {
-			dest = "/dashboard"
 		}
-		http.Redirect(w, r, dest, http.StatusFound)
 		return
 	}
 	if !errors.Is(err, sql.ErrNoRows) {
@@ -300,31 +305,39 @@ func (a *App) handleGoogleLoginCallback(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// New user from Google
-	username, err := a.allocateGoogleUsername(googleEmail)
-	if err != nil {
+	// New user from Google: same registration mode and invite rules as the form.
+	now := time.Now().UTC()
+	adm, err := a.admitRegistration(inviteCode, now)
+	switch {
+	case errors.Is(err, errRegistrationClosed):
+		http.Redirect(w, r, "/login?google_error=closed", http.StatusFound)
+		return
+	case errors.Is(err, errInviteRequired), errors.Is(err, errInviteInvalid):
+		http.Redirect(w, r, "/login?google_error=invite", http.StatusFound)
+		return
+	case err != nil:
 		http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
 		return
 	}
-	validated := 0
-	if a.Settings != nil && !a.Settings.RequireAccountValidation {
-		validated = 1
-	}
-	res, err := a.DB.Exec(
-		`INSERT INTO users (username, password, validated, is_admin, google_sub, google_email)
-		 VALUES (?, NULL, ?, 0, ?, ?)`,
-		username, validated, googleSub, nullStringOrEmpty(googleEmail),
-	)
+	username, err := a.allocateGoogleUsername(googleEmail)
 	if err != nil {
+		a.releaseInvite(adm.InviteID)
 		http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
 		return
 	}
-	newID, err := res.LastInsertId()
+	validated := adm.Validated
+	newID, err := a.DB.InsertID(
+		`INSERT INTO users (username, password, validated, is_admin, google_sub, google_email, registered_at, signup_lang, invite_id)
+		 VALUES (?, NULL, ?, 0, ?, ?, ?, ?, ?)`,
+		username, validated, googleSub, nullStringOrEmpty(googleEmail), now.Format("2006-01-02 15:04:05"), a.currentLang(r), inviteIDArg(adm.InviteID),
+	)
 	if err != nil || newID <= 0 {
+		a.releaseInvite(adm.InviteID)
 		http.Redirect(w, r, "/login?google_error=server", http.StatusFound)
 		return
 	}
 	if validated == 0 {
+		a.notifyPendingRegistration(r.Context(), int(newID), username)
 		http.Redirect(w, r, "/login?pending=1", http.StatusFound)
 		return
 	}
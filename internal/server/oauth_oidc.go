@@ -0,0 +1,399 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/config"
+	"bookstorage/internal/database"
+	"bookstorage/internal/oauthgoogle"
+	"bookstorage/internal/oidc"
+)
+
+// oidcLoginButton is one "Continue with ..." entry on the login and register pages.
+type oidcLoginButton struct {
+	ID   string
+	Name string
+	URL  string
+}
+
+// userIdentity is a row of user_identities: one account at an OIDC provider linked to a user.
+type userIdentity struct {
+	ID           int
+	Provider     string
+	ProviderName string
+	Subject      string
+	Email        string
+	CreatedAt    string
+	LastLoginAt  string
+}
+
+func (a *App) oidcProvider(id string) (*oidc.Provider, bool) {
+	if a.Settings == nil {
+		return nil, false
+	}
+	p, ok := a.Settings.OIDCProvider(id)
+	if !ok {
+		return nil, false
+	}
+	return oidc.For(p, a.Settings.PublicOrigin), true
+}
+
+// oidcLoginButtons lists the configured providers; next and invite are forwarded to the start URL.
+func (a *App) oidcLoginButtons(next, invite string) []oidcLoginButton {
+	if a.Settings == nil || !a.Settings.OIDCConfigured() {
+		return nil
+	}
+	q := url.Values{}
+	if next != "" {
+		q.Set("next", next)
+	}
+	if invite != "" {
+		q.Set("invite", invite)
+	}
+	suffix := ""
+	if len(q) > 0 {
+		suffix = "?" + q.Encode()
+	}
+	out := make([]oidcLoginButton, 0, len(a.Settings.OIDCProviders))
+	for _, p := range a.Settings.OIDCProviders {
+		out = append(out, oidcLoginButton{ID: p.ID, Name: p.Name, URL: "/auth/oidc/" + p.ID + suffix})
+	}
+	return out
+}
+
+func (a *App) oidcProviderName(id string) string {
+	if p, ok := a.Settings.OIDCProvider(id); ok {
+		return p.Name
+	}
+	return id
+}
+
+func (a *App) listUserIdentities(userID int) ([]userIdentity, error) {
+	rows, err := a.DB.Query(
+		`SELECT id, provider, subject, email, created_at, last_login_at
+		 FROM user_identities WHERE user_id = ? AND provider <> ? ORDER BY created_at, id`,
+		userID, proxyIdentityProvider,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []userIdentity
+	for rows.Next() {
+		var it userIdentity
+		var email sql.NullString
+		var created, lastLogin any
+		if err := rows.Scan(&it.ID, &it.Provider, &it.Subject, &email, &created, &lastLogin); err != nil {
+			return nil, err
+		}
+		it.Email = email.String
+		it.ProviderName = a.oidcProviderName(it.Provider)
+		if created != nil {
+			it.CreatedAt = formatFlexTime(created)
+		}
+		if lastLogin != nil {
+			it.LastLoginAt = formatFlexTime(lastLogin)
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+func (a *App) userIdentityCount(userID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE user_id = ?`, userID).Scan(&n)
+	return n
+}
+
+// oidcIdentityCount counts the identities listed on the profile: the proxy one only works behind
+// the proxy, so it does not count as a way to sign in once an OIDC login is unlinked.
+func (a *App) oidcIdentityCount(userID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE user_id = ? AND provider <> ?`, userID, proxyIdentityProvider).Scan(&n)
+	return n
+}
+
+// oidcCanUnlink reports whether the user keeps a way to sign in after dropping one linked identity.
+func (a *App) oidcCanUnlink(userID int) bool {
+	var pwd, googleSub sql.NullString
+	if err := a.DB.QueryRow(`SELECT password, google_sub FROM users WHERE id = ?`, userID).Scan(&pwd, &googleSub); err != nil {
+		return false
+	}
+	if strings.TrimSpace(pwd.String) != "" || strings.TrimSpace(googleSub.String) != "" {
+		return true
+	}
+	return a.oidcIdentityCount(userID) > 1 || a.userHasPasskeys(userID)
+}
+
+// beginOIDC stores the state row and redirects to the provider's authorization endpoint.
+func (a *App) beginOIDC(w http.ResponseWriter, r *http.Request, prov *oidc.Provider, row database.OAuthStateRow, errorBase string) {
+	database.DeleteExpiredOAuthStates(a.DB)
+
+	statePlain, err := database.NewOAuthStatePlain()
+	if err != nil {
+		http.Redirect(w, r, errorBase+"server", http.StatusFound)
+		return
+	}
+	if row.CodeVerifier, err = oauthgoogle.NewPKCEVerifier(); err != nil {
+		http.Redirect(w, r, errorBase+"server", http.StatusFound)
+		return
+	}
+	if row.Nonce, err = oidc.NewNonce(); err != nil {
+		http.Redirect(w, r, errorBase+"server", http.StatusFound)
+		return
+	}
+	row.Provider = prov.Config.ID
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	authURL, err := prov.AuthCodeURL(ctx, statePlain, row.CodeVerifier, row.Nonce)
+	if err != nil {
+		http.Redirect(w, r, errorBase+"provider", http.StatusFound)
+		return
+	}
+	if err := database.InsertOAuthStateRow(a.DB, statePlain, row); err != nil {
+		http.Redirect(w, r, errorBase+"server", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandleOIDCStart begins login or sign-up with an OIDC provider (GET /auth/oidc/{provider}).
+func (a *App) HandleOIDCStart(w http.ResponseWriter, r *http.Request) {
+	prov, ok := a.oidcProvider(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	a.beginOIDC(w, r, prov, database.OAuthStateRow{
+		Purpose: database.OAuthPurposeLogin,
+		Next:    safePostLoginRedirect(strings.TrimSpace(r.URL.Query().Get("next"))),
+		// As for Google, an invite only matters if the callback creates a new account.
+		InviteCode: normalizeInviteCode(r.URL.Query().Get("invite")),
+	}, "/login?oidc_error=")
+}
+
+// HandleOIDCLink begins linking a provider account to the logged-in user (GET /auth/oidc/{provider}/link).
+func (a *App) HandleOIDCLink(w http.ResponseWriter, r *http.Request) {
+	prov, ok := a.oidcProvider(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	a.beginOIDC(w, r, prov, database.OAuthStateRow{
+		Purpose: database.OAuthPurposeLink,
+		UserID:  sql.NullInt64{Int64: int64(userID), Valid: true},
+	}, "/profile?oidc_error=")
+}
+
+// HandleOIDCCallback completes the authorization code flow (GET /auth/oidc/{provider}/callback).
+func (a *App) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	prov, ok := a.oidcProvider(r.PathValue("provider"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	code := strings.TrimSpace(q.Get("code"))
+	statePlain := strings.TrimSpace(q.Get("state"))
+	if code == "" || statePlain == "" {
+		http.Redirect(w, r, "/login?oidc_error=token", http.StatusFound)
+		return
+	}
+	row, err := database.ConsumeOAuthState(a.DB, statePlain)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Redirect(w, r, "/login?oidc_error=state", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+	// The state names the provider it was issued for: a code from one provider cannot be
+	// replayed against another provider's callback (or Google's).
+	if row.Provider != prov.Config.ID || row.Nonce == "" {
+		http.Redirect(w, r, "/login?oidc_error=state", http.StatusFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	rawIDToken, err := prov.Exchange(ctx, code, row.CodeVerifier)
+	if err != nil {
+		http.Redirect(w, r, "/login?oidc_error=token", http.StatusFound)
+		return
+	}
+	claims, err := prov.VerifyIDToken(ctx, rawIDToken, row.Nonce, time.Now())
+	if err != nil {
+		http.Redirect(w, r, "/login?oidc_error=token", http.StatusFound)
+		return
+	}
+
+	switch row.Purpose {
+	case database.OAuthPurposeLink:
+		if !row.UserID.Valid {
+			http.Redirect(w, r, "/login?oidc_error=state", http.StatusFound)
+			return
+		}
+		a.handleOIDCLinkCallback(w, r, int(row.UserID.Int64), prov.Config.ID, claims)
+	case database.OAuthPurposeLogin:
+		a.handleOIDCLoginCallback(w, r, prov, row.Next, row.InviteCode, claims)
+	default:
+		http.Redirect(w, r, "/login?oidc_error=state", http.StatusFound)
+	}
+}
+
+func (a *App) handleOIDCLinkCallback(w http.ResponseWriter, r *http.Request, userID int, provider string, claims oidc.Claims) {
+	curID, ok := a.currentUserID(r)
+	if !ok || curID != userID {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	var owner int
+	err := a.DB.QueryRow(`SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`, provider, claims.Subject()).Scan(&owner)
+	switch {
+	case err == nil && owner == userID:
+		http.Redirect(w, r, "/profile?oidc_linked=1", http.StatusFound)
+		return
+	case err == nil:
+		http.Redirect(w, r, "/profile?oidc_error=link_taken", http.StatusFound)
+		return
+	case !errors.Is(err, sql.ErrNoRows):
+		http.Redirect(w, r, "/profile?oidc_error=server", http.StatusFound)
+		return
+	}
+	if _, err := a.DB.Exec(
+		`INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)`,
+		userID, provider, claims.Subject(), nullStringOrEmpty(claims.Email()),
+	); err != nil {
+		http.Redirect(w, r, "/profile?oidc_error=server", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/profile?oidc_linked=1", http.StatusFound)
+}
+
+func (a *App) handleOIDCLoginCallback(w http.ResponseWriter, r *http.Request, prov *oidc.Provider, nextPath, inviteCode string, claims oidc.Claims) {
+	provider := prov.Config.ID
+	var u struct {
+		id        int
+		validated int
+		isAdmin   int
+	}
+	err := a.DB.QueryRow(
+		`SELECT u.id, u.validated, u.is_admin FROM user_identities i
+		 JOIN users u ON u.id = i.user_id
+		 WHERE i.provider = ? AND i.subject = ?`,
+		provider, claims.Subject(),
+	).Scan(&u.id, &u.validated, &u.isAdmin)
+	if err == nil {
+		if (a.Settings == nil || a.Settings.RequireAccountValidation) && u.validated == 0 && u.isAdmin == 0 {
+			http.Redirect(w, r, "/login?pending=1", http.StatusFound)
+			return
+		}
+		_, _ = a.DB.Exec(
+			`UPDATE user_identities SET last_login_at = ?, email = COALESCE(?, email) WHERE provider = ? AND subject = ?`,
+			time.Now().UTC().Format("2006-01-02 15:04:05"), nullStringOrEmpty(claims.Email()), provider, claims.Subject(),
+		)
+		if err := a.completeLogin(w, r, u.id, nextPath); err != nil {
+			http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		}
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+
+	// New user: same registration mode and invite rules as the form. An existing local account
+	// is never matched by username or email; its owner links the provider from the profile.
+	now := time.Now().UTC()
+	adm, err := a.admitRegistration(inviteCode, now)
+	switch {
+	case errors.Is(err, errRegistrationClosed):
+		http.Redirect(w, r, "/login?oidc_error=closed", http.StatusFound)
+		return
+	case errors.Is(err, errInviteRequired), errors.Is(err, errInviteInvalid):
+		http.Redirect(w, r, "/login?oidc_error=invite", http.StatusFound)
+		return
+	case err != nil:
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+	username, err := a.allocateUsername(prov.Username(claims))
+	if err != nil {
+		a.releaseInvite(adm.InviteID)
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+	nowStr := now.Format("2006-01-02 15:04:05")
+	newID, err := a.DB.InsertID(
+		`INSERT INTO users (username, password, validated, is_admin, registered_at, signup_lang, invite_id)
+		 VALUES (?, NULL, ?, 0, ?, ?, ?)`,
+		username, adm.Validated, nowStr, a.currentLang(r), inviteIDArg(adm.InviteID),
+	)
+	if err != nil || newID <= 0 {
+		a.releaseInvite(adm.InviteID)
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+	if _, err := a.DB.Exec(
+		`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, ?)`,
+		newID, provider, claims.Subject(), nullStringOrEmpty(claims.Email()), nowStr,
+	); err != nil {
+		// Lost a race with a concurrent sign-up for the same subject: drop the orphan account.
+		_, _ = a.DB.Exec(`DELETE FROM users WHERE id = ?`, newID)
+		a.releaseInvite(adm.InviteID)
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+		return
+	}
+	if adm.Validated == 0 {
+		a.notifyPendingRegistration(r.Context(), int(newID), username)
+		http.Redirect(w, r, "/login?pending=1", http.StatusFound)
+		return
+	}
+	if err := a.completeLogin(w, r, int(newID), nextPath); err != nil {
+		http.Redirect(w, r, "/login?oidc_error=server", http.StatusFound)
+	}
+}
+
+// HandleOIDCUnlink removes one linked identity (POST /profile/identities/{id}/unlink).
+func (a *App) HandleOIDCUnlink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Redirect(w, r, "/profile?tab=auth", http.StatusFound)
+		return
+	}
+	if !a.oidcCanUnlink(userID) {
+		http.Redirect(w, r, "/profile?oidc_error=unlink_need_password", http.StatusFound)
+		return
+	}
+	if _, err := a.DB.Exec(`DELETE FROM user_identities WHERE id = ? AND user_id = ? AND provider <> ?`, id, userID, proxyIdentityProvider); err != nil {
+		http.Redirect(w, r, "/profile?oidc_error=server", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/profile?oidc_unlinked=1", http.StatusFound)
+}
+
+// oidcProviderConfigs exposes the configured providers to the profile page.
+func (a *App) oidcProviderConfigs() []config.OIDCProvider {
+	if a.Settings == nil || !a.Settings.OIDCConfigured() {
+		return nil
+	}
+	return a.Settings.OIDCProviders
+}
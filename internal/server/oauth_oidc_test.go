@@ -0,0 +1,281 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bookstorage/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIdP is a minimal stand-in OpenID provider: discovery, JWKS, an authorize endpoint that
+// approves immediately as the configured subject, and a token endpoint that checks PKCE.
+type testIdP struct {
+	t        *testing.T
+	srv      *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu     sync.Mutex
+	claims map[string]any // claims for the next authorization
+	grants map[string]idpGrant
+	// tamper, if set, rewrites the ID-token claims before signing.
+	tamper func(jwt.MapClaims)
+}
+
+type idpGrant struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+func newTestIdP(t *testing.T, clientID string) *testIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{t: t, key: key, clientID: clientID, grants: map[string]idpGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := idp.key.PublicKey
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != idp.clientID || q.Get("code_challenge_method") != "S256" || q.Get("nonce") == "" {
+			http.Error(w, "bad authorization request", http.StatusBadRequest)
+			return
+		}
+		code := "code-" + q.Get("state")[:8]
+		idp.mu.Lock()
+		idp.grants[code] = idpGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: idp.claims}
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		idp.mu.Lock()
+		g, ok := idp.grants[r.PostForm.Get("code")]
+		delete(idp.grants, r.PostForm.Get("code"))
+		tamper := idp.tamper
+		idp.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		now := time.Now()
+		claims := jwt.MapClaims{"iss": idp.srv.URL, "aud": idp.clientID, "iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(), "nonce": g.nonce}
+		for k, v := range g.claims {
+			claims[k] = v
+		}
+		if tamper != nil {
+			tamper(claims)
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = "k1"
+		signed, err := tok.SignedString(idp.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "at", "token_type": "Bearer", "expires_in": 300, "id_token": signed})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (idp *testIdP) as(claims map[string]any) {
+	idp.mu.Lock()
+	idp.claims = claims
+	idp.mu.Unlock()
+}
+
+// signIn runs start → IdP authorize → callback and returns the callback response.
+func (idp *testIdP) signIn(app *App, startPath string, start http.HandlerFunc, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	idp.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, startPath, nil)
+	req.SetPathValue("provider", "authelia")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	start(rec, req)
+	authURL := rec.Header().Get("Location")
+	if !strings.HasPrefix(authURL, idp.srv.URL+"/authorize?") {
+		idp.t.Fatalf("start redirect %q", authURL)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	cb, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || cb.Path != "/auth/oidc/authelia/callback" {
+		idp.t.Fatalf("authorize redirect %q", resp.Header.Get("Location"))
+	}
+	return idp.callback(app, cb.RequestURI(), cookies...)
+}
+
+func (idp *testIdP) callback(app *App, uri string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	req.SetPathValue("provider", "authelia")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	app.HandleOIDCCallback(rec, req)
+	return rec
+}
+
+func testAppWithOIDC(t *testing.T) (*App, *testIdP) {
+	t.Helper()
+	db, s := openTestDB(t)
+	idp := newTestIdP(t, "bookstorage")
+	s.PublicOrigin = "http://books.test"
+	s.OIDCProviders = []config.OIDCProvider{{
+		ID: "authelia", Name: "Authelia", Issuer: idp.srv.URL, ClientID: "bookstorage", ClientSecret: "s3cret",
+		Scopes: []string{"openid", "email", "profile"}, UsernameClaim: "preferred_username",
+	}}
+	return &App{Settings: s, DB: db}, idp
+}
+
+func TestOIDC_signUpLoginAndLink(t *testing.T) {
+	app, idp := testAppWithOIDC(t)
+	// A local "alice" exists: the provider's alice gets a separate account, never this one.
+	if _, err := app.DB.Exec(`INSERT INTO users (id, username, password, validated) VALUES (60, 'alice', 'x', 1)`); err != nil {
+		t.Fatal(err)
+	}
+	idp.as(map[string]any{"sub": "u-alice", "preferred_username": "Alice", "email": "alice@idp.test", "email_verified": true})
+
+	rec := idp.signIn(app, "/auth/oidc/authelia?next=/stats", app.HandleOIDCStart)
+	if loc := rec.Header().Get("Location"); loc != "/stats" || responseCookie(rec, sessionCookieName) == nil {
+		t.Fatalf("sign-up redirect %q", loc)
+	}
+	var uid int
+	var username, email string
+	if err := app.DB.QueryRow(
+		`SELECT u.id, u.username, i.email FROM user_identities i JOIN users u ON u.id = i.user_id
+		 WHERE i.provider = 'authelia' AND i.subject = 'u-alice'`,
+	).Scan(&uid, &username, &email); err != nil {
+		t.Fatal(err)
+	}
+	if uid == 60 || username != "alice_1" || email != "alice@idp.test" {
+		t.Fatalf("new account id=%d username=%q email=%q", uid, username, email)
+	}
+
+	// Signing in again reuses the account.
+	if loc := idp.signIn(app, "/auth/oidc/authelia", app.HandleOIDCStart).Header().Get("Location"); loc != "/dashboard" {
+		t.Fatalf("second login redirect %q", loc)
+	}
+	var users int
+	_ = app.DB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users)
+	if users != 3 {
+		t.Fatalf("%d users after two sign-ins", users)
+	}
+
+	// The admin links a second provider account; the subject already owned by alice_1 cannot be linked twice.
+	admin := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 1)}
+	idp.as(map[string]any{"sub": "u-admin"})
+	if loc := idp.signIn(app, "/auth/oidc/authelia/link", app.HandleOIDCLink, admin).Header().Get("Location"); loc != "/profile?oidc_linked=1" {
+		t.Fatalf("link redirect %q", loc)
+	}
+	idp.as(map[string]any{"sub": "u-alice"})
+	if loc := idp.signIn(app, "/auth/oidc/authelia/link", app.HandleOIDCLink, admin).Header().Get("Location"); loc != "/profile?oidc_error=link_taken" {
+		t.Fatalf("link of a taken subject %q", loc)
+	}
+
+	// alice_1 has no password, so its only identity cannot be unlinked; a proxy identity does not count
+	// as another way in, and cannot itself be unlinked from the profile.
+	if _, err := app.DB.Exec(`INSERT INTO user_identities (user_id, provider, subject) VALUES (?, 'proxy', 'alice'), (1, 'proxy', 'admin')`, uid); err != nil {
+		t.Fatal(err)
+	}
+	unlink := func(identityID int, session *http.Cookie) string {
+		req := httptest.NewRequest(http.MethodPost, "/profile/identities/x/unlink", nil)
+		req.SetPathValue("id", strconv.Itoa(identityID))
+		req.AddCookie(session)
+		rec := httptest.NewRecorder()
+		app.HandleOIDCUnlink(rec, req)
+		return rec.Header().Get("Location")
+	}
+	alice := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, uid)}
+	var identityID, adminProxyID int
+	_ = app.DB.QueryRow(`SELECT id FROM user_identities WHERE subject = 'u-alice'`).Scan(&identityID)
+	_ = app.DB.QueryRow(`SELECT id FROM user_identities WHERE user_id = 1 AND provider = 'proxy'`).Scan(&adminProxyID)
+	if loc := unlink(identityID, alice); loc != "/profile?oidc_error=unlink_need_password" {
+		t.Fatalf("unlink of the last sign-in method %q", loc)
+	}
+	unlink(adminProxyID, admin)
+	if n := app.userIdentityCount(1); n != 2 {
+		t.Fatalf("admin identities after unlinking the proxy one: %d", n)
+	}
+	if loc := postLoginStep(t, app, "/login", app.HandleLogin, url.Values{"username": {"alice_1"}, "password": {"x"}}).Header().Get("Location"); loc != "/login?oidc_error=use_sso" {
+		t.Fatalf("password login of an SSO account %q", loc)
+	}
+}
+
+func TestOIDC_rejectsBadTokensAndState(t *testing.T) {
+	app, idp := testAppWithOIDC(t)
+	idp.as(map[string]any{"sub": "u-mallory", "preferred_username": "mallory"})
+
+	for name, tamper := range map[string]func(jwt.MapClaims){
+		"nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+	} {
+		idp.tamper = tamper
+		if loc := idp.signIn(app, "/auth/oidc/authelia", app.HandleOIDCStart).Header().Get("Location"); loc != "/login?oidc_error=token" {
+			t.Errorf("%s: redirect %q", name, loc)
+		}
+	}
+	idp.tamper = nil
+	var n int
+	_ = app.DB.QueryRow(`SELECT COUNT(*) FROM user_identities`).Scan(&n)
+	if n != 0 {
+		t.Fatalf("%d identities created from rejected tokens", n)
+	}
+
+	// A state is single-use and bound to its provider.
+	if loc := idp.callback(app, "/auth/oidc/authelia/callback?code=c&state=unknown").Header().Get("Location"); loc != "/login?oidc_error=state" {
+		t.Fatalf("unknown state %q", loc)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/nope", nil)
+	req.SetPathValue("provider", "nope")
+	rec := httptest.NewRecorder()
+	app.HandleOIDCStart(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown provider status %d", rec.Code)
+	}
+}
@@ -0,0 +1,524 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BookStorage as an OAuth 2.0 authorization server (RFC 6749 authorization code grant with
+// mandatory PKCE S256, RFC 7636). Access tokens are ordinary api_tokens rows tied to the
+// user's grant, so resolveAPIToken and RequireAPIScope treat them like personal tokens.
+const (
+	oauthCodeTTL        = 10 * time.Minute
+	oauthAccessTokenTTL = time.Hour
+)
+
+// oauthRefreshTokenTTL follows the personal API token lifetime; each refresh rotates the token.
+func oauthRefreshTokenTTL() time.Duration { return apiTokenTTL() }
+
+// oauthAuthorizeRequest is a validated /oauth/authorize request (query on GET, form on POST).
+type oauthAuthorizeRequest struct {
+	Client        oauthClient
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+}
+
+// oauthAuthorizeError is reported to the client through its redirect URI (RFC 6749 section 4.1.2.1).
+type oauthAuthorizeError struct{ code string }
+
+func (e oauthAuthorizeError) Error() string { return e.code }
+
+// errOAuthBadClient means client_id or redirect_uri cannot be trusted: the error is shown to the
+// user instead of redirecting anywhere.
+var errOAuthBadClient = errors.New("unknown client or redirect URI")
+
+// oauthAuthorizedApp is one client the user has authorized, for the profile page.
+type oauthAuthorizedApp struct {
+	GrantID    int
+	ClientName string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+func oauthScopeList(raw string) []string {
+	return strings.Fields(strings.ReplaceAll(raw, ",", " "))
+}
+
+func pkceS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// validPKCEValue checks the RFC 7636 verifier/challenge syntax (43-128 unreserved characters).
+func validPKCEValue(s string) bool {
+	if len(s) < 43 || len(s) > 128 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-._~", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *App) parseOAuthAuthorizeRequest(get func(string) string) (oauthAuthorizeRequest, error) {
+	client, err := a.oauthClientByClientID(get("client_id"))
+	if err != nil {
+		return oauthAuthorizeRequest{}, errOAuthBadClient
+	}
+	redirectURI := strings.TrimSpace(get("redirect_uri"))
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.allowsRedirect(redirectURI) {
+		return oauthAuthorizeRequest{}, errOAuthBadClient
+	}
+	req := oauthAuthorizeRequest{Client: client, RedirectURI: redirectURI, State: get("state")}
+	if get("response_type") != "code" {
+		return req, oauthAuthorizeError{"unsupported_response_type"}
+	}
+	req.CodeChallenge = get("code_challenge")
+	if get("code_challenge_method") != "S256" || !validPKCEValue(req.CodeChallenge) {
+		return req, oauthAuthorizeError{"invalid_request"}
+	}
+	requested := oauthScopeList(get("scope"))
+	if len(requested) == 0 {
+		requested = client.Scopes
+	}
+	for _, s := range requested {
+		if !slices.Contains(client.Scopes, s) {
+			return req, oauthAuthorizeError{"invalid_scope"}
+		}
+	}
+	req.Scopes = normalizeAPIScopes(requested)
+	if len(req.Scopes) == 0 {
+		return req, oauthAuthorizeError{"invalid_scope"}
+	}
+	return req, nil
+}
+
+// oauthRedirect sends the browser back to the client with the given parameters and the state.
+func oauthRedirect(w http.ResponseWriter, r *http.Request, redirectURI, state string, params url.Values) {
+	if state != "" {
+		params.Set("state", state)
+	}
+	sep := "?"
+	if strings.Contains(redirectURI, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, redirectURI+sep+params.Encode(), http.StatusFound)
+}
+
+// allowFormActionTo lets the consent form follow its redirect to the client: browsers apply the
+// page's form-action directive to redirects after submission.
+func allowFormActionTo(w http.ResponseWriter, redirectURI string) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return
+	}
+	source := u.Scheme + ":"
+	if u.Host != "" {
+		source = u.Scheme + "://" + u.Host
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); csp != "" {
+		w.Header().Set("Content-Security-Policy", strings.Replace(csp, "form-action 'self'", "form-action 'self' "+source, 1))
+	}
+}
+
+// oauthGrantCovers reports whether the user already authorized the client for all scopes.
+func (a *App) oauthGrantCovers(clientID, userID int, scopes []string) bool {
+	var raw string
+	if err := a.DB.QueryRow(`SELECT scopes FROM oauth_grants WHERE client_id = ? AND user_id = ?`, clientID, userID).Scan(&raw); err != nil {
+		return false
+	}
+	granted := decodeAPIScopes(raw)
+	for _, s := range scopes {
+		if !slices.Contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// HandleOAuthAuthorize shows the consent screen (GET /oauth/authorize). With a strict SameSite
+// session cookie, the navigation from the client's site arrives without the cookie; the page then
+// reloads itself once from this origin before sending the user to the login form.
+func (a *App) HandleOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	if _, ok := a.currentUserID(r); !ok && r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = fmt.Fprintf(w, `<!DOCTYPE html><meta http-equiv="refresh" content="0;url=%s">`, html.EscapeString(r.URL.RequestURI()))
+		return
+	}
+	a.RequireLogin(a.handleOAuthAuthorizeForm)(w, r)
+}
+
+func (a *App) handleOAuthAuthorizeForm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	req, err := a.parseOAuthAuthorizeRequest(r.URL.Query().Get)
+	var authErr oauthAuthorizeError
+	switch {
+	case errors.Is(err, errOAuthBadClient):
+		w.WriteHeader(http.StatusBadRequest)
+		a.renderTemplate(w, r, "oauth_authorize", a.mergeData(r, map[string]any{"OAuthBadClient": true}))
+		return
+	case errors.As(err, &authErr):
+		oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"error": {authErr.code}})
+		return
+	}
+	if r.URL.Query().Get("prompt") != "consent" && a.oauthGrantCovers(req.Client.ID, userID, req.Scopes) {
+		a.issueOAuthCode(w, r, userID, req)
+		return
+	}
+	allowFormActionTo(w, req.RedirectURI)
+	a.renderTemplate(w, r, "oauth_authorize", a.mergeData(r, map[string]any{
+		"OAuthClient":   req.Client,
+		"OAuthScopes":   req.Scopes,
+		"OAuthRedirect": req.RedirectURI,
+		"OAuthState":    req.State,
+		"OAuthPKCE":     req.CodeChallenge,
+	}))
+}
+
+// HandleOAuthAuthorizeDecision records the user's answer on the consent screen (POST /oauth/authorize).
+func (a *App) HandleOAuthAuthorizeDecision(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	req, err := a.parseOAuthAuthorizeRequest(r.PostFormValue)
+	var authErr oauthAuthorizeError
+	switch {
+	case errors.Is(err, errOAuthBadClient):
+		w.WriteHeader(http.StatusBadRequest)
+		a.renderTemplate(w, r, "oauth_authorize", a.mergeData(r, map[string]any{"OAuthBadClient": true}))
+		return
+	case errors.As(err, &authErr):
+		oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"error": {authErr.code}})
+		return
+	}
+	if r.PostFormValue("decision") != "allow" {
+		oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"error": {"access_denied"}})
+		return
+	}
+	var existing string
+	err = a.DB.QueryRow(`SELECT scopes FROM oauth_grants WHERE client_id = ? AND user_id = ?`, req.Client.ID, userID).Scan(&existing)
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		scopes := normalizeAPIScopes(append(decodeAPIScopes(existing), req.Scopes...))
+		_, err = a.DB.Exec(`UPDATE oauth_grants SET scopes = ?, updated_at = ? WHERE client_id = ? AND user_id = ?`,
+			encodeAPIScopes(scopes), now, req.Client.ID, userID)
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = a.DB.Exec(`INSERT INTO oauth_grants (client_id, user_id, scopes, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			req.Client.ID, userID, encodeAPIScopes(req.Scopes), now, now)
+	}
+	if err != nil {
+		oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"error": {"server_error"}})
+		return
+	}
+	a.issueOAuthCode(w, r, userID, req)
+}
+
+func (a *App) issueOAuthCode(w http.ResponseWriter, r *http.Request, userID int, req oauthAuthorizeRequest) {
+	code, err := randomOAuthString("", 32)
+	if err == nil {
+		_, err = a.DB.Exec(
+			`INSERT INTO oauth_authorization_codes (code_hash, grant_id, redirect_uri, scopes, code_challenge, expires_at)
+			 SELECT ?, id, ?, ?, ?, ? FROM oauth_grants WHERE client_id = ? AND user_id = ?`,
+			hashAPIToken(code), req.RedirectURI, encodeAPIScopes(req.Scopes), req.CodeChallenge,
+			time.Now().UTC().Add(oauthCodeTTL), req.Client.ID, userID,
+		)
+	}
+	if err != nil {
+		oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"error": {"server_error"}})
+		return
+	}
+	oauthRedirect(w, r, req.RedirectURI, req.State, url.Values{"code": {code}})
+}
+
+// oauthTokenError writes an RFC 6749 section 5.2 error response.
+func (a *App) oauthTokenError(w http.ResponseWriter, status int, code string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="BookStorage"`)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	a.apiWriteJSON(w, status, map[string]string{"error": code})
+}
+
+// oauthTokenClient authenticates the client from HTTP Basic or the client_id/client_secret form fields.
+func (a *App) oauthTokenClient(r *http.Request) (oauthClient, bool) {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 section 2.3.1: both parts are form-urlencoded before Basic encoding.
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	client, err := a.oauthClientByClientID(clientID)
+	if err != nil || !client.authenticate(secret) {
+		return oauthClient{}, false
+	}
+	return client, true
+}
+
+// HandleOAuthToken exchanges an authorization code or a refresh token for tokens (POST /oauth/token).
+func (a *App) HandleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	if err := r.ParseForm(); err != nil {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	client, ok := a.oauthTokenClient(r)
+	if !ok {
+		a.oauthTokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		a.oauthTokenFromCode(w, r, client)
+	case "refresh_token":
+		a.oauthTokenFromRefresh(w, r, client)
+	default:
+		a.oauthTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+	}
+}
+
+func (a *App) oauthTokenFromCode(w http.ResponseWriter, r *http.Request, client oauthClient) {
+	codeHash := hashAPIToken(r.PostFormValue("code"))
+	var grantID, grantClient int
+	var redirectURI, scopesRaw, challenge string
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err := a.DB.QueryRow(
+		`SELECT c.grant_id, g.client_id, c.redirect_uri, c.scopes, c.code_challenge, c.expires_at, c.used_at
+		 FROM oauth_authorization_codes c JOIN oauth_grants g ON g.id = c.grant_id
+		 WHERE c.code_hash = ?`,
+		codeHash,
+	).Scan(&grantID, &grantClient, &redirectURI, &scopesRaw, &challenge, &expiresAt, &usedAt)
+	if err != nil || grantClient != client.ID {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if usedAt.Valid {
+		// A replayed code may have leaked: drop what the first exchange issued (RFC 6749 section 4.1.2).
+		a.revokeOAuthGrantTokens(grantID)
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	verifier := r.PostFormValue("code_verifier")
+	if time.Now().UTC().After(expiresAt) || r.PostFormValue("redirect_uri") != redirectURI || !validPKCEValue(verifier) ||
+		subtle.ConstantTimeCompare([]byte(pkceS256(verifier)), []byte(challenge)) != 1 {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	res, err := a.DB.Exec(`UPDATE oauth_authorization_codes SET used_at = ? WHERE code_hash = ? AND used_at IS NULL`, time.Now().UTC(), codeHash)
+	if n, _ := rowsAffected(res, err); n != 1 {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	a.writeOAuthTokens(w, client, grantID, decodeAPIScopes(scopesRaw))
+}
+
+func (a *App) oauthTokenFromRefresh(w http.ResponseWriter, r *http.Request, client oauthClient) {
+	tokenHash := hashAPIToken(r.PostFormValue("refresh_token"))
+	var grantID, grantClient int
+	var scopesRaw string
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err := a.DB.QueryRow(
+		`SELECT t.grant_id, g.client_id, t.scopes, t.expires_at, t.used_at
+		 FROM oauth_refresh_tokens t JOIN oauth_grants g ON g.id = t.grant_id
+		 WHERE t.token_hash = ?`,
+		tokenHash,
+	).Scan(&grantID, &grantClient, &scopesRaw, &expiresAt, &usedAt)
+	if err != nil || grantClient != client.ID {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if usedAt.Valid {
+		// Refresh tokens rotate; seeing an old one again means two parties hold it.
+		a.revokeOAuthGrantTokens(grantID)
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if time.Now().UTC().After(expiresAt) {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	scopes := decodeAPIScopes(scopesRaw)
+	if requested := oauthScopeList(r.PostFormValue("scope")); len(requested) > 0 {
+		for _, s := range requested {
+			if !slices.Contains(scopes, s) {
+				a.oauthTokenError(w, http.StatusBadRequest, "invalid_scope")
+				return
+			}
+		}
+		scopes = normalizeAPIScopes(requested)
+	}
+	res, err := a.DB.Exec(`UPDATE oauth_refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL`, time.Now().UTC(), tokenHash)
+	if n, _ := rowsAffected(res, err); n != 1 {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	a.writeOAuthTokens(w, client, grantID, scopes)
+}
+
+func rowsAffected(res sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// writeOAuthTokens issues an access token and a refresh token for the grant. Scopes the admin
+// has since removed from the client, or the user from the grant, are dropped.
+func (a *App) writeOAuthTokens(w http.ResponseWriter, client oauthClient, grantID int, scopes []string) {
+	var userID int
+	var grantRaw string
+	if err := a.DB.QueryRow(`SELECT user_id, scopes FROM oauth_grants WHERE id = ?`, grantID).Scan(&userID, &grantRaw); err != nil {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	granted := decodeAPIScopes(grantRaw)
+	scopes = slices.DeleteFunc(slices.Clone(scopes), func(s string) bool {
+		return !slices.Contains(granted, s) || !slices.Contains(client.Scopes, s)
+	})
+	if len(scopes) == 0 {
+		a.oauthTokenError(w, http.StatusBadRequest, "invalid_scope")
+		return
+	}
+	access, err := newAPITokenSecret()
+	if err != nil {
+		a.oauthTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	refresh, err := randomOAuthString("bsr_", 32)
+	if err != nil {
+		a.oauthTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	now := time.Now().UTC()
+	if _, err := a.DB.Exec(
+		`INSERT INTO api_tokens (user_id, name, token_hash, scopes, created_at, expires_at, oauth_grant_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userID, client.Name, hashAPIToken(access), encodeAPIScopes(scopes), now, now.Add(oauthAccessTokenTTL), grantID,
+	); err != nil {
+		a.oauthTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	if _, err := a.DB.Exec(
+		`INSERT INTO oauth_refresh_tokens (grant_id, token_hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		grantID, hashAPIToken(refresh), encodeAPIScopes(scopes), now, now.Add(oauthRefreshTokenTTL()),
+	); err != nil {
+		a.oauthTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	// Access tokens past their hour are useless; keep the table from growing with each refresh.
+	_, _ = a.DB.Exec(`DELETE FROM api_tokens WHERE oauth_grant_id = ? AND expires_at < ?`, grantID, now)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    int(oauthAccessTokenTTL.Seconds()),
+		"refresh_token": refresh,
+		"scope":         strings.Join(scopes, " "),
+	})
+}
+
+// revokeOAuthGrantTokens invalidates every token issued under a grant but keeps the consent.
+func (a *App) revokeOAuthGrantTokens(grantID int) {
+	_, _ = a.DB.Exec(`DELETE FROM api_tokens WHERE oauth_grant_id = ?`, grantID)
+	_, _ = a.DB.Exec(`DELETE FROM oauth_refresh_tokens WHERE grant_id = ?`, grantID)
+}
+
+// HandleOAuthMetadata publishes RFC 8414 authorization server metadata
+// (GET /.well-known/oauth-authorization-server). It needs BOOKSTORAGE_PUBLIC_ORIGIN.
+func (a *App) HandleOAuthMetadata(w http.ResponseWriter, r *http.Request) {
+	origin := ""
+	if a.Settings != nil {
+		origin = a.Settings.PublicOrigin
+	}
+	if origin == "" {
+		http.NotFound(w, r)
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                origin,
+		"authorization_endpoint":                origin + "/oauth/authorize",
+		"token_endpoint":                        origin + "/oauth/token",
+		"scopes_supported":                      sortedAPIScopes(),
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+func (a *App) listOAuthAuthorizedApps(userID int) ([]oauthAuthorizedApp, error) {
+	rows, err := a.DB.Query(
+		`SELECT g.id, c.name, g.scopes, g.created_at,
+		        (SELECT MAX(t.last_used_at) FROM api_tokens t WHERE t.oauth_grant_id = g.id)
+		 FROM oauth_grants g JOIN oauth_clients c ON c.id = g.client_id
+		 WHERE g.user_id = ? AND c.revoked_at IS NULL
+		 ORDER BY g.created_at DESC, g.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []oauthAuthorizedApp
+	for rows.Next() {
+		var app oauthAuthorizedApp
+		var scopesRaw string
+		if err := rows.Scan(&app.GrantID, &app.ClientName, &scopesRaw, &app.CreatedAt, &app.LastUsedAt); err != nil {
+			return nil, err
+		}
+		app.Scopes = decodeAPIScopes(scopesRaw)
+		out = append(out, app)
+	}
+	return out, rows.Err()
+}
+
+// HandleOAuthAppRevoke removes an app's access to the account (POST /profile/apps/{id}/revoke).
+// Deleting the grant cascades to its codes, refresh tokens and access tokens.
+func (a *App) HandleOAuthAppRevoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	if _, apiOK := apiAuthUserIDFromContext(r.Context()); apiOK {
+		http.Redirect(w, r, "/profile", http.StatusFound)
+		return
+	}
+	grantID, _ := strconv.Atoi(r.PathValue("id"))
+	if _, err := a.DB.Exec(`DELETE FROM oauth_grants WHERE id = ? AND user_id = ?`, grantID, userID); err != nil {
+		http.Redirect(w, r, "/profile?tab=integrations", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/profile?tab=integrations&app_revoked=1", http.StatusFound)
+}
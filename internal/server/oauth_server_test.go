@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testPKCEVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func TestValidOAuthRedirectURI(t *testing.T) {
+	for uri, want := range map[string]bool{
+		"https://bot.example.com/cb":      true,
+		"http://127.0.0.1:8765/cb":        true,
+		"http://localhost/cb":             true,
+		"com.example.books:/oauth":        true,
+		"http://bot.example.com/cb":       false,
+		"https://bot.example.com/cb#frag": false,
+		"javascript:alert(1)":             false,
+		"/relative":                       false,
+	} {
+		if got := validOAuthRedirectURI(uri); got != want {
+			t.Errorf("validOAuthRedirectURI(%q) = %v", uri, got)
+		}
+	}
+}
+
+// registerTestOAuthClient goes through the admin form and returns the client ID and secret.
+func registerTestOAuthClient(t *testing.T, app *App) (string, string) {
+	t.Helper()
+	form := url.Values{"name": {"Discord bot"}, "redirect_uris": {"https://bot.example.com/cb"}, "scopes": {ScopeWorksRead, ScopeWorksWrite}, "confidential": {"1"}}
+	rec := postLoginStep(t, app, "/admin/oauth-clients/create", app.HandleAdminOAuthClientCreate, form,
+		&http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 1)})
+	loc, _ := url.Parse(rec.Header().Get("Location"))
+	secret, ok := app.consumeAPITokenFlash(1, loc.Query().Get("secret_flash"))
+	if !ok {
+		t.Fatalf("no secret after registration: %q", rec.Header().Get("Location"))
+	}
+	var clientID string
+	if err := app.DB.QueryRow(`SELECT client_id FROM oauth_clients ORDER BY id DESC LIMIT 1`).Scan(&clientID); err != nil {
+		t.Fatal(err)
+	}
+	return clientID, secret
+}
+
+func oauthTokenRequest(t *testing.T, app *App, clientID, secret string, form url.Values) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, secret)
+	rec := httptest.NewRecorder()
+	app.HandleOAuthToken(rec, req)
+	var body map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func bearerReachesWorks(app *App, token string) bool {
+	reached := false
+	h := app.WithAPITokenContext(http.HandlerFunc(app.RequireLogin(app.RequireAPIScope(ScopeWorksRead)(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))))
+	req := httptest.NewRequest(http.MethodGet, "/api/works", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return reached
+}
+
+func TestOAuthServer_authorizationCodeFlow(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db, TemplatesWeb: template.New("")}
+	if _, err := db.Exec(`INSERT INTO users (id, username, password, validated, is_admin) VALUES (70, 'reader', NULL, 1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+	clientID, secret := registerTestOAuthClient(t, app)
+	session := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 70)}
+	authorize := url.Values{
+		"response_type": {"code"}, "client_id": {clientID}, "redirect_uri": {"https://bot.example.com/cb"},
+		"scope": {ScopeWorksRead}, "state": {"xyz"}, "code_challenge": {pkceS256(testPKCEVerifier)}, "code_challenge_method": {"S256"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorize.Encode(), nil)
+	req.AddCookie(session)
+	rec := httptest.NewRecorder()
+	app.HandleOAuthAuthorize(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("consent screen: %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	bad := url.Values{"client_id": {clientID}, "redirect_uri": {"https://evil.example/cb"}}
+	req = httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+bad.Encode(), nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	app.HandleOAuthAuthorize(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Location") != "" {
+		t.Fatalf("unregistered redirect: %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	deny := url.Values{}
+	for k, v := range authorize {
+		deny[k] = v
+	}
+	deny.Set("decision", "deny")
+	if loc := postLoginStep(t, app, "/oauth/authorize", app.HandleOAuthAuthorizeDecision, deny, session).Header().Get("Location"); loc != "https://bot.example.com/cb?error=access_denied&state=xyz" {
+		t.Fatalf("deny redirect %q", loc)
+	}
+	allow := deny
+	allow.Set("decision", "allow")
+	loc, _ := url.Parse(postLoginStep(t, app, "/oauth/authorize", app.HandleOAuthAuthorizeDecision, allow, session).Header().Get("Location"))
+	code := loc.Query().Get("code")
+	if loc.Host != "bot.example.com" || code == "" || loc.Query().Get("state") != "xyz" {
+		t.Fatalf("allow redirect %q", loc)
+	}
+
+	exchange := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "redirect_uri": {"https://bot.example.com/cb"}, "code_verifier": {strings.Repeat("a", 43)}}
+	if status, body := oauthTokenRequest(t, app, clientID, secret, exchange); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Fatalf("wrong verifier: %d %v", status, body)
+	}
+	exchange.Set("code_verifier", testPKCEVerifier)
+	if status, body := oauthTokenRequest(t, app, clientID, "bss_wrong", exchange); status != http.StatusUnauthorized || body["error"] != "invalid_client" {
+		t.Fatalf("wrong secret: %d %v", status, body)
+	}
+	status, tokens := oauthTokenRequest(t, app, clientID, secret, exchange)
+	access, _ := tokens["access_token"].(string)
+	refresh, _ := tokens["refresh_token"].(string)
+	if status != http.StatusOK || access == "" || refresh == "" || tokens["scope"] != ScopeWorksRead {
+		t.Fatalf("exchange: %d %v", status, tokens)
+	}
+	if !bearerReachesWorks(app, access) {
+		t.Fatal("issued access token rejected")
+	}
+	// Issuing a personal token does not revoke the app's tokens, and the app's tokens are not listed as personal.
+	if _, _, err := app.createAPIToken(70, "mine", []string{ScopeWorksRead}); err != nil {
+		t.Fatal(err)
+	}
+	_ = app.revokeAllUserAPITokens(70)
+	if personal, _ := app.listAPITokens(70); len(personal) != 0 || !bearerReachesWorks(app, access) {
+		t.Fatalf("personal tokens=%d, app token valid=%v", len(personal), bearerReachesWorks(app, access))
+	}
+
+	status, rotated := oauthTokenRequest(t, app, clientID, secret, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}})
+	if status != http.StatusOK || rotated["refresh_token"] == refresh {
+		t.Fatalf("refresh: %d %v", status, rotated)
+	}
+	newAccess := rotated["access_token"].(string)
+	if status, _ := oauthTokenRequest(t, app, clientID, secret, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}); status != http.StatusBadRequest {
+		t.Fatalf("reused refresh token: %d", status)
+	}
+	if bearerReachesWorks(app, newAccess) {
+		t.Fatal("tokens kept after refresh token reuse")
+	}
+
+	// Consent is remembered; the code comes back without the screen. Replaying it revokes the grant's tokens.
+	req = httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorize.Encode(), nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	app.HandleOAuthAuthorize(rec, req)
+	loc, _ = url.Parse(rec.Header().Get("Location"))
+	exchange.Set("code", loc.Query().Get("code"))
+	status, tokens = oauthTokenRequest(t, app, clientID, secret, exchange)
+	if status != http.StatusOK {
+		t.Fatalf("remembered consent: %d %v (%q)", status, tokens, rec.Header().Get("Location"))
+	}
+	if status, _ := oauthTokenRequest(t, app, clientID, secret, exchange); status != http.StatusBadRequest || bearerReachesWorks(app, tokens["access_token"].(string)) {
+		t.Fatalf("replayed code: %d", status)
+	}
+
+	// Revoking the app from the profile drops the grant and everything issued under it.
+	if status, body := oauthTokenRequest(t, app, clientID, secret, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"unknown"}}); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Fatalf("unknown refresh token: %d %v", status, body)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorize.Encode(), nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	app.HandleOAuthAuthorize(rec, req)
+	loc, _ = url.Parse(rec.Header().Get("Location"))
+	exchange.Set("code", loc.Query().Get("code"))
+	_, tokens = oauthTokenRequest(t, app, clientID, secret, exchange)
+	apps, _ := app.listOAuthAuthorizedApps(70)
+	if len(apps) != 1 || apps[0].ClientName != "Discord bot" || !bearerReachesWorks(app, tokens["access_token"].(string)) {
+		t.Fatalf("authorized apps: %+v", apps)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/profile/apps/"+strconv.Itoa(apps[0].GrantID)+"/revoke", nil)
+	req.SetPathValue("id", strconv.Itoa(apps[0].GrantID))
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	app.HandleOAuthAppRevoke(rec, req)
+	if apps, _ := app.listOAuthAuthorizedApps(70); len(apps) != 0 || bearerReachesWorks(app, tokens["access_token"].(string)) {
+		t.Fatalf("access kept after revoke (%q): %+v", rec.Header().Get("Location"), apps)
+	}
+}
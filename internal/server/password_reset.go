@@ -127,6 +127,7 @@ func (a *App) userEligibleForPasswordReset(userID int, password sql.NullString)
 	return true
 }
 
+// findUsersByEmailForPasswordReset only trusts verified addresses for account recovery.
 func (a *App) findUsersByEmailForPasswordReset(email string) ([]struct {
 	ID       int
 	Password sql.NullString
@@ -137,7 +138,8 @@ func (a *App) findUsersByEmailForPasswordReset(email string) ([]struct {
 		return nil, nil
 	}
 	rows, err := a.DB.Query(
-		`SELECT id, password, email FROM users WHERE email IS NOT NULL AND LOWER(TRIM(email)) = ?`,
+		`SELECT id, password, email FROM users
+		 WHERE email IS NOT NULL AND email_verified_at IS NOT NULL AND LOWER(TRIM(email)) = ?`,
 		norm,
 	)
 	if err != nil {
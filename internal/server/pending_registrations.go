@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/i18n"
+	"bookstorage/internal/mail"
+)
+
+// maxRejectionReasonLen bounds the free-text reason mailed to a rejected applicant.
+const maxRejectionReasonLen = 1000
+
+func registrationNotificationKey(userID int) string {
+	return "registration:" + strconv.Itoa(userID)
+}
+
+func registrationReviewURL(origin string, userID int, action string) string {
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	return origin + "/admin/accounts/" + strconv.Itoa(userID) + "/review?action=" + action
+}
+
+type registrationAdmin struct {
+	ID    int
+	Email string
+	Lang  string
+}
+
+// notifyPendingRegistration tells every admin that userID awaits approval: in the notification
+// inbox, and by email (with approve/reject links) to admins whose address is verified.
+func (a *App) notifyPendingRegistration(ctx context.Context, userID int, username string) {
+	rows, err := a.DB.Query(
+		`SELECT id, CASE WHEN email_verified_at IS NOT NULL THEN COALESCE(email, '') ELSE '' END,
+		        COALESCE(NULLIF(digest_lang, ''), signup_lang, '')
+		 FROM users WHERE is_admin = 1 OR is_superadmin = 1`,
+	)
+	if err != nil {
+		log.Printf("[registrations] list admins: %v", err)
+		return
+	}
+	var admins []registrationAdmin
+	for rows.Next() {
+		var ad registrationAdmin
+		if rows.Scan(&ad.ID, &ad.Email, &ad.Lang) == nil {
+			admins = append(admins, ad)
+		}
+	}
+	_ = rows.Close()
+
+	mailOn := a.Settings != nil && a.Settings.MailConfigured()
+	for _, ad := range admins {
+		a.notify(ad.ID, notification{
+			Kind:      notificationRegistrationPending,
+			Subject:   username,
+			Link:      "/admin/accounts/" + strconv.Itoa(userID) + "/review",
+			DedupeKey: registrationNotificationKey(userID),
+		})
+		if !mailOn || strings.TrimSpace(ad.Email) == "" {
+			continue
+		}
+		if err := a.sendRegistrationPendingEmail(ctx, ad, userID, username); err != nil {
+			log.Printf("[registrations] email admin %d about user %d: %v", ad.ID, userID, err)
+		}
+	}
+}
+
+func (a *App) sendRegistrationPendingEmail(ctx context.Context, ad registrationAdmin, userID int, username string) error {
+	branding, footer := a.mailBranding()
+	tr := i18n.T(ad.Lang)
+	content := mail.RegistrationPendingContent{
+		Subject:  fmt.Sprintf(tr["mail.registration_pending.subject"], branding.SiteName, username),
+		Greeting: tr["mail.registration_pending.greeting"],
+		Body:     fmt.Sprintf(tr["mail.registration_pending.body"], username, branding.SiteName),
+		Approve:  tr["mail.registration_pending.approve"],
+		Reject:   tr["mail.registration_pending.reject"],
+		Footer:   footer,
+	}
+	approveLink := registrationReviewURL(a.Settings.PublicOrigin, userID, "approve")
+	rejectLink := registrationReviewURL(a.Settings.PublicOrigin, userID, "reject")
+	return a.mailOutbox(mailOutboxDefaultTTL).Send(ctx, mail.Message{
+		To:       ad.Email,
+		Subject:  content.Subject,
+		TextBody: mail.BuildRegistrationPendingText(content, approveLink, rejectLink),
+		HTMLBody: mail.BuildRegistrationPendingHTML(content, branding, approveLink, rejectLink),
+		CustomID: fmt.Sprintf("registration-%d", userID),
+	})
+}
+
+func (a *App) sendRegistrationRejectedEmail(ctx context.Context, to, lang, reason string) error {
+	branding, footer := a.mailBranding()
+	tr := i18n.T(lang)
+	content := mail.RegistrationRejectedContent{
+		Subject:     fmt.Sprintf(tr["mail.registration_rejected.subject"], branding.SiteName),
+		Greeting:    tr["mail.registration_rejected.greeting"],
+		Body:        fmt.Sprintf(tr["mail.registration_rejected.body"], branding.SiteName),
+		ReasonLabel: tr["mail.registration_rejected.reason"],
+		Reason:      reason,
+		Footer:      footer,
+	}
+	return a.mailOutbox(mailOutboxDefaultTTL).Send(ctx, mail.Message{
+		To:       to,
+		Subject:  content.Subject,
+		TextBody: mail.BuildRegistrationRejectedText(content),
+		HTMLBody: mail.BuildRegistrationRejectedHTML(content, branding),
+		CustomID: "registration-rejected",
+	})
+}
+
+// clearRegistrationNotifications marks the admins' "pending registration" entries for userID as
+// read once the account was approved, rejected or expired.
+func (a *App) clearRegistrationNotifications(userID int) {
+	_, _ = a.DB.Exec(
+		`UPDATE notifications SET read_at = ? WHERE dedupe_key = ? AND read_at IS NULL`,
+		time.Now().UTC().Format("2006-01-02 15:04:05"), registrationNotificationKey(userID),
+	)
+}
+
+type pendingAccount struct {
+	ID           int
+	Username     string
+	Email        sql.NullString
+	Lang         string
+	RegisteredAt nullFlexTime
+}
+
+// loadPendingAccount returns userID only while it still awaits approval (and is not an admin).
+func (a *App) loadPendingAccount(userID int) (pendingAccount, bool) {
+	var p pendingAccount
+	err := a.DB.QueryRow(
+		`SELECT id, username, email, COALESCE(signup_lang, ''), registered_at
+		 FROM users WHERE id = ? AND COALESCE(validated, 0) = 0 AND COALESCE(is_admin, 0) = 0 AND COALESCE(is_superadmin, 0) = 0`,
+		userID,
+	).Scan(&p.ID, &p.Username, &p.Email, &p.Lang, &p.RegisteredAt)
+	return p, err == nil
+}
+
+// HandleAdminRegistrationReview shows one pending account with a single approve or reject
+// button (GET /admin/accounts/{id}/review?action=approve|reject): the target of the links in
+// admin emails. Acting needs a POST so mail scanners prefetching links change nothing.
+func (a *App) HandleAdminRegistrationReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, _ := strconv.Atoi(r.PathValue("id"))
+	p, ok := a.loadPendingAccount(userID)
+	action := r.URL.Query().Get("action")
+	if action != "reject" {
+		action = "approve"
+	}
+	a.renderTemplate(w, r, "admin_registration_review", a.mergeData(r, map[string]any{
+		"Pending":      p,
+		"PendingFound": ok,
+		"ReviewAction": action,
+		"ReviewMailOn": a.Settings.MailConfigured(),
+		"ReasonMaxLen": maxRejectionReasonLen,
+	}))
+}
+
+// HandleRejectAccount deletes a pending account and, when mail is configured, tells the
+// applicant with the optional reason (POST /admin/reject/{id}, form field reason).
+func (a *App) HandleRejectAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, _ := strconv.Atoi(r.PathValue("id"))
+	p, ok := a.loadPendingAccount(userID)
+	if !ok {
+		http.Redirect(w, r, "/admin/accounts", http.StatusFound)
+		return
+	}
+	reason := strings.TrimSpace(r.FormValue("reason"))
+	if runes := []rune(reason); len(runes) > maxRejectionReasonLen {
+		reason = string(runes[:maxRejectionReasonLen])
+	}
+	if _, err := a.DB.Exec(`DELETE FROM users WHERE id = ? AND COALESCE(validated, 0) = 0`, userID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	mailed := false
+	if to := strings.TrimSpace(p.Email.String); to != "" && a.Settings.MailConfigured() {
+		if err := a.sendRegistrationRejectedEmail(r.Context(), to, p.Lang, reason); err != nil {
+			log.Printf("[registrations] rejection email for user %d: %v", userID, err)
+		} else {
+			mailed = true
+		}
+	}
+	a.clearRegistrationNotifications(userID)
+	a.logAdminAction(r, "reject_account", "user", strconv.Itoa(userID), map[string]any{
+		"username": p.Username,
+		"reason":   reason,
+		"mailed":   mailed,
+	})
+	http.Redirect(w, r, "/admin/accounts", http.StatusFound)
+}
+
+// expirePendingAccounts deletes accounts that waited longer than PendingAccountExpiryDays for
+// approval and returns how many were removed. Each deletion is audited as a server action.
+func (a *App) expirePendingAccounts(now time.Time) int {
+	if a.Settings == nil || a.Settings.PendingAccountExpiryDays <= 0 {
+		return 0
+	}
+	cutoff := now.UTC().AddDate(0, 0, -a.Settings.PendingAccountExpiryDays).Format("2006-01-02 15:04:05")
+	rows, err := a.DB.Query(
+		`SELECT id, username FROM users
+		 WHERE COALESCE(validated, 0) = 0 AND COALESCE(is_admin, 0) = 0 AND COALESCE(is_superadmin, 0) = 0
+		   AND registered_at IS NOT NULL AND registered_at < ?`,
+		cutoff,
+	)
+	if err != nil {
+		log.Printf("[registrations] list expired accounts: %v", err)
+		return 0
+	}
+	type expired struct {
+		ID       int
+		Username string
+	}
+	var due []expired
+	for rows.Next() {
+		var e expired
+		if rows.Scan(&e.ID, &e.Username) == nil {
+			due = append(due, e)
+		}
+	}
+	_ = rows.Close()
+
+	removed := 0
+	for _, e := range due {
+		if _, err := a.DB.Exec(`DELETE FROM users WHERE id = ? AND COALESCE(validated, 0) = 0`, e.ID); err != nil {
+			log.Printf("[registrations] expire user %d: %v", e.ID, err)
+			continue
+		}
+		removed++
+		a.clearRegistrationNotifications(e.ID)
+		a.logAdminAction(nil, "expire_account", "user", strconv.Itoa(e.ID), map[string]any{
+			"username": e.Username,
+			"days":     a.Settings.PendingAccountExpiryDays,
+		})
+	}
+	if removed > 0 {
+		log.Printf("[registrations] %d pending accounts expired", removed)
+	}
+	return removed
+}
+
+// StartPendingAccountExpiry launches a goroutine that deletes expired pending accounts every
+// interval. It does nothing while PendingAccountExpiryDays is 0. It stops when ctx is cancelled.
+func (a *App) StartPendingAccountExpiry(ctx context.Context, interval time.Duration) {
+	if a.Settings == nil || a.Settings.PendingAccountExpiryDays <= 0 {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[registrations] recovered from panic: %v — restarting in 30s", r)
+				time.Sleep(30 * time.Second)
+				a.StartPendingAccountExpiry(ctx, interval)
+			}
+		}()
+
+		log.Printf("[registrations] pending account expiry started — %d days, interval %v", a.Settings.PendingAccountExpiryDays, interval)
+		a.expirePendingAccounts(time.Now().UTC())
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.expirePendingAccounts(time.Now().UTC())
+			}
+		}
+	}()
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleRegister_notifiesAdminsOfPendingAccount(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	s.RequireAccountValidation = true
+	app := &App{Settings: s, DB: db}
+	sent := captureMail(t)
+	if _, err := db.Exec(`UPDATE users SET email = 'admin@example.com', email_verified_at = CURRENT_TIMESTAMP WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"username": {"applicant"}, "email": {"applicant@example.com"}, "password": {"LongEnough!1"}}
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	app.HandleRegister(httptest.NewRecorder(), req)
+
+	var uid int
+	var registeredAt nullFlexTime
+	if err := db.QueryRow(`SELECT id, registered_at FROM users WHERE username = 'applicant'`).Scan(&uid, &registeredAt); err != nil {
+		t.Fatal(err)
+	}
+	if !registeredAt.Valid {
+		t.Fatal("registered_at not recorded")
+	}
+	var link string
+	if err := db.QueryRow(
+		`SELECT COALESCE(link, '') FROM notifications WHERE user_id = 1 AND kind = ? AND read_at IS NULL`, notificationRegistrationPending,
+	).Scan(&link); err != nil {
+		t.Fatalf("admin not notified in-app: %v", err)
+	}
+	if link != "/admin/accounts/"+strconv.Itoa(uid)+"/review" {
+		t.Fatalf("link %q", link)
+	}
+
+	app.runMailOutboxCycle(context.Background())
+	var adminMail bool
+	for _, m := range *sent {
+		if m.To == "admin@example.com" {
+			adminMail = strings.Contains(m.TextBody, "https://books.example.com/admin/accounts/"+strconv.Itoa(uid)+"/review?action=reject")
+		}
+	}
+	if !adminMail {
+		t.Fatalf("sent %+v", *sent)
+	}
+
+	// Approving clears the admin's pending entry.
+	approve := httptest.NewRequest(http.MethodPost, "/admin/approve/"+strconv.Itoa(uid), nil)
+	approve.SetPathValue("id", strconv.Itoa(uid))
+	approve.AddCookie(&http.Cookie{Name: "session", Value: mustCreateSession(t, app, 1)})
+	app.HandleApproveAccount(httptest.NewRecorder(), approve)
+	var unread int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = 1 AND kind = ? AND read_at IS NULL`, notificationRegistrationPending).Scan(&unread)
+	if unread != 0 {
+		t.Fatalf("%d pending notifications left after approval", unread)
+	}
+}
+
+func TestHandleRejectAccount_mailsReasonAndAudits(t *testing.T) {
+	db, s := openTestDB(t)
+	enableMailSettings(s)
+	app := &App{Settings: s, DB: db}
+	sent := captureMail(t)
+	if _, err := db.Exec(
+		`INSERT INTO users (id, username, password, validated, is_admin, email, signup_lang) VALUES (30, 'spammy', 'x', 0, 0, 'spammy@example.com', 'fr')`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	adminSession := mustCreateSession(t, app, 1)
+
+	form := url.Values{"reason": {"Looks like a bot."}}
+	req := httptest.NewRequest(http.MethodPost, "/admin/reject/30", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", "30")
+	req.AddCookie(&http.Cookie{Name: "session", Value: adminSession})
+	rec := httptest.NewRecorder()
+	app.HandleRejectAccount(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var n int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = 30`).Scan(&n)
+	if n != 0 {
+		t.Fatal("rejected account still exists")
+	}
+
+	app.runMailOutboxCycle(context.Background())
+	if len(*sent) != 1 || (*sent)[0].To != "spammy@example.com" || !strings.Contains((*sent)[0].TextBody, "Looks like a bot.") {
+		t.Fatalf("sent %+v", *sent)
+	}
+	if !strings.Contains((*sent)[0].Subject, "refusée") {
+		t.Fatalf("subject %q not in the signup language", (*sent)[0].Subject)
+	}
+
+	var actor sql.NullInt64
+	var detail string
+	if err := db.QueryRow(
+		`SELECT actor_user_id, COALESCE(detail_json, '') FROM admin_audit_log WHERE action = 'reject_account' AND target_id = '30'`,
+	).Scan(&actor, &detail); err != nil {
+		t.Fatal(err)
+	}
+	if actor.Int64 != 1 || !strings.Contains(detail, "Looks like a bot.") {
+		t.Fatalf("actor=%v detail=%s", actor, detail)
+	}
+}
+
+func TestExpirePendingAccounts(t *testing.T) {
+	db, s := openTestDB(t)
+	s.PendingAccountExpiryDays = 7
+	app := &App{Settings: s, DB: db}
+	now := time.Now().UTC()
+	old := now.AddDate(0, 0, -8).Format("2006-01-02 15:04:05")
+	recent := now.AddDate(0, 0, -2).Format("2006-01-02 15:04:05")
+	if _, err := db.Exec(
+		`INSERT INTO users (id, username, password, validated, is_admin, registered_at) VALUES
+		 (40, 'stale', 'x', 0, 0, ?), (41, 'fresh', 'x', 0, 0, ?), (42, 'approved', 'x', 1, 0, ?)`,
+		old, recent, old,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed := app.expirePendingAccounts(now); removed != 1 {
+		t.Fatalf("removed %d, want 1", removed)
+	}
+	var left int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM users WHERE id IN (40, 41, 42)`).Scan(&left)
+	if left != 2 {
+		t.Fatalf("%d accounts left, want 2", left)
+	}
+	var actor sql.NullInt64
+	if err := db.QueryRow(`SELECT actor_user_id FROM admin_audit_log WHERE action = 'expire_account' AND target_id = '40'`).Scan(&actor); err != nil {
+		t.Fatal(err)
+	}
+	if actor.Valid {
+		t.Fatalf("expiry audited with actor %d", actor.Int64)
+	}
+	entries, err := app.listAuditLog(10)
+	if err != nil || len(entries) != 1 || entries[0].ActorUserID != 0 {
+		t.Fatalf("entries=%+v err=%v", entries, err)
+	}
+
+	s.PendingAccountExpiryDays = 0
+	if removed := app.expirePendingAccounts(now.AddDate(1, 0, 0)); removed != 0 {
+		t.Fatalf("expiry disabled but removed %d", removed)
+	}
+}
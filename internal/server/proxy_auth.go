@@ -0,0 +1,243 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// proxyIdentityProvider is the user_identities provider for accounts asserted by the reverse proxy.
+// The subject is the raw header value, so a later rename in BookStorage keeps the mapping.
+const proxyIdentityProvider = "proxy"
+
+// WithProxyAuth signs in the user named by Settings.ProxyAuthHeader when the request comes straight
+// from a trusted proxy. It runs before the session is read: the request continues with a session
+// cookie for that user, so RequireLogin, RequireAdmin and currentUserID need no special case.
+// The admin flag follows the groups header on every request (written only when it changes); the
+// identity's last login is only refreshed when a session is created for the header user.
+// Requests from any other peer are left untouched, whatever headers they carry.
+func (a *App) WithProxyAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.Settings == nil || !a.Settings.ProxyAuthConfigured() || a.DB == nil ||
+			strings.HasPrefix(r.URL.Path, "/static/") || r.Header.Get("Authorization") != "" ||
+			!a.Settings.TrustedProxy(r.RemoteAddr) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		remoteUser := strings.TrimSpace(r.Header.Get(a.Settings.ProxyAuthHeader))
+		if remoteUser == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Already signed in as the header user: only a change of admin group membership is written.
+		sessionUser, _, hasSession := a.currentSession(r)
+		if hasSession && sessionUser == a.proxyIdentityUser(remoteUser) {
+			a.syncProxyAdminGroups(r, sessionUser, remoteUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, ok := a.resolveProxyUser(r, remoteUser)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.syncProxyAdminGroups(r, userID, remoteUser)
+		if hasSession && sessionUser == userID {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// No session yet, or one left by another account on this browser: the proxy decides who is here.
+		if _, tok, ok := a.currentSession(r); ok {
+			a.revokeSession(tok)
+		}
+		token, err := a.createSession(r, userID)
+		if err != nil {
+			log.Printf("proxy auth: session for user %d: %v", userID, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.setSessionCookie(w, token, sessionSlidingTTL)
+		next.ServeHTTP(w, withSessionCookie(r, token))
+	})
+}
+
+// withSessionCookie returns a copy of r whose only session cookie is token.
+func withSessionCookie(r *http.Request, token string) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.Header.Del("Cookie")
+	for _, c := range r.Cookies() {
+		if c.Name != sessionCookieName {
+			r2.AddCookie(c)
+		}
+	}
+	r2.AddCookie(&http.Cookie{Name: sessionCookieName, Value: token})
+	return r2
+}
+
+// proxyIdentityUser returns the account linked to the proxy username, or 0.
+func (a *App) proxyIdentityUser(remoteUser string) int {
+	var userID int
+	_ = a.DB.QueryRow(
+		`SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`,
+		proxyIdentityProvider, remoteUser,
+	).Scan(&userID)
+	return userID
+}
+
+// resolveProxyUser maps the proxy username to an account: a linked proxy identity first, then an
+// existing account with that username or verified email (linked on first use), then a new account
+// when ProxyAuthAutoCreate is set. Accounts awaiting validation are not signed in.
+func (a *App) resolveProxyUser(r *http.Request, remoteUser string) (int, bool) {
+	email := ""
+	if a.Settings.ProxyAuthEmailHeader != "" {
+		email = strings.TrimSpace(r.Header.Get(a.Settings.ProxyAuthEmailHeader))
+		if !strings.Contains(email, "@") {
+			email = ""
+		}
+	}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+
+	var userID int
+	err := a.DB.QueryRow(
+		`SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`,
+		proxyIdentityProvider, remoteUser,
+	).Scan(&userID)
+	switch {
+	case err == nil:
+		_, _ = a.DB.Exec(
+			`UPDATE user_identities SET last_login_at = ?, email = COALESCE(?, email) WHERE provider = ? AND subject = ?`,
+			now, nullStringOrEmpty(email), proxyIdentityProvider, remoteUser,
+		)
+	case errors.Is(err, sql.ErrNoRows):
+		userID, err = a.matchProxyAccount(remoteUser, email)
+		if err != nil {
+			log.Printf("proxy auth: lookup %q: %v", remoteUser, err)
+			return 0, false
+		}
+		if userID == 0 {
+			if !a.Settings.ProxyAuthAutoCreate {
+				return 0, false
+			}
+			if userID, err = a.createProxyAccount(r, remoteUser, email, now); err != nil {
+				log.Printf("proxy auth: create account for %q: %v", remoteUser, err)
+				return 0, false
+			}
+		}
+		if _, err := a.DB.Exec(
+			`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, ?)`,
+			userID, proxyIdentityProvider, remoteUser, nullStringOrEmpty(email), now,
+		); err != nil {
+			log.Printf("proxy auth: link %q to user %d: %v", remoteUser, userID, err)
+			return 0, false
+		}
+	default:
+		log.Printf("proxy auth: identity %q: %v", remoteUser, err)
+		return 0, false
+	}
+
+	var validated, isAdmin int
+	if err := a.DB.QueryRow(`SELECT validated, is_admin FROM users WHERE id = ?`, userID).Scan(&validated, &isAdmin); err != nil {
+		return 0, false
+	}
+	if a.Settings.RequireAccountValidation && validated == 0 && isAdmin == 0 {
+		return 0, false
+	}
+	return userID, true
+}
+
+// matchProxyAccount finds the existing account for a first proxy login: the exact username, else the
+// single account whose verified email matches. It returns 0 when there is no unambiguous match.
+func (a *App) matchProxyAccount(remoteUser, email string) (int, error) {
+	var id int
+	err := a.DB.QueryRow(`SELECT id FROM users WHERE username = ?`, remoteUser).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	if email == "" {
+		return 0, nil
+	}
+	rows, err := a.DB.Query(
+		`SELECT id FROM users WHERE LOWER(email) = LOWER(?) AND email_verified_at IS NOT NULL LIMIT 2`,
+		email,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	var ids []int
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil || len(ids) != 1 {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// createProxyAccount provisions a validated, password-less account. The operator opted in with
+// ProxyAuthAutoCreate, so the registration mode and invites do not apply: the proxy is the gate.
+func (a *App) createProxyAccount(r *http.Request, remoteUser, email, now string) (int, error) {
+	username, err := a.allocateUsername(remoteUser)
+	if err != nil {
+		return 0, err
+	}
+	var verifiedAt any
+	if email != "" {
+		verifiedAt = now
+	}
+	id, err := a.DB.InsertID(
+		`INSERT INTO users (username, password, validated, is_admin, registered_at, signup_lang, email, email_verified_at)
+		 VALUES (?, NULL, 1, 0, ?, ?, ?, ?)`,
+		username, now, a.currentLang(r), nullStringOrEmpty(email), verifiedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	a.logAdminAction(nil, "proxy_create_account", "user", strconv.FormatInt(id, 10), map[string]any{
+		"username":    username,
+		"remote_user": remoteUser,
+	})
+	return int(id), nil
+}
+
+// syncProxyAdminGroups applies syncProxyAdmin when admin groups are configured.
+func (a *App) syncProxyAdminGroups(r *http.Request, userID int, remoteUser string) {
+	if a.Settings.ProxyAuthGroupsHeader != "" && len(a.Settings.ProxyAuthAdminGroups) > 0 {
+		a.syncProxyAdmin(userID, remoteUser, r.Header.Get(a.Settings.ProxyAuthGroupsHeader))
+	}
+}
+
+// syncProxyAdmin makes is_admin follow membership of ProxyAuthAdminGroups. Superadmins are left alone.
+func (a *App) syncProxyAdmin(userID int, remoteUser, groupsHeader string) {
+	want := 0
+	for _, g := range strings.Split(groupsHeader, ",") {
+		if slices.Contains(a.Settings.ProxyAuthAdminGroups, strings.ToLower(strings.TrimSpace(g))) {
+			want = 1
+			break
+		}
+	}
+	var isAdmin, isSuperadmin int
+	if err := a.DB.QueryRow(`SELECT is_admin, is_superadmin FROM users WHERE id = ?`, userID).Scan(&isAdmin, &isSuperadmin); err != nil {
+		return
+	}
+	if isSuperadmin == 1 || isAdmin == want {
+		return
+	}
+	if _, err := a.DB.Exec(`UPDATE users SET is_admin = ? WHERE id = ?`, want, userID); err != nil {
+		log.Printf("proxy auth: admin flag for user %d: %v", userID, err)
+		return
+	}
+	action := "proxy_grant_admin"
+	if want == 0 {
+		action = "proxy_revoke_admin"
+	}
+	a.logAdminAction(nil, action, "user", strconv.Itoa(userID), map[string]any{"remote_user": remoteUser})
+}
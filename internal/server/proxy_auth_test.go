@@ -0,0 +1,190 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func testAppWithProxyAuth(t *testing.T) *App {
+	t.Helper()
+	db, s := openTestDB(t)
+	s.RequireAccountValidation = true
+	// httptest.NewRequest peers come from 192.0.2.1.
+	s.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+	s.ProxyAuthHeader = "Remote-User"
+	s.ProxyAuthEmailHeader = "Remote-Email"
+	s.ProxyAuthGroupsHeader = "Remote-Groups"
+	s.ProxyAuthAdminGroups = []string{"book-admins"}
+	return &App{Settings: s, DB: db}
+}
+
+// proxyRequest runs one request through WithProxyAuth and RequireLogin and reports the user the handler saw.
+func proxyRequest(t *testing.T, app *App, remoteAddr string, headers map[string]string, cookies ...*http.Cookie) (int, *httptest.ResponseRecorder) {
+	t.Helper()
+	seen := 0
+	h := app.WithProxyAuth(app.RequireLogin(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = app.currentUserID(r)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return seen, rec
+}
+
+func TestWithProxyAuth_trustedPeersOnly(t *testing.T) {
+	app := testAppWithProxyAuth(t)
+	if _, err := app.DB.Exec(`INSERT INTO users (id, username, password, validated, is_admin) VALUES (60, 'reader', NULL, 1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if uid, rec := proxyRequest(t, app, "203.0.113.9:5000", map[string]string{"Remote-User": "reader"}); uid != 0 || rec.Code != http.StatusFound {
+		t.Fatalf("untrusted peer signed in as %d (status %d)", uid, rec.Code)
+	}
+	if uid, _ := proxyRequest(t, app, "", map[string]string{"Remote-User": "nobody"}); uid != 0 {
+		t.Fatalf("unknown user signed in as %d without auto-create", uid)
+	}
+
+	uid, rec := proxyRequest(t, app, "", map[string]string{"Remote-User": "reader"})
+	session := responseCookie(rec, sessionCookieName)
+	if uid != 60 || session == nil {
+		t.Fatalf("trusted proxy: uid=%d cookie=%v", uid, session)
+	}
+	// The existing session is reused, and the username match is now a linked identity.
+	if _, rec := proxyRequest(t, app, "", map[string]string{"Remote-User": "reader"}, session); responseCookie(rec, sessionCookieName).Value != session.Value {
+		t.Fatal("session not reused")
+	}
+	if _, err := app.DB.Exec(`UPDATE users SET username = 'renamed' WHERE id = 60`); err != nil {
+		t.Fatal(err)
+	}
+	if uid, _ := proxyRequest(t, app, "", map[string]string{"Remote-User": "reader"}); uid != 60 {
+		t.Fatalf("renamed account resolved to %d", uid)
+	}
+	if ids, _ := app.listUserIdentities(60); len(ids) != 0 {
+		t.Fatalf("proxy identity listed on the profile: %+v", ids)
+	}
+
+	// Another account's session on the same browser is replaced by the proxy user's.
+	other := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 1)}
+	if uid, _ := proxyRequest(t, app, "", map[string]string{"Remote-User": "reader"}, other); uid != 60 {
+		t.Fatalf("stale session kept: uid=%d", uid)
+	}
+	var revoked int
+	_ = app.DB.QueryRow(`SELECT COUNT(*) FROM sessions WHERE user_id = 1 AND revoked_at IS NOT NULL`).Scan(&revoked)
+	if revoked != 1 {
+		t.Fatalf("%d admin sessions revoked", revoked)
+	}
+
+	if _, err := app.DB.Exec(`UPDATE users SET validated = 0 WHERE id = 60`); err != nil {
+		t.Fatal(err)
+	}
+	if uid, _ := proxyRequest(t, app, "", map[string]string{"Remote-User": "reader"}); uid != 0 {
+		t.Fatal("pending account signed in")
+	}
+}
+
+func TestWithProxyAuth_autoCreateAndAdminGroups(t *testing.T) {
+	app := testAppWithProxyAuth(t)
+	app.Settings.ProxyAuthAutoCreate = true
+	if _, err := app.DB.Exec(`INSERT INTO users (id, username, password, validated, is_admin, email, email_verified_at)
+		VALUES (61, 'mailmatch', NULL, 1, 0, 'Shared@Example.com', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if uid, _ := proxyRequest(t, app, "", map[string]string{"Remote-User": "sso-61", "Remote-Email": "shared@example.com"}); uid != 61 {
+		t.Fatalf("verified email match: uid=%d", uid)
+	}
+
+	headers := map[string]string{"Remote-User": "New.Person", "Remote-Email": "new@example.com", "Remote-Groups": "readers, Book-Admins"}
+	uid, _ := proxyRequest(t, app, "", headers)
+	var username, email string
+	var isAdmin int
+	if err := app.DB.QueryRow(`SELECT username, email, is_admin FROM users WHERE id = ?`, uid).Scan(&username, &email, &isAdmin); err != nil {
+		t.Fatalf("auto-created account: uid=%d err=%v", uid, err)
+	}
+	if username != "newperson" || email != "new@example.com" || isAdmin != 1 {
+		t.Fatalf("username=%q email=%q is_admin=%d", username, email, isAdmin)
+	}
+
+	headers["Remote-Groups"] = "readers"
+	if again, _ := proxyRequest(t, app, "", headers); again != uid {
+		t.Fatalf("second login resolved to %d, want %d", again, uid)
+	}
+	_ = app.DB.QueryRow(`SELECT is_admin FROM users WHERE id = ?`, uid).Scan(&isAdmin)
+	if isAdmin != 0 {
+		t.Fatal("admin kept after leaving the admin group")
+	}
+	var logged int
+	_ = app.DB.QueryRow(`SELECT COUNT(*) FROM admin_audit_log WHERE action IN ('proxy_create_account', 'proxy_grant_admin', 'proxy_revoke_admin')`).Scan(&logged)
+	if logged != 3 {
+		t.Fatalf("%d audit entries", logged)
+	}
+}
+
+func TestWithProxyAuth_productionChain(t *testing.T) {
+	app := testAppWithProxyAuth(t)
+	if _, err := app.DB.Exec(`INSERT INTO users (id, username, password, validated, is_admin) VALUES (62, 'chain', NULL, 1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+	seen := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dashboard", app.RequireLogin(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = app.currentUserID(r)
+	}))
+	handler := app.Handler(mux)
+	get := func(groups string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		seen = 0
+		req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		req.Header.Set("Remote-User", "chain")
+		req.Header.Set("Remote-Groups", groups)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("readers")
+	session := responseCookie(rec, sessionCookieName)
+	if seen != 62 || session == nil {
+		t.Fatalf("through the chain: uid=%d status=%d", seen, rec.Code)
+	}
+	// With a session for the header user, later requests leave the identity alone but the admin flag
+	// follows group membership right away, both ways.
+	if _, err := app.DB.Exec(`UPDATE user_identities SET last_login_at = '2000-01-01 00:00:00' WHERE provider = 'proxy'`); err != nil {
+		t.Fatal(err)
+	}
+	isAdmin := func() int {
+		var n int
+		_ = app.DB.QueryRow(`SELECT is_admin FROM users WHERE id = 62`).Scan(&n)
+		return n
+	}
+	if get("book-admins", session); seen != 62 || isAdmin() != 1 {
+		t.Fatalf("promotion with a session: uid=%d is_admin=%d", seen, isAdmin())
+	}
+	if get("readers", session); seen != 62 || isAdmin() != 0 {
+		t.Fatalf("demotion with a session: uid=%d is_admin=%d", seen, isAdmin())
+	}
+	var lastLogin string
+	_ = app.DB.QueryRow(`SELECT last_login_at FROM user_identities WHERE provider = 'proxy'`).Scan(&lastLogin)
+	if !strings.HasPrefix(lastLogin, "2000-01-01") {
+		t.Fatalf("session request wrote last_login_at=%q", lastLogin)
+	}
+	var logged int
+	_ = app.DB.QueryRow(`SELECT COUNT(*) FROM admin_audit_log WHERE action IN ('proxy_grant_admin', 'proxy_revoke_admin')`).Scan(&logged)
+	if logged != 2 {
+		t.Fatalf("%d admin changes logged", logged)
+	}
+}
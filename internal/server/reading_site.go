@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +33,8 @@ type readingSite struct {
 	ProbeStatus     string
 	ProbeHTTPStatus sql.NullInt64
 	ProbeDetail     sql.NullString
+	// FeedTemplate builds the release feed URL of linked works (see expandFeedTemplate).
+	FeedTemplate sql.NullString
 }
 
 // MatchReadingSite finds the best reading_sites row for a given link URL.
@@ -222,9 +225,31 @@ func (a *App) ProbeAndUpdateSite(ctx context.Context, site readingSite) ProbeSta
 		`UPDATE reading_sites SET last_probe_at = ?, probe_status = ?, probe_http_status = ?, probe_detail = ? WHERE id = ?`,
 		now, string(status), httpArg, detailArg, site.ID,
 	)
+	a.notifySiteStatusChange(site, status)
 	return status
 }
 
+// notifySiteStatusChange tells the site owner when a probe moves a site between reachable (up)
+// and unreachable (down/degraded). Unknown results never notify.
+func (a *App) notifySiteStatusChange(site readingSite, status ProbeStatus) {
+	wasDown := linkStatusIsDead(site.ProbeStatus)
+	kind := ""
+	switch {
+	case linkStatusIsDead(string(status)) && !wasDown:
+		kind = notificationSiteDown
+	case status == ProbeStatusUp && wasDown:
+		kind = notificationSiteUp
+	default:
+		return
+	}
+	a.notify(site.UserID, notification{
+		Kind:      kind,
+		Subject:   site.Name,
+		Link:      "/reading-sites",
+		DedupeKey: "site:" + strconv.Itoa(site.ID),
+	})
+}
+
 // ProbeAllUserSites probes all sites for a user, respecting TTL (skip if probed within minInterval).
 func (a *App) ProbeAllUserSites(ctx context.Context, userID int, minInterval time.Duration) {
 	sites := a.loadUserReadingSites(userID)
@@ -243,7 +268,7 @@ func (a *App) ProbeAllUserSites(ctx context.Context, userID int, minInterval tim
 
 func (a *App) loadUserReadingSites(userID int) []readingSite {
 	rows, err := a.DB.Query(
-		`SELECT id, user_id, name, base_url, last_probe_at, COALESCE(probe_status, 'unknown'), probe_http_status, probe_detail FROM reading_sites WHERE user_id = ? ORDER BY name`,
+		`SELECT id, user_id, name, base_url, last_probe_at, COALESCE(probe_status, 'unknown'), probe_http_status, probe_detail, feed_template FROM reading_sites WHERE user_id = ? ORDER BY name`,
 		userID,
 	)
 	if err != nil {
@@ -253,7 +278,7 @@ func (a *App) loadUserReadingSites(userID int) []readingSite {
 	var sites []readingSite
 	for rows.Next() {
 		var s readingSite
-		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.BaseURL, &s.LastProbeAt, &s.ProbeStatus, &s.ProbeHTTPStatus, &s.ProbeDetail); err != nil {
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.BaseURL, &s.LastProbeAt, &s.ProbeStatus, &s.ProbeHTTPStatus, &s.ProbeDetail, &s.FeedTemplate); err != nil {
 			continue
 		}
 		sites = append(sites, s)
@@ -261,6 +286,16 @@ func (a *App) loadUserReadingSites(userID int) []readingSite {
 	return sites
 }
 
+// loadUserReadingSite returns one of the user's sites, or sql.ErrNoRows.
+func (a *App) loadUserReadingSite(userID, id int) (readingSite, error) {
+	var s readingSite
+	err := a.DB.QueryRow(
+		`SELECT id, user_id, name, base_url, last_probe_at, COALESCE(probe_status, 'unknown'), probe_http_status, probe_detail, feed_template FROM reading_sites WHERE id = ? AND user_id = ?`,
+		id, userID,
+	).Scan(&s.ID, &s.UserID, &s.Name, &s.BaseURL, &s.LastProbeAt, &s.ProbeStatus, &s.ProbeHTTPStatus, &s.ProbeDetail, &s.FeedTemplate)
+	return s, err
+}
+
 func (a *App) loadReadingSiteStatusMap(userID int) map[int]readingSite {
 	sites := a.loadUserReadingSites(userID)
 	m := make(map[int]readingSite, len(sites))
@@ -358,8 +393,8 @@ func (a *App) ProbeWorkLinks(ctx context.Context, limit int) {
 	if a.DB != nil && a.DB.B != database.BackendPostgres {
 		orderClause = "ORDER BY CASE WHEN link_probe_at IS NULL THEN 0 ELSE 1 END, link_probe_at ASC, id ASC"
 	}
-	q := `SELECT id, link FROM works WHERE link IS NOT NULL AND TRIM(link) != '' AND status IN ('En cours', 'Reading') ` + orderClause + ` LIMIT ?`
-	rows, err := a.DB.Query(q, limit)
+	q := `SELECT id, user_id, title, link, COALESCE(link_probe_status, 'unknown') FROM works WHERE link IS NOT NULL AND TRIM(link) != '' AND status = ? ` + orderClause + ` LIMIT ?`
+	rows, err := a.DB.Query(q, statusReading, limit)
 	if err != nil {
 		log.Printf("[prober] failed to list work links: %v", err)
 		return
@@ -367,13 +402,16 @@ func (a *App) ProbeWorkLinks(ctx context.Context, limit int) {
 	defer func() { _ = rows.Close() }()
 
 	type workLink struct {
-		ID   int
-		Link string
+		ID         int
+		UserID     int
+		Title      string
+		Link       string
+		PrevStatus string
 	}
 	var pending []workLink
 	for rows.Next() {
 		var w workLink
-		if err := rows.Scan(&w.ID, &w.Link); err != nil {
+		if err := rows.Scan(&w.ID, &w.UserID, &w.Title, &w.Link, &w.PrevStatus); err != nil {
 			continue
 		}
 		pending = append(pending, w)
@@ -404,6 +442,15 @@ func (a *App) ProbeWorkLinks(ctx context.Context, limit int) {
 			`UPDATE works SET link_probe_status = ?, link_probe_at = ?, link_probe_http_status = ?, link_probe_detail = ? WHERE id = ?`,
 			string(status), now, httpArg, detailArg, w.ID,
 		)
+		if linkStatusIsDead(string(status)) && !linkStatusIsDead(w.PrevStatus) {
+			id := strconv.Itoa(w.ID)
+			a.notify(w.UserID, notification{
+				Kind:      notificationLinkDead,
+				Subject:   w.Title,
+				Link:      "/edit/" + id,
+				DedupeKey: "link:" + id,
+			})
+		}
 	}
 }
 
@@ -0,0 +1,421 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/database"
+)
+
+const (
+	releaseFeedTimeout     = 15 * time.Second
+	releaseFeedMaxBytes    = 2 << 20
+	releaseFeedMaxEntries  = 50
+	releaseFeedQuota       = 50
+	releaseFeedRecheck     = time.Hour
+	releaseFeedStatusOK    = "ok"
+	releaseFeedStatusEmpty = "no_chapters"
+	releaseFeedStatusError = "error"
+)
+
+var errReleaseFeedUnsafe = errors.New("unsafe feed URL")
+
+// fetchReleaseFeed downloads a feed body through the SSRF-safe probe client.
+// Tests replace it to serve fixtures from a loopback server.
+var fetchReleaseFeed = func(ctx context.Context, feedURL string) ([]byte, error) {
+	if !isProbeURLSafe(feedURL) {
+		return nil, errReleaseFeedUnsafe
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", probeBrowserUA)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	resp, err := newProbeHTTPClient(releaseFeedTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("feed http %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, releaseFeedMaxBytes))
+}
+
+// releaseEntry is one chapter found in an RSS 2.0 item or Atom entry.
+type releaseEntry struct {
+	Chapter    float64
+	Title      string
+	URL        string
+	ReleasedAt time.Time // zero when the feed gives no parsable date
+}
+
+// feedDocument covers both RSS 2.0 (rss>channel>item) and Atom (feed>entry); element names
+// match regardless of namespace.
+type feedDocument struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		GUID    string `xml:"guid"`
+		PubDate string `xml:"pubDate"`
+		DCDate  string `xml:"date"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// parseReleaseFeed extracts numbered chapters from an RSS 2.0 or Atom document, keeping the first
+// occurrence of each chapter. Entries without a recognizable chapter number are skipped.
+func parseReleaseFeed(body []byte) ([]releaseEntry, error) {
+	var doc feedDocument
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+	var out []releaseEntry
+	seen := map[float64]bool{}
+	add := func(title, link, date string) {
+		if len(out) >= releaseFeedMaxEntries {
+			return
+		}
+		title, link = strings.TrimSpace(title), strings.TrimSpace(link)
+		ch, ok := extractChapterNumber(title, link)
+		if !ok || seen[ch] {
+			return
+		}
+		seen[ch] = true
+		out = append(out, releaseEntry{Chapter: ch, Title: title, URL: link, ReleasedAt: parseFeedTime(date)})
+	}
+	for _, it := range doc.Items {
+		link := it.Link
+		if link == "" {
+			link = it.GUID
+		}
+		date := it.PubDate
+		if date == "" {
+			date = it.DCDate
+		}
+		add(it.Title, link, date)
+	}
+	for _, e := range doc.Entries {
+		link := ""
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		add(e.Title, link, date)
+	}
+	return out, nil
+}
+
+var (
+	// chapterTitlePattern matches "Chapter 12", "Ch. 12.5", "Chapitre 3", "Episode 40", "Ep 7"…
+	chapterTitlePattern = regexp.MustCompile(`(?i)(?:^|[^\pL])(?:chapter|chap|ch|chapitre|cap[ií]tulo|capitolo|kapitel|[eé]pisode|ep)\.?\s*#?\s*(\d{1,5}(?:[.,]\d{1,2})?)\b`)
+	// chapterHashPattern matches "#12" when the title has no chapter keyword.
+	chapterHashPattern = regexp.MustCompile(`#(\d{1,5}(?:\.\d{1,2})?)\b`)
+	// chapterURLPattern matches ".../chapter-12", "/ch_12", "episode_no=12" in entry links.
+	chapterURLPattern = regexp.MustCompile(`(?i)(?:^|[^a-z])(?:chapter|chapitre|episode|ch|ep)(?:_no)?[-_/=]?(\d{1,5})(?:[-_.](\d{1,2}))?(?:\D|$)`)
+)
+
+// extractChapterNumber finds the chapter number of a feed entry, title first, then its link.
+func extractChapterNumber(title, link string) (float64, bool) {
+	for _, re := range []*regexp.Regexp{chapterTitlePattern, chapterHashPattern} {
+		if m := re.FindStringSubmatch(title); m != nil {
+			if n, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64); err == nil && n > 0 {
+				return n, true
+			}
+		}
+	}
+	if u, err := url.Parse(link); err == nil && link != "" {
+		target := u.Path
+		if u.RawQuery != "" {
+			target += "?" + u.RawQuery
+		}
+		if m := chapterURLPattern.FindStringSubmatch(target); m != nil {
+			num := m[1]
+			if m[2] != "" {
+				num += "." + m[2]
+			}
+			if n, err := strconv.ParseFloat(num, 64); err == nil && n > 0 {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", "2006-01-02 15:04:05", "2006-01-02",
+}
+
+func parseFeedTime(raw string) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// expandFeedTemplate builds a work's feed URL from its reading site template. Placeholders:
+// {link} is the work link without trailing slash, {slug} its last path segment, {path} its path.
+// A template starting with "/" is resolved against the site base URL.
+func expandFeedTemplate(template, baseURL, link string) string {
+	template = strings.TrimSpace(template)
+	link = strings.TrimSpace(link)
+	if template == "" || link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	path := strings.TrimRight(u.Path, "/")
+	slug := path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		slug = path[i+1:]
+	}
+	if strings.Contains(template, "{slug}") && slug == "" {
+		return ""
+	}
+	out := strings.NewReplacer(
+		"{link}", strings.TrimRight(link, "/"),
+		"{path}", path,
+		"{slug}", url.PathEscape(slug),
+	).Replace(template)
+	if strings.HasPrefix(out, "/") {
+		base, err := url.Parse(strings.TrimSpace(baseURL))
+		if err != nil || base.Host == "" {
+			return ""
+		}
+		out = base.Scheme + "://" + base.Host + out
+	}
+	return out
+}
+
+// workFeedURL is the effective feed of a work: its own feed_url, else its site's template.
+func workFeedURL(feedURL, siteTemplate, siteBaseURL, link string) string {
+	if u := strings.TrimSpace(feedURL); u != "" {
+		return u
+	}
+	return expandFeedTemplate(siteTemplate, siteBaseURL, link)
+}
+
+// validateFeedURL normalizes a user-entered feed URL ("" clears it).
+func validateFeedURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", true
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	return raw, true
+}
+
+// validateFeedTemplate accepts an absolute http(s) template, a path starting with "/" or a
+// template starting with {link}; it must use at least one placeholder ("" clears it).
+func validateFeedTemplate(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", true
+	}
+	if !strings.Contains(raw, "{link}") && !strings.Contains(raw, "{slug}") && !strings.Contains(raw, "{path}") {
+		return "", false
+	}
+	if strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, "{link}") {
+		return raw, true
+	}
+	u, err := url.Parse(strings.NewReplacer("{link}", "x", "{slug}", "x", "{path}", "/x").Replace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	return raw, true
+}
+
+// PollReleaseFeeds checks the RSS/Atom feed of up to limit followed works (notify_new_chapters),
+// oldest check first, and records the chapters found in work_releases.
+func (a *App) PollReleaseFeeds(ctx context.Context, limit int) {
+	if limit <= 0 {
+		return
+	}
+	orderClause := "ORDER BY w.feed_checked_at ASC NULLS FIRST, w.id ASC"
+	if a.DB != nil && a.DB.B != database.BackendPostgres {
+		orderClause = "ORDER BY CASE WHEN w.feed_checked_at IS NULL THEN 0 ELSE 1 END, w.feed_checked_at ASC, w.id ASC"
+	}
+	cutoff := time.Now().UTC().Add(-releaseFeedRecheck).Format("2006-01-02 15:04:05")
+	rows, err := a.DB.Query(
+		`SELECT w.id, COALESCE(w.feed_url, ''), COALESCE(w.link, ''), COALESCE(rs.feed_template, ''), COALESCE(rs.base_url, '')
+		 FROM works w LEFT JOIN reading_sites rs ON rs.id = w.reading_site_id AND rs.user_id = w.user_id
+		 WHERE COALESCE(w.notify_new_chapters, 1) = 1
+		   AND (COALESCE(w.feed_url, '') != '' OR (COALESCE(rs.feed_template, '') != '' AND COALESCE(w.link, '') != ''))
+		   AND (w.feed_checked_at IS NULL OR w.feed_checked_at < ?)
+		 `+orderClause+` LIMIT ?`,
+		cutoff, limit,
+	)
+	if err != nil {
+		log.Printf("[feeds] failed to list works: %v", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	type workFeed struct {
+		ID      int
+		FeedURL string
+	}
+	var pending []workFeed
+	for rows.Next() {
+		var id int
+		var feedURL, link, template, baseURL string
+		if err := rows.Scan(&id, &feedURL, &link, &template, &baseURL); err != nil {
+			continue
+		}
+		pending = append(pending, workFeed{ID: id, FeedURL: workFeedURL(feedURL, template, baseURL, link)})
+	}
+	if len(pending) == 0 {
+		return
+	}
+	log.Printf("[feeds] checking %d works", len(pending))
+	for _, wf := range pending {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		status := a.pollWorkReleaseFeed(ctx, wf.ID, wf.FeedURL)
+		now := time.Now().UTC().Format("2006-01-02 15:04:05")
+		_, _ = a.DB.Exec(`UPDATE works SET feed_checked_at = ?, feed_status = ? WHERE id = ?`, now, status, wf.ID)
+	}
+}
+
+func (a *App) pollWorkReleaseFeed(ctx context.Context, workID int, feedURL string) string {
+	if feedURL == "" {
+		return releaseFeedStatusError
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, releaseFeedTimeout)
+	body, err := fetchReleaseFeed(fetchCtx, feedURL)
+	cancel()
+	if err != nil {
+		log.Printf("[feeds] work %d: %v", workID, err)
+		return releaseFeedStatusError
+	}
+	entries, err := parseReleaseFeed(body)
+	if err != nil {
+		log.Printf("[feeds] work %d: %v", workID, err)
+		return releaseFeedStatusError
+	}
+	if len(entries) == 0 {
+		return releaseFeedStatusEmpty
+	}
+	var previous sql.NullFloat64
+	_ = a.DB.QueryRow(`SELECT MAX(chapter) FROM work_releases WHERE work_id = ?`, workID).Scan(&previous)
+	newest := 0.0
+	for _, e := range entries {
+		newest = math.Max(newest, e.Chapter)
+		var releasedAt any
+		if !e.ReleasedAt.IsZero() {
+			releasedAt = e.ReleasedAt.Format("2006-01-02 15:04:05")
+		}
+		if _, err := a.DB.Exec(
+			`INSERT INTO work_releases (work_id, chapter, title, url, released_at) VALUES (?, ?, ?, ?, ?)
+			 ON CONFLICT (work_id, chapter) DO NOTHING`,
+			workID, e.Chapter, nullIfEmpty(e.Title), nullIfEmpty(e.URL), releasedAt,
+		); err != nil {
+			log.Printf("[feeds] work %d: record chapter %v: %v", workID, e.Chapter, err)
+		}
+	}
+	// The first fetch only records a baseline; later ones notify when the feed moved forward.
+	if previous.Valid && newest > previous.Float64 {
+		a.notifyNewChapters(workID)
+	}
+	return releaseFeedStatusOK
+}
+
+// workRelease is one chapter recorded from a work's release feed.
+type workRelease struct {
+	Chapter    float64 `json:"chapter"`
+	Title      string  `json:"title,omitempty"`
+	URL        string  `json:"url,omitempty"`
+	ReleasedAt string  `json:"released_at,omitempty"`
+	DetectedAt string  `json:"detected_at"`
+}
+
+const workReleasesListLimit = 100
+
+func (a *App) listWorkReleases(workID int) ([]workRelease, error) {
+	rows, err := a.DB.Query(
+		`SELECT chapter, COALESCE(title, ''), COALESCE(url, ''), released_at, detected_at
+		 FROM work_releases WHERE work_id = ? ORDER BY chapter DESC LIMIT ?`,
+		workID, workReleasesListLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := []workRelease{}
+	for rows.Next() {
+		var rel workRelease
+		var releasedAt, detectedAt nullFlexTime
+		if err := rows.Scan(&rel.Chapter, &rel.Title, &rel.URL, &releasedAt, &detectedAt); err != nil {
+			return nil, err
+		}
+		rel.ReleasedAt, rel.DetectedAt = releasedAt.String, detectedAt.String
+		out = append(out, rel)
+	}
+	return out, rows.Err()
+}
+
+// HandleAPIWorkReleases lists the chapters recorded from the work's release feed, newest first.
+func (a *App) HandleAPIWorkReleases(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	if ok, err := a.userOwnsWork(userID, workID); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	} else if !ok {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	releases, err := a.listWorkReleases(workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": releases})
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Tower</title>
+<item><title>Tower of God - Episode 601</title><link>https://www.webtoons.com/en/fantasy/tower-of-god/list?title_no=95&amp;episode_no=601</link><pubDate>Sun, 11 Oct 2026 14:00:00 GMT</pubDate></item>
+<item><title>Season 3 Ep. 600.5 (special)</title><link>https://example.com/tog/600-5</link><pubDate>Sun, 04 Oct 2026 14:00:00 +0000</pubDate></item>
+<item><title>Hiatus announcement</title><link>https://example.com/news/hiatus</link></item>
+</channel></rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Novel</title>
+<entry><title>Volume 2</title><link rel="alternate" href="https://novels.example.com/my-novel/chapter-45"/><updated>2026-10-10T08:30:00Z</updated></entry>
+<entry><title>Chapitre 44 : le retour</title><link href="https://novels.example.com/my-novel/44"/><published>2026-10-03T08:30:00+02:00</published></entry>
+</feed>`
+
+func TestParseReleaseFeed(t *testing.T) {
+	rss, err := parseReleaseFeed([]byte(testRSSFeed))
+	if err != nil || len(rss) != 2 {
+		t.Fatalf("rss=%+v err=%v", rss, err)
+	}
+	if rss[0].Chapter != 601 || rss[0].ReleasedAt.Format("2006-01-02 15:04") != "2026-10-11 14:00" || rss[1].Chapter != 600.5 {
+		t.Fatalf("rss entries=%+v", rss)
+	}
+
+	atom, err := parseReleaseFeed([]byte(testAtomFeed))
+	if err != nil || len(atom) != 2 {
+		t.Fatalf("atom=%+v err=%v", atom, err)
+	}
+	if atom[0].Chapter != 45 || atom[0].URL != "https://novels.example.com/my-novel/chapter-45" || atom[1].Chapter != 44 || atom[1].ReleasedAt.Hour() != 6 {
+		t.Fatalf("atom entries=%+v", atom)
+	}
+
+	if _, err := parseReleaseFeed([]byte("not xml")); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestExtractChapterNumber(t *testing.T) {
+	cases := []struct {
+		title, link string
+		want        float64
+	}{
+		{"Chapter 12", "", 12},
+		{"Ch.7,5 - Interlude", "", 7.5},
+		{"Capítulo 3", "", 3},
+		{"Épisode 9", "", 9},
+		{"Solo Leveling #200", "", 200},
+		{"Match 3 recap", "https://example.com/posts/match-3", 0},
+		{"New release", "https://example.com/read?episode_no=88", 88},
+		{"New release", "https://example.com/series/ch_14-2/", 14.2},
+	}
+	for _, c := range cases {
+		got, ok := extractChapterNumber(c.title, c.link)
+		if got != c.want || ok != (c.want > 0) {
+			t.Fatalf("%q %q: got %v ok=%v want %v", c.title, c.link, got, ok, c.want)
+		}
+	}
+}
+
+func TestExpandFeedTemplate(t *testing.T) {
+	link := "https://reader.example.com/series/my-novel/"
+	cases := map[string]string{
+		"{link}/rss":                "https://reader.example.com/series/my-novel/rss",
+		"/feeds/{slug}.xml":         "https://reader.example.com/feeds/my-novel.xml",
+		"https://rss.example{path}": "https://rss.example/series/my-novel",
+		"":                          "",
+	}
+	for tmpl, want := range cases {
+		if got := expandFeedTemplate(tmpl, "https://reader.example.com", link); got != want {
+			t.Fatalf("%q: got %q want %q", tmpl, got, want)
+		}
+	}
+	for raw, ok := range map[string]bool{"{link}/rss": true, "/rss/{slug}": true, "https://x.example/{slug}": true, "/rss": false, "ftp://x/{slug}": false} {
+		if _, valid := validateFeedTemplate(raw); valid != ok {
+			t.Fatalf("validateFeedTemplate(%q)=%v", raw, valid)
+		}
+	}
+}
+
+func TestPollReleaseFeeds_recordsReleases(t *testing.T) {
+	feeds := map[string]string{
+		"https://reader.example.com/series/tower/rss": testRSSFeed,
+		"https://novels.example.com/my-novel.atom":    testAtomFeed,
+	}
+	var fetched []string
+	orig := fetchReleaseFeed
+	fetchReleaseFeed = func(_ context.Context, feedURL string) ([]byte, error) {
+		fetched = append(fetched, feedURL)
+		body, ok := feeds[feedURL]
+		if !ok {
+			return nil, errors.New("feed http 404")
+		}
+		return []byte(body), nil
+	}
+	t.Cleanup(func() { fetchReleaseFeed = orig })
+
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	res, err := db.Exec(`INSERT INTO reading_sites (user_id, name, base_url, feed_template) VALUES (1, 'Reader', 'https://reader.example.com', '{link}/rss')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siteID, _ := res.LastInsertId()
+	viaSite := insertTestWork(t, app, "Tower", 598)
+	direct := insertTestWork(t, app, "My novel", 44)
+	muted := insertTestWork(t, app, "Muted", 1)
+	if _, err := db.Exec(`UPDATE works SET link = 'https://reader.example.com/series/tower/', reading_site_id = ?, notify_new_chapters = 1 WHERE id = ?`, siteID, viaSite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE works SET feed_url = 'https://novels.example.com/my-novel.atom', notify_new_chapters = 1 WHERE id = ?`, direct); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE works SET feed_url = 'https://novels.example.com/muted.atom', notify_new_chapters = 0 WHERE id = ?`, muted); err != nil {
+		t.Fatal(err)
+	}
+
+	app.PollReleaseFeeds(context.Background(), 10)
+	app.PollReleaseFeeds(context.Background(), 10)
+	if len(fetched) != 2 {
+		t.Fatalf("fetched=%v", fetched)
+	}
+
+	detail := func(id int) apiWork {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/works/"+strconv.Itoa(id), nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", strconv.Itoa(id))
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorksDetail(rec, req)
+		var payload struct {
+			Data apiWork `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+		}
+		return payload.Data
+	}
+	if w := detail(viaSite); w.LatestChapter == nil || *w.LatestChapter != 601 || w.ChaptersBehind != 3 || w.LatestReleaseAt == "" {
+		t.Fatalf("site work=%+v", w)
+	}
+	if w := detail(direct); w.ChaptersBehind != 1 || w.FeedURL == "" {
+		t.Fatalf("direct work=%+v", w)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/works/"+strconv.Itoa(direct)+"/releases", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", strconv.Itoa(direct))
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkReleases(rec, req)
+	var releases struct {
+		Data []workRelease `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &releases); err != nil {
+		t.Fatal(err)
+	}
+	if len(releases.Data) != 2 || releases.Data[0].Chapter != 45 || releases.Data[1].ReleasedAt != "2026-10-03 06:30:00" {
+		t.Fatalf("releases=%+v", releases.Data)
+	}
+	var status string
+	if err := db.QueryRow(`SELECT COALESCE(feed_status, '') FROM works WHERE id = ?`, direct).Scan(&status); err != nil || status != releaseFeedStatusOK {
+		t.Fatalf("feed_status=%q err=%v", status, err)
+	}
+
+	// A later fetch that moves the feed forward notifies the reader; the first one was the baseline.
+	if n := app.unreadNotificationCount(1); n != 0 {
+		t.Fatalf("unread after baseline=%d", n)
+	}
+	feeds["https://novels.example.com/my-novel.atom"] = strings.Replace(testAtomFeed, "chapter-45", "chapter-46", 1)
+	if _, err := db.Exec(`UPDATE works SET feed_checked_at = NULL WHERE id = ?`, direct); err != nil {
+		t.Fatal(err)
+	}
+	app.PollReleaseFeeds(context.Background(), 10)
+	var detailText string
+	if err := db.QueryRow(`SELECT detail FROM notifications WHERE user_id = 1 AND kind = ?`, notificationNewChapters).Scan(&detailText); err != nil || detailText != "2" {
+		t.Fatalf("new_chapters detail=%q err=%v", detailText, err)
+	}
+}
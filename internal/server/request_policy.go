@@ -124,7 +124,9 @@ func isSameOriginRequest(r *http.Request, publicOrigin string) bool {
 
 func shouldRateLimit(path string) (key string, capacity, refillPerSec float64, ok bool) {
 	switch {
-	case path == "/login", path == "/register", path == "/forgot-password", path == "/reset-password":
+	case path == "/oauth/token":
+		return "oauth_token", 30, 1.0, true
+	case path == "/login", path == "/login/2fa", path == "/register", path == "/forgot-password", path == "/reset-password", path == "/profile/email/resend":
 		return "auth", 8, 0.5, true
 	case path == "/api/works/bulk",
 		strings.HasPrefix(path, "/api/works"),
@@ -132,8 +134,14 @@ func shouldRateLimit(path string) (key string, capacity, refillPerSec float64, o
 		strings.HasPrefix(path, "/api/decrement/"),
 		strings.HasPrefix(path, "/api/set-chapter/"),
 		strings.HasPrefix(path, "/api/delete/"),
+		strings.HasPrefix(path, "/api/webhooks"),
+		strings.HasPrefix(path, "/api/reading-sites"),
 		path == "/profile/delete",
 		path == "/profile/google/unlink",
+		strings.HasPrefix(path, "/profile/identities/"),
+		strings.HasPrefix(path, "/profile/apps/"),
+		path == "/oauth/authorize",
+		strings.HasPrefix(path, "/profile/2fa/"),
 		path == "/import",
 		strings.HasPrefix(path, "/tools/csv-import"),
 		strings.HasPrefix(path, "/users/"),
@@ -149,8 +157,9 @@ func shouldRateLimitOAuthGET(path, method string) (key string, capacity, refillP
 	if method != http.MethodGet {
 		return "", 0, 0, false
 	}
-	switch path {
-	case "/auth/google", "/auth/google/link":
+	switch {
+	case path == "/auth/google", path == "/auth/google/link",
+		strings.HasPrefix(path, "/auth/oidc/") && !strings.HasSuffix(path, "/callback"):
 		return "auth_oauth", 20, 0.4, true
 	default:
 		return "", 0, 0, false
@@ -184,7 +193,8 @@ func (a *App) WithRequestPolicies(next http.Handler) http.Handler {
 		if a.Settings != nil {
 			publicOrigin = a.Settings.PublicOrigin
 		}
-		if isMutatingMethod(r.Method) && !strings.HasPrefix(r.URL.Path, "/auth/webauthn/") && !isSameOriginRequest(r, publicOrigin) {
+		// The token endpoint is called by client backends and never reads the session cookie.
+		if isMutatingMethod(r.Method) && !strings.HasPrefix(r.URL.Path, "/auth/webauthn/") && r.URL.Path != "/oauth/token" && !isSameOriginRequest(r, publicOrigin) {
 			if strings.HasPrefix(r.URL.Path, "/api/") && a.hasValidAPIToken(r) {
 				next.ServeHTTP(w, r)
 				return
@@ -46,6 +46,23 @@ func TestWithRequestPolicies_blocksMismatchedPublicOriginHost(t *testing.T) {
 	}
 }
 
+func TestWithRequestPolicies_oauthTokenEndpointCrossOrigin(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	handler := app.WithRequestPolicies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for path, want := range map[string]int{"/oauth/token": http.StatusOK, "/oauth/authorize": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("grant_type=refresh_token"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s without Origin: got %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
 func TestRateLimiter_evictsStaleBuckets(t *testing.T) {
 	rl := newRateLimiter()
 	rl.mu.Lock()
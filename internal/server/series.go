@@ -0,0 +1,294 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookstorage/internal/i18n"
+)
+
+// A series is a work plus its direct children (parent_work_id), ordered by series_sort.
+// Any work can be viewed as a series root; a child that has its own children is a sub-series.
+
+const sqlSeriesChildOrder = `ORDER BY COALESCE(series_sort, 0), LOWER(title), id`
+
+var errInvalidSeriesOrder = errors.New("invalid_order")
+
+type seriesSummary struct {
+	ChildCount      int            `json:"child_count"`
+	CompletedCount  int            `json:"completed_count"`
+	ProgressPercent int            `json:"progress_percent"`
+	TotalChapters   float64        `json:"total_chapters"`
+	TotalVolumes    float64        `json:"total_volumes"`
+	AvgRating       float64        `json:"avg_rating"`
+	RatedCount      int            `json:"rated_count"`
+	StatusCounts    map[string]int `json:"status_counts"`
+}
+
+type apiSeries struct {
+	Root     apiWork       `json:"root"`
+	Children []apiWork     `json:"children"`
+	Summary  seriesSummary `json:"summary"`
+}
+
+// loadSeries returns the root work and its direct children with tags and reread counts filled.
+func (a *App) loadSeries(userID, rootID int) (workRow, []workRow, error) {
+	var root workRow
+	if err := scanFullWorkRow(&root, a.DB.QueryRow(
+		`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ? AND user_id = ?`, rootID, userID,
+	)); err != nil {
+		return workRow{}, nil, err
+	}
+	rows, err := a.DB.Query(
+		`SELECT `+sqlWorkRowFull+` FROM works WHERE user_id = ? AND parent_work_id = ? `+sqlSeriesChildOrder,
+		userID, rootID,
+	)
+	if err != nil {
+		return workRow{}, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var children []workRow
+	for rows.Next() {
+		var wr workRow
+		if err := scanFullWorkRow(&wr, rows); err != nil {
+			return workRow{}, nil, err
+		}
+		children = append(children, wr)
+	}
+	if err := rows.Err(); err != nil {
+		return workRow{}, nil, err
+	}
+
+	tagsByWork := a.tagNamesByWork(userID)
+	rereads := a.rereadCountsByWork(userID)
+	root.Tags, root.RereadCount = tagsByWork[root.ID], rereads[root.ID]
+	for i := range children {
+		children[i].Tags, children[i].RereadCount = tagsByWork[children[i].ID], rereads[children[i].ID]
+	}
+	return root, children, nil
+}
+
+// summarizeSeries aggregates the children (the root usually only groups them).
+func summarizeSeries(children []workRow) seriesSummary {
+	s := seriesSummary{ChildCount: len(children), StatusCounts: map[string]int{}}
+	ratingSum := 0
+	for _, c := range children {
+		status := statusReading
+		if c.Status.Valid && c.Status.String != "" {
+			status = c.Status.String
+		}
+		s.StatusCounts[status]++
+		if status == statusCompleted {
+			s.CompletedCount++
+		}
+		s.TotalChapters += c.Chapter
+		s.TotalVolumes += c.Volume
+		if c.Rating > 0 {
+			ratingSum += c.Rating
+			s.RatedCount++
+		}
+	}
+	if s.RatedCount > 0 {
+		s.AvgRating = float64(ratingSum) / float64(s.RatedCount)
+	}
+	if s.ChildCount > 0 {
+		s.ProgressPercent = s.CompletedCount * 100 / s.ChildCount
+	}
+	return s
+}
+
+func (a *App) buildAPISeries(userID int, root workRow, children []workRow) apiSeries {
+	siteMap := a.loadReadingSiteStatusMap(userID)
+	out := apiSeries{
+		Root:     workRowToAPIWork(root, siteMap),
+		Children: make([]apiWork, 0, len(children)),
+		Summary:  summarizeSeries(children),
+	}
+	for _, c := range children {
+		out.Children = append(out.Children, workRowToAPIWork(c, siteMap))
+	}
+	return out
+}
+
+// reorderSeries sets series_sort to 1..n following order; every id must be a direct child of rootID.
+// Children missing from order keep their relative position after the listed ones.
+func (a *App) reorderSeries(userID, rootID int, order []int, children []workRow) error {
+	isChild := make(map[int]bool, len(children))
+	for _, c := range children {
+		isChild[c.ID] = true
+	}
+	seen := make(map[int]bool, len(order))
+	for _, id := range order {
+		if !isChild[id] || seen[id] {
+			return errInvalidSeriesOrder
+		}
+		seen[id] = true
+	}
+	full := append([]int{}, order...)
+	for _, c := range children {
+		if !seen[c.ID] {
+			full = append(full, c.ID)
+		}
+	}
+	for i, id := range full {
+		if _, err := a.DB.Exec(
+			`UPDATE works SET series_sort = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND parent_work_id = ?`,
+			i+1, id, userID, rootID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setSeriesStatus applies one status to the children (and the root when includeRoot), with the same
+// started_at / finished_at side effects as a bulk status patch.
+func (a *App) setSeriesStatus(userID int, root workRow, children []workRow, status string, includeRoot bool) error {
+	ids := make([]int, 0, len(children)+1)
+	if includeRoot {
+		ids = append(ids, root.ID)
+	}
+	for _, c := range children {
+		ids = append(ids, c.ID)
+	}
+	// Patches read the current rows, so they are all built before the transaction takes the write lock.
+	patch := map[string]any{"status": status}
+	type update struct {
+		query string
+		args  []any
+	}
+	updates := make([]update, 0, len(ids))
+	for _, id := range ids {
+		setParts, args, err := a.buildBulkWorkPatch(userID, id, patch)
+		if err != nil {
+			return err
+		}
+		setParts = append(setParts, "updated_at = CURRENT_TIMESTAMP")
+		args = append(args, id, userID)
+		updates = append(updates, update{"UPDATE works SET " + strings.Join(setParts, ", ") + " WHERE id = ? AND user_id = ?", args})
+	}
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if _, err := tx.Exec(u.query, u.args...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	a.refreshGoals(userID)
+	return nil
+}
+
+// HandleAPISeries serves GET and POST /api/series/{id}.
+// POST body: {"order": [child ids], "status": "completed", "include_root": true}; both fields are optional
+// but at least one is required. The response is the series after the changes.
+func (a *App) HandleAPISeries(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	rootID, _ := strconv.Atoi(r.PathValue("id"))
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+
+	root, children, err := a.loadSeries(userID, rootID)
+	if errors.Is(err, sql.ErrNoRows) {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var req struct {
+			Order       []int  `json:"order"`
+			Status      string `json:"status"`
+			IncludeRoot bool   `json:"include_root"`
+		}
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+		status := strings.TrimSpace(req.Status)
+		if len(req.Order) == 0 && status == "" {
+			a.apiWriteError(w, http.StatusBadRequest, "no_operation")
+			return
+		}
+		if status != "" && !isValidStatus(normalizeStatus(status)) {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_status")
+			return
+		}
+		if len(req.Order) > 0 {
+			if err := a.reorderSeries(userID, rootID, req.Order, children); err != nil {
+				if errors.Is(err, errInvalidSeriesOrder) {
+					a.apiWriteError(w, http.StatusBadRequest, err.Error())
+					return
+				}
+				a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+				return
+			}
+		}
+		if status != "" {
+			if err := a.setSeriesStatus(userID, root, children, status, req.IncludeRoot); err != nil {
+				a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+				return
+			}
+		}
+		if root, children, err = a.loadSeries(userID, rootID); err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+	}
+
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": a.buildAPISeries(userID, root, children)})
+}
+
+// HandleSeriesPage renders /series/{id}: children in order with drag-reorder and a series-wide status change.
+func (a *App) HandleSeriesPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	rootID, _ := strconv.Atoi(r.PathValue("id"))
+	root, children, err := a.loadSeries(userID, rootID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("series page (work %d): %v", rootID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var parentTitle string
+	if root.ParentWorkID.Valid {
+		_ = a.DB.QueryRow(`SELECT title FROM works WHERE id = ? AND user_id = ?`, root.ParentWorkID.Int64, userID).Scan(&parentTitle)
+	}
+	lang := a.currentLang(r)
+	a.renderTemplate(w, r, "series", a.mergeData(r, map[string]any{
+		"Root":              root,
+		"Children":          children,
+		"Summary":           summarizeSeries(children),
+		"ParentTitle":       parentTitle,
+		"Statuses":          readingStatuses,
+		"MobileTopbarTitle": i18n.T(lang)["series.title"],
+	}))
+}
+
+// seriesChildCount is used by the edit page to link works that group others.
+func (a *App) seriesChildCount(userID, workID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM works WHERE user_id = ? AND parent_work_id = ?`, userID, workID).Scan(&n)
+	return n
+}
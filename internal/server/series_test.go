@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func insertTestSeries(t *testing.T, app *App) (root int, children []int) {
+	t.Helper()
+	root = insertTestWork(t, app, "Saga", 0)
+	for i, title := range []string{"Saga Vol. 2", "Saga Vol. 1", "Saga Vol. 3"} {
+		id := insertTestWork(t, app, title, 10*(i+1))
+		if _, err := app.DB.Exec(`UPDATE works SET parent_work_id = ?, series_sort = ?, rating = ? WHERE id = ?`, root, []int{2, 1, 3}[i], i+2, id); err != nil {
+			t.Fatal(err)
+		}
+		children = append(children, id)
+	}
+	return root, children
+}
+
+func seriesRequest(t *testing.T, app *App, session, method string, rootID int, body string) (*httptest.ResponseRecorder, apiSeries) {
+	t.Helper()
+	id := strconv.Itoa(rootID)
+	req := httptest.NewRequest(method, "/api/series/"+id, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPISeries(rec, req)
+	var payload struct {
+		Data apiSeries `json:"data"`
+	}
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, payload.Data
+}
+
+func seriesTitles(s apiSeries) []string {
+	out := make([]string, 0, len(s.Children))
+	for _, c := range s.Children {
+		out = append(out, c.Title)
+	}
+	return out
+}
+
+func TestHandleAPISeries_listAndAggregate(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	root, children := insertTestSeries(t, app)
+	if _, err := db.Exec(`UPDATE works SET status = ? WHERE id = ?`, statusCompleted, children[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, series := seriesRequest(t, app, session, http.MethodGet, root, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := strings.Join(seriesTitles(series), "|"); got != "Saga Vol. 1|Saga Vol. 2|Saga Vol. 3" {
+		t.Fatalf("children order=%s", got)
+	}
+	sum := series.Summary
+	if series.Root.ID != root || sum.ChildCount != 3 || sum.CompletedCount != 1 || sum.ProgressPercent != 33 ||
+		sum.TotalChapters != 60 || sum.AvgRating != 3 || sum.StatusCounts[statusReading] != 2 {
+		t.Fatalf("summary=%+v", sum)
+	}
+
+	if rec, _ := seriesRequest(t, app, session, http.MethodGet, 999, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing series status=%d", rec.Code)
+	}
+}
+
+func TestHandleAPISeries_reorderAndBulkStatus(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	root, children := insertTestSeries(t, app)
+
+	body := `{"order": [` + strconv.Itoa(children[2]) + `, ` + strconv.Itoa(children[0]) + `]}`
+	rec, series := seriesRequest(t, app, session, http.MethodPost, root, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reorder status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := strings.Join(seriesTitles(series), "|"); got != "Saga Vol. 3|Saga Vol. 2|Saga Vol. 1" {
+		t.Fatalf("order after reorder=%s", got)
+	}
+
+	other := insertTestWork(t, app, "Stranger", 1)
+	if rec, _ := seriesRequest(t, app, session, http.MethodPost, root, `{"order": [`+strconv.Itoa(other)+`]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("foreign id in order status=%d", rec.Code)
+	}
+	if rec, _ := seriesRequest(t, app, session, http.MethodPost, root, `{"status": "nope"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid status=%d", rec.Code)
+	}
+
+	rec, series = seriesRequest(t, app, session, http.MethodPost, root, `{"status": "Terminé"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status change=%d body=%s", rec.Code, rec.Body.String())
+	}
+	for _, c := range series.Children {
+		if c.Status != statusCompleted || c.FinishedAt == "" {
+			t.Fatalf("child after bulk status=%+v", c)
+		}
+	}
+	if series.Root.Status != statusReading || series.Summary.ProgressPercent != 100 {
+		t.Fatalf("root=%+v summary=%+v", series.Root, series.Summary)
+	}
+}
+
+func TestSetSeriesStatus_allOrNothing(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	root, children := insertTestSeries(t, app)
+	if _, err := db.Exec(`CREATE TRIGGER fail_last_child BEFORE UPDATE OF status ON works
+		WHEN NEW.id = ` + strconv.Itoa(children[2]) + ` BEGIN SELECT RAISE(ABORT, 'boom'); END`); err != nil {
+		t.Fatal(err)
+	}
+	rootRow, childRows, err := app.loadSeries(1, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.setSeriesStatus(1, rootRow, childRows, statusCompleted, true); err == nil {
+		t.Fatal("expected the failing update to surface")
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM works WHERE status = ?`, statusCompleted).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("%d works completed after a failed series update", n)
+	}
+}
+
+func TestSeries_exportImportRoundTrip(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	insertTestSeries(t, app)
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=json", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleExport(rec, req)
+	data := rec.Body.Bytes()
+	var payload struct {
+		Works []exportWork `json:"works"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	linked := 0
+	for _, w := range payload.Works {
+		if w.ParentWorkID != nil && w.SeriesSort > 0 {
+			linked++
+		}
+	}
+	if linked != 3 {
+		t.Fatalf("export without series structure: %+v", payload.Works)
+	}
+
+	if _, err := db.Exec(`DELETE FROM works WHERE user_id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	imp := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(string(data)))
+	imp.Header.Set("Content-Type", "application/json")
+	imp.AddCookie(&http.Cookie{Name: "session", Value: session})
+	app.HandleImport(httptest.NewRecorder(), imp)
+
+	var rootID int
+	if err := db.QueryRow(`SELECT id FROM works WHERE user_id = 1 AND title = 'Saga'`).Scan(&rootID); err != nil {
+		t.Fatal(err)
+	}
+	root, children, err := app.loadSeries(1, rootID)
+	if err != nil || root.ParentWorkID.Valid || len(children) != 3 || children[0].Title != "Saga Vol. 1" {
+		t.Fatalf("imported series root=%+v children=%d err=%v", root, len(children), err)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import "strings"
+
+// Reading statuses are stored as language-neutral codes; templates translate them with
+// translateStatus. Older databases held French display strings (migration 29 converts them).
+const (
+	statusReading   = "reading"
+	statusCompleted = "completed"
+	statusOnHold    = "on_hold"
+	statusDropped   = "dropped"
+	statusPlanned   = "planned"
+)
+
+var readingStatuses = []string{
+	statusReading,
+	statusCompleted,
+	statusOnHold,
+	statusDropped,
+	statusPlanned,
+}
+
+// legacyStatusCodes maps former display values (French UI, English imports and API clients) to codes.
+// Keys are lower-cased and match statusCodeUpdates in the database migrations.
+var legacyStatusCodes = map[string]string{
+	"en cours":          statusReading,
+	"current":           statusReading,
+	"terminé":           statusCompleted,
+	"termine":           statusCompleted,
+	"en pause":          statusOnHold,
+	"abandonné":         statusDropped,
+	"abandonne":         statusDropped,
+	"à lire":            statusPlanned,
+	"a lire":            statusPlanned,
+	"on hold":           statusOnHold,
+	"on-hold":           statusOnHold,
+	"paused":            statusOnHold,
+	"dropped/abandoned": statusDropped,
+	"plan to read":      statusPlanned,
+	"plan_to_read":      statusPlanned,
+	"planning":          statusPlanned,
+}
+
+// normalizeStatus returns the status code for raw, accepting codes and legacy values in any case.
+// Unknown values are returned trimmed so callers can reject them with isValidStatus.
+func normalizeStatus(raw string) string {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return statusReading
+	}
+	lower := strings.ToLower(s)
+	if code, ok := legacyStatusCodes[lower]; ok {
+		return code
+	}
+	for _, st := range readingStatuses {
+		if lower == st {
+			return st
+		}
+	}
+	return s
+}
+
+func isValidStatus(s string) bool {
+	for _, st := range readingStatuses {
+		if s == st {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeStatusForWrite(raw string) string {
+	s := normalizeStatus(raw)
+	if !isValidStatus(s) {
+		return statusReading
+	}
+	return s
+}
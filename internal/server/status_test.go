@@ -0,0 +1,34 @@
+package server
+
+import "testing"
+
+func TestNormalizeStatus_acceptsCodesAndLegacyValues(t *testing.T) {
+	cases := map[string]string{
+		"reading":      statusReading,
+		" Completed ":  statusCompleted,
+		"En cours":     statusReading,
+		"Terminé":      statusCompleted,
+		"en pause":     statusOnHold,
+		"Abandonné":    statusDropped,
+		"À lire":       statusPlanned,
+		"Plan to Read": statusPlanned,
+		"ON_HOLD":      statusOnHold,
+		"Current":      statusReading,
+		"PLANNING":     statusPlanned,
+		"":             statusReading,
+	}
+	for raw, want := range cases {
+		if got := normalizeStatus(raw); got != want {
+			t.Errorf("normalizeStatus(%q)=%q want %q", raw, got, want)
+		}
+	}
+	if got := normalizeStatus("Someday"); isValidStatus(got) {
+		t.Fatalf("unknown status accepted as %q", got)
+	}
+	if got := normalizeStatusForWrite("Someday"); got != statusReading {
+		t.Fatalf("normalizeStatusForWrite fallback=%q", got)
+	}
+	if mapMALStatus("plan_to_read") != statusPlanned || mapAniListStatus("PAUSED") != statusOnHold {
+		t.Fatal("importer status mappers must return codes")
+	}
+}
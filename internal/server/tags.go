@@ -0,0 +1,256 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tags (shelves) are free-form, per-user labels attached to works through work_tags.
+// Names are matched case-insensitively; the first spelling used is the one stored.
+const (
+	maxTagNameRunes = 40
+	maxTagsPerWork  = 30
+)
+
+// tagFilterSQL restricts a works query to one tag name (args: user_id, name).
+const tagFilterSQL = `id IN (SELECT wt.work_id FROM work_tags wt INNER JOIN tags t ON t.id = wt.tag_id WHERE t.user_id = ? AND LOWER(t.name) = LOWER(?))`
+
+type userTag struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	WorkCount int    `json:"work_count"`
+}
+
+// normalizeTagName trims, collapses inner whitespace and drops commas (the list separator in forms and CSV).
+func normalizeTagName(raw string) string {
+	s := strings.Join(strings.Fields(strings.ReplaceAll(raw, ",", " ")), " ")
+	if utf8.RuneCountInString(s) > maxTagNameRunes {
+		s = strings.TrimSpace(string([]rune(s)[:maxTagNameRunes]))
+	}
+	return s
+}
+
+// normalizeTagList normalizes names, drops empties and case-insensitive duplicates, and caps the count.
+func normalizeTagList(names []string) []string {
+	out := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, raw := range names {
+		name := normalizeTagName(raw)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, name)
+		if len(out) == maxTagsPerWork {
+			break
+		}
+	}
+	return out
+}
+
+// parseTagList splits a comma-separated form or CSV value ("summer binge, owned").
+func parseTagList(raw string) []string {
+	return normalizeTagList(strings.Split(raw, ","))
+}
+
+// tagsFromJSON accepts a JSON array of strings or a comma-separated string.
+func tagsFromJSON(raw any) ([]string, bool) {
+	switch v := raw.(type) {
+	case nil:
+		return []string{}, true
+	case string:
+		return parseTagList(v), true
+	case []any:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			names = append(names, s)
+		}
+		return normalizeTagList(names), true
+	default:
+		return nil, false
+	}
+}
+
+func (a *App) listUserTags(userID int) ([]userTag, error) {
+	rows, err := a.DB.Query(
+		`SELECT t.id, t.name, COUNT(wt.work_id)
+		 FROM tags t INNER JOIN work_tags wt ON wt.tag_id = t.id
+		 WHERE t.user_id = ?
+		 GROUP BY t.id, t.name
+		 ORDER BY LOWER(t.name)`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := []userTag{}
+	for rows.Next() {
+		var t userTag
+		if err := rows.Scan(&t.ID, &t.Name, &t.WorkCount); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// userTagNames returns the names of the user's tags that are in use, for datalists and filters.
+func (a *App) userTagNames(userID int) []string {
+	tags, err := a.listUserTags(userID)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func (a *App) workTagNames(userID, workID int) ([]string, error) {
+	rows, err := a.DB.Query(
+		`SELECT t.name FROM work_tags wt INNER JOIN tags t ON t.id = wt.tag_id
+		 WHERE wt.work_id = ? AND t.user_id = ?
+		 ORDER BY LOWER(t.name)`,
+		workID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// tagNamesByWork maps every tagged work of the user to its sorted tag names (one query for list pages).
+func (a *App) tagNamesByWork(userID int) map[int][]string {
+	out := map[int][]string{}
+	rows, err := a.DB.Query(
+		`SELECT wt.work_id, t.name FROM work_tags wt INNER JOIN tags t ON t.id = wt.tag_id WHERE t.user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return out
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var workID int
+		var name string
+		if err := rows.Scan(&workID, &name); err != nil {
+			return out
+		}
+		out[workID] = append(out[workID], name)
+	}
+	for _, names := range out {
+		sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	}
+	return out
+}
+
+func (a *App) ensureTagID(userID int, name string) (int64, error) {
+	var id int64
+	err := a.DB.QueryRow(`SELECT id FROM tags WHERE user_id = ? AND LOWER(name) = LOWER(?)`, userID, name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	return a.DB.InsertID(`INSERT INTO tags (user_id, name) VALUES (?, ?)`, userID, name)
+}
+
+// addWorkTags attaches tags to a work the user owns, creating missing tags.
+func (a *App) addWorkTags(userID, workID int, names []string) error {
+	if ok, err := a.userOwnsWork(userID, workID); err != nil || !ok {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	for _, name := range normalizeTagList(names) {
+		tagID, err := a.ensureTagID(userID, name)
+		if err != nil {
+			return err
+		}
+		if _, err := a.DB.Exec(
+			`INSERT INTO work_tags (work_id, tag_id) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM work_tags WHERE work_id = ? AND tag_id = ?)`,
+			workID, tagID, workID, tagID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeWorkTags detaches tags by name; tags left without any work are deleted.
+func (a *App) removeWorkTags(userID, workID int, names []string) error {
+	for _, name := range normalizeTagList(names) {
+		if _, err := a.DB.Exec(
+			`DELETE FROM work_tags WHERE work_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ? AND LOWER(name) = LOWER(?))`,
+			workID, userID, name,
+		); err != nil {
+			return err
+		}
+	}
+	return a.pruneUnusedTags(userID)
+}
+
+// setWorkTags replaces the whole tag set of a work.
+func (a *App) setWorkTags(userID, workID int, names []string) error {
+	if ok, err := a.userOwnsWork(userID, workID); err != nil || !ok {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	if _, err := a.DB.Exec(
+		`DELETE FROM work_tags WHERE work_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ?)`,
+		workID, userID,
+	); err != nil {
+		return err
+	}
+	if err := a.addWorkTags(userID, workID, names); err != nil {
+		return err
+	}
+	return a.pruneUnusedTags(userID)
+}
+
+func (a *App) pruneUnusedTags(userID int) error {
+	_, err := a.DB.Exec(`DELETE FROM tags WHERE user_id = ? AND id NOT IN (SELECT tag_id FROM work_tags)`, userID)
+	return err
+}
+
+// HandleAPITagsList returns the user's tags with the number of works carrying each one.
+func (a *App) HandleAPITagsList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	tags, err := a.listUserTags(userID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": tags})
+}
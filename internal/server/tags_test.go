@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTagListHelpers(t *testing.T) {
+	if got := normalizeTagName("  summer \t binge, "); got != "summer binge" {
+		t.Fatalf("normalizeTagName=%q", got)
+	}
+	if got := normalizeTagName(strings.Repeat("a", 60)); len(got) != maxTagNameRunes {
+		t.Fatalf("normalizeTagName length=%d", len(got))
+	}
+	if got := parseTagList("Owned, owned ,, Favorites"); !reflect.DeepEqual(got, []string{"Owned", "Favorites"}) {
+		t.Fatalf("parseTagList=%v", got)
+	}
+	if got, ok := tagsFromJSON([]any{"a", "b"}); !ok || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("tagsFromJSON array=%v ok=%v", got, ok)
+	}
+	if got, ok := tagsFromJSON("x, y"); !ok || !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Fatalf("tagsFromJSON string=%v ok=%v", got, ok)
+	}
+	if _, ok := tagsFromJSON([]any{1}); ok {
+		t.Fatal("tagsFromJSON accepted a number")
+	}
+}
+
+func apiListTitles(t *testing.T, app *App, session, query string) []string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/works?"+query, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorksList(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data []apiWork `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, 0, len(payload.Data))
+	for _, w := range payload.Data {
+		titles = append(titles, w.Title)
+	}
+	return titles
+}
+
+func TestHandleAPIWorksUpdate_tagsAndListFilter(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	tagged := insertTestWork(t, app, "Tagged", 1)
+	insertTestWork(t, app, "Untagged", 1)
+	id := strconv.Itoa(tagged)
+
+	patch := httptest.NewRequest(http.MethodPatch, "/api/works/"+id, strings.NewReader(`{"tags": ["Owned", "favorites", "owned"]}`))
+	patch.Header.Set("Content-Type", "application/json")
+	patch.AddCookie(&http.Cookie{Name: "session", Value: session})
+	patch.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorksUpdate(rec, patch)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data apiWork `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(payload.Data.Tags, []string{"favorites", "Owned"}) {
+		t.Fatalf("tags=%v", payload.Data.Tags)
+	}
+
+	if got := apiListTitles(t, app, session, "tag=OWNED"); !reflect.DeepEqual(got, []string{"Tagged"}) {
+		t.Fatalf("tag filter titles=%v", got)
+	}
+
+	bad := httptest.NewRequest(http.MethodPatch, "/api/works/"+id, strings.NewReader(`{"tags": 3}`))
+	bad.Header.Set("Content-Type", "application/json")
+	bad.AddCookie(&http.Cookie{Name: "session", Value: session})
+	bad.SetPathValue("id", id)
+	rec = httptest.NewRecorder()
+	app.HandleAPIWorksUpdate(rec, bad)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid tags status=%d", rec.Code)
+	}
+
+	reset := httptest.NewRequest(http.MethodPatch, "/api/works/"+id, strings.NewReader(`{"tags": []}`))
+	reset.Header.Set("Content-Type", "application/json")
+	reset.AddCookie(&http.Cookie{Name: "session", Value: session})
+	reset.SetPathValue("id", id)
+	app.HandleAPIWorksUpdate(httptest.NewRecorder(), reset)
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags WHERE user_id = 1`).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("unused tags not pruned: n=%d err=%v", n, err)
+	}
+}
+
+func TestHandleAPIWorksBulk_addAndRemoveTags(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	a := insertTestWork(t, app, "Bulk A", 1)
+	b := insertTestWork(t, app, "Bulk B", 1)
+
+	post := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/works/bulk", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorksBulk(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("bulk status=%d body=%s", rec.Code, rec.Body.String())
+		}
+	}
+	post(`{"ids": [` + strconv.Itoa(a) + `, ` + strconv.Itoa(b) + `], "add_tags": ["Shelf", "Later"]}`)
+	post(`{"ids": [` + strconv.Itoa(b) + `], "remove_tags": ["later"]}`)
+
+	tagsA, _ := app.workTagNames(1, a)
+	tagsB, _ := app.workTagNames(1, b)
+	if !reflect.DeepEqual(tagsA, []string{"Later", "Shelf"}) || !reflect.DeepEqual(tagsB, []string{"Shelf"}) {
+		t.Fatalf("tagsA=%v tagsB=%v", tagsA, tagsB)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tags", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleAPITagsList(rec, req)
+	var payload struct {
+		Data []userTag `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 2 || payload.Data[0].Name != "Later" || payload.Data[0].WorkCount != 1 || payload.Data[1].WorkCount != 2 {
+		t.Fatalf("tags list=%+v", payload.Data)
+	}
+}
+
+func TestTags_exportImportRoundTrip(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Exported", 3)
+	if err := app.setWorkTags(1, workID, []string{"Owned", "Re-read"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleExport(rec, req)
+	r := csv.NewReader(bytes.NewReader(rec.Body.Bytes()[3:]))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil || len(records) < 2 {
+		t.Fatalf("records=%v err=%v", records, err)
+	}
+	if records[0][14] != "Tags" || records[1][14] != "Owned, Re-read" {
+		t.Fatalf("unexpected export: %v", records)
+	}
+
+	row, ok := parseCSVWorkRow(records[1])
+	if !ok {
+		t.Fatal("parseCSVWorkRow rejected exported row")
+	}
+	row.Title = "Imported"
+	report := &ImportReport{}
+	app.importOneWork(1, 1, row, DuplicateSkip, report)
+	if report.Imported != 1 {
+		t.Fatalf("report=%+v", report)
+	}
+	var importedID int
+	if err := db.QueryRow(`SELECT id FROM works WHERE user_id = 1 AND title = 'Imported'`).Scan(&importedID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := app.workTagNames(1, importedID); !reflect.DeepEqual(got, []string{"Owned", "Re-read"}) {
+		t.Fatalf("imported tags=%v", got)
+	}
+
+	jsonReq := httptest.NewRequest(http.MethodGet, "/export?format=json", nil)
+	jsonReq.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec = httptest.NewRecorder()
+	app.HandleExport(rec, jsonReq)
+	var payload struct {
+		Works []exportWork `json:"works"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Works) != 2 || !reflect.DeepEqual(payload.Works[0].Tags, []string{"Owned", "Re-read"}) {
+		t.Fatalf("json export=%+v", payload.Works)
+	}
+}
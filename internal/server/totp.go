@@ -0,0 +1,230 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// RFC 6238 parameters understood by every authenticator app (the otpauth defaults).
+const (
+	totpPeriod      = 30
+	totpDigits      = 6
+	totpSkewSteps   = 1
+	totpSecretBytes = 20
+)
+
+const (
+	// recoveryCodeCount codes are issued on enrollment; each one signs in once.
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 10
+)
+
+var totpBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newTOTPSecret() (string, error) {
+	var b [totpSecretBytes]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return totpBase32.EncodeToString(b[:]), nil
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "=", "").Replace(secret))
+	return totpBase32.DecodeString(secret)
+}
+
+// totpCode is the HOTP value (RFC 4226) for counter, zero-padded to totpDigits.
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, bin%mod)
+}
+
+// totpMatch checks code against the steps around now and returns the matching step.
+// Steps at or before lastStep are refused so an observed code cannot be replayed.
+func totpMatch(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		if step < 0 || step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(step))), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI is the otpauth:// enrollment URI (Key Uri Format) shown as a QR code.
+func totpURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(totpDigits))
+	q.Set("period", strconv.Itoa(totpPeriod))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// totpQRDataURL renders uri as an inline PNG for the enrollment page.
+func totpQRDataURL(uri string) (template.URL, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, 240)
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png)), nil
+}
+
+// groupSecret splits a base32 secret in blocks of four for manual entry.
+func groupSecret(secret string) string {
+	var b strings.Builder
+	for i, r := range secret {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (a *App) totpIssuer() string {
+	if a.SiteConfig != nil {
+		if n := strings.TrimSpace(a.SiteConfig.SiteName); n != "" {
+			return n
+		}
+	}
+	return "BookStorage"
+}
+
+// userTOTPEnabled reports whether password and Google sign-ins need the second step.
+func (a *App) userTOTPEnabled(userID int) bool {
+	var enabledAt nullFlexTime
+	if err := a.DB.QueryRow(`SELECT totp_enabled_at FROM users WHERE id = ?`, userID).Scan(&enabledAt); err != nil {
+		return false
+	}
+	return enabledAt.Valid
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code, consuming it.
+// Both updates are conditional so two concurrent requests cannot use the same code.
+func (a *App) verifySecondFactor(userID int, code string, now time.Time) bool {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+	if code == "" {
+		return false
+	}
+	if len(code) == totpDigits {
+		var secret sql.NullString
+		var lastStep sql.NullInt64
+		if err := a.DB.QueryRow(
+			`SELECT totp_secret, totp_last_step FROM users WHERE id = ? AND totp_enabled_at IS NOT NULL`, userID,
+		).Scan(&secret, &lastStep); err != nil || !secret.Valid {
+			return false
+		}
+		step, ok := totpMatch(secret.String, code, now, lastStep.Int64)
+		if !ok {
+			return false
+		}
+		res, err := a.DB.Exec(
+			`UPDATE users SET totp_last_step = ? WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)`,
+			step, userID, step,
+		)
+		if err != nil {
+			return false
+		}
+		n, _ := res.RowsAffected()
+		return n == 1
+	}
+	res, err := a.DB.Exec(
+		`UPDATE totp_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		now, userID, hashRecoveryCode(code),
+	)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
+func hashRecoveryCode(code string) string {
+	return hashSessionToken(normalizeInviteCode(code))
+}
+
+// replaceRecoveryCodes issues a fresh set of recovery codes, invalidating the previous ones.
+// The plain codes are returned for display once; only their hashes are stored.
+func (a *App) replaceRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		b, err := randomCodeChars(recoveryCodeLen)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, b[:recoveryCodeLen/2]+"-"+b[recoveryCodeLen/2:])
+	}
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	for _, c := range codes {
+		if _, err := tx.Exec(`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hashRecoveryCode(c)); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	return codes, tx.Commit()
+}
+
+func (a *App) recoveryCodesLeft(userID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM totp_recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&n)
+	return n
+}
+
+// adminNeedsTwoFactor is true for admins without TOTP when BOOKSTORAGE_REQUIRE_ADMIN_2FA is set.
+func (a *App) adminNeedsTwoFactor(userID int) bool {
+	if a.Settings == nil || !a.Settings.RequireAdmin2FA {
+		return false
+	}
+	var isAdmin int
+	var enabledAt nullFlexTime
+	if err := a.DB.QueryRow(`SELECT is_admin, totp_enabled_at FROM users WHERE id = ?`, userID).Scan(&isAdmin, &enabledAt); err != nil {
+		return false
+	}
+	return isAdmin == 1 && !enabledAt.Valid
+}
@@ -0,0 +1,341 @@
+package server
+
+import (
+	"database/sql"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	loginChallengeCookie = "login_2fa"
+	// loginChallengeTTL bounds the time between the password and the second step.
+	loginChallengeTTL = 5 * time.Minute
+	// loginChallengeMaxAttempts wrong codes end the challenge; failures also count towards the
+	// account lockout in recordLoginFailure.
+	loginChallengeMaxAttempts = 5
+)
+
+func (a *App) setLoginChallengeCookie(w http.ResponseWriter, token string, maxAge time.Duration) {
+	secs := int(maxAge.Seconds())
+	if secs < 0 {
+		secs = -1
+	}
+	c := &http.Cookie{
+		Name:     loginChallengeCookie,
+		Value:    token,
+		Path:     "/login/2fa",
+		MaxAge:   secs,
+		HttpOnly: true,
+		SameSite: sessionSameSite(a.Settings.Environment),
+	}
+	if a.Settings != nil && cookieSecure(a.Settings.Environment, a.Settings.PublicOrigin) {
+		c.Secure = true
+	}
+	http.SetCookie(w, c)
+}
+
+// completeLogin finishes a successful password, Google or OIDC sign-in: accounts with TOTP go to
+// the second step, others get their session. Passkey sign-in already proves two factors and
+// does not come through here.
+func (a *App) completeLogin(w http.ResponseWriter, r *http.Request, userID int, next string) error {
+	dest := safePostLoginRedirect(next)
+	if a.userTOTPEnabled(userID) {
+		token, err := newSessionToken()
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		_, _ = a.DB.Exec(`DELETE FROM login_challenges WHERE expires_at < ?`, now)
+		if _, err := a.DB.Exec(
+			`INSERT INTO login_challenges (challenge_hash, user_id, next, expires_at) VALUES (?, ?, ?, ?)`,
+			hashSessionToken(token), userID, nullStringOrEmpty(dest), now.Add(loginChallengeTTL),
+		); err != nil {
+			return err
+		}
+		a.setLoginChallengeCookie(w, token, loginChallengeTTL)
+		http.Redirect(w, r, "/login/2fa", http.StatusFound)
+		return nil
+	}
+
+	token, err := a.createSession(r, userID)
+	if err != nil {
+		return err
+	}
+	a.setSessionCookie(w, token, sessionSlidingTTL)
+	if a.adminNeedsTwoFactor(userID) {
+		dest = "/profile/2fa?required=1"
+	}
+	if dest == "" {
+		dest = "/dashboard"
+	}
+	http.Redirect(w, r, dest, http.StatusFound)
+	return nil
+}
+
+type loginChallenge struct {
+	Hash     string
+	UserID   int
+	Username string
+	Next     string
+	Attempts int
+}
+
+func (a *App) currentLoginChallenge(r *http.Request) (loginChallenge, bool) {
+	c, err := r.Cookie(loginChallengeCookie)
+	if err != nil || strings.TrimSpace(c.Value) == "" {
+		return loginChallenge{}, false
+	}
+	ch := loginChallenge{Hash: hashSessionToken(strings.TrimSpace(c.Value))}
+	var next sql.NullString
+	var expiresAt time.Time
+	err = a.DB.QueryRow(
+		`SELECT lc.user_id, u.username, lc.next, lc.attempts, lc.expires_at
+		 FROM login_challenges lc JOIN users u ON u.id = lc.user_id
+		 WHERE lc.challenge_hash = ?`,
+		ch.Hash,
+	).Scan(&ch.UserID, &ch.Username, &next, &ch.Attempts, &expiresAt)
+	if err != nil || !time.Now().UTC().Before(expiresAt) {
+		return loginChallenge{}, false
+	}
+	ch.Next = next.String
+	return ch, true
+}
+
+func (a *App) endLoginChallenge(w http.ResponseWriter, hash string) {
+	_, _ = a.DB.Exec(`DELETE FROM login_challenges WHERE challenge_hash = ?`, hash)
+	a.setLoginChallengeCookie(w, "", -1)
+}
+
+// HandleLoginTwoFactor is the second login step (GET/POST /login/2fa; form field code, a TOTP
+// or recovery code).
+func (a *App) HandleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ch, ok := a.currentLoginChallenge(r)
+	if !ok {
+		a.setLoginChallengeCookie(w, "", -1)
+		http.Redirect(w, r, "/login?expired=1", http.StatusFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Cache-Control", "no-store")
+		a.renderTemplate(w, r, "login_2fa", a.mergeData(r, map[string]any{
+			"TwoFactorError": r.URL.Query().Get("error") == "1",
+		}))
+	case http.MethodPost:
+		if a.isLoginLocked(ch.Username) {
+			a.endLoginChallenge(w, ch.Hash)
+			http.Redirect(w, r, "/login?error=1", http.StatusFound)
+			return
+		}
+		if !a.verifySecondFactor(ch.UserID, r.FormValue("code"), time.Now().UTC()) {
+			a.recordLoginFailure(ch.Username)
+			if ch.Attempts+1 >= loginChallengeMaxAttempts {
+				a.endLoginChallenge(w, ch.Hash)
+				http.Redirect(w, r, "/login?error=1", http.StatusFound)
+				return
+			}
+			_, _ = a.DB.Exec(`UPDATE login_challenges SET attempts = attempts + 1 WHERE challenge_hash = ?`, ch.Hash)
+			http.Redirect(w, r, "/login/2fa?error=1", http.StatusFound)
+			return
+		}
+		a.endLoginChallenge(w, ch.Hash)
+		a.clearLoginFailures(ch.Username)
+		token, err := a.createSession(r, ch.UserID)
+		if err != nil {
+			http.Redirect(w, r, "/login?error=1", http.StatusFound)
+			return
+		}
+		a.setSessionCookie(w, token, sessionSlidingTTL)
+		dest := safePostLoginRedirect(ch.Next)
+		if dest == "" {
+			dest = "/dashboard"
+		}
+		http.Redirect(w, r, dest, http.StatusFound)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleProfileTwoFactor shows the TOTP enrollment (QR code and secret of a pending enrollment)
+// or, once enabled, the recovery code and disable forms (GET /profile/2fa).
+func (a *App) HandleProfileTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	a.renderTwoFactorPage(w, r, userID, nil)
+}
+
+func (a *App) renderTwoFactorPage(w http.ResponseWriter, r *http.Request, userID int, recoveryCodes []string) {
+	var username string
+	var password, pending sql.NullString
+	var enabledAt nullFlexTime
+	var isAdmin int
+	if err := a.DB.QueryRow(
+		`SELECT username, password, totp_pending_secret, totp_enabled_at, is_admin FROM users WHERE id = ?`, userID,
+	).Scan(&username, &password, &pending, &enabledAt, &isAdmin); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	data := map[string]any{
+		"TwoFactorEnabled":   enabledAt.Valid,
+		"TwoFactorEnabledAt": enabledAt.String,
+		"TwoFactorRequired":  isAdmin == 1 && a.Settings != nil && a.Settings.RequireAdmin2FA,
+		"TwoFactorError":     strings.TrimSpace(r.URL.Query().Get("error")),
+		"HasPassword":        password.Valid && strings.TrimSpace(password.String) != "",
+		"RecoveryCodes":      recoveryCodes,
+	}
+	if enabledAt.Valid {
+		data["RecoveryCodesLeft"] = a.recoveryCodesLeft(userID)
+	} else {
+		// The pending secret survives reloads so a scanned QR code stays valid until confirmed.
+		secret := pending.String
+		if !pending.Valid || secret == "" {
+			var err error
+			if secret, err = newTOTPSecret(); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if _, err := a.DB.Exec(`UPDATE users SET totp_pending_secret = ? WHERE id = ?`, secret, userID); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		uri := totpURI(a.totpIssuer(), username, secret)
+		qr, err := totpQRDataURL(uri)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		data["TOTPSecret"] = groupSecret(secret)
+		data["TOTPURI"] = template.URL(uri)
+		data["TOTPQR"] = qr
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	a.renderTemplate(w, r, "profile_2fa", a.mergeData(r, data))
+}
+
+// HandleProfileTwoFactorEnable confirms the pending enrollment with a first code
+// (POST /profile/2fa/enable; form field code) and shows the recovery codes once.
+func (a *App) HandleProfileTwoFactorEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	var pending sql.NullString
+	var enabledAt nullFlexTime
+	if err := a.DB.QueryRow(`SELECT totp_pending_secret, totp_enabled_at FROM users WHERE id = ?`, userID).Scan(&pending, &enabledAt); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if enabledAt.Valid || !pending.Valid {
+		http.Redirect(w, r, "/profile/2fa", http.StatusFound)
+		return
+	}
+	now := time.Now().UTC()
+	step, ok := totpMatch(pending.String, strings.ReplaceAll(r.FormValue("code"), " ", ""), now, -1)
+	if !ok {
+		http.Redirect(w, r, "/profile/2fa?error=code", http.StatusFound)
+		return
+	}
+	res, err := a.DB.Exec(
+		`UPDATE users SET totp_secret = totp_pending_secret, totp_pending_secret = NULL, totp_enabled_at = ?, totp_last_step = ?
+		 WHERE id = ? AND totp_pending_secret = ? AND totp_enabled_at IS NULL`,
+		now, step, userID, pending.String,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// Another tab restarted or finished the enrollment since this page loaded: that secret is not the one confirmed.
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		http.Redirect(w, r, "/profile/2fa?error=stale", http.StatusFound)
+		return
+	}
+	codes, err := a.replaceRecoveryCodes(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.renderTwoFactorPage(w, r, userID, codes)
+}
+
+// HandleProfileTwoFactorRecoveryCodes replaces the recovery codes (POST /profile/2fa/recovery-codes;
+// form field code, a current TOTP or recovery code).
+func (a *App) HandleProfileTwoFactorRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	if !a.verifySecondFactor(userID, r.FormValue("code"), time.Now().UTC()) {
+		http.Redirect(w, r, "/profile/2fa?error=code", http.StatusFound)
+		return
+	}
+	codes, err := a.replaceRecoveryCodes(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.renderTwoFactorPage(w, r, userID, codes)
+}
+
+// HandleProfileTwoFactorDisable turns TOTP off (POST /profile/2fa/disable; form fields code and,
+// for accounts with a password, current_password). Every session is revoked, this one included.
+func (a *App) HandleProfileTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := a.currentUserID(r)
+	if !ok {
+		http.Redirect(w, r, loginRedirectURL(r), http.StatusFound)
+		return
+	}
+	var password sql.NullString
+	var isAdmin int
+	if err := a.DB.QueryRow(`SELECT password, is_admin FROM users WHERE id = ?`, userID).Scan(&password, &isAdmin); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if isAdmin == 1 && a.Settings != nil && a.Settings.RequireAdmin2FA {
+		http.Redirect(w, r, "/profile/2fa?error=required", http.StatusFound)
+		return
+	}
+	if password.Valid && strings.TrimSpace(password.String) != "" && !verifyPassword(password.String, r.FormValue("current_password")) {
+		http.Redirect(w, r, "/profile/2fa?error=password", http.StatusFound)
+		return
+	}
+	if !a.verifySecondFactor(userID, r.FormValue("code"), time.Now().UTC()) {
+		http.Redirect(w, r, "/profile/2fa?error=code", http.StatusFound)
+		return
+	}
+	if _, err := a.DB.Exec(
+		`UPDATE users SET totp_secret = NULL, totp_pending_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?`,
+		userID,
+	); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = a.DB.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = ?`, userID)
+	_, _ = a.DB.Exec(`DELETE FROM login_challenges WHERE user_id = ?`, userID)
+	a.revokeAllUserSessions(userID)
+	a.clearSession(w)
+	http.Redirect(w, r, "/login?twofa_disabled=1", http.StatusFound)
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B (SHA-1 key), truncated to the 6 digits authenticator apps show.
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		if got := totpCode(key, uint64(tc.unix/totpPeriod)); got != tc.want {
+			t.Errorf("T=%d: got %s want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestTOTPMatch_windowAndReplay(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := decodeTOTPSecret(secret)
+	now := time.Unix(1_700_000_000, 0)
+	step := now.Unix() / totpPeriod
+	if got, ok := totpMatch(secret, totpCode(key, uint64(step-1)), now, 0); !ok || got != step-1 {
+		t.Fatalf("previous step not accepted: %d %v", got, ok)
+	}
+	if _, ok := totpMatch(secret, totpCode(key, uint64(step-2)), now, 0); ok {
+		t.Fatal("code two steps old accepted")
+	}
+	if _, ok := totpMatch(secret, totpCode(key, uint64(step)), now, step); ok {
+		t.Fatal("replayed step accepted")
+	}
+	uri := totpURI("Book Club", "alice", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Book%20Club:alice?") || !strings.Contains(uri, "secret="+secret) || !strings.Contains(uri, "issuer=Book+Club") {
+		t.Fatalf("uri %q", uri)
+	}
+}
+
+func enableTestTOTP(t *testing.T, app *App, uid int) []byte {
+	t.Helper()
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.DB.Exec(`UPDATE users SET totp_secret = ?, totp_enabled_at = CURRENT_TIMESTAMP WHERE id = ?`, secret, uid); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := decodeTOTPSecret(secret)
+	return key
+}
+
+func postLoginStep(t *testing.T, app *App, path string, handler http.HandlerFunc, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name && c.MaxAge >= 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestHandleLogin_twoFactorStep(t *testing.T) {
+	db, s := openTestDB(t)
+	s.RequireAccountValidation = false
+	app := &App{Settings: s, DB: db}
+	hash, _ := hashPassword("LongEnough!1")
+	if _, err := db.Exec(`INSERT INTO users (id, username, password, validated, is_admin) VALUES (50, 'twofa', ?, 1, 0)`, hash); err != nil {
+		t.Fatal(err)
+	}
+	key := enableTestTOTP(t, app, 50)
+	codes, err := app.replaceRecoveryCodes(50)
+	if err != nil || len(codes) != recoveryCodeCount {
+		t.Fatalf("codes=%v err=%v", codes, err)
+	}
+
+	login := func() *http.Cookie {
+		rec := postLoginStep(t, app, "/login", app.HandleLogin, url.Values{"username": {"twofa"}, "password": {"LongEnough!1"}, "next": {"/stats"}})
+		if loc := rec.Header().Get("Location"); loc != "/login/2fa" {
+			t.Fatalf("password step redirect %q", loc)
+		}
+		if responseCookie(rec, sessionCookieName) != nil {
+			t.Fatal("session issued before the second step")
+		}
+		c := responseCookie(rec, loginChallengeCookie)
+		if c == nil {
+			t.Fatal("no challenge cookie")
+		}
+		return c
+	}
+
+	challenge := login()
+	if loc := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {"000000"}}, challenge).Header().Get("Location"); loc != "/login/2fa?error=1" {
+		t.Fatalf("wrong code redirect %q", loc)
+	}
+	var fails int
+	_ = db.QueryRow(`SELECT fail_count FROM login_attempts WHERE username = 'twofa'`).Scan(&fails)
+	if fails != 1 {
+		t.Fatalf("fail_count=%d after a wrong code", fails)
+	}
+	code := totpCode(key, uint64(time.Now().Unix()/totpPeriod))
+	rec := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {code}}, challenge)
+	if loc := rec.Header().Get("Location"); loc != "/stats" || responseCookie(rec, sessionCookieName) == nil {
+		t.Fatalf("second step: %q", loc)
+	}
+	if loc := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {code}}, challenge).Header().Get("Location"); loc != "/login?expired=1" {
+		t.Fatalf("challenge reused: %q", loc)
+	}
+
+	// The same TOTP code cannot open a second session; a recovery code works exactly once.
+	challenge = login()
+	if loc := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {code}}, challenge).Header().Get("Location"); loc != "/login/2fa?error=1" {
+		t.Fatalf("replayed code redirect %q", loc)
+	}
+	typed := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+	if loc := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {typed}}, challenge).Header().Get("Location"); loc != "/stats" {
+		t.Fatalf("recovery code redirect %q", loc)
+	}
+	challenge = login()
+	if loc := postLoginStep(t, app, "/login/2fa", app.HandleLoginTwoFactor, url.Values{"code": {codes[0]}}, challenge).Header().Get("Location"); loc != "/login/2fa?error=1" {
+		t.Fatalf("used recovery code accepted: %q", loc)
+	}
+	if left := app.recoveryCodesLeft(50); left != recoveryCodeCount-1 {
+		t.Fatalf("%d recovery codes left", left)
+	}
+}
+
+func TestHandleProfileTwoFactor_enrollAndDisable(t *testing.T) {
+	db, s := openTestDB(t)
+	s.RequireAccountValidation = false
+	app := &App{Settings: s, DB: db, TemplatesWeb: template.New("")}
+	hash, _ := hashPassword("LongEnough!1")
+	if _, err := db.Exec(`INSERT INTO users (id, username, password, validated, is_admin) VALUES (51, 'enroll', ?, 1, 0)`, hash); err != nil {
+		t.Fatal(err)
+	}
+	session := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 51)}
+	mustCreateSession(t, app, 51) // a second device
+
+	req := httptest.NewRequest(http.MethodGet, "/profile/2fa", nil)
+	req.AddCookie(session)
+	app.HandleProfileTwoFactor(httptest.NewRecorder(), req)
+	var pending string
+	if err := db.QueryRow(`SELECT totp_pending_secret FROM users WHERE id = 51`).Scan(&pending); err != nil || pending == "" {
+		t.Fatalf("pending secret %q err=%v", pending, err)
+	}
+	key, _ := decodeTOTPSecret(pending)
+	step := time.Now().Unix() / totpPeriod
+
+	if loc := postLoginStep(t, app, "/profile/2fa/enable", app.HandleProfileTwoFactorEnable, url.Values{"code": {"123"}}, session).Header().Get("Location"); loc != "/profile/2fa?error=code" {
+		t.Fatalf("bad enrollment code redirect %q", loc)
+	}
+	postLoginStep(t, app, "/profile/2fa/enable", app.HandleProfileTwoFactorEnable, url.Values{"code": {totpCode(key, uint64(step))}}, session)
+	if !app.userTOTPEnabled(51) || app.recoveryCodesLeft(51) != recoveryCodeCount {
+		t.Fatalf("enabled=%v codes=%d", app.userTOTPEnabled(51), app.recoveryCodesLeft(51))
+	}
+
+	next := totpCode(key, uint64(step+1))
+	if loc := postLoginStep(t, app, "/profile/2fa/disable", app.HandleProfileTwoFactorDisable, url.Values{"code": {next}, "current_password": {"wrong"}}, session).Header().Get("Location"); loc != "/profile/2fa?error=password" {
+		t.Fatalf("wrong password redirect %q", loc)
+	}
+	if loc := postLoginStep(t, app, "/profile/2fa/disable", app.HandleProfileTwoFactorDisable, url.Values{"code": {next}, "current_password": {"LongEnough!1"}}, session).Header().Get("Location"); loc != "/login?twofa_disabled=1" {
+		t.Fatalf("disable redirect %q", loc)
+	}
+	if app.userTOTPEnabled(51) || app.recoveryCodesLeft(51) != 0 {
+		t.Fatal("two-factor still enabled")
+	}
+	var active int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE user_id = 51 AND revoked_at IS NULL`).Scan(&active)
+	if active != 0 {
+		t.Fatalf("%d sessions still active", active)
+	}
+}
+
+func TestHandleProfileTwoFactorEnable_pendingSecretReplaced(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db, TemplatesWeb: template.New("")}
+	secret := totpBase32.EncodeToString([]byte("12345678901234567890"))
+	if _, err := db.Exec(`INSERT INTO users (id, username, password, validated, is_admin, totp_pending_secret) VALUES (52, 'tabs', NULL, 1, 0, ?)`, secret); err != nil {
+		t.Fatal(err)
+	}
+	// Another tab swaps the pending secret between the handler's read and its update.
+	if _, err := db.Exec(`CREATE TRIGGER replace_pending BEFORE UPDATE OF totp_secret ON users BEGIN SELECT RAISE(IGNORE); END`); err != nil {
+		t.Fatal(err)
+	}
+	session := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 52)}
+	code := totpCode([]byte("12345678901234567890"), uint64(time.Now().Unix()/totpPeriod))
+	if loc := postLoginStep(t, app, "/profile/2fa/enable", app.HandleProfileTwoFactorEnable, url.Values{"code": {code}}, session).Header().Get("Location"); loc != "/profile/2fa?error=stale" {
+		t.Fatalf("redirect %q", loc)
+	}
+	if app.userTOTPEnabled(52) || app.recoveryCodesLeft(52) != 0 {
+		t.Fatal("two-factor reported enabled for a replaced secret")
+	}
+}
+
+func TestRequireAdmin_twoFactorRequired(t *testing.T) {
+	db, s := openTestDB(t)
+	s.RequireAdmin2FA = true
+	app := &App{Settings: s, DB: db}
+	reached := false
+	h := app.RequireAdmin(func(w http.ResponseWriter, r *http.Request) { reached = true })
+	session := &http.Cookie{Name: sessionCookieName, Value: mustCreateSession(t, app, 1)}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/accounts", nil)
+	req.AddCookie(session)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if reached || rec.Header().Get("Location") != "/profile/2fa?required=1" {
+		t.Fatalf("reached=%v location=%q", reached, rec.Header().Get("Location"))
+	}
+
+	enableTestTOTP(t, app, 1)
+	h(httptest.NewRecorder(), req)
+	if !reached {
+		t.Fatal("admin with two-factor enabled was refused")
+	}
+	if loc := postLoginStep(t, app, "/profile/2fa/disable", app.HandleProfileTwoFactorDisable, url.Values{"code": {"000000"}}, session).Header().Get("Location"); loc != "/profile/2fa?error=required" {
+		t.Fatalf("required two-factor disabled: %q", loc)
+	}
+}
@@ -1,42 +1,103 @@
 package server
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
 const (
 	maxChapterValue = 9999
+	maxVolumeValue  = 999
 	maxRatingValue  = 5
 )
 
+// Progress units: light novels are followed volume by volume, manga and webtoons chapter by chapter.
+const (
+	progressUnitChapter = "chapter"
+	progressUnitVolume  = "volume"
+)
+
 func sanitizeTitle(s string) string {
 	return strings.TrimSpace(s)
 }
 
-func clampChapter(v int) int {
-	if v < 0 {
+// clampChapter bounds a chapter number and keeps two decimals at most (webtoon extras such as 45.5).
+func clampChapter(v float64) float64 {
+	return clampProgress(v, maxChapterValue)
+}
+
+func clampVolume(v float64) float64 {
+	return clampProgress(v, maxVolumeValue)
+}
+
+func clampProgress(v, limit float64) float64 {
+	if math.IsNaN(v) || v < 0 {
 		return 0
 	}
-	if v > maxChapterValue {
-		return maxChapterValue
+	if v > limit {
+		return limit
 	}
-	return v
+	return math.Round(v*100) / 100
 }
 
-func clampRating(v int) int {
-	if v < 0 || v > maxRatingValue {
+// parseProgressValue reads a chapter or volume typed by the user; "45.5" and "45,5" are both accepted.
+func parseProgressValue(raw string) float64 {
+	raw = strings.ReplaceAll(strings.TrimSpace(raw), ",", ".")
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
 		return 0
 	}
 	return v
 }
 
-func normalizeStatusForWrite(raw string) string {
-	s := normalizeStatus(raw)
-	if !isValidStatus(s) {
-		return "En cours"
+func formatProgress(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func progressUnitForReadingType(readingType string) string {
+	if normalizeReadingType(readingType) == "Light Novel" {
+		return progressUnitVolume
 	}
-	return s
+	return progressUnitChapter
+}
+
+// progressUnitColumn maps a progress unit to its works column.
+func progressUnitColumn(unit string) string {
+	if unit == progressUnitVolume {
+		return "volume"
+	}
+	return "chapter"
+}
+
+// nextProgressValue is the target of a "+1": 45.5 moves to 46, not 46.5.
+func nextProgressValue(v float64) float64 {
+	return math.Floor(v) + 1
+}
+
+// prevProgressValue is the target of a "−1": 45.5 moves back to 45.
+func prevProgressValue(v float64) float64 {
+	if p := math.Ceil(v) - 1; p > 0 {
+		return p
+	}
+	return 0
+}
+
+// wholeUnitDelta counts whole units crossed between two values, so reading an extra (45 → 45.5)
+// does not add to the reading stats while finishing it (45.5 → 46) does.
+func wholeUnitDelta(before, after float64) int {
+	return int(math.Floor(after) - math.Floor(before))
+}
+
+func clampRating(v int) int {
+	if v < 0 || v > maxRatingValue {
+		return 0
+	}
+	return v
 }
 
 func normalizeReadingTypeForWrite(raw string) string {
@@ -48,9 +109,9 @@ func normalizeReadingTypeForWrite(raw string) string {
 }
 
 // notifyNewChaptersDB returns DB notify_new_chapters: 1 = suivi (hors filtre « Non suivis »), 0 = non-suivi.
-// Hors statut « En cours », la valeur est toujours 1 (le marquage non-suivi ne s’applique qu’aux œuvres en cours).
+// Hors statut « reading » (en cours), la valeur est toujours 1 (le marquage non-suivi ne s’applique qu’aux œuvres en cours).
 func notifyNewChaptersDB(status string, suivi bool) int {
-	if status != "En cours" {
+	if status != statusReading {
 		return 1
 	}
 	if suivi {
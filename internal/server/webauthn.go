@@ -265,5 +265,5 @@ func (a *App) userPasskeyOnly(userID int) bool {
 	}
 	hasPwd := pwd.Valid && strings.TrimSpace(pwd.String) != ""
 	hasGoogle := googleSub.Valid && strings.TrimSpace(googleSub.String) != ""
-	return !hasPwd && !hasGoogle && a.userHasPasskeys(userID)
+	return !hasPwd && !hasGoogle && a.userIdentityCount(userID) == 0 && a.userHasPasskeys(userID)
 }
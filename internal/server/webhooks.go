@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -25,6 +26,7 @@ const (
 	webhookEventWorkUpdated        = "work.updated"
 	webhookEventWorkDeleted        = "work.deleted"
 	webhookEventWorkChapterChanged = "work.chapter_changed"
+	webhookEventGoalReached        = "goal.reached"
 	webhookEventPing               = "ping"
 
 	webhookMaxAttempts     = 5
@@ -36,9 +38,13 @@ var validWebhookEvents = map[string]bool{
 	webhookEventWorkUpdated:        true,
 	webhookEventWorkDeleted:        true,
 	webhookEventWorkChapterChanged: true,
+	webhookEventGoalReached:        true,
 	webhookEventPing:               true,
 }
 
+// webhookSubscribableEvents are the events offered on the profile form (ping is sent on demand).
+var webhookSubscribableEvents = []string{webhookEventWorkUpdated, webhookEventWorkDeleted, webhookEventWorkChapterChanged, webhookEventGoalReached}
+
 type webhookEndpointRow struct {
 	ID        int
 	UserID    int
@@ -391,6 +397,7 @@ func (a *App) scheduleWebhookRetry(deliveryID, httpStatus int) {
 			attempts, deliveryID,
 		)
 		log.Printf("[webhooks] delivery %d failed permanently (http=%d)", deliveryID, httpStatus)
+		a.notifyWebhookFailed(deliveryID)
 		return
 	}
 	delay := webhookRetryDelay(attempts)
@@ -404,6 +411,26 @@ func (a *App) scheduleWebhookRetry(deliveryID, httpStatus int) {
 	)
 }
 
+// notifyWebhookFailed tells the endpoint owner that a delivery gave up after webhookMaxAttempts.
+func (a *App) notifyWebhookFailed(deliveryID int) {
+	var userID, endpointID int
+	var endpointURL, event string
+	if err := a.DB.QueryRow(
+		`SELECT e.user_id, e.id, e.url, d.event FROM webhook_deliveries d
+		 JOIN webhook_endpoints e ON e.id = d.endpoint_id WHERE d.id = ?`,
+		deliveryID,
+	).Scan(&userID, &endpointID, &endpointURL, &event); err != nil {
+		return
+	}
+	a.notify(userID, notification{
+		Kind:      notificationWebhookFailed,
+		Subject:   endpointURL,
+		Detail:    event,
+		Link:      "/profile",
+		DedupeKey: "webhook:" + strconv.Itoa(endpointID),
+	})
+}
+
 func (a *App) runWebhookWorkerCycle(ctx context.Context) {
 	now := time.Now().UTC()
 	rows, err := a.DB.Query(
@@ -481,7 +508,7 @@ func (a *App) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var events []string
-	for _, ev := range []string{webhookEventWorkUpdated, webhookEventWorkDeleted, webhookEventWorkChapterChanged} {
+	for _, ev := range webhookSubscribableEvents {
 		if r.FormValue("event_"+strings.ReplaceAll(ev, ".", "_")) == "1" {
 			events = append(events, ev)
 		}
@@ -521,7 +548,7 @@ func (a *App) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var events []string
-	for _, ev := range []string{webhookEventWorkUpdated, webhookEventWorkDeleted, webhookEventWorkChapterChanged} {
+	for _, ev := range webhookSubscribableEvents {
 		if r.FormValue("event_"+strings.ReplaceAll(ev, ".", "_")) == "1" {
 			events = append(events, ev)
 		}
@@ -613,3 +640,92 @@ func (a *App) HandleTestWebhook(w http.ResponseWriter, r *http.Request) {
 	)
 	http.Redirect(w, r, "/profile?webhook_test=1", http.StatusFound)
 }
+
+type apiWebhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	// Secret is only returned when the endpoint is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+func webhookToAPI(row webhookEndpointRow) apiWebhook {
+	return apiWebhook{ID: row.ID, URL: row.URL, Events: row.Events, Enabled: row.Enabled, CreatedAt: row.CreatedAt}
+}
+
+// HandleAPIWebhooks lists the user's webhook endpoints (GET) or registers one (POST, JSON body
+// {"url", "events"}; events default to every subscribable event). The signing secret is only
+// returned on creation.
+func (a *App) HandleAPIWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	switch r.Method {
+	case http.MethodGet:
+		rows, err := a.listWebhookEndpoints(userID)
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		out := make([]apiWebhook, 0, len(rows))
+		for _, row := range rows {
+			out = append(out, webhookToAPI(row))
+		}
+		a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": out})
+	case http.MethodPost:
+		var req struct {
+			URL    string   `json:"url"`
+			Events []string `json:"events"`
+		}
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+		if !isWebhookURLSafe(req.URL) {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_url")
+			return
+		}
+		events := webhookSubscribableEvents
+		if req.Events != nil {
+			events = nil
+			for _, ev := range normalizeWebhookEvents(req.Events) {
+				if ev != webhookEventPing {
+					events = append(events, ev)
+				}
+			}
+			if len(events) != len(req.Events) || len(events) == 0 {
+				a.apiWriteError(w, http.StatusBadRequest, "invalid_events")
+				return
+			}
+		}
+		row, err := a.createWebhookEndpoint(userID, req.URL, events)
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		out := webhookToAPI(row)
+		out.Secret = row.Secret
+		a.apiWriteJSON(w, http.StatusCreated, map[string]any{"data": out})
+	default:
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+	}
+}
+
+// HandleAPIWebhookDelete removes a webhook endpoint (DELETE /api/webhooks/{id}).
+func (a *App) HandleAPIWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	endpointID, _ := strconv.Atoi(r.PathValue("id"))
+	if err := a.deleteWebhookEndpoint(userID, endpointID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			a.apiWriteError(w, http.StatusNotFound, "not_found")
+			return
+		}
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,437 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookstorage/internal/database"
+)
+
+// Sources recorded in work_progress_events.source (one per chapter-changing path).
+const (
+	progressSourceIncrement  = "increment"
+	progressSourceDecrement  = "decrement"
+	progressSourceSetChapter = "set_chapter"
+	progressSourceEdit       = "edit"
+	progressSourceAPI        = "api"
+	progressSourceBulk       = "bulk"
+	progressSourceImport     = "import"
+	progressSourceReread     = "reread"
+)
+
+const (
+	defaultWorkHistoryLimit = 50
+	maxWorkHistoryLimit     = 500
+	workPaceRecentDays      = 30
+)
+
+// workProgressEvent is one logged move; ChapterBefore/ChapterAfter hold volumes when Unit is "volume".
+type workProgressEvent struct {
+	ID            int     `json:"id"`
+	Unit          string  `json:"unit"`
+	ChapterBefore float64 `json:"chapter_before"`
+	ChapterAfter  float64 `json:"chapter_after"`
+	Delta         float64 `json:"delta"`
+	Source        string  `json:"source"`
+	CreatedAt     string  `json:"created_at"`
+	UndoneAt      string  `json:"undone_at,omitempty"`
+}
+
+// workReadingPace summarises active (not undone) events in the work's progress unit; imports are ignored
+// because they replay progress made elsewhere and would otherwise show up as a single huge reading day.
+type workReadingPace struct {
+	Unit               string  `json:"unit"`
+	TrackedSince       string  `json:"tracked_since,omitempty"`
+	LastReadAt         string  `json:"last_read_at,omitempty"`
+	ChaptersRead       float64 `json:"chapters_read"`
+	ChaptersLast30Days float64 `json:"chapters_last_30_days"`
+	ChaptersPerWeek    float64 `json:"chapters_per_week"`
+	ActiveDays         int     `json:"active_days"`
+}
+
+var errNoProgressToUndo = errors.New("nothing_to_undo")
+var errProgressChapterMismatch = errors.New("chapter_mismatch")
+
+// errProgressRereadUndo is returned when the last change is the reset of a reread, which undo cannot
+// revert on its own (the archived read would be left behind).
+var errProgressRereadUndo = errors.New("reread_not_undoable")
+
+// recordWorkProgressEvent appends one row to the per-work progress log; no-op when the value did not move.
+func (a *App) recordWorkProgressEvent(userID, workID int, unit string, before, after float64, source string) {
+	if a.DB == nil || userID <= 0 || workID <= 0 || before == after {
+		return
+	}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	if _, err := a.DB.Exec(insertWorkProgressEventSQL, userID, workID, unit, before, after, source, now); err != nil {
+		log.Printf("work_progress_events insert (work %d): %v", workID, err)
+	}
+}
+
+const insertWorkProgressEventSQL = `INSERT INTO work_progress_events (user_id, work_id, unit, chapter_before, chapter_after, source, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+// recordWorkProgressEventTx is recordWorkProgressEvent within tx; the error aborts the caller's change.
+func recordWorkProgressEventTx(tx *database.Tx, userID, workID int, unit string, before, after float64, source string) error {
+	if before == after {
+		return nil
+	}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	_, err := tx.Exec(insertWorkProgressEventSQL, userID, workID, unit, before, after, source, now)
+	return err
+}
+
+func (a *App) listWorkProgressEvents(userID, workID, limit int) ([]workProgressEvent, error) {
+	if limit <= 0 {
+		limit = defaultWorkHistoryLimit
+	}
+	if limit > maxWorkHistoryLimit {
+		limit = maxWorkHistoryLimit
+	}
+	rows, err := a.DB.Query(
+		`SELECT id, unit, chapter_before, chapter_after, source, created_at, undone_at
+		 FROM work_progress_events
+		 WHERE work_id = ? AND user_id = ?
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		workID, userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	out := []workProgressEvent{}
+	for rows.Next() {
+		var e workProgressEvent
+		var created, undone nullFlexTime
+		if err := rows.Scan(&e.ID, &e.Unit, &e.ChapterBefore, &e.ChapterAfter, &e.Source, &created, &undone); err != nil {
+			return nil, err
+		}
+		e.Delta = math.Round((e.ChapterAfter-e.ChapterBefore)*100) / 100
+		e.CreatedAt = created.String
+		if undone.Valid {
+			e.UndoneAt = undone.String
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func parseProgressEventTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05Z"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (a *App) computeWorkReadingPace(userID, workID int, now time.Time) (workReadingPace, error) {
+	var pace workReadingPace
+	var readingType sql.NullString
+	if err := a.DB.QueryRow(`SELECT reading_type FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&readingType); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return pace, err
+	}
+	pace.Unit = progressUnitForReadingType(readingType.String)
+	rows, err := a.DB.Query(
+		`SELECT chapter_before, chapter_after, created_at
+		 FROM work_progress_events
+		 WHERE work_id = ? AND user_id = ? AND unit = ? AND undone_at IS NULL AND source NOT IN (?, ?)
+		 ORDER BY id`,
+		workID, userID, pace.Unit, progressSourceImport, progressSourceReread,
+	)
+	if err != nil {
+		return pace, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var first, last time.Time
+	recentFrom := now.AddDate(0, 0, -workPaceRecentDays)
+	days := map[string]struct{}{}
+	for rows.Next() {
+		var before, after float64
+		var created nullFlexTime
+		if err := rows.Scan(&before, &after, &created); err != nil {
+			return pace, err
+		}
+		t, ok := parseProgressEventTime(created.String)
+		if !ok {
+			continue
+		}
+		if first.IsZero() {
+			first = t
+		}
+		last = t
+		delta := after - before
+		pace.ChaptersRead += delta
+		if !t.Before(recentFrom) {
+			pace.ChaptersLast30Days += delta
+		}
+		if delta > 0 {
+			days[t.Format("2006-01-02")] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return pace, err
+	}
+	if first.IsZero() {
+		return pace, nil
+	}
+	if pace.ChaptersRead < 0 {
+		pace.ChaptersRead = 0
+	}
+	if pace.ChaptersLast30Days < 0 {
+		pace.ChaptersLast30Days = 0
+	}
+	pace.TrackedSince = first.Format("2006-01-02 15:04:05")
+	pace.LastReadAt = last.Format("2006-01-02 15:04:05")
+	pace.ActiveDays = len(days)
+	spanDays := now.Sub(first).Hours() / 24
+	if spanDays < 1 {
+		spanDays = 1
+	}
+	pace.ChaptersRead = math.Round(pace.ChaptersRead*100) / 100
+	pace.ChaptersLast30Days = math.Round(pace.ChaptersLast30Days*100) / 100
+	pace.ChaptersPerWeek = math.Round(pace.ChaptersRead/spanDays*7*10) / 10
+	return pace, nil
+}
+
+// undoLastWorkProgress reverts the newest active event of a work, provided the chapter (or volume) still
+// matches what that event wrote (a change made outside the log would otherwise be silently overwritten).
+func (a *App) undoLastWorkProgress(userID, workID int) (workProgressEvent, error) {
+	var ev workProgressEvent
+	err := a.DB.QueryRow(
+		`SELECT id, unit, chapter_before, chapter_after, source
+		 FROM work_progress_events
+		 WHERE work_id = ? AND user_id = ? AND undone_at IS NULL
+		 ORDER BY id DESC LIMIT 1`,
+		workID, userID,
+	).Scan(&ev.ID, &ev.Unit, &ev.ChapterBefore, &ev.ChapterAfter, &ev.Source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ev, errNoProgressToUndo
+	}
+	if err != nil {
+		return ev, err
+	}
+	if ev.Source == progressSourceReread {
+		return ev, errProgressRereadUndo
+	}
+	col := progressUnitColumn(ev.Unit)
+	var current float64
+	var readingType sql.NullString
+	var lastAt nullFlexTime
+	if err := a.DB.QueryRow(`SELECT COALESCE(`+col+`, 0), reading_type, last_chapter_at FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&current, &readingType, &lastAt); err != nil {
+		return ev, err
+	}
+	if current != ev.ChapterAfter {
+		return ev, errProgressChapterMismatch
+	}
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return ev, err
+	}
+	res, err := tx.Exec(
+		`UPDATE works SET `+col+` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND `+col+` = ?`,
+		ev.ChapterBefore, workID, userID, ev.ChapterAfter,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return ev, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return ev, errProgressChapterMismatch
+	}
+	if _, err := tx.Exec(`UPDATE work_progress_events SET undone_at = ? WHERE id = ?`, now, ev.ID); err != nil {
+		_ = tx.Rollback()
+		return ev, err
+	}
+	if err := tx.Commit(); err != nil {
+		return ev, err
+	}
+	ev.Delta = math.Round((ev.ChapterAfter-ev.ChapterBefore)*100) / 100
+	ev.UndoneAt = now
+	a.applyProgressChangeToReadingStats(userID, ev.Unit, readingType.String, ev.ChapterAfter, ev.ChapterBefore, lastAt)
+	a.emitWorkProgressWebhook(userID, workID)
+	return ev, nil
+}
+
+// workProgressMove is the outcome of moveWorkProgress; WorkUnit is the progress unit of the work itself.
+type workProgressMove struct {
+	Unit                string
+	WorkUnit            string
+	Before              float64
+	After               float64
+	LastChapterAtBefore nullFlexTime
+}
+
+func (m workProgressMove) Changed() bool {
+	return m.Before != m.After
+}
+
+// moveWorkProgress reads the current value of a work's chapter or volume (unit "" = the work's own
+// progress unit), writes next(value) and logs the change. last_chapter_at is bumped on forward moves.
+// Reading stats and webhooks stay with the callers, which do not all account for moves the same way.
+func (a *App) moveWorkProgress(userID, workID int, unit string, next func(float64) float64, source string) (workProgressMove, error) {
+	var m workProgressMove
+	var chapter, volume float64
+	var readingType sql.NullString
+	err := a.DB.QueryRow(
+		`SELECT chapter, COALESCE(volume, 0), reading_type, last_chapter_at FROM works WHERE id = ? AND user_id = ?`,
+		workID, userID,
+	).Scan(&chapter, &volume, &readingType, &m.LastChapterAtBefore)
+	if err != nil {
+		return m, err
+	}
+	m.WorkUnit = progressUnitForReadingType(readingType.String)
+	m.Unit = unit
+	if m.Unit == "" {
+		m.Unit = m.WorkUnit
+	}
+	m.Before = chapter
+	clamp := clampChapter
+	if m.Unit == progressUnitVolume {
+		m.Before = volume
+		clamp = clampVolume
+	}
+	m.After = clamp(next(m.Before))
+	if m.After == m.Before {
+		return m, nil
+	}
+	col := progressUnitColumn(m.Unit)
+	stmt := `UPDATE works SET ` + col + ` = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`
+	if m.After > m.Before {
+		stmt = `UPDATE works SET ` + col + ` = ?, last_chapter_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`
+	}
+	if _, err := a.DB.Exec(stmt, m.After, workID, userID); err != nil {
+		return m, err
+	}
+	a.recordWorkProgressEvent(userID, workID, m.Unit, m.Before, m.After, source)
+	return m, nil
+}
+
+// emitWorkProgressWebhook sends work.chapter_changed with the stored chapter and volume.
+func (a *App) emitWorkProgressWebhook(userID, workID int) {
+	var chapter, volume float64
+	if err := a.DB.QueryRow(`SELECT chapter, COALESCE(volume, 0) FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&chapter, &volume); err != nil {
+		return
+	}
+	a.EmitWebhookEvent(userID, webhookEventWorkChapterChanged, map[string]any{
+		"work_id": workID,
+		"chapter": chapter,
+		"volume":  volume,
+	})
+	a.refreshGoals(userID)
+}
+
+func (a *App) userOwnsWork(userID, workID int) (bool, error) {
+	var one int
+	err := a.DB.QueryRow(`SELECT 1 FROM works WHERE id = ? AND user_id = ?`, workID, userID).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HandleAPIWorkHistory serves GET /api/works/{id}/history: newest chapter changes first plus reading pace.
+func (a *App) HandleAPIWorkHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+
+	owned, err := a.userOwnsWork(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if !owned {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+
+	limit := defaultWorkHistoryLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+		if limit > maxWorkHistoryLimit {
+			limit = maxWorkHistoryLimit
+		}
+	}
+	events, err := a.listWorkProgressEvents(userID, workID, limit)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	pace, err := a.computeWorkReadingPace(userID, workID, time.Now().UTC())
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"data": map[string]any{
+			"events": events,
+			"pace":   pace,
+		},
+		"meta": map[string]any{
+			"work_id": workID,
+			"limit":   limit,
+		},
+	})
+}
+
+// HandleAPIWorkUndo serves POST /api/works/{id}/undo and reverts the last logged chapter change.
+func (a *App) HandleAPIWorkUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+
+	owned, err := a.userOwnsWork(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if !owned {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+
+	ev, err := a.undoLastWorkProgress(userID, workID)
+	switch {
+	case errors.Is(err, errNoProgressToUndo):
+		a.apiWriteError(w, http.StatusConflict, errNoProgressToUndo.Error())
+		return
+	case errors.Is(err, errProgressChapterMismatch):
+		a.apiWriteError(w, http.StatusConflict, errProgressChapterMismatch.Error())
+		return
+	case errors.Is(err, errProgressRereadUndo):
+		a.apiWriteError(w, http.StatusConflict, errProgressRereadUndo.Error())
+		return
+	case err != nil:
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+
+	var wr workRow
+	if err := scanFullWorkRow(&wr, a.DB.QueryRow(
+		`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ? AND user_id = ?`, workID, userID,
+	)); err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"data":   workRowToAPIWork(wr, a.loadReadingSiteStatusMap(userID)),
+		"undone": ev,
+	})
+}
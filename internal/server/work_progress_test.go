@@ -0,0 +1,376 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func insertTestWork(t *testing.T, app *App, title string, chapter int) int {
+	t.Helper()
+	res, err := app.DB.Exec(
+		`INSERT INTO works (title, chapter, status, reading_type, user_id, updated_at)
+		 VALUES (?, ?, 'reading', 'Manga', 1, CURRENT_TIMESTAMP)`,
+		title, chapter,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+func workChapter(t *testing.T, app *App, workID int) float64 {
+	t.Helper()
+	var ch float64
+	if err := app.DB.QueryRow(`SELECT chapter FROM works WHERE id = ?`, workID).Scan(&ch); err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+func TestWorkProgress_webHandlersRecordEvents(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "History A", 3)
+	id := strconv.Itoa(workID)
+
+	post := func(h http.HandlerFunc, path string, form url.Values) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s status=%d", path, rec.Code)
+		}
+	}
+	post(app.HandleIncrement, "/api/increment/"+id, nil)
+	post(app.HandleSetChapter, "/api/set-chapter/"+id, url.Values{"chapter": {"10"}})
+	post(app.HandleDecrement, "/api/decrement/"+id, nil)
+
+	events, err := app.listWorkProgressEvents(1, workID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events=%d want 3: %+v", len(events), events)
+	}
+	want := []struct {
+		before, after float64
+		source        string
+	}{
+		{10, 9, progressSourceDecrement},
+		{4, 10, progressSourceSetChapter},
+		{3, 4, progressSourceIncrement},
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.ChapterBefore != w.before || e.ChapterAfter != w.after || e.Source != w.source {
+			t.Fatalf("event %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestHandleAPIWorkUndo_revertsLastChange(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Undo A", 5)
+	id := strconv.Itoa(workID)
+
+	patch := httptest.NewRequest(http.MethodPatch, "/api/works/"+id, strings.NewReader(`{"chapter": 15}`))
+	patch.Header.Set("Content-Type", "application/json")
+	patch.AddCookie(&http.Cookie{Name: "session", Value: session})
+	patch.SetPathValue("id", id)
+	patchRec := httptest.NewRecorder()
+	app.HandleAPIWorksUpdate(patchRec, patch)
+	if patchRec.Code != http.StatusOK {
+		t.Fatalf("patch status=%d body=%s", patchRec.Code, patchRec.Body.String())
+	}
+
+	undo := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/works/"+id+"/undo", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorkUndo(rec, req)
+		return rec
+	}
+	rec := undo()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("undo status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data   apiWork           `json:"data"`
+		Undone workProgressEvent `json:"undone"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.Chapter != 5 || payload.Undone.Source != progressSourceAPI || payload.Undone.Delta != 10 {
+		t.Fatalf("unexpected undo payload: %+v", payload)
+	}
+	if got := workChapter(t, app, workID); got != 5 {
+		t.Fatalf("chapter=%v want 5", got)
+	}
+
+	rec = undo()
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "nothing_to_undo") {
+		t.Fatalf("second undo status=%d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleAPIWorkUndo_rejectsChapterChangedOutsideLog(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Undo B", 2)
+	app.recordWorkProgressEvent(1, workID, progressUnitChapter, 1, 2, progressSourceEdit)
+	if _, err := db.Exec(`UPDATE works SET chapter = 7 WHERE id = ?`, workID); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/works/"+strconv.Itoa(workID)+"/undo", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", strconv.Itoa(workID))
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkUndo(rec, req)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "chapter_mismatch") {
+		t.Fatalf("status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := workChapter(t, app, workID); got != 7 {
+		t.Fatalf("chapter=%v want 7 (unchanged)", got)
+	}
+}
+
+func TestHandleAPIWorkHistory_eventsAndPace(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "History B", 0)
+
+	body := fmt.Sprintf(`{"ids":[%d],"patch":{"chapter":12}}`, workID)
+	bulk := httptest.NewRequest(http.MethodPost, "/api/works/bulk", strings.NewReader(body))
+	bulk.Header.Set("Content-Type", "application/json")
+	bulk.AddCookie(&http.Cookie{Name: "session", Value: session})
+	bulkRec := httptest.NewRecorder()
+	app.HandleAPIWorksBulk(bulkRec, bulk)
+	if bulkRec.Code != http.StatusOK {
+		t.Fatalf("bulk status=%d body=%s", bulkRec.Code, bulkRec.Body.String())
+	}
+	app.recordWorkProgressEvent(1, workID, progressUnitChapter, 12, 40, progressSourceImport)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/works/"+strconv.Itoa(workID)+"/history", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", strconv.Itoa(workID))
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkHistory(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("history status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data struct {
+			Events []workProgressEvent `json:"events"`
+			Pace   workReadingPace     `json:"pace"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data.Events) != 2 || payload.Data.Events[1].Source != progressSourceBulk {
+		t.Fatalf("unexpected events: %+v", payload.Data.Events)
+	}
+	// Import events stay in the timeline but do not count as reading.
+	if payload.Data.Pace.ChaptersRead != 12 || payload.Data.Pace.ChaptersLast30Days != 12 || payload.Data.Pace.ActiveDays != 1 {
+		t.Fatalf("unexpected pace: %+v", payload.Data.Pace)
+	}
+
+	other := httptest.NewRequest(http.MethodGet, "/api/works/999999/history", nil)
+	other.AddCookie(&http.Cookie{Name: "session", Value: session})
+	other.SetPathValue("id", "999999")
+	otherRec := httptest.NewRecorder()
+	app.HandleAPIWorkHistory(otherRec, other)
+	if otherRec.Code != http.StatusNotFound {
+		t.Fatalf("missing work status=%d", otherRec.Code)
+	}
+}
+
+func TestComputeWorkReadingPace_perWeek(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	workID := insertTestWork(t, app, "Pace A", 20)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, ev := range []struct {
+		before, after int
+		at            time.Time
+	}{
+		{0, 6, now.AddDate(0, 0, -13)},
+		{6, 20, now.AddDate(0, 0, -1)},
+	} {
+		if _, err := db.Exec(
+			`INSERT INTO work_progress_events (user_id, work_id, chapter_before, chapter_after, source, created_at) VALUES (1, ?, ?, ?, ?, ?)`,
+			workID, ev.before, ev.after, progressSourceIncrement, ev.at.Format("2006-01-02 15:04:05"),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pace, err := app.computeWorkReadingPace(1, workID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pace.ChaptersRead != 20 || pace.ActiveDays != 2 || pace.ChaptersPerWeek != 10.8 {
+		t.Fatalf("unexpected pace: %+v", pace)
+	}
+}
+
+func TestComputeWorkReadingPace_ignoresRereadReset(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	workID := insertTestWork(t, app, "Pace B", 0)
+	app.recordWorkProgressEvent(1, workID, progressUnitForReadingType(""), 0, 12, progressSourceIncrement)
+	if _, err := db.Exec(`UPDATE works SET chapter = 12 WHERE id = ?`, workID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.startWorkReread(1, workID, ""); err != nil {
+		t.Fatal(err)
+	}
+	pace, err := app.computeWorkReadingPace(1, workID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pace.ChaptersRead != 12 || pace.ChaptersLast30Days != 12 || pace.ActiveDays != 1 {
+		t.Fatalf("unexpected pace after reread: %+v", pace)
+	}
+}
+
+func TestMatchAPITokenRoute_workUndo(t *testing.T) {
+	if route, ok := matchAPITokenRoute(http.MethodPost, "/api/works/12/undo"); !ok || route.Scope != ScopeWorksWrite {
+		t.Fatal("expected POST /api/works/{id}/undo to accept API tokens")
+	}
+	if _, ok := matchAPITokenRoute(http.MethodGet, "/api/works/12/history"); !ok {
+		t.Fatal("expected GET /api/works/{id}/history to accept API tokens")
+	}
+	if _, ok := matchAPITokenRoute(http.MethodPost, "/api/works/12"); ok {
+		t.Fatal("POST /api/works/{id} must stay rejected")
+	}
+}
+
+func TestProgressValueHelpers(t *testing.T) {
+	t.Parallel()
+	if got := parseProgressValue(" 12,5 "); got != 12.5 {
+		t.Fatalf("parseProgressValue comma = %v", got)
+	}
+	if got := parseProgressValue("abc"); got != 0 {
+		t.Fatalf("parseProgressValue invalid = %v", got)
+	}
+	if got := clampChapter(12.345); got != 12.35 {
+		t.Fatalf("clampChapter rounding = %v", got)
+	}
+	if got := clampChapter(-3); got != 0 {
+		t.Fatalf("clampChapter negative = %v", got)
+	}
+	if got := clampVolume(5000); got != maxVolumeValue {
+		t.Fatalf("clampVolume cap = %v", got)
+	}
+	cases := []struct{ in, next, prev float64 }{
+		{0, 1, 0},
+		{12, 13, 11},
+		{12.5, 13, 12},
+		{0.5, 1, 0},
+	}
+	for _, tc := range cases {
+		if got := nextProgressValue(tc.in); got != tc.next {
+			t.Fatalf("next(%v) = %v, want %v", tc.in, got, tc.next)
+		}
+		if got := prevProgressValue(tc.in); got != tc.prev {
+			t.Fatalf("prev(%v) = %v, want %v", tc.in, got, tc.prev)
+		}
+	}
+	if got := wholeUnitDelta(12.5, 14); got != 2 {
+		t.Fatalf("wholeUnitDelta = %d", got)
+	}
+	if progressUnitForReadingType("ln") != progressUnitVolume || progressUnitForReadingType("Webtoon") != progressUnitChapter {
+		t.Fatal("unexpected progress unit mapping")
+	}
+}
+
+func TestHandleIncrement_lightNovelMovesVolume(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	res, err := db.Exec(
+		`INSERT INTO works (title, chapter, volume, status, reading_type, user_id, updated_at)
+		 VALUES ('LN A', 40, 3.5, 'reading', 'Light Novel', 1, CURRENT_TIMESTAMP)`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id64, _ := res.LastInsertId()
+	id := strconv.FormatInt(id64, 10)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/increment/"+id, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", id)
+	app.HandleIncrement(httptest.NewRecorder(), req)
+
+	var chapter, volume float64
+	if err := db.QueryRow(`SELECT chapter, volume FROM works WHERE id = ?`, id64).Scan(&chapter, &volume); err != nil {
+		t.Fatal(err)
+	}
+	if chapter != 40 || volume != 4 {
+		t.Fatalf("chapter=%v volume=%v, want 40 / 4", chapter, volume)
+	}
+	events, err := app.listWorkProgressEvents(1, int(id64), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Unit != progressUnitVolume || events[0].Delta != 0.5 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestHandleAPIWorksUpdate_fractionalChapter(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Fraction A", 12)
+	id := strconv.Itoa(workID)
+
+	patch := httptest.NewRequest(http.MethodPatch, "/api/works/"+id, strings.NewReader(`{"chapter": 12.5, "volume": 2}`))
+	patch.Header.Set("Content-Type", "application/json")
+	patch.AddCookie(&http.Cookie{Name: "session", Value: session})
+	patch.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorksUpdate(rec, patch)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data apiWork `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.Chapter != 12.5 || payload.Data.Volume != 2 || payload.Data.ProgressUnit != progressUnitChapter {
+		t.Fatalf("unexpected work: %+v", payload.Data)
+	}
+
+	set := httptest.NewRequest(http.MethodPost, "/api/set-chapter/"+id, strings.NewReader("chapter=13,75"))
+	set.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	set.AddCookie(&http.Cookie{Name: "session", Value: session})
+	set.SetPathValue("id", id)
+	app.HandleSetChapter(httptest.NewRecorder(), set)
+	if got := workChapter(t, app, workID); got != 13.75 {
+		t.Fatalf("chapter=%v want 13.75", got)
+	}
+}
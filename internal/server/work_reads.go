@@ -0,0 +1,390 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// workRead is one archived read-through of a work. The ongoing read lives on the works row itself
+// (chapter, started_at, finished_at); starting a reread moves it here and resets the work.
+type workRead struct {
+	ID         int    `json:"id"`
+	ReadNumber int    `json:"read_number"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	Rating     int    `json:"rating"`
+	Notes      string `json:"notes,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Started / Finished adapt the dates for the fmtDateDisplay template helper.
+func (r workRead) Started() nullFlexTime {
+	return nullFlexTime{sql.NullString{String: r.StartedAt, Valid: r.StartedAt != ""}}
+}
+
+func (r workRead) Finished() nullFlexTime {
+	return nullFlexTime{sql.NullString{String: r.FinishedAt, Valid: r.FinishedAt != ""}}
+}
+
+var errInvalidReadDate = errors.New("invalid_date")
+
+const sqlWorkReadColumns = `id, work_id, read_number, started_at, finished_at, COALESCE(rating, 0), COALESCE(notes, ''), created_at`
+
+func scanWorkReads(rows *sql.Rows) (map[int][]workRead, error) {
+	defer func() { _ = rows.Close() }()
+	out := map[int][]workRead{}
+	for rows.Next() {
+		var rd workRead
+		var workID int
+		var started, finished, created nullFlexTime
+		if err := rows.Scan(&rd.ID, &workID, &rd.ReadNumber, &started, &finished, &rd.Rating, &rd.Notes, &created); err != nil {
+			return nil, err
+		}
+		rd.StartedAt = started.String
+		rd.FinishedAt = finished.String
+		rd.CreatedAt = created.String
+		out[workID] = append(out[workID], rd)
+	}
+	return out, rows.Err()
+}
+
+func (a *App) listWorkReads(userID, workID int) ([]workRead, error) {
+	rows, err := a.DB.Query(
+		`SELECT `+sqlWorkReadColumns+` FROM work_reads WHERE work_id = ? AND user_id = ? ORDER BY read_number, id`,
+		workID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	byWork, err := scanWorkReads(rows)
+	if err != nil {
+		return nil, err
+	}
+	if reads := byWork[workID]; reads != nil {
+		return reads, nil
+	}
+	return []workRead{}, nil
+}
+
+// workReadsByWork returns every archived read of the user grouped by work (export).
+func (a *App) workReadsByWork(userID int) map[int][]workRead {
+	rows, err := a.DB.Query(`SELECT `+sqlWorkReadColumns+` FROM work_reads WHERE user_id = ? ORDER BY work_id, read_number, id`, userID)
+	if err != nil {
+		return map[int][]workRead{}
+	}
+	byWork, err := scanWorkReads(rows)
+	if err != nil {
+		return map[int][]workRead{}
+	}
+	return byWork
+}
+
+// rereadCountsByWork maps each work of the user to its number of archived reads (one query for list pages).
+func (a *App) rereadCountsByWork(userID int) map[int]int {
+	out := map[int]int{}
+	rows, err := a.DB.Query(`SELECT work_id, COUNT(*) FROM work_reads WHERE user_id = ? GROUP BY work_id`, userID)
+	if err != nil {
+		return out
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var workID, n int
+		if err := rows.Scan(&workID, &n); err != nil {
+			return out
+		}
+		out[workID] = n
+	}
+	return out
+}
+
+func (a *App) workRereadCount(userID, workID int) int {
+	var n int
+	_ = a.DB.QueryRow(`SELECT COUNT(*) FROM work_reads WHERE work_id = ? AND user_id = ?`, workID, userID).Scan(&n)
+	return n
+}
+
+// startWorkReread archives the current read (dates, rating) as the next work_reads row, then restarts
+// the work from zero as « reading » with no rating, in one transaction. The reset is logged in the
+// progress history but not in reading stats.
+func (a *App) startWorkReread(userID, workID int, notes string) (workRead, error) {
+	var wr workRow
+	if err := scanFullWorkRow(&wr, a.DB.QueryRow(
+		`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ? AND user_id = ?`, workID, userID,
+	)); err != nil {
+		return workRead{}, err
+	}
+	tx, err := a.DB.Begin()
+	if err != nil {
+		return workRead{}, err
+	}
+	var last int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(read_number), 0) FROM work_reads WHERE work_id = ? AND user_id = ?`, workID, userID).Scan(&last); err != nil {
+		_ = tx.Rollback()
+		return workRead{}, err
+	}
+	readID, err := tx.InsertID(
+		`INSERT INTO work_reads (user_id, work_id, read_number, started_at, finished_at, rating, notes)
+		 SELECT user_id, id, ?, started_at, finished_at, COALESCE(rating, 0), ? FROM works WHERE id = ? AND user_id = ?`,
+		last+1, nullIfEmpty(truncateNotes(strings.TrimSpace(notes))), workID, userID,
+	)
+	if err != nil {
+		_ = tx.Rollback()
+		return workRead{}, err
+	}
+	now := time.Now().UTC().Format("2006-01-02 15:04:05")
+	if _, err := tx.Exec(
+		`UPDATE works SET chapter = 0, volume = 0, rating = 0, status = ?, started_at = ?, last_chapter_at = NULL, finished_at = NULL, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND user_id = ?`,
+		statusReading, now, workID, userID,
+	); err != nil {
+		_ = tx.Rollback()
+		return workRead{}, err
+	}
+	if err := recordWorkProgressEventTx(tx, userID, workID, progressUnitChapter, wr.Chapter, 0, progressSourceReread); err != nil {
+		_ = tx.Rollback()
+		return workRead{}, err
+	}
+	if err := recordWorkProgressEventTx(tx, userID, workID, progressUnitVolume, wr.Volume, 0, progressSourceReread); err != nil {
+		_ = tx.Rollback()
+		return workRead{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return workRead{}, err
+	}
+	a.emitWorkProgressWebhook(userID, workID)
+
+	reads, err := a.listWorkReads(userID, workID)
+	if err != nil {
+		return workRead{}, err
+	}
+	for _, rd := range reads {
+		if int64(rd.ID) == readID {
+			return rd, nil
+		}
+	}
+	return workRead{}, sql.ErrNoRows
+}
+
+// addPlaceholderReads records n earlier reads without dates (AniList « repeat » counts on import).
+func (a *App) addPlaceholderReads(userID, workID, n int) error {
+	have := a.workRereadCount(userID, workID)
+	for i := have + 1; i <= n; i++ {
+		if _, err := a.DB.Exec(
+			`INSERT INTO work_reads (user_id, work_id, read_number, rating) VALUES (?, ?, ?, 0)`,
+			userID, workID, i,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importWorkReads restores exported reads when the work has none yet, then pads the history up to
+// w.RereadCount with undated reads.
+func (a *App) importWorkReads(userID, workID int, w exportWork) error {
+	if len(w.Reads) > 0 && a.workRereadCount(userID, workID) == 0 {
+		for i, rd := range w.Reads {
+			// Unparseable dates are dropped rather than failing the whole work.
+			started, _ := normalizeReadDate(rd.StartedAt)
+			finished, _ := normalizeReadDate(rd.FinishedAt)
+			number := rd.ReadNumber
+			if number <= 0 {
+				number = i + 1
+			}
+			if _, err := a.DB.Exec(
+				`INSERT INTO work_reads (user_id, work_id, read_number, started_at, finished_at, rating, notes) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				userID, workID, number, started, finished, clampRating(rd.Rating), nullIfEmpty(truncateNotes(strings.TrimSpace(rd.Notes))),
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return a.addPlaceholderReads(userID, workID, w.RereadCount)
+}
+
+// normalizeReadDate accepts "", YYYY-MM-DD or a full timestamp and returns the stored value (nil when empty).
+func normalizeReadDate(raw string) (any, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format("2006-01-02 15:04:05"), nil
+		}
+	}
+	return nil, errInvalidReadDate
+}
+
+func (a *App) apiWorkWithReads(userID, workID int) (apiWork, error) {
+	var wr workRow
+	if err := scanFullWorkRow(&wr, a.DB.QueryRow(
+		`SELECT `+sqlWorkRowFull+` FROM works WHERE id = ? AND user_id = ?`, workID, userID,
+	)); err != nil {
+		return apiWork{}, err
+	}
+	wr.Tags, _ = a.workTagNames(userID, workID)
+	wr.RereadCount = a.workRereadCount(userID, workID)
+	return workRowToAPIWork(wr, a.loadReadingSiteStatusMap(userID)), nil
+}
+
+// HandleAPIWorkReads serves GET /api/works/{id}/reads: archived reads, oldest first.
+func (a *App) HandleAPIWorkReads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	owned, err := a.userOwnsWork(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if !owned {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	reads, err := a.listWorkReads(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"data": reads,
+		"meta": map[string]any{
+			"work_id":      workID,
+			"reread_count": len(reads),
+		},
+	})
+}
+
+// HandleAPIWorkReread serves POST /api/works/{id}/reread. Optional body: {"notes": "..."} for the archived read.
+func (a *App) HandleAPIWorkReread(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	if r.ContentLength > 0 {
+		if err := decodeAPIJSONBody(w, r, &req); err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+			return
+		}
+	}
+	read, err := a.startWorkReread(userID, workID, req.Notes)
+	if errors.Is(err, sql.ErrNoRows) {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	work, err := a.apiWorkWithReads(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	a.apiWriteJSON(w, http.StatusOK, map[string]any{
+		"data": work,
+		"read": read,
+	})
+}
+
+// HandleAPIWorkReadUpdate serves PATCH and DELETE /api/works/{id}/reads/{readID}.
+// PATCH accepts started_at, finished_at (YYYY-MM-DD or empty), rating and notes.
+func (a *App) HandleAPIWorkReadUpdate(w http.ResponseWriter, r *http.Request) {
+	userID, _ := a.currentUserID(r)
+	workID, _ := strconv.Atoi(r.PathValue("id"))
+	readID, _ := strconv.Atoi(r.PathValue("readID"))
+
+	switch r.Method {
+	case http.MethodDelete:
+		res, err := a.DB.Exec(`DELETE FROM work_reads WHERE id = ? AND work_id = ? AND user_id = ?`, readID, workID, userID)
+		if err != nil {
+			a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			a.apiWriteError(w, http.StatusNotFound, "not_found")
+			return
+		}
+		a.refreshGoals(userID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPatch:
+	default:
+		a.apiWriteError(w, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+
+	var req map[string]any
+	if err := decodeAPIJSONBody(w, r, &req); err != nil {
+		a.apiWriteError(w, http.StatusBadRequest, "invalid_json")
+		return
+	}
+	var setParts []string
+	var args []any
+	for _, col := range []string{"started_at", "finished_at"} {
+		raw, present := req[col]
+		if !present {
+			continue
+		}
+		s, ok := raw.(string)
+		if !ok && raw != nil {
+			a.apiWriteError(w, http.StatusBadRequest, errInvalidReadDate.Error())
+			return
+		}
+		v, err := normalizeReadDate(s)
+		if err != nil {
+			a.apiWriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		setParts = append(setParts, col+" = ?")
+		args = append(args, v)
+	}
+	if v, ok := req["rating"].(float64); ok {
+		setParts = append(setParts, "rating = ?")
+		args = append(args, clampRating(int(v)))
+	}
+	if v, ok := req["notes"].(string); ok {
+		setParts = append(setParts, "notes = ?")
+		args = append(args, nullIfEmpty(truncateNotes(strings.TrimSpace(v))))
+	}
+	if len(setParts) == 0 {
+		a.apiWriteError(w, http.StatusBadRequest, "no_fields_to_update")
+		return
+	}
+	args = append(args, readID, workID, userID)
+	res, err := a.DB.Exec(`UPDATE work_reads SET `+strings.Join(setParts, ", ")+` WHERE id = ? AND work_id = ? AND user_id = ?`, args...)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		a.apiWriteError(w, http.StatusNotFound, "not_found")
+		return
+	}
+	a.refreshGoals(userID)
+	reads, err := a.listWorkReads(userID, workID)
+	if err != nil {
+		a.apiWriteError(w, http.StatusInternalServerError, "internal_error")
+		return
+	}
+	for _, rd := range reads {
+		if rd.ID == readID {
+			a.apiWriteJSON(w, http.StatusOK, map[string]any{"data": rd})
+			return
+		}
+	}
+	a.apiWriteError(w, http.StatusNotFound, "not_found")
+}
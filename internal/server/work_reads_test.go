@@ -0,0 +1,223 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandleAPIWorkReread_archivesReadAndResetsWork(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Finished", 120)
+	if _, err := db.Exec(
+		`UPDATE works SET status = ?, rating = 4, started_at = '2024-01-05 00:00:00', finished_at = '2024-03-01 00:00:00' WHERE id = ?`,
+		statusCompleted, workID,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := strconv.Itoa(workID)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/works/"+id+"/reread", strings.NewReader(`{"notes": "first time"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkReread(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reread status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data apiWork  `json:"data"`
+		Read workRead `json:"read"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.Chapter != 0 || payload.Data.Status != statusReading || payload.Data.FinishedAt != "" || payload.Data.RereadCount != 1 || payload.Data.Rating != 0 {
+		t.Fatalf("work after reread=%+v", payload.Data)
+	}
+	rd := payload.Read
+	if rd.ReadNumber != 1 || rd.Rating != 4 || rd.Notes != "first time" ||
+		!strings.HasPrefix(rd.StartedAt, "2024-01-05") || !strings.HasPrefix(rd.FinishedAt, "2024-03-01") {
+		t.Fatalf("archived read=%+v", rd)
+	}
+
+	events, err := app.listWorkProgressEvents(1, workID, 10)
+	if err != nil || len(events) != 1 || events[0].Source != progressSourceReread || events[0].ChapterAfter != 0 {
+		t.Fatalf("events=%+v err=%v", events, err)
+	}
+
+	if _, err := app.startWorkReread(1, workID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := app.workRereadCount(1, workID); n != 2 {
+		t.Fatalf("reread count=%d", n)
+	}
+
+	stats := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	stats.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec = httptest.NewRecorder()
+	app.HandleAPIStats(rec, stats)
+	var statsPayload struct {
+		Data map[string]float64 `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&statsPayload); err != nil {
+		t.Fatal(err)
+	}
+	if statsPayload.Data["total_rereads"] != 2 || statsPayload.Data["reread_works"] != 1 {
+		t.Fatalf("stats=%v", statsPayload.Data)
+	}
+
+	missing := httptest.NewRequest(http.MethodPost, "/api/works/999/reread", nil)
+	missing.AddCookie(&http.Cookie{Name: "session", Value: session})
+	missing.SetPathValue("id", "999")
+	rec = httptest.NewRecorder()
+	app.HandleAPIWorkReread(rec, missing)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing work status=%d", rec.Code)
+	}
+}
+
+func TestHandleAPIWorkUndo_refusesRereadReset(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Finished", 120)
+	if _, err := db.Exec(`UPDATE works SET volume = 12, status = ? WHERE id = ?`, statusCompleted, workID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.startWorkReread(1, workID, ""); err != nil {
+		t.Fatal(err)
+	}
+	id := strconv.Itoa(workID)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/works/"+id+"/undo", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	app.HandleAPIWorkUndo(rec, req)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "reread_not_undoable") {
+		t.Fatalf("undo after reread: %d %s", rec.Code, rec.Body.String())
+	}
+	var chapter, volume float64
+	if err := db.QueryRow(`SELECT chapter, volume FROM works WHERE id = ?`, workID).Scan(&chapter, &volume); err != nil {
+		t.Fatal(err)
+	}
+	if chapter != 0 || volume != 0 || app.workRereadCount(1, workID) != 1 {
+		t.Fatalf("after refused undo: chapter=%v volume=%v reads=%d", chapter, volume, app.workRereadCount(1, workID))
+	}
+	events, _ := app.listWorkProgressEvents(1, workID, 10)
+	for _, ev := range events {
+		if ev.UndoneAt != "" {
+			t.Fatalf("event undone: %+v", ev)
+		}
+	}
+}
+
+func TestHandleAPIWorkReadUpdate_patchAndDelete(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+	workID := insertTestWork(t, app, "Reread", 10)
+	read, err := app.startWorkReread(1, workID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, readID := strconv.Itoa(workID), strconv.Itoa(read.ID)
+
+	call := func(method, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/works/"+id+"/reads/"+readID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.SetPathValue("id", id)
+		req.SetPathValue("readID", readID)
+		rec := httptest.NewRecorder()
+		app.HandleAPIWorkReadUpdate(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodPatch, `{"started_at": "2023-02-01", "finished_at": "2023-04-15", "rating": 3, "notes": " great "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload struct {
+		Data workRead `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.StartedAt != "2023-02-01 00:00:00" || payload.Data.FinishedAt != "2023-04-15 00:00:00" ||
+		payload.Data.Rating != 3 || payload.Data.Notes != "great" {
+		t.Fatalf("patched read=%+v", payload.Data)
+	}
+
+	if rec := call(http.MethodPatch, `{"finished_at": "15/04/2023"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid date status=%d", rec.Code)
+	}
+	if rec := call(http.MethodDelete, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status=%d", rec.Code)
+	}
+	if rec := call(http.MethodDelete, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete status=%d", rec.Code)
+	}
+}
+
+func TestImportWorkReads_aniListRepeatAndExportRoundTrip(t *testing.T) {
+	db, s := openTestDB(t)
+	app := &App{Settings: s, DB: db}
+	session := mustCreateSession(t, app, 1)
+
+	works, ok := parseAniListExportJSON([]byte(`[{"status": "COMPLETED", "progress": 50, "score": 8, "repeat": 2, "media": {"id": 7, "title": {"romaji": "Twice Read"}}}]`))
+	if !ok || len(works) != 1 || works[0].RereadCount != 2 {
+		t.Fatalf("parsed=%+v ok=%v", works, ok)
+	}
+	report := &ImportReport{}
+	app.importOneWork(1, 1, works[0], DuplicateSkip, report)
+	if report.Imported != 1 {
+		t.Fatalf("report=%+v", report)
+	}
+	var workID int
+	if err := db.QueryRow(`SELECT id FROM works WHERE user_id = 1 AND title = 'Twice Read'`).Scan(&workID); err != nil {
+		t.Fatal(err)
+	}
+	if n := app.workRereadCount(1, workID); n != 2 {
+		t.Fatalf("placeholder reads=%d", n)
+	}
+	// Importing the same count again must not duplicate reads.
+	app.importOneWork(1, 2, works[0], DuplicateUpdate, &ImportReport{})
+	if n := app.workRereadCount(1, workID); n != 2 {
+		t.Fatalf("reads after update=%d", n)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=json", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session})
+	rec := httptest.NewRecorder()
+	app.HandleExport(rec, req)
+	var payload struct {
+		Works []exportWork `json:"works"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Works) != 1 || payload.Works[0].RereadCount != 2 || len(payload.Works[0].Reads) != 2 {
+		t.Fatalf("json export=%+v", payload.Works)
+	}
+
+	exported := payload.Works[0]
+	exported.Title = "Copy"
+	exported.Reads[0].Notes = "kept"
+	app.importOneWork(1, 3, exported, DuplicateSkip, &ImportReport{})
+	if err := db.QueryRow(`SELECT id FROM works WHERE user_id = 1 AND title = 'Copy'`).Scan(&workID); err != nil {
+		t.Fatal(err)
+	}
+	reads, err := app.listWorkReads(1, workID)
+	if err != nil || len(reads) != 2 || reads[0].Notes != "kept" {
+		t.Fatalf("imported reads=%+v err=%v", reads, err)
+	}
+}
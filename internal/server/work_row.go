@@ -3,9 +3,11 @@ package server
 import (
 	"database/sql"
 	"fmt"
-	"net/url"
+	"math"
 	"strings"
 	"time"
+
+	"bookstorage/internal/catalog"
 )
 
 type nullFlexTime struct {
@@ -34,7 +36,8 @@ func (n *nullFlexTime) Scan(src any) error {
 type workRow struct {
 	ID                  int
 	Title               string
-	Chapter             int
+	Chapter             float64
+	Volume              float64
 	Link                sql.NullString
 	Status              sql.NullString
 	ImagePath           sql.NullString
@@ -55,35 +58,86 @@ type workRow struct {
 	LinkProbeAt         nullFlexTime
 	LinkProbeHTTPStatus sql.NullInt64
 	LinkProbeDetail     sql.NullString
+	// LatestChapter is the newest chapter found by the chapter feed poller (MangaDex catalog rows only).
+	LatestChapter sql.NullFloat64
+	FeedURL       sql.NullString
+	// FeedLatestChapter and FeedLatestReleaseAt summarize work_releases (RSS/Atom release feeds).
+	FeedLatestChapter   sql.NullFloat64
+	FeedLatestReleaseAt nullFlexTime
+	// Tags and RereadCount are filled separately (work_tags, work_reads), not by scanFullWorkRow.
+	Tags        []string
+	RereadCount int
 }
 
 // sqlWorkRowFull must match scanFullWorkRow field order.
-const sqlWorkRowFull = `id, title, chapter, link, status, image_path, reading_type, COALESCE(rating, 0), notes, user_id, updated_at, COALESCE(is_adult, 0), parent_work_id, COALESCE(series_sort, 0), COALESCE(notify_new_chapters, 1), reading_site_id, started_at, last_chapter_at, finished_at, COALESCE(link_probe_status, 'unknown'), link_probe_at, link_probe_http_status, link_probe_detail`
+const sqlWorkRowFull = `id, title, chapter, link, status, image_path, reading_type, COALESCE(rating, 0), notes, user_id, updated_at, COALESCE(is_adult, 0), parent_work_id, COALESCE(series_sort, 0), COALESCE(notify_new_chapters, 1), reading_site_id, started_at, last_chapter_at, finished_at, COALESCE(link_probe_status, 'unknown'), link_probe_at, link_probe_http_status, link_probe_detail, COALESCE(volume, 0), (SELECT c.latest_chapter FROM catalog c WHERE c.id = works.catalog_id AND c.source = 'mangadex'), feed_url, (SELECT MAX(r.chapter) FROM work_releases r WHERE r.work_id = works.id), (SELECT MAX(r.released_at) FROM work_releases r WHERE r.work_id = works.id)`
 
 func scanFullWorkRow(w *workRow, s interface{ Scan(dest ...any) error }) error {
 	return s.Scan(
 		&w.ID, &w.Title, &w.Chapter, &w.Link, &w.Status, &w.ImagePath, &w.ReadingType,
 		&w.Rating, &w.Notes, &w.UserID, &w.UpdatedAt, &w.IsAdult, &w.ParentWorkID, &w.SeriesSort,
 		&w.NotifyNewChapters, &w.ReadingSiteID, &w.StartedAt, &w.LastChapterAt, &w.FinishedAt,
-		&w.LinkProbeStatus, &w.LinkProbeAt, &w.LinkProbeHTTPStatus, &w.LinkProbeDetail,
+		&w.LinkProbeStatus, &w.LinkProbeAt, &w.LinkProbeHTTPStatus, &w.LinkProbeDetail, &w.Volume,
+		&w.LatestChapter, &w.FeedURL, &w.FeedLatestChapter, &w.FeedLatestReleaseAt,
 	)
 }
 
-// catalogSourcePageURL builds a public web page URL for a catalog row (AniList, MangaDex), or "".
+// ProgressUnit is the unit followed on the dashboard for this work (volume for light novels).
+func (w workRow) ProgressUnit() string {
+	rt := ""
+	if w.ReadingType.Valid {
+		rt = w.ReadingType.String
+	}
+	return progressUnitForReadingType(rt)
+}
+
+// Progress returns the value of the work's progress unit.
+func (w workRow) Progress() float64 {
+	if w.ProgressUnit() == progressUnitVolume {
+		return w.Volume
+	}
+	return w.Chapter
+}
+
+// LatestReleasedChapter is the newest known chapter from MangaDex or the work's release feed.
+func (w workRow) LatestReleasedChapter() (float64, bool) {
+	switch {
+	case w.LatestChapter.Valid && w.FeedLatestChapter.Valid:
+		return math.Max(w.LatestChapter.Float64, w.FeedLatestChapter.Float64), true
+	case w.LatestChapter.Valid:
+		return w.LatestChapter.Float64, true
+	case w.FeedLatestChapter.Valid:
+		return w.FeedLatestChapter.Float64, true
+	}
+	return 0, false
+}
+
+// ChaptersBehind is how many released chapters the user has not read yet, for followed works
+// (notify_new_chapters) whose latest chapter is known. Fractional chapters count with their
+// whole chapter (reading 111.5 of 112 is one behind).
+func (w workRow) ChaptersBehind() int {
+	latest, ok := w.LatestReleasedChapter()
+	if w.NotifyNewChapters == 0 || !ok || w.ProgressUnit() != progressUnitChapter {
+		return 0
+	}
+	behind := int(math.Floor(latest) - math.Floor(w.Chapter))
+	if behind < 0 {
+		return 0
+	}
+	return behind
+}
+
+// catalogSourcePageURL builds a public web page URL for a catalog row from its provider, or "".
 func catalogSourcePageURL(source, externalID string) string {
-	source = strings.ToLower(strings.TrimSpace(source))
 	ext := strings.TrimSpace(externalID)
 	if ext == "" {
 		return ""
 	}
-	switch source {
-	case "anilist":
-		return "https://anilist.co/manga/" + url.PathEscape(ext)
-	case "mangadex":
-		return "https://mangadex.org/title/" + url.PathEscape(ext)
-	default:
+	p, ok := catalog.LookupProvider(source)
+	if !ok {
 		return ""
 	}
+	return p.PageURL(ext)
 }
 
 func (a *App) catalogPageURLForUserWork(userID, workID int) string {
@@ -121,7 +175,7 @@ func workIsInProgress(w workRow) bool {
 		return false
 	}
 	switch strings.TrimSpace(w.Status.String) {
-	case "En cours", "Reading":
+	case statusReading:
 		return true
 	default:
 		return false
@@ -129,7 +183,7 @@ func workIsInProgress(w workRow) bool {
 }
 
 // effectiveLinkDotStatus matches dashboard display: link probe, or reading site status when link probe is still unknown.
-// Only applies to works « En cours » — other statuses are not link-checked.
+// Only applies to works in progress (status reading) — other statuses are not link-checked.
 func effectiveLinkDotStatus(w workRow, siteMap map[int]readingSite) string {
 	if !workIsInProgress(w) {
 		return "none"
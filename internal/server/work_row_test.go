@@ -8,20 +8,20 @@ import (
 func TestEffectiveLinkDotStatus_onlyInProgress(t *testing.T) {
 	deadProbe := sql.NullString{String: "down", Valid: true}
 	wReading := workRow{
-		Status:          sql.NullString{String: "En cours", Valid: true},
+		Status:          sql.NullString{String: statusReading, Valid: true},
 		Link:            sql.NullString{String: "https://example.com/a", Valid: true},
 		LinkProbeStatus: deadProbe,
 	}
 	if st := effectiveLinkDotStatus(wReading, nil); st != "down" {
-		t.Fatalf("expected down for En cours, got %q", st)
+		t.Fatalf("expected down for reading, got %q", st)
 	}
 
 	wDone := workRow{
-		Status:          sql.NullString{String: "Terminé", Valid: true},
+		Status:          sql.NullString{String: statusCompleted, Valid: true},
 		Link:            sql.NullString{String: "https://example.com/a", Valid: true},
 		LinkProbeStatus: deadProbe,
 	}
 	if st := effectiveLinkDotStatus(wDone, nil); st != "none" {
-		t.Fatalf("expected none for Terminé, got %q", st)
+		t.Fatalf("expected none for completed, got %q", st)
 	}
 }
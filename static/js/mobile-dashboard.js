@@ -78,8 +78,9 @@
       fetch(url, { method: "POST", credentials: "same-origin" })
         .then(function (r) {
           if (!r.ok) throw new Error(r.status);
-          var cur = parseInt(counter.textContent, 10) || 0;
-          counter.textContent = isInc ? cur + 1 : Math.max(0, cur - 1);
+          // Decimal progress (12.5) steps to the next / previous whole number, like the server.
+          var cur = parseFloat(counter.textContent) || 0;
+          counter.textContent = isInc ? Math.floor(cur) + 1 : Math.max(0, Math.ceil(cur) - 1);
         })
         .catch(function () {
           if (counter) counter.classList.add("chapter-error");
@@ -100,8 +101,9 @@
         if (!payload || !payload.data) return;
         payload.data.forEach(function (work) {
           var el = document.getElementById("chapter-count-" + work.id);
-          if (el && String(el.textContent) !== String(work.chapter)) {
-            el.textContent = work.chapter;
+          var progress = work.progress_unit === "volume" ? work.volume : work.chapter;
+          if (el && String(el.textContent) !== String(progress)) {
+            el.textContent = progress;
             el.classList.remove("chapter-error");
           }
         });
@@ -14,6 +14,7 @@
       followCheck: document.getElementById("mobile-follow-unfollowed-check"),
       adultOnlyCheck: document.getElementById("mobile-adult-only-check"),
       site: document.getElementById("mobile-filter-site"),
+      tag: document.getElementById("mobile-filter-tag"),
       sortSel: document.getElementById("mobile-sort"),
       quickFilters: document.getElementById("mobile-quick-filters"),
       badge: document.getElementById("mobile-filters-badge"),
@@ -78,6 +79,7 @@
     var n = 0;
     if (els.status && els.status.value) n += 1;
     if (els.site && els.site.value) n += 1;
+    if (els.tag && els.tag.value) n += 1;
     if (els.followCheck && els.followCheck.checked) n += 1;
     if (els.adultOnlyCheck && els.adultOnlyCheck.checked) n += 1;
     if (els.sortSel && els.sortSel.value && els.sortSel.value !== "title") n += 1;
@@ -110,7 +112,7 @@
       var notifyVal = card.getAttribute("data-notify-new-chapters") || "1";
       var cardSite = card.getAttribute("data-reading-site-id") || "none";
       var matchFollow =
-        !fl || (fl === "unfollowed" && rawStatus === "En cours" && notifyVal === "0");
+        !fl || (fl === "unfollowed" && rawStatus === "reading" && notifyVal === "0");
       var matchSite =
         !siteVal || (siteVal === "none" ? cardSite === "none" : cardSite === siteVal);
       var visible =
@@ -138,6 +140,11 @@
     } else {
       u.searchParams.delete("adult");
     }
+    if (els.tag && els.tag.value) {
+      u.searchParams.set("tag", els.tag.value);
+    } else {
+      u.searchParams.delete("tag");
+    }
     u.searchParams.set("partial", "works");
     return u.toString();
   }